@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"notification/internal/config"
+	"notification/internal/health"
+	"notification/internal/infra"
+	"notification/internal/repository"
+)
+
+// newAPIKeyRepository creates the configured API key store, applying the pending schema migrations
+// of the SQL stores, whose database is checked by the readiness probe.
+func newAPIKeyRepository(ctx context.Context, cfg *config.AppConfig, databases *sqlDatabases,
+	checks *health.Registry) (repository.APIKeyRepository, error) {
+	switch cfg.APIKeyStore {
+	case "memory":
+		return repository.NewInMemoryAPIKeyRepository(), nil
+	case "sql", "sqlite":
+		db, dialect, err := databases.open(ctx, cfg.APIKeyStore)
+		if err != nil {
+			return nil, err
+		}
+		repo := infra.NewSQLAPIKeyRepository(db, dialect)
+		if err := repo.Migrate(ctx); err != nil {
+			return nil, err
+		}
+		checks.Register("apiKeyStore", health.CheckerFunc(db.PingContext))
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown api key store %q", cfg.APIKeyStore)
+	}
+}
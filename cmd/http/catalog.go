@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"notification/internal/config"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"notification/internal/service"
+)
+
+// applyCatalog applies the notification types and users of the catalog.
+//
+// When overwriteRules is set, the rate limit rules of the catalog replace the stored ones,
+// since the configuration file is the source of truth. Otherwise, they only seed the missing
+// rules so that the changes made through the API survive restarts. Users missing from the
+// catalog are kept.
+func applyCatalog(ctx context.Context, catalog config.Catalog,
+	rulesRepo repository.RateLimitRuleRepository,
+	userRepo repository.UserRepository,
+	subjects *service.Subjects,
+	overwriteRules bool) {
+	logger := logging.FromContext(ctx)
+	typeSubjects := make(map[domain.NotificationType]string, len(catalog.NotificationTypes))
+	for name, typeConfig := range catalog.NotificationTypes {
+		notificationType, err := domain.ToNotificationType(name)
+		if err != nil {
+			logger.Warn().Err(err).Str("type", name).Msg("skipping notification type")
+			continue
+		}
+		typeSubjects[notificationType] = typeConfig.Subject
+
+		if typeConfig.RateLimit == nil {
+			continue
+		}
+		rule := typeConfig.RateLimit.Rule()
+		if overwriteRules {
+			err = rulesRepo.Update(ctx, notificationType, rule)
+		} else if err = rulesRepo.Save(ctx, notificationType, rule); errors.Is(err, repository.ErrRuleAlreadyExists) {
+			err = nil
+		}
+		if err != nil {
+			logger.Error().Err(err).Stringer(logging.NotificationTypeKey, notificationType).
+				Msg("failed to apply the rate limit rule")
+		}
+	}
+	subjects.Set(typeSubjects)
+
+	for _, userConfig := range catalog.Users {
+		user := domain.User{
+			ID:       userConfig.ID,
+			Name:     userConfig.Name,
+			LastName: userConfig.LastName,
+			Email:    userConfig.Email,
+			Segments: userConfig.Segments,
+		}
+		err := userRepo.Save(ctx, user)
+		if errors.Is(err, repository.ErrUserAlreadyExists) {
+			err = userRepo.Update(ctx, user)
+		}
+		if err != nil {
+			logger.Error().Err(err).Str(logging.UserIDKey, user.ID).Msg("failed to apply the user")
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"notification/internal/config"
+	"notification/internal/health"
+	"notification/internal/infra"
+	"notification/internal/repository"
+)
+
+// newDeliveryRepository creates the configured delivery tracking store, applying the pending schema
+// migrations of the SQL stores, whose database is checked by the readiness probe.
+func newDeliveryRepository(ctx context.Context, cfg *config.AppConfig, redisClient *redis.Client,
+	databases *sqlDatabases, checks *health.Registry) (repository.DeliveryRepository, error) {
+	switch cfg.DeliveryStore {
+	case "redis":
+		return infra.NewRedisDeliveryRepository(redisClient, cfg.DeliveryRetention), nil
+	case "memory":
+		return repository.NewInMemoryDeliveryRepository(), nil
+	case "sql", "sqlite":
+		db, dialect, err := databases.open(ctx, cfg.DeliveryStore)
+		if err != nil {
+			return nil, err
+		}
+		repo := infra.NewSQLDeliveryRepository(db, dialect)
+		if err := repo.Migrate(ctx); err != nil {
+			return nil, err
+		}
+		checks.Register("deliveryStore", health.CheckerFunc(db.PingContext))
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown delivery store %q", cfg.DeliveryStore)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"notification/internal/config"
+	"notification/internal/health"
+	"notification/internal/infra"
+	"notification/internal/service"
+)
+
+// newMailer creates the Mailer of the configured provider, or a FailoverMailer when
+// multiple providers are configured. The single provider is checked by the readiness probe when
+// it can be probed, e.g. SMTP servers: with multiple providers, the FailoverMailer reports its
+// readiness itself. The returned function releases the resources held by the Mailer and must be
+// called on shutdown.
+func newMailer(cfg config.Mail, logger zerolog.Logger, checks *health.Registry) (service.Mailer, func(), error) {
+	if len(cfg.MailProviders) == 0 {
+		mailer, closeMailer, err := newProviderMailer(cfg, cfg.MailProvider, logger)
+		if checker, ok := mailer.(health.Checker); ok {
+			checks.Register(cfg.MailProvider, checker)
+		}
+		return mailer, closeMailer, err
+	}
+
+	var providers []service.MailProvider
+	var closers []func()
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+	for _, p := range cfg.MailProviders {
+		mailer, closeMailer, err := newProviderMailer(cfg, p.Name, logger)
+		if err != nil {
+			closeAll()
+			return nil, func() {}, err
+		}
+		closers = append(closers, closeMailer)
+		providers = append(providers, service.MailProvider{
+			Name:     p.Name,
+			Mailer:   mailer,
+			Priority: p.Priority,
+			Weight:   p.Weight,
+		})
+	}
+
+	failoverMailer := service.NewFailoverMailer(providers, service.CircuitBreakerConfig{
+		FailureThreshold: cfg.MailCircuitFailureThreshold,
+		OpenTimeout:      cfg.MailCircuitOpenTimeout,
+	}, service.WithFailoverLogger(logger))
+	return failoverMailer, closeAll, nil
+}
+
+// newProviderMailer creates the Mailer of the given provider. Its log entries carry the provider name.
+func newProviderMailer(cfg config.Mail, provider string, logger zerolog.Logger) (service.Mailer, func(), error) {
+	noop := func() {}
+	logger = logger.With().Str("provider", provider).Logger()
+	httpOpts := []infra.HTTPMailerOption{infra.WithHTTPMailerLogger(logger)}
+
+	switch provider {
+	case "smtp":
+		mailer, err := newSMTPMailer(cfg, logger)
+		if err != nil {
+			return nil, noop, err
+		}
+		return mailer, mailer.Close, nil
+	case "sendgrid":
+		return infra.NewSendGridMailer(cfg.SendGridAPIKey, cfg.MailFrom, httpOpts...), noop, nil
+	case "mailgun":
+		if cfg.MailgunBaseURL != "" {
+			httpOpts = append(httpOpts, infra.WithBaseURL(cfg.MailgunBaseURL))
+		}
+		return infra.NewMailgunMailer(cfg.MailgunAPIKey, cfg.MailgunDomain, cfg.MailFrom, httpOpts...), noop, nil
+	case "ses":
+		credentials := infra.SESCredentials{
+			AccessKeyID:     cfg.SESAccessKeyID,
+			SecretAccessKey: cfg.SESSecretAccessKey,
+			SessionToken:    cfg.SESSessionToken,
+		}
+		return infra.NewSESMailer(cfg.SESRegion, credentials, cfg.MailFrom, httpOpts...), noop, nil
+	default:
+		return nil, noop, fmt.Errorf("unsupported mail provider %q", provider)
+	}
+}
+
+// newSMTPMailer creates the SMTPMailer with the authentication, connection pool
+// and DKIM signing settings.
+func newSMTPMailer(cfg config.Mail, logger zerolog.Logger) (*infra.SMTPMailer, error) {
+	opts, err := smtpMailerOptions(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP authentication settings: %w", err)
+	}
+	opts = append(opts, infra.WithSMTPLogger(logger))
+	if cfg.SMTPPoolMaxIdle > 0 {
+		opts = append(opts, infra.WithConnectionPool(infra.PoolConfig{
+			MaxIdle:            cfg.SMTPPoolMaxIdle,
+			MaxLifetime:        cfg.SMTPPoolMaxLifetime,
+			IdleTimeout:        cfg.SMTPPoolIdleTimeout,
+			HealthCheckAfter:   cfg.SMTPPoolHealthCheckAfter,
+			MaxMessagesPerConn: cfg.SMTPPoolMaxMessages,
+		}))
+	}
+	if cfg.DKIMPrivateKeyFile != "" {
+		dkimSigner, err := newDKIMSigner(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DKIM settings: %w", err)
+		}
+		opts = append(opts, infra.WithDKIMSigner(dkimSigner))
+	}
+
+	smtpAddress := fmt.Sprintf("%s:%d", cfg.SMTPHost, cfg.SMTPPort)
+	return infra.NewSMTPMailer(smtpAddress, cfg.MailFrom, opts...), nil
+}
+
+// smtpMailerOptions translates the mail configuration into the SMTPMailer options,
+// selecting the authentication mechanism.
+func smtpMailerOptions(cfg config.Mail) ([]infra.SMTPMailerOption, error) {
+	switch cfg.SMTPAuthMechanism {
+	case "":
+		return nil, nil
+	case "plain":
+		return []infra.SMTPMailerOption{
+			infra.WithAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost),
+		}, nil
+	case "login":
+		return []infra.SMTPMailerOption{
+			infra.WithLoginAuth(cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost),
+		}, nil
+	case "cram-md5":
+		return []infra.SMTPMailerOption{
+			infra.WithCRAMMD5Auth(cfg.SMTPUsername, cfg.SMTPPassword),
+		}, nil
+	case "xoauth2":
+		var tokenSource infra.TokenSource = infra.StaticTokenSource(cfg.SMTPOAuth2Token)
+		if cfg.SMTPOAuth2TokenFile != "" {
+			tokenSource = infra.FileTokenSource(cfg.SMTPOAuth2TokenFile)
+		}
+		return []infra.SMTPMailerOption{
+			infra.WithXOAUTH2Auth(cfg.SMTPUsername, tokenSource, cfg.SMTPHost),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported SMTP auth mechanism %q", cfg.SMTPAuthMechanism)
+	}
+}
+
+// newDKIMSigner loads the DKIM private key and creates the signer.
+func newDKIMSigner(cfg config.Mail) (*infra.DKIMSigner, error) {
+	key, err := infra.LoadDKIMPrivateKey(cfg.DKIMPrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return infra.NewDKIMSigner(cfg.DKIMDomain, cfg.DKIMSelector, key, cfg.DKIMHeaders)
+}
@@ -3,18 +3,28 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/gorilla/mux"
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"log"
 	"net/http"
 	_ "notification/api"
+	"notification/internal/auth"
 	"notification/internal/config"
 	"notification/internal/controller"
-	"notification/internal/domain"
+	"notification/internal/controller/middleware"
+	"notification/internal/health"
 	"notification/internal/infra"
+	"notification/internal/logging"
+	"notification/internal/metrics"
 	"notification/internal/repository"
 	"notification/internal/service"
+	"notification/internal/tracing"
 	"os"
 	"os/signal"
 	"syscall"
@@ -31,44 +41,248 @@ import (
 // @host notification
 // @BasePath /
 func main() {
+	printConfig := flag.Bool("print-config", false,
+		"print the effective configuration, with the secrets redacted, and exit")
+	flag.Parse()
+
+	// Until the configuration is loaded, the default logger is used.
+	logger := logging.Default()
+
 	// Load the application configuration params
-	cfg := config.NewAppConfig()
+	cfg, err := config.NewAppConfig()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid configuration")
+	}
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			logger.Fatal().Err(err).Msg("failed to print the configuration")
+		}
+		return
+	}
+
+	configuredLogger, err := logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid logging settings")
+	}
+	logger = configuredLogger
+	logging.SetDefault(logger)
+	// the standard logger, used by the HTTP server and some dependencies, writes through zerolog as well.
+	log.SetFlags(0)
+	log.SetOutput(logger)
+
+	// OpenTelemetry tracing set up: spans are created even without an exporter,
+	// so that the trace context of the requests is propagated and logged.
+	tracerProvider, err := tracing.NewTracerProvider(context.Background(), tracing.Config{
+		Exporter:    cfg.TracesExporter,
+		Endpoint:    cfg.OTLPEndpoint,
+		ServiceName: cfg.ServiceName,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid tracing settings")
+	}
+	tracing.SetGlobal(tracerProvider)
 
 	// set the controller handlers injecting the dependency
 	// in the router
 	r := mux.NewRouter()
 
-	// Health Check controller set up
-	controller.NewHealthCheck().SetRouter(r)
+	// Prometheus metrics set up: the services are instrumented through decorators.
+	appMetrics := metrics.New(prometheus.NewRegistry())
+	r.Use(middleware.Tracing, middleware.Metrics(appMetrics))
+	r.Handle("/metrics", appMetrics.Handler()).Methods(http.MethodGet)
+
+	// Readiness checks set up: the dependencies register their checks as they're created.
+	checks := health.NewRegistry(
+		health.WithTimeout(cfg.ReadinessCheckTimeout),
+		health.WithCacheTTL(cfg.ReadinessCacheTTL))
+	startup := &health.Startup{}
 
 	redisAddress := fmt.Sprintf("%s:%d", cfg.RedisHost, cfg.RedisPort)
-	redisCache := infra.NewRedisCache(infra.WithAddr(redisAddress))
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if err := redisCache.Ping(ctxWithTimeout); err != nil {
-		log.Fatalf("failed to connect to redis using the address %s: %v", redisAddress, err)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisAddress})
+	redisCache := infra.NewRedisCache(infra.WithClient(redisClient))
+	// each startup phase has its own time budget, so that a slow phase doesn't starve the next ones.
+	pingCtx, cancelPing := startupContext(context.Background())
+	err = redisCache.Ping(pingCtx)
+	cancelPing()
+	if err != nil {
+		logger.Fatal().Err(err).Str("address", redisAddress).Msg("failed to connect to redis")
+	}
+	checks.Register("redis", health.CheckerFunc(redisCache.Ping))
+
+	// the SQL stores of the same dialect share its connection pool.
+	databases := newSQLDatabases(cfg)
+	defer databases.Close()
+
+	// Tenants set up: the tenant quotas are enforced by the rate limiter.
+	tenantCtx, cancelTenant := startupContext(context.Background())
+	tenantRepo, err := newTenantRepository(tenantCtx, cfg, databases, checks)
+	cancelTenant()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid tenant store settings")
+	}
+
+	// Users set up: their segments select the rate limit overrides.
+	userCtx, cancelUser := startupContext(context.Background())
+	userRepo, err := newUserRepository(userCtx, cfg, databases, checks)
+	cancelUser()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid user store settings")
 	}
 
 	// Notification resource controller set up
-	rateLimitRulesRepo := repository.NewInMemoryRateLimitRuleRepository()
-	rateLimitHandler := service.NewCacheRateLimitHandler(redisCache, rateLimitRulesRepo)
-	smtpAddress := fmt.Sprintf("%s:%d", cfg.SMTPHost, cfg.SMTPPort)
-	mailClient := infra.NewSMTPMailer(smtpAddress, cfg.MailFrom)
-	userRepo := repository.NewInMemoryUserRepository()
-	notificationSvc := service.NewEmailNotificationSender(rateLimitHandler, mailClient, userRepo, redisCache)
-
-	notificationController := controller.NewNotification(notificationSvc)
+	rulesCtx, cancelRules := startupContext(context.Background())
+	rateLimitRulesRepo, ruleHistory, err := newRateLimitRuleRepository(rulesCtx, cfg, redisClient, databases, checks)
+	cancelRules()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid rule store settings")
+	}
+	overrideRepo, exemptionRepo, err := newRateLimitOverrideRepositories(cfg, redisClient)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid override store settings")
+	}
+	rateLimitOpts := []service.CacheRateLimitHandlerOption{
+		service.WithTenantQuotas(tenantRepo),
+		service.WithOverrides(overrideRepo, userRepo),
+		service.WithExemptions(exemptionRepo),
+	}
+	if cfg.DefaultRule != nil {
+		rateLimitOpts = append(rateLimitOpts, service.WithDefaultRule(*cfg.DefaultRule))
+	}
+	cache := metrics.NewCache(redisCache, appMetrics)
+	rateLimitHandler := service.NewCacheRateLimitHandler(cache, rateLimitRulesRepo, rateLimitOpts...)
+	mailClient, closeMailer, err := newMailer(cfg.Mail, componentLogger(logger, "mail"), checks)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid mail settings")
+	}
+	defer closeMailer()
+	if reporter, ok := mailClient.(metrics.ProviderStateReporter); ok {
+		appMetrics.RegisterProviderStates(reporter)
+	}
+
+	// Health Check controller set up
+	healthCheckOpts := []controller.HealthCheckOption{
+		controller.WithReadinessChecks(checks),
+		controller.WithStartup(startup),
+	}
+	if reporter, ok := mailClient.(controller.ReadinessReporter); ok {
+		healthCheckOpts = append(healthCheckOpts, controller.WithReadinessReporter("mail", reporter))
+	}
+	controller.NewHealthCheck(healthCheckOpts...).SetRouter(r)
+
+	suppressionRepo, err := newSuppressionRepository(cfg, redisClient)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid suppression list settings")
+	}
+	deliveryCtx, cancelDelivery := startupContext(context.Background())
+	deliveryRepo, err := newDeliveryRepository(deliveryCtx, cfg, redisClient, databases, checks)
+	cancelDelivery()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid delivery tracking settings")
+	}
+
+	// API authentication set up: the controllers set the scopes required by each route.
+	apiKeyCtx, cancelAPIKey := startupContext(context.Background())
+	apiKeyRepo, err := newAPIKeyRepository(apiKeyCtx, cfg, databases, checks)
+	cancelAPIKey()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid api key store settings")
+	}
+	if cfg.AuthEnabled {
+		var authenticator auth.Authenticator = auth.NewAPIKeyAuthenticator(apiKeyRepo, auth.WithBootstrapKey(cfg.AdminAPIKey))
+		if cfg.JWKSURL != "" {
+			keys := auth.NewJWKS(cfg.JWKSURL, auth.WithJWKSRefreshInterval(cfg.JWKSRefreshInterval))
+			jwtAuthenticator := auth.NewJWTAuthenticator(keys, cfg.JWTIssuer, cfg.JWTAudience,
+				auth.WithTypesClaim(cfg.JWTTypesClaim),
+				auth.WithTenantClaim(cfg.JWTTenantClaim))
+			authenticator = auth.Chain(jwtAuthenticator, authenticator)
+		}
+		r.Use(middleware.Authenticate(authenticator))
+	} else {
+		logger.Warn().Msg("AUTH_ENABLED is not set: the API doesn't require authentication")
+	}
+	controller.NewAPIKey(apiKeyRepo).SetRouter(r)
+	controller.NewTenant(tenantRepo).SetRouter(r)
+
+	// Delivery event callbacks set up
+	callbackLog := repository.NewInMemoryCallbackLogRepository(cfg.WebhookLogSize)
+	if cfg.WebhookSigningSecret == "" {
+		logger.Warn().Msg("WEBHOOK_SIGNING_SECRET is not set: delivery event callbacks won't be signed")
+	}
+	webhookLogger := componentLogger(logger, "webhook")
+	webhookDispatcher := infra.NewWebhookDispatcher(infra.WebhookConfig{
+		Secret:         cfg.WebhookSigningSecret,
+		MaxAttempts:    cfg.WebhookMaxAttempts,
+		InitialBackoff: cfg.WebhookInitialBackoff,
+		MaxBackoff:     cfg.WebhookMaxBackoff,
+		Timeout:        cfg.WebhookTimeout,
+		Workers:        cfg.WebhookWorkers,
+		QueueSize:      cfg.WebhookQueueSize,
+		Logger:         &webhookLogger,
+
+		AllowPrivateNetworks: cfg.WebhookAllowPrivateNetworks,
+	}, callbackLog)
+	webhookDispatcher.Start()
+	defer webhookDispatcher.Close()
+
+	// the throughput limits delay the emails before they're handed to the provider, so the
+	// wait isn't recorded as delivery duration.
+	var notificationMailer service.Mailer = metrics.NewMailer(mailClient, appMetrics)
+	if cfg.Throttled() {
+		throttle := infra.NewRedisThrottle(redisClient, infra.WithMaxWait(cfg.MailThrottleMaxWait))
+		notificationMailer = service.NewThrottledMailer(notificationMailer, metrics.NewThrottle(throttle, appMetrics),
+			cfg.MailFrom, service.ThroughputLimits{
+				Rate:        cfg.MailThrottleRate,
+				TenantRate:  cfg.MailThrottleTenantRate,
+				DomainRates: cfg.MailThrottleDomainRates,
+			})
+	}
+
+	// the subjects of the notification types are loaded with the catalog, once the server is started.
+	subjects := service.NewSubjects(nil)
+	notificationSvc := service.NewEmailNotificationSender(rateLimitHandler,
+		notificationMailer, userRepo, cache,
+		service.WithSubjects(subjects),
+		service.WithTenants(tenantRepo),
+		service.WithSuppressionList(suppressionRepo),
+		service.WithDeliveryTracking(deliveryRepo),
+		service.WithDeliveryEvents(webhookDispatcher))
+
+	notificationController := controller.NewNotification(metrics.NewNotificationSender(notificationSvc, appMetrics))
 	notificationController.SetRouter(r)
+	controller.NewUser(userRepo, controller.WithQuotas(rateLimitHandler)).SetRouter(r)
+	var ruleOpts []controller.RateLimitRuleOption
+	if ruleHistory != nil {
+		ruleOpts = append(ruleOpts, controller.WithRuleHistory(ruleHistory))
+	}
+	controller.NewRateLimitRule(rateLimitRulesRepo, ruleOpts...).SetRouter(r)
+	controller.NewRateLimitOverride(overrideRepo, exemptionRepo).SetRouter(r)
+	controller.NewDelivery(deliveryRepo, controller.WithCallbackLog(callbackLog)).SetRouter(r)
+
+	// Bounce processing and suppression list controllers set up
+	bounceHandler := service.NewSuppressionBounceHandler(suppressionRepo,
+		service.WithBouncedEvents(deliveryRepo, webhookDispatcher))
+	// the bounce webhooks are public, so they're only mounted along with the token authenticating them.
+	if cfg.BounceWebhookToken != "" {
+		controller.NewBounce(bounceHandler,
+			controller.WithWebhookToken(cfg.BounceWebhookToken),
+			controller.WithSNSVerifier(auth.NewSNSVerifier())).SetRouter(r)
+	} else {
+		logger.Warn().Msg("BOUNCE_WEBHOOK_TOKEN is not set: the bounce webhooks are disabled")
+	}
+	controller.NewSuppression(suppressionRepo).SetRouter(r)
+
+	bounceCtx, stopBounceReader := context.WithCancel(logging.NewContext(context.Background(), componentLogger(logger, "bounce")))
+	defer stopBounceReader()
+	if cfg.BounceMaildir != "" {
+		maildirReader := infra.NewMaildirBounceReader(cfg.BounceMaildir, bounceHandler, cfg.BounceMaildirPollInterval)
+		go maildirReader.Run(bounceCtx)
+	}
 
 	// Set the Swagger endpoint to render the OpenAPI specs.
 	r.PathPrefix("/swagger").Handler(httpSwagger.WrapHandler)
 
 	// start the HTTP server
-	log.Printf("Starting server on port %d", cfg.ServerPort)
-
-	// TODO: temporary approach. If there's enough time, create the necessary
-	// HTTP handlers for the rules and user resources and populate data from a script instead.
-	populateInitialData(rateLimitRulesRepo, userRepo)
+	logger.Info().Int("port", cfg.ServerPort).Msg("Starting server")
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.ServerPort),
@@ -82,11 +296,47 @@ func main() {
 		// When the server exits, make sure the error states that the server
 		// was closed normally, meaning there's no unexpected error.
 		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("HTTP server error: %v", err)
+			logger.Fatal().Err(err).Msg("HTTP server error")
 		}
-		log.Println("Server is shutting down")
+		logger.Info().Msg("Server is shutting down")
 	}()
 
+	// Notification types and users set up, while the startup and readiness probes fail. Without
+	// a configuration file, the built-in catalog only seeds the missing rules, so the changes made
+	// through the API survive restarts.
+	// The changes made by the catalog are recorded in the rule history as made by the configuration.
+	catalogCtx, cancelCatalog := startupContext(
+		logging.NewContext(repository.WithActor(context.Background(), "config"), componentLogger(logger, "catalog")))
+	applyCatalog(catalogCtx, cfg.Catalog, rateLimitRulesRepo, userRepo, subjects, cfg.ConfigFile != "")
+	cancelCatalog()
+	checkCtx, cancelCheck := startupContext(context.Background())
+	err = rateLimitHandler.CheckRules(checkCtx)
+	cancelCheck()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid rate limit rules, define them or set RULE_DEFAULT")
+	}
+
+	watcherCtx, stopWatcher := context.WithCancel(logging.NewContext(context.Background(), componentLogger(logger, "config")))
+	defer stopWatcher()
+	if cfg.ConfigFile != "" {
+		watcher := config.NewFileWatcher(cfg.ConfigFile, func(file *config.FileConfig) {
+			ctx, cancel := context.WithTimeout(repository.WithActor(watcherCtx, "config"), 10*time.Second)
+			defer cancel()
+			applyCatalog(ctx, file.Catalog, rateLimitRulesRepo, userRepo, subjects, true)
+			if err := rateLimitHandler.CheckRules(ctx); err != nil {
+				logging.FromContext(ctx).Error().Err(err).Msg("the notifications of some types will be rejected")
+			}
+		}, config.WithLoadedHash(cfg.ConfigFileHash))
+		go func() {
+			if err := watcher.Run(watcherCtx); err != nil {
+				logger.Error().Err(err).Str("file", cfg.ConfigFile).Msg("the configuration file won't be reloaded")
+			}
+		}()
+	}
+	// from now on, the readiness probe lets the traffic in.
+	startup.Complete()
+	logger.Info().Msg("Initial load complete")
+
 	// Listen to OS termination signals to allow for a graceful shutdown
 	// (especially important in ephemeral environments, such as Kubernetes)
 	signals := make(chan os.Signal, 1)
@@ -100,44 +350,24 @@ func main() {
 
 	// Call Shutdown for a graceful shutdown.
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server shutdown error: %v", err)
+		logger.Fatal().Err(err).Msg("Server shutdown error")
+	}
+	// flush the pending spans.
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		logger.Error().Err(err).Msg("tracer provider shutdown error")
 	}
-	log.Println("Server graceful shutdown complete.")
+	logger.Info().Msg("Server graceful shutdown complete")
+}
+
+// startupTimeout bounds each startup phase, e.g. connecting to Redis or opening and migrating a store.
+const startupTimeout = 10 * time.Second
+
+// startupContext returns a child of parent bound to startupTimeout, for a single startup phase.
+func startupContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, startupTimeout)
 }
 
-func populateInitialData(rateLimitRulesRepo *repository.InMemoryRateLimitRuleRepository,
-	userRepo *repository.InMemoryUserRepository) {
-	rules := domain.RateLimitRules{
-		domain.Status: domain.RateLimitRule{
-			MaxCount:   2,
-			Expiration: time.Minute * 1,
-		},
-		domain.News: domain.RateLimitRule{
-			MaxCount:   1,
-			Expiration: time.Hour * 24,
-		},
-		domain.Marketing: domain.RateLimitRule{
-			MaxCount:   3,
-			Expiration: time.Hour * 1,
-		},
-	}
-	for k, v := range rules {
-		_ = rateLimitRulesRepo.Save(k, v)
-	}
-
-	user1 := domain.User{
-		ID:       "123-abc",
-		Name:     "John",
-		LastName: "Doe",
-		Email:    "john@example.com",
-	}
-	_ = userRepo.Save(user1)
-
-	user2 := domain.User{
-		ID:       "456-bbb",
-		Name:     "Jane",
-		LastName: "Doe",
-		Email:    "jane@example.com",
-	}
-	_ = userRepo.Save(user2)
+// componentLogger returns a child of logger whose entries carry the name of the component.
+func componentLogger(logger zerolog.Logger, component string) zerolog.Logger {
+	return logger.With().Str(logging.ComponentKey, component).Logger()
 }
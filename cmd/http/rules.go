@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"notification/internal/config"
+	"notification/internal/health"
+	"notification/internal/infra"
+	"notification/internal/repository"
+)
+
+// newRateLimitRuleRepository creates the configured rate limit rule store, behind the in-process
+// cache unless it's disabled, along with the history of the rule changes if the store keeps one.
+func newRateLimitRuleRepository(ctx context.Context, cfg *config.AppConfig, redisClient *redis.Client,
+	databases *sqlDatabases, checks *health.Registry) (
+	repository.RateLimitRuleRepository, repository.RateLimitRuleHistory, error) {
+	var (
+		repo    repository.RateLimitRuleRepository
+		history repository.RateLimitRuleHistory
+	)
+	switch cfg.RuleStore {
+	case "redis":
+		repo = infra.NewRedisRateLimitRuleRepository(redisClient)
+	case "memory":
+		memoryRepo := repository.NewInMemoryRateLimitRuleRepository()
+		repo, history = memoryRepo, memoryRepo
+	case "sql", "sqlite":
+		db, dialect, err := databases.open(ctx, cfg.RuleStore)
+		if err != nil {
+			return nil, nil, err
+		}
+		sqlRepo := infra.NewSQLRateLimitRuleRepository(db, dialect)
+		if err := sqlRepo.Migrate(ctx); err != nil {
+			return nil, nil, err
+		}
+		repo, history = sqlRepo, sqlRepo
+		checks.Register("ruleStore", health.CheckerFunc(db.PingContext))
+	default:
+		return nil, nil, fmt.Errorf("unknown rule store %q", cfg.RuleStore)
+	}
+
+	if cfg.RuleCacheTTL > 0 {
+		repo = repository.NewCachedRateLimitRuleRepository(repo, cfg.RuleCacheTTL)
+	}
+	return repo, history, nil
+}
+
+// newRateLimitOverrideRepositories creates the configured rate limit override and exemption stores.
+func newRateLimitOverrideRepositories(cfg *config.AppConfig, redisClient *redis.Client) (
+	repository.RateLimitOverrideRepository, repository.RateLimitExemptionRepository, error) {
+	switch cfg.OverrideStore {
+	case "redis":
+		return infra.NewRedisRateLimitOverrideRepository(redisClient),
+			infra.NewRedisRateLimitExemptionRepository(redisClient), nil
+	case "memory":
+		return repository.NewInMemoryRateLimitOverrideRepository(),
+			repository.NewInMemoryRateLimitExemptionRepository(), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown override store %q", cfg.OverrideStore)
+	}
+}
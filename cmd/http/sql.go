@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"notification/internal/config"
+	"notification/internal/infra"
+)
+
+// sqlDatabases opens the databases of the SQL stores on first use, so that the stores of the same
+// dialect share a single connection pool.
+type sqlDatabases struct {
+	cfg       *config.AppConfig
+	databases map[infra.SQLDialect]*sql.DB
+}
+
+// newSQLDatabases creates a new sqlDatabases instance. Close must be called on shutdown.
+func newSQLDatabases(cfg *config.AppConfig) *sqlDatabases {
+	return &sqlDatabases{cfg: cfg, databases: make(map[infra.SQLDialect]*sql.DB)}
+}
+
+// open returns the database of the "sql" (PostgreSQL) or "sqlite" store, opening it if it isn't open yet.
+func (d *sqlDatabases) open(ctx context.Context, store string) (*sql.DB, infra.SQLDialect, error) {
+	var dialect infra.SQLDialect
+	var dsn string
+	switch store {
+	case "sql":
+		dialect, dsn = infra.SQLDialectPostgres, d.cfg.DatabaseURL
+	case "sqlite":
+		// concurrent writes wait for the lock instead of failing straight away.
+		dialect, dsn = infra.SQLDialectSQLite, fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)", d.cfg.SQLitePath)
+	default:
+		return nil, "", fmt.Errorf("unknown SQL store %q", store)
+	}
+
+	if db, ok := d.databases[dialect]; ok {
+		return db, dialect, nil
+	}
+	db, err := infra.OpenSQLDatabase(ctx, dialect, dsn)
+	if err != nil {
+		return nil, "", err
+	}
+	d.databases[dialect] = db
+	return db, dialect, nil
+}
+
+// Close closes the opened databases.
+func (d *sqlDatabases) Close() {
+	for _, db := range d.databases {
+		_ = db.Close()
+	}
+}
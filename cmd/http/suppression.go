@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"notification/internal/config"
+	"notification/internal/infra"
+	"notification/internal/repository"
+)
+
+// newSuppressionRepository creates the configured suppression list store.
+func newSuppressionRepository(cfg *config.AppConfig, redisClient *redis.Client) (repository.SuppressionRepository, error) {
+	switch cfg.SuppressionStore {
+	case "redis":
+		return infra.NewRedisSuppressionRepository(redisClient), nil
+	case "memory":
+		return repository.NewInMemorySuppressionRepository(), nil
+	default:
+		return nil, fmt.Errorf("unknown suppression store %q", cfg.SuppressionStore)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"notification/internal/config"
+	"notification/internal/health"
+	"notification/internal/infra"
+	"notification/internal/repository"
+)
+
+// newTenantRepository creates the configured tenant store, applying the pending schema migrations
+// of the SQL stores, whose database is checked by the readiness probe.
+func newTenantRepository(ctx context.Context, cfg *config.AppConfig, databases *sqlDatabases,
+	checks *health.Registry) (repository.TenantRepository, error) {
+	switch cfg.TenantStore {
+	case "memory":
+		return repository.NewInMemoryTenantRepository(), nil
+	case "sql", "sqlite":
+		db, dialect, err := databases.open(ctx, cfg.TenantStore)
+		if err != nil {
+			return nil, err
+		}
+		repo := infra.NewSQLTenantRepository(db, dialect)
+		if err := repo.Migrate(ctx); err != nil {
+			return nil, err
+		}
+		checks.Register("tenantStore", health.CheckerFunc(db.PingContext))
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown tenant store %q", cfg.TenantStore)
+	}
+}
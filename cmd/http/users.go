@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"notification/internal/config"
+	"notification/internal/health"
+	"notification/internal/infra"
+	"notification/internal/repository"
+)
+
+// newUserRepository creates the configured user store, applying the pending schema migrations
+// of the SQL stores, whose database is checked by the readiness probe.
+func newUserRepository(ctx context.Context, cfg *config.AppConfig, databases *sqlDatabases,
+	checks *health.Registry) (repository.UserRepository, error) {
+	switch cfg.UserStore {
+	case "memory":
+		return repository.NewInMemoryUserRepository(), nil
+	case "sql", "sqlite":
+		db, dialect, err := databases.open(ctx, cfg.UserStore)
+		if err != nil {
+			return nil, err
+		}
+		repo := infra.NewSQLUserRepository(db, dialect)
+		if err := repo.Migrate(ctx); err != nil {
+			return nil, err
+		}
+		checks.Register("userStore", health.CheckerFunc(db.PingContext))
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown user store %q", cfg.UserStore)
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"notification/internal/domain"
+	"notification/internal/repository"
+)
+
+const (
+	// APIKeyPrefix starts every generated API key, which makes them easy to spot, e.g. by secret scanners.
+	APIKeyPrefix = "nk_"
+	// BootstrapKeyID is the ID of the principal authenticated with the bootstrap key.
+	BootstrapKeyID = "bootstrap"
+)
+
+// GenerateAPIKey returns a new random API key. It's only shown once: just its hash is stored.
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate api key: %w", err)
+	}
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashAPIKey returns the SHA-256 hash of the API key, hex encoded. The keys are random
+// and long enough for a fast hash to be safe, which keeps the lookups by hash cheap.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyOption defines the optional params for APIKeyAuthenticator.
+type APIKeyOption func(*APIKeyAuthenticator)
+
+// WithBootstrapKey accepts the given key, granting every scope in the default tenant, in addition
+// to the stored ones. It allows provisioning the tenants and creating the first API keys,
+// and shouldn't be used by the clients.
+func WithBootstrapKey(key string) APIKeyOption {
+	return func(a *APIKeyAuthenticator) {
+		if key != "" {
+			a.bootstrapHash = HashAPIKey(key)
+		}
+	}
+}
+
+// NewAPIKeyAuthenticator creates a new APIKeyAuthenticator instance.
+func NewAPIKeyAuthenticator(repo repository.APIKeyRepository, opts ...APIKeyOption) *APIKeyAuthenticator {
+	a := &APIKeyAuthenticator{repo: repo}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// APIKeyAuthenticator authenticates the clients by the API keys of the repository, looked up by hash.
+type APIKeyAuthenticator struct {
+	repo          repository.APIKeyRepository
+	bootstrapHash string
+}
+
+// Authenticate returns the principal of the API key, or ErrInvalidCredentials if it's not stored.
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, credential string) (Principal, error) {
+	hash := HashAPIKey(credential)
+	if a.bootstrapHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(a.bootstrapHash)) == 1 {
+		return Principal{ID: BootstrapKeyID, Name: BootstrapKeyID, TenantID: domain.DefaultTenantID,
+			Scopes: []domain.Scope{domain.ScopeAll}}, nil
+	}
+
+	key, err := a.repo.GetByHash(ctx, hash)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return Principal{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return Principal{}, fmt.Errorf("look api key up: %w", err)
+	}
+	return Principal{ID: key.ID, Name: key.Name, TenantID: key.TenantID, Scopes: key.Scopes}, nil
+}
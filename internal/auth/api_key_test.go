@@ -0,0 +1,90 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/auth"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"strings"
+	"testing"
+)
+
+// failingAPIKeyRepository is an API key repository whose lookups fail.
+type failingAPIKeyRepository struct {
+	repository.APIKeyRepository
+}
+
+func (failingAPIKeyRepository) GetByHash(context.Context, string) (domain.APIKey, error) {
+	return domain.APIKey{}, errors.New("connection refused")
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, err := auth.GenerateAPIKey()
+	require.NoError(t, err)
+	other, err := auth.GenerateAPIKey()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key, auth.APIKeyPrefix))
+	assert.Greater(t, len(key), 40)
+	assert.NotEqual(t, key, other)
+	assert.Len(t, auth.HashAPIKey(key), 64)
+	assert.NotEqual(t, auth.HashAPIKey(key), auth.HashAPIKey(other))
+}
+
+func TestAPIKeyAuthenticator_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryAPIKeyRepository()
+	require.NoError(t, repo.Save(ctx, domain.APIKey{
+		ID:       "a1",
+		Name:     "billing-service",
+		TenantID: "billing",
+		Hash:     auth.HashAPIKey("nk_billing"),
+		Scopes:   []domain.Scope{"send:status"},
+	}))
+
+	t.Run("stored key is authenticated", func(t *testing.T) {
+		authenticator := auth.NewAPIKeyAuthenticator(repo)
+
+		principal, err := authenticator.Authenticate(ctx, "nk_billing")
+		require.NoError(t, err)
+		assert.Equal(t, auth.Principal{ID: "a1", Name: "billing-service", TenantID: "billing",
+			Scopes: []domain.Scope{"send:status"}}, principal)
+		assert.True(t, principal.HasScope("send:status"))
+		assert.False(t, principal.HasScope("send:marketing"))
+	})
+
+	t.Run("unknown key is rejected", func(t *testing.T) {
+		authenticator := auth.NewAPIKeyAuthenticator(repo, auth.WithBootstrapKey("nk_admin"))
+
+		_, err := authenticator.Authenticate(ctx, "nk_unknown")
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+
+	t.Run("bootstrap key is granted every scope", func(t *testing.T) {
+		authenticator := auth.NewAPIKeyAuthenticator(repo, auth.WithBootstrapKey("nk_admin"))
+
+		principal, err := authenticator.Authenticate(ctx, "nk_admin")
+		require.NoError(t, err)
+		assert.Equal(t, auth.BootstrapKeyID, principal.ID)
+		assert.Equal(t, domain.DefaultTenantID, principal.TenantID)
+		assert.True(t, principal.HasScope(domain.ScopeAdminKeys))
+	})
+
+	t.Run("empty bootstrap key is ignored", func(t *testing.T) {
+		authenticator := auth.NewAPIKeyAuthenticator(repo, auth.WithBootstrapKey(""))
+
+		_, err := authenticator.Authenticate(ctx, "")
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+
+	t.Run("repository errors out", func(t *testing.T) {
+		authenticator := auth.NewAPIKeyAuthenticator(failingAPIKeyRepository{})
+
+		_, err := authenticator.Authenticate(ctx, "nk_billing")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+}
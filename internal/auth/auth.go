@@ -0,0 +1,85 @@
+// Package auth authenticates the API clients and authorizes their requests by scope.
+package auth
+
+import (
+	"context"
+	"errors"
+	"notification/internal/domain"
+)
+
+// ErrInvalidCredentials is the error when the credentials of a request are unknown, revoked or malformed.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal is the authenticated API client making a request.
+type Principal struct {
+	// ID identifies the client, e.g. the API key ID.
+	ID string
+	// Name describes the client, e.g. "billing-service". It's recorded as the actor of the changes.
+	Name string
+	// TenantID is the tenant the client acts on behalf of. Empty means domain.DefaultTenantID.
+	TenantID string
+	// Scopes are the permissions granted to the client.
+	Scopes []domain.Scope
+}
+
+// HasScope reports whether any of the scopes of the principal grants the required one.
+// domain.ScopeAdminTenants is only ever granted to the principals of the default tenant,
+// since it allows acting on behalf of any tenant.
+func (p Principal) HasScope(required domain.Scope) bool {
+	if required == domain.ScopeAdminTenants && p.TenantID != "" && p.TenantID != domain.DefaultTenantID {
+		return false
+	}
+	for _, scope := range p.Scopes {
+		if scope.Grants(required) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator authenticates the API clients by the credential sent with their requests.
+type Authenticator interface {
+	// Authenticate returns the principal the credential belongs to,
+	// or ErrInvalidCredentials if it doesn't belong to any.
+	Authenticate(ctx context.Context, credential string) (Principal, error)
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying the authenticated principal.
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the authenticated principal carried by ctx, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Chain returns an authenticator trying each of the authenticators in turn, e.g. to accept both
+// JWTs and API keys. The credentials are invalid if none of them accepts the credentials, in
+// which case the most detailed error is returned. Other errors are returned straight away.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+type chain []Authenticator
+
+// Authenticate returns the principal of the first authenticator accepting the credential.
+func (c chain) Authenticate(ctx context.Context, credential string) (Principal, error) {
+	invalid := ErrInvalidCredentials
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(ctx, credential)
+		if err == nil {
+			return principal, nil
+		}
+		if !errors.Is(err, ErrInvalidCredentials) {
+			return Principal{}, err
+		}
+		if invalid == ErrInvalidCredentials { //nolint:errorlint // the bare error is replaced by a detailed one
+			invalid = err
+		}
+	}
+	return Principal{}, invalid
+}
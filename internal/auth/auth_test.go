@@ -0,0 +1,69 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/auth"
+	"notification/internal/domain"
+	"testing"
+)
+
+func TestPrincipal_HasScope(t *testing.T) {
+	admin := auth.Principal{ID: "k1", Scopes: []domain.Scope{domain.ScopeAll}}
+	assert.True(t, admin.HasScope(domain.ScopeAdminRules))
+	assert.True(t, admin.HasScope(domain.ScopeAdminTenants))
+	admin.TenantID = domain.DefaultTenantID
+	assert.True(t, admin.HasScope(domain.ScopeAdminTenants))
+
+	tenantAdmin := auth.Principal{ID: "k2", TenantID: "billing", Scopes: []domain.Scope{domain.ScopeAll}}
+	assert.True(t, tenantAdmin.HasScope(domain.ScopeAdminRules))
+	assert.False(t, tenantAdmin.HasScope(domain.ScopeAdminTenants), "only the default tenant manages the tenants")
+
+	sender := auth.Principal{ID: "k3", Scopes: []domain.Scope{"send:status"}}
+	assert.True(t, sender.HasScope("send:status"))
+	assert.False(t, sender.HasScope("send:news"))
+}
+
+// stubAuthenticator authenticates the given credential only.
+type stubAuthenticator struct {
+	credential string
+	principal  auth.Principal
+	err        error
+}
+
+func (a stubAuthenticator) Authenticate(_ context.Context, credential string) (auth.Principal, error) {
+	if a.err != nil {
+		return auth.Principal{}, a.err
+	}
+	if credential != a.credential {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	return a.principal, nil
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	service := stubAuthenticator{credential: "token", principal: auth.Principal{ID: "billing-service"}}
+	client := stubAuthenticator{credential: "key", principal: auth.Principal{ID: "k1"}}
+	expired := stubAuthenticator{err: errors.Join(auth.ErrInvalidCredentials, errors.New("token is expired"))}
+	failing := stubAuthenticator{err: errors.New("connection refused")}
+
+	principal, err := auth.Chain(service, client).Authenticate(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "billing-service", principal.ID)
+
+	principal, err = auth.Chain(service, client).Authenticate(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", principal.ID)
+
+	_, err = auth.Chain(service, client).Authenticate(ctx, "unknown")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+	_, err = auth.Chain(service, expired, client).Authenticate(ctx, "unknown")
+	assert.ErrorContains(t, err, "token is expired", "the detailed error is returned")
+
+	_, err = auth.Chain(failing, client).Authenticate(ctx, "key")
+	assert.EqualError(t, err, "connection refused", "the lookup failures aren't hidden")
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// maxJWKSSize is the maximum accepted size of the JWKS documents.
+	maxJWKSSize = 1 << 20
+	// defaultJWKSRefreshInterval is how long the keys are cached by default.
+	defaultJWKSRefreshInterval = time.Hour
+	// defaultJWKSMinRefreshInterval is the default minimum time between two fetches of the JWKS.
+	defaultJWKSMinRefreshInterval = time.Minute
+	// minRSAKeySize is the minimum size of the RSA keys, in bits.
+	minRSAKeySize = 2048
+)
+
+var (
+	// ErrJWKSUnavailable is the error when the JWKS can't be fetched.
+	ErrJWKSUnavailable = errors.New("jwks unavailable")
+	// ErrUnknownKey is the error when the JWKS doesn't hold the requested key.
+	ErrUnknownKey = errors.New("unknown signing key")
+)
+
+// KeySet provides the public keys verifying the JWT signatures, by key ID.
+type KeySet interface {
+	// Key returns the public key of the given ID, or ErrUnknownKey if there's none.
+	Key(ctx context.Context, kid string) (any, error)
+}
+
+// JWKSOption defines the optional params for JWKS.
+type JWKSOption func(*JWKS)
+
+// WithJWKSClient sets the HTTP client fetching the JWKS. Defaults to a client with a 10s timeout.
+func WithJWKSClient(client *http.Client) JWKSOption {
+	return func(j *JWKS) {
+		j.client = client
+	}
+}
+
+// WithJWKSRefreshInterval sets how long the keys are cached before being fetched again. Defaults to 1h.
+func WithJWKSRefreshInterval(d time.Duration) JWKSOption {
+	return func(j *JWKS) {
+		j.refreshInterval = d
+	}
+}
+
+// WithJWKSMinRefreshInterval sets the minimum time between two fetches of the JWKS,
+// whatever the key IDs of the tokens received. Defaults to 1m.
+func WithJWKSMinRefreshInterval(d time.Duration) JWKSOption {
+	return func(j *JWKS) {
+		j.minRefreshInterval = d
+	}
+}
+
+// NewJWKS creates a new JWKS instance fetching the key set from the given URL.
+func NewJWKS(url string, opts ...JWKSOption) *JWKS {
+	j := &JWKS{
+		url:                url,
+		client:             &http.Client{Timeout: 10 * time.Second},
+		refreshInterval:    defaultJWKSRefreshInterval,
+		minRefreshInterval: defaultJWKSMinRefreshInterval,
+		now:                time.Now,
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
+}
+
+// JWKS is the JSON Web Key Set published at a URL, e.g. by an OpenID Connect provider.
+// It supports the RSA, ECDSA (P-256, P-384 and P-521) and Ed25519 signing keys.
+//
+// The keys are fetched on first use and cached for the refresh interval. A token signed with
+// an unknown key triggers a fetch as well, so that rotated keys are picked up straight away,
+// but the JWKS is fetched at most once per minimum refresh interval, so that tokens with made-up
+// key IDs can't flood the provider. The cached keys keep being used while the provider is unavailable.
+//
+// It's safe for concurrent use.
+type JWKS struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	now                func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]any
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// Key returns the public key of the given ID, fetching the JWKS if needed.
+func (j *JWKS) Key(ctx context.Context, kid string) (any, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.now()
+	key, ok := j.keys[kid]
+	stale := now.Sub(j.fetchedAt) >= j.refreshInterval
+	if (!ok || stale) && (j.attemptedAt.IsZero() || now.Sub(j.attemptedAt) >= j.minRefreshInterval) {
+		j.attemptedAt = now
+		keys, err := j.fetch(ctx)
+		switch {
+		case err == nil:
+			j.keys, j.fetchedAt = keys, now
+			key, ok = keys[kid]
+		case !ok:
+			return nil, err
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// jsonWebKey is a JSON Web Key (RFC 7517), restricted to the params of the supported key types.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch retrieves the signing keys of the JWKS by key ID. The keys of unsupported types are skipped.
+func (j *JWKS) fetch(ctx context.Context) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWKSUnavailable, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWKSUnavailable, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unexpected status %d", ErrJWKSUnavailable, res.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.NewDecoder(io.LimitReader(res.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("%w: decode: %v", ErrJWKSUnavailable, err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// publicKey decodes the public key of the JWK.
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		if n.BitLen() < minRSAKeySize {
+			return nil, errors.New("RSA key too short")
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 2 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		return k.ecdsaPublicKey()
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// ecdsaPublicKey decodes the ECDSA public key of the JWK, checking that the point is on the curve.
+func (k jsonWebKey) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var (
+		curve     elliptic.Curve
+		ecdhCurve ecdh.Curve
+	)
+	switch k.Crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	x, errX := base64.RawURLEncoding.DecodeString(k.X)
+	y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+	if errX != nil || errY != nil || len(x) != size || len(y) != size {
+		return nil, errors.New("invalid EC coordinates")
+	}
+	// the uncompressed point encoding, which ecdh validates.
+	if _, err := ecdhCurve.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return nil, fmt.Errorf("invalid EC point: %w", err)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+// decodeBigInt decodes a base64url-encoded big-endian unsigned integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
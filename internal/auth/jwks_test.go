@@ -0,0 +1,204 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/auth"
+	"sync"
+	"testing"
+)
+
+// jwksServer is a local JWKS endpoint whose keys can be rotated and which can be made unavailable.
+type jwksServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	keys        []map[string]string
+	unavailable bool
+	requests    int
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		if s.unavailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// publish replaces the keys of the JWKS.
+func (s *jwksServer) publish(t *testing.T, keys map[string]crypto.PublicKey) {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = nil
+	for kid, key := range keys {
+		s.keys = append(s.keys, encodeJWK(t, kid, key))
+	}
+}
+
+func (s *jwksServer) setUnavailable(unavailable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unavailable = unavailable
+}
+
+func (s *jwksServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// encodeJWK encodes the public key as a JWK.
+func encodeJWK(t *testing.T, kid string, key crypto.PublicKey) map[string]string {
+	t.Helper()
+	encode := base64.RawURLEncoding.EncodeToString
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "use": "sig",
+			"n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return map[string]string{"kty": "EC", "kid": kid, "crv": key.Curve.Params().Name,
+			"x": encode(key.X.FillBytes(make([]byte, size))), "y": encode(key.Y.FillBytes(make([]byte, size)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": encode(key)}
+	default:
+		t.Fatalf("unsupported key %T", key)
+		return nil
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func TestJWKS_Key(t *testing.T) {
+	ctx := context.Background()
+	rsaKey := newRSAKey(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("keys are decoded and cached", func(t *testing.T) {
+		server := newJWKSServer(t)
+		server.publish(t, map[string]crypto.PublicKey{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey, "ed": edKey})
+		jwks := auth.NewJWKS(server.URL)
+
+		key, err := jwks.Key(ctx, "rsa")
+		require.NoError(t, err)
+		assert.True(t, rsaKey.PublicKey.Equal(key))
+		key, err = jwks.Key(ctx, "ec")
+		require.NoError(t, err)
+		assert.True(t, ecKey.PublicKey.Equal(key))
+		key, err = jwks.Key(ctx, "ed")
+		require.NoError(t, err)
+		assert.True(t, edKey.Equal(key))
+		assert.Equal(t, 1, server.requestCount())
+	})
+
+	t.Run("rotated key is fetched", func(t *testing.T) {
+		server := newJWKSServer(t)
+		server.publish(t, map[string]crypto.PublicKey{"k1": &rsaKey.PublicKey})
+		jwks := auth.NewJWKS(server.URL, auth.WithJWKSMinRefreshInterval(0))
+		_, err := jwks.Key(ctx, "k1")
+		require.NoError(t, err)
+
+		rotated := newRSAKey(t)
+		server.publish(t, map[string]crypto.PublicKey{"k2": &rotated.PublicKey})
+
+		key, err := jwks.Key(ctx, "k2")
+		require.NoError(t, err)
+		assert.True(t, rotated.PublicKey.Equal(key))
+		assert.Equal(t, 2, server.requestCount())
+	})
+
+	t.Run("unknown keys don't flood the provider", func(t *testing.T) {
+		server := newJWKSServer(t)
+		server.publish(t, map[string]crypto.PublicKey{"k1": &rsaKey.PublicKey})
+		jwks := auth.NewJWKS(server.URL)
+
+		for range 5 {
+			_, err := jwks.Key(ctx, "made-up")
+			assert.ErrorIs(t, err, auth.ErrUnknownKey)
+		}
+		assert.Equal(t, 1, server.requestCount())
+	})
+
+	t.Run("stale keys are refreshed", func(t *testing.T) {
+		server := newJWKSServer(t)
+		server.publish(t, map[string]crypto.PublicKey{"k1": &rsaKey.PublicKey})
+		jwks := auth.NewJWKS(server.URL, auth.WithJWKSRefreshInterval(0), auth.WithJWKSMinRefreshInterval(0))
+		_, err := jwks.Key(ctx, "k1")
+		require.NoError(t, err)
+
+		server.publish(t, nil)
+
+		_, err = jwks.Key(ctx, "k1")
+		assert.ErrorIs(t, err, auth.ErrUnknownKey, "revoked keys are dropped")
+		assert.Equal(t, 2, server.requestCount())
+	})
+
+	t.Run("cached keys are used while the provider is unavailable", func(t *testing.T) {
+		server := newJWKSServer(t)
+		server.publish(t, map[string]crypto.PublicKey{"k1": &rsaKey.PublicKey})
+		jwks := auth.NewJWKS(server.URL, auth.WithJWKSRefreshInterval(0), auth.WithJWKSMinRefreshInterval(0))
+		_, err := jwks.Key(ctx, "k1")
+		require.NoError(t, err)
+
+		server.setUnavailable(true)
+
+		key, err := jwks.Key(ctx, "k1")
+		require.NoError(t, err)
+		assert.True(t, rsaKey.PublicKey.Equal(key))
+		_, err = jwks.Key(ctx, "k2")
+		assert.ErrorIs(t, err, auth.ErrJWKSUnavailable)
+	})
+
+	t.Run("invalid keys are skipped", func(t *testing.T) {
+		server := newJWKSServer(t)
+		server.keys = []map[string]string{
+			{"kty": "RSA", "kid": "short", "n": "AQAB", "e": "AQAB"},
+			{"kty": "EC", "kid": "off-curve", "crv": "P-256",
+				"x": base64.RawURLEncoding.EncodeToString(make([]byte, 32)),
+				"y": base64.RawURLEncoding.EncodeToString(make([]byte, 32))},
+			{"kty": "oct", "kid": "symmetric", "k": "c2VjcmV0"},
+			encodeJWK(t, "encryption", &rsaKey.PublicKey),
+			encodeJWK(t, "valid", &rsaKey.PublicKey),
+		}
+		server.keys[3]["use"] = "enc"
+		jwks := auth.NewJWKS(server.URL)
+
+		for _, kid := range []string{"short", "off-curve", "symmetric", "encryption"} {
+			_, err := jwks.Key(ctx, kid)
+			assert.ErrorIs(t, err, auth.ErrUnknownKey, kid)
+		}
+		_, err := jwks.Key(ctx, "valid")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"notification/internal/domain"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTypesClaim is the default claim listing the notification types a token allows sending.
+	DefaultTypesClaim = "notification_types"
+	// DefaultTenantClaim is the default claim holding the ID of the tenant a token acts on behalf of.
+	DefaultTenantClaim = "tenant"
+	// scopeClaim is the standard OAuth 2.0 claim listing the scopes of a token, space separated.
+	scopeClaim = "scope"
+	// defaultJWTLeeway is the default clock skew tolerated when checking the token times.
+	defaultJWTLeeway = 30 * time.Second
+)
+
+// jwtMethods are the accepted signing algorithms, which are all asymmetric:
+// the tokens are verified with the public keys of the JWKS.
+var jwtMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// JWTOption defines the optional params for JWTAuthenticator.
+type JWTOption func(*JWTAuthenticator)
+
+// WithTypesClaim sets the claim listing the notification types a token allows sending,
+// either as an array or space separated. Defaults to DefaultTypesClaim.
+func WithTypesClaim(claim string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.typesClaim = claim
+	}
+}
+
+// WithTenantClaim sets the claim holding the ID of the tenant a token acts on behalf of.
+// Defaults to DefaultTenantClaim.
+func WithTenantClaim(claim string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.tenantClaim = claim
+	}
+}
+
+// WithJWTLeeway sets the clock skew tolerated when checking the expiration and the other token times.
+// Defaults to 30s.
+func WithJWTLeeway(leeway time.Duration) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.leeway = leeway
+	}
+}
+
+// NewJWTAuthenticator creates a new JWTAuthenticator instance accepting the tokens
+// issued by issuer for audience, signed with the keys of keys.
+func NewJWTAuthenticator(keys KeySet, issuer, audience string, opts ...JWTOption) *JWTAuthenticator {
+	a := &JWTAuthenticator{
+		keys:        keys,
+		issuer:      issuer,
+		audience:    audience,
+		typesClaim:  DefaultTypesClaim,
+		tenantClaim: DefaultTenantClaim,
+		leeway:      defaultJWTLeeway,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// JWTAuthenticator authenticates the clients by the JSON Web Tokens issued to them, e.g. by the
+// platform OpenID Connect provider. The tokens must be signed with a key of the key set, and hold
+// the expected issuer and audience, a subject and an expiration time.
+//
+// The subject is the ID of the principal. Its scopes are the ones of the standard "scope" claim,
+// plus the "send:<type>" scope of each notification type listed in the types claim. Its tenant
+// is the one of the tenant claim, or domain.DefaultTenantID if the token has none.
+type JWTAuthenticator struct {
+	keys        KeySet
+	issuer      string
+	audience    string
+	typesClaim  string
+	tenantClaim string
+	leeway      time.Duration
+	now         func() time.Time
+}
+
+// Authenticate returns the principal of the token, or ErrInvalidCredentials if the token isn't valid.
+// Credentials which aren't JWTs are rejected straight away.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, credential string) (Principal, error) {
+	if strings.Count(credential, ".") != 2 {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(credential, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("missing kid header")
+		}
+		return a.keys.Key(ctx, kid)
+	},
+		jwt.WithValidMethods(jwtMethods),
+		jwt.WithIssuer(a.issuer),
+		jwt.WithAudience(a.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(a.leeway),
+		jwt.WithTimeFunc(a.now))
+	if errors.Is(err, ErrJWKSUnavailable) {
+		return Principal{}, fmt.Errorf("verify token: %w", err)
+	}
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+	tenantID := domain.DefaultTenantID
+	if claim, ok := claims[a.tenantClaim]; ok {
+		if tenantID, _ = claim.(string); !domain.ValidTenantID(tenantID) {
+			return Principal{}, fmt.Errorf("%w: invalid %s claim", ErrInvalidCredentials, a.tenantClaim)
+		}
+	}
+	principal := Principal{ID: subject, Name: subject, TenantID: tenantID}
+	for _, scope := range claimValues(claims[scopeClaim]) {
+		if scope := domain.Scope(scope); scope.Valid() {
+			principal.Scopes = append(principal.Scopes, scope)
+		}
+	}
+	for _, value := range claimValues(claims[a.typesClaim]) {
+		// the types unknown to this service are ignored, since the tokens may be shared with other services.
+		if notificationType, err := domain.ToNotificationType(value); err == nil {
+			principal.Scopes = append(principal.Scopes, domain.SendScope(notificationType))
+		}
+	}
+	return principal, nil
+}
+
+// claimValues returns the string values of a claim, which is either a space separated string or an array.
+func claimValues(claim any) []string {
+	switch claim := claim.(type) {
+	case string:
+		return strings.Fields(claim)
+	case []any:
+		values := make([]string, 0, len(claim))
+		for _, value := range claim {
+			if value, ok := value.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
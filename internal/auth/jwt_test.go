@@ -0,0 +1,180 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/auth"
+	"notification/internal/domain"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://id.example.com"
+	testAudience = "notification"
+)
+
+// signToken signs the claims with key, as the key ID kid.
+func signToken(t *testing.T, method jwt.SigningMethod, key crypto.Signer, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// validClaims returns the claims of a valid token of the billing service, which can be overridden.
+func validClaims(overrides jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss": testIssuer,
+		"aud": testAudience,
+		"sub": "billing-service",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+func TestJWTAuthenticator_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	rsaKey := newRSAKey(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey := newRSAKey(t)
+
+	server := newJWKSServer(t)
+	server.publish(t, map[string]crypto.PublicKey{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey})
+	authenticator := auth.NewJWTAuthenticator(auth.NewJWKS(server.URL), testIssuer, testAudience)
+
+	t.Run("valid tokens", func(t *testing.T) {
+		tests := map[string]struct {
+			token string
+			want  auth.Principal
+		}{
+			"RSA": {
+				token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(nil)),
+				want:  auth.Principal{ID: "billing-service", Name: "billing-service", TenantID: domain.DefaultTenantID},
+			},
+			"ECDSA": {
+				token: signToken(t, jwt.SigningMethodES256, ecKey, "ec", validClaims(nil)),
+				want:  auth.Principal{ID: "billing-service", Name: "billing-service", TenantID: domain.DefaultTenantID},
+			},
+			"audience among others": {
+				token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa",
+					validClaims(jwt.MapClaims{"aud": []string{"billing", testAudience}})),
+				want: auth.Principal{ID: "billing-service", Name: "billing-service", TenantID: domain.DefaultTenantID},
+			},
+			"types claim": {
+				token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa",
+					validClaims(jwt.MapClaims{"notification_types": []string{"status", "invoice", "news"}})),
+				want: auth.Principal{ID: "billing-service", Name: "billing-service", TenantID: domain.DefaultTenantID, Scopes: []domain.Scope{
+					domain.SendScope(domain.Status), domain.SendScope(domain.News),
+				}},
+			},
+			"space separated types claim": {
+				token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa",
+					validClaims(jwt.MapClaims{"notification_types": "marketing"})),
+				want: auth.Principal{ID: "billing-service", Name: "billing-service", TenantID: domain.DefaultTenantID, Scopes: []domain.Scope{
+					domain.SendScope(domain.Marketing),
+				}},
+			},
+			"scope claim": {
+				token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa",
+					validClaims(jwt.MapClaims{"scope": "openid read:notifications send:*"})),
+				want: auth.Principal{ID: "billing-service", Name: "billing-service", TenantID: domain.DefaultTenantID, Scopes: []domain.Scope{
+					domain.ScopeReadNotifications, "send:*",
+				}},
+			},
+			"tenant claim": {
+				token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(jwt.MapClaims{"tenant": "billing"})),
+				want:  auth.Principal{ID: "billing-service", Name: "billing-service", TenantID: "billing"},
+			},
+			"expired within leeway": {
+				token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa",
+					validClaims(jwt.MapClaims{"exp": time.Now().Add(-10 * time.Second).Unix()})),
+				want: auth.Principal{ID: "billing-service", Name: "billing-service", TenantID: domain.DefaultTenantID},
+			},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				principal, err := authenticator.Authenticate(ctx, tt.token)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, principal)
+			})
+		}
+	})
+
+	t.Run("invalid tokens", func(t *testing.T) {
+		noKid := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims(nil))
+		noKidToken, err := noKid.SignedString(rsaKey)
+		require.NoError(t, err)
+		unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims(nil))
+		unsigned.Header["kid"] = "rsa"
+		unsignedToken, err := unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+		require.NoError(t, err)
+		symmetric := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims(nil))
+		symmetric.Header["kid"] = "rsa"
+		symmetricToken, err := symmetric.SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		tests := map[string]string{
+			"not a JWT":       "nk_not-a-jwt",
+			"malformed":       "a.b.c",
+			"wrong issuer":    signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(jwt.MapClaims{"iss": "https://evil.example.com"})),
+			"wrong audience":  signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(jwt.MapClaims{"aud": "billing"})),
+			"expired":         signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})),
+			"no expiration":   signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(jwt.MapClaims{"exp": nil})),
+			"not yet valid":   signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(jwt.MapClaims{"nbf": time.Now().Add(time.Minute).Unix()})),
+			"no subject":      signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(jwt.MapClaims{"sub": nil})),
+			"unknown key":     signToken(t, jwt.SigningMethodRS256, otherKey, "other", validClaims(nil)),
+			"wrong signature": signToken(t, jwt.SigningMethodRS256, otherKey, "rsa", validClaims(nil)),
+			"wrong key type":  signToken(t, jwt.SigningMethodES256, ecKey, "rsa", validClaims(nil)),
+			"invalid tenant":  signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(jwt.MapClaims{"tenant": "Billing:EU"})),
+			"tenant not text": signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(jwt.MapClaims{"tenant": 42})),
+			"no kid":          noKidToken,
+			"alg none":        unsignedToken,
+			"symmetric alg":   symmetricToken,
+		}
+		for name, token := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := authenticator.Authenticate(ctx, token)
+				assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+			})
+		}
+	})
+
+	t.Run("custom types claim", func(t *testing.T) {
+		authenticator := auth.NewJWTAuthenticator(auth.NewJWKS(server.URL), testIssuer, testAudience,
+			auth.WithTypesClaim("https://example.com/notifications"))
+		token := signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa",
+			validClaims(jwt.MapClaims{"https://example.com/notifications": []string{"status"}}))
+
+		principal, err := authenticator.Authenticate(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Scope{domain.SendScope(domain.Status)}, principal.Scopes)
+	})
+
+	t.Run("JWKS unavailable", func(t *testing.T) {
+		unavailable := newJWKSServer(t)
+		unavailable.setUnavailable(true)
+		authenticator := auth.NewJWTAuthenticator(auth.NewJWKS(unavailable.URL), testIssuer, testAudience)
+
+		_, err := authenticator.Authenticate(ctx, signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(nil)))
+		assert.ErrorIs(t, err, auth.ErrJWKSUnavailable)
+		assert.NotErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+}
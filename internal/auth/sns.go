@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // SNS signature version 1 is SHA1withRSA.
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSNSCertificateSize is the maximum accepted size of the SNS signing certificates.
+const maxSNSCertificateSize = 64 << 10
+
+var (
+	// ErrInvalidSNSSignature is the error when an Amazon SNS message isn't signed by SNS.
+	ErrInvalidSNSSignature = errors.New("invalid sns signature")
+	// ErrSNSCertificateUnavailable is the error when the SNS signing certificate can't be fetched.
+	ErrSNSCertificateUnavailable = errors.New("sns signing certificate unavailable")
+)
+
+// snsCertificateHost matches the hosts of the SNS endpoints serving the signing certificates.
+var snsCertificateHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// SNSMessage holds the fields of an Amazon SNS message that are relevant to its signature.
+type SNSMessage struct {
+	Type             string
+	MessageID        string
+	TopicArn         string
+	Subject          string
+	Message          string
+	Timestamp        string
+	Token            string
+	SubscribeURL     string
+	SignatureVersion string
+	Signature        string
+	SigningCertURL   string
+}
+
+// stringToSign returns the string the signature of the message is computed on, which depends on its type.
+func (m SNSMessage) stringToSign() (string, error) {
+	var fields [][2]string
+	switch m.Type {
+	case "Notification":
+		fields = [][2]string{{"Message", m.Message}, {"MessageId", m.MessageID}}
+		if m.Subject != "" {
+			fields = append(fields, [2]string{"Subject", m.Subject})
+		}
+		fields = append(fields, [2]string{"Timestamp", m.Timestamp}, [2]string{"TopicArn", m.TopicArn},
+			[2]string{"Type", m.Type})
+	case "SubscriptionConfirmation", "UnsubscribeConfirmation":
+		fields = [][2]string{{"Message", m.Message}, {"MessageId", m.MessageID}, {"SubscribeURL", m.SubscribeURL},
+			{"Timestamp", m.Timestamp}, {"Token", m.Token}, {"TopicArn", m.TopicArn}, {"Type", m.Type}}
+	default:
+		return "", fmt.Errorf("%w: unsupported message type %q", ErrInvalidSNSSignature, m.Type)
+	}
+
+	var b strings.Builder
+	for _, field := range fields {
+		b.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return b.String(), nil
+}
+
+// SNSVerifierOption defines the optional params for SNSVerifier.
+type SNSVerifierOption func(*SNSVerifier)
+
+// WithSNSClient sets the HTTP client fetching the signing certificates. Defaults to a client with a 10s timeout.
+func WithSNSClient(client *http.Client) SNSVerifierOption {
+	return func(v *SNSVerifier) {
+		v.client = client
+	}
+}
+
+// NewSNSVerifier creates a new SNSVerifier instance.
+func NewSNSVerifier(opts ...SNSVerifierOption) *SNSVerifier {
+	v := &SNSVerifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+		certs:  make(map[string]*x509.Certificate),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// SNSVerifier verifies that the Amazon SNS messages are signed by SNS, with the signature
+// versions 1 (SHA1withRSA) and 2 (SHA256withRSA).
+//
+// The signing certificates are only fetched over HTTPS from the SNS endpoints, and cached
+// until they expire. It's safe for concurrent use.
+type SNSVerifier struct {
+	client *http.Client
+	now    func() time.Time
+
+	mu    sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+// Verify returns ErrInvalidSNSSignature if the message isn't signed by SNS.
+func (v *SNSVerifier) Verify(ctx context.Context, message SNSMessage) error {
+	var hash crypto.Hash
+	switch message.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("%w: unsupported signature version %q", ErrInvalidSNSSignature, message.SignatureVersion)
+	}
+	signature, err := base64.StdEncoding.DecodeString(message.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature encoding", ErrInvalidSNSSignature)
+	}
+	signed, err := message.stringToSign()
+	if err != nil {
+		return err
+	}
+
+	cert, err := v.certificate(ctx, message.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: unsupported signing key", ErrInvalidSNSSignature)
+	}
+
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum([]byte(signed)) //nolint:gosec // required by the signature version 1.
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(signed))
+		digest = sum[:]
+	}
+	if err = rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSNSSignature, err)
+	}
+	return nil
+}
+
+// certificate returns the signing certificate published at the URL, fetching it if it isn't cached.
+func (v *SNSVerifier) certificate(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	u, err := url.Parse(certURL)
+	if err != nil || u.Scheme != "https" || !snsCertificateHost.MatchString(u.Hostname()) ||
+		u.Port() != "" || !strings.HasSuffix(u.Path, ".pem") {
+		return nil, fmt.Errorf("%w: untrusted signing certificate URL %q", ErrInvalidSNSSignature, certURL)
+	}
+
+	v.mu.Lock()
+	cert, ok := v.certs[certURL]
+	v.mu.Unlock()
+	now := v.now()
+	if ok && now.Before(cert.NotAfter) {
+		return cert, nil
+	}
+
+	if cert, err = v.fetch(ctx, certURL); err != nil {
+		return nil, err
+	}
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("%w: expired signing certificate", ErrInvalidSNSSignature)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// drop the expired certificates, SNS rotating them from time to time.
+	for cachedURL, cached := range v.certs {
+		if now.After(cached.NotAfter) {
+			delete(v.certs, cachedURL)
+		}
+	}
+	v.certs[certURL] = cert
+	return cert, nil
+}
+
+// fetch retrieves the PEM encoded certificate published at the URL.
+func (v *SNSVerifier) fetch(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSNSCertificateUnavailable, err)
+	}
+	res, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSNSCertificateUnavailable, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unexpected status %d", ErrSNSCertificateUnavailable, res.StatusCode)
+	}
+
+	encoded, err := io.ReadAll(io.LimitReader(res.Body, maxSNSCertificateSize))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSNSCertificateUnavailable, err)
+	}
+	block, _ := pem.Decode(encoded)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%w: invalid PEM certificate", ErrSNSCertificateUnavailable)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSNSCertificateUnavailable, err)
+	}
+	return cert, nil
+}
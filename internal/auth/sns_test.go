@@ -0,0 +1,162 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/big"
+	"net/http"
+	"notification/internal/auth"
+	"strings"
+	"testing"
+	"time"
+)
+
+const snsCertURL = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-abc.pem"
+
+// snsSigner signs SNS messages with a self-signed certificate served in place of the SNS one.
+type snsSigner struct {
+	key      *rsa.PrivateKey
+	pem      []byte
+	requests int
+}
+
+func newSNSSigner(t *testing.T) *snsSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return &snsSigner{key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// client returns an HTTP client serving the certificate of the signer at any URL.
+func (s *snsSigner) client() *http.Client {
+	return &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		s.requests++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(s.pem)))}, nil
+	})}
+}
+
+// sign sets the signature of the message, computed on the given string to sign.
+func (s *snsSigner) sign(t *testing.T, message *auth.SNSMessage, stringToSign string) {
+	t.Helper()
+	var (
+		hash   crypto.Hash
+		digest []byte
+	)
+	if message.SignatureVersion == "1" {
+		sum := sha1.Sum([]byte(stringToSign))
+		hash, digest = crypto.SHA1, sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(stringToSign))
+		hash, digest = crypto.SHA256, sum[:]
+	}
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, hash, digest)
+	require.NoError(t, err)
+	message.Signature = base64.StdEncoding.EncodeToString(signature)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestSNSVerifier_Verify(t *testing.T) {
+	ctx := context.Background()
+	notification := func(version string) auth.SNSMessage {
+		return auth.SNSMessage{
+			Type:             "Notification",
+			MessageID:        "m1",
+			TopicArn:         "arn:aws:sns:us-east-1:123456789012:bounces",
+			Message:          `{"notificationType":"Bounce"}`,
+			Timestamp:        "2026-10-19T10:00:00.000Z",
+			SignatureVersion: version,
+			SigningCertURL:   snsCertURL,
+		}
+	}
+	const notificationString = "Message\n{\"notificationType\":\"Bounce\"}\nMessageId\nm1\n" +
+		"Timestamp\n2026-10-19T10:00:00.000Z\nTopicArn\narn:aws:sns:us-east-1:123456789012:bounces\nType\nNotification\n"
+
+	t.Run("signature versions", func(t *testing.T) {
+		signer := newSNSSigner(t)
+		verifier := auth.NewSNSVerifier(auth.WithSNSClient(signer.client()))
+		for _, version := range []string{"1", "2"} {
+			message := notification(version)
+			signer.sign(t, &message, notificationString)
+			assert.NoError(t, verifier.Verify(ctx, message), "version %s", version)
+		}
+		assert.Equal(t, 1, signer.requests, "the certificate is cached")
+	})
+
+	t.Run("subscription confirmation", func(t *testing.T) {
+		signer := newSNSSigner(t)
+		verifier := auth.NewSNSVerifier(auth.WithSNSClient(signer.client()))
+		message := auth.SNSMessage{
+			Type:             "SubscriptionConfirmation",
+			MessageID:        "m1",
+			TopicArn:         "arn:aws:sns:us-east-1:123456789012:bounces",
+			Message:          "confirm",
+			Timestamp:        "2026-10-19T10:00:00.000Z",
+			Token:            "t1",
+			SubscribeURL:     "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription",
+			SignatureVersion: "2",
+			SigningCertURL:   snsCertURL,
+		}
+		signer.sign(t, &message, "Message\nconfirm\nMessageId\nm1\n"+
+			"SubscribeURL\nhttps://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription\n"+
+			"Timestamp\n2026-10-19T10:00:00.000Z\nToken\nt1\nTopicArn\narn:aws:sns:us-east-1:123456789012:bounces\n"+
+			"Type\nSubscriptionConfirmation\n")
+		assert.NoError(t, verifier.Verify(ctx, message))
+	})
+
+	t.Run("tampered message", func(t *testing.T) {
+		signer := newSNSSigner(t)
+		verifier := auth.NewSNSVerifier(auth.WithSNSClient(signer.client()))
+		message := notification("2")
+		signer.sign(t, &message, notificationString)
+		message.Message = `{"notificationType":"Complaint"}`
+		assert.ErrorIs(t, verifier.Verify(ctx, message), auth.ErrInvalidSNSSignature)
+	})
+
+	t.Run("untrusted certificate URL", func(t *testing.T) {
+		for _, certURL := range []string{
+			"http://sns.us-east-1.amazonaws.com/cert.pem",
+			"https://sns.us-east-1.amazonaws.com.example.com/cert.pem",
+			"https://attacker.example.com/cert.pem",
+			"https://sns.us-east-1.amazonaws.com:8443/cert.pem",
+			"https://sns.us-east-1.amazonaws.com/cert",
+		} {
+			signer := newSNSSigner(t)
+			verifier := auth.NewSNSVerifier(auth.WithSNSClient(signer.client()))
+			message := notification("2")
+			message.SigningCertURL = certURL
+			signer.sign(t, &message, notificationString)
+			assert.ErrorIs(t, verifier.Verify(ctx, message), auth.ErrInvalidSNSSignature, certURL)
+			assert.Zero(t, signer.requests, certURL)
+		}
+	})
+
+	t.Run("unsigned message", func(t *testing.T) {
+		verifier := auth.NewSNSVerifier(auth.WithSNSClient(newSNSSigner(t).client()))
+		message := notification("")
+		assert.ErrorIs(t, verifier.Verify(ctx, message), auth.ErrInvalidSNSSignature)
+	})
+}
@@ -2,22 +2,745 @@ package config_test
 
 import (
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"notification/internal/config"
+	"notification/internal/domain"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewAppConfig(t *testing.T) {
+	setRequiredEnv(t)
+
 	t.Run("server port is populated", func(t *testing.T) {
-		os.Setenv("SERVER_PORT", "8081")
-		defer os.Unsetenv("SERVER_PORT")
+		t.Setenv("SERVER_PORT", "8081")
 
-		cfg := config.NewAppConfig()
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
 
 		assert.Equal(t, 8081, cfg.ServerPort)
 	})
 	t.Run("server port defaults to 8080", func(t *testing.T) {
-		cfg := config.NewAppConfig()
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
 		assert.Equal(t, 8080, cfg.ServerPort)
 	})
 }
+
+func TestNewAppConfig_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "missing mail from",
+			env:  map[string]string{"MAIL_FROM": ""},
+			want: []string{"MAIL_FROM: is required"},
+		},
+		{
+			name: "invalid mail from",
+			env:  map[string]string{"MAIL_FROM": "John <john@example.com>"},
+			want: []string{`MAIL_FROM: "John <john@example.com>" is not a valid email address`},
+		},
+		{
+			name: "invalid integers",
+			env: map[string]string{
+				"SERVER_PORT":          "80a",
+				"WEBHOOK_MAX_ATTEMPTS": "five",
+			},
+			want: []string{
+				`SERVER_PORT: "80a" is not a valid integer`,
+				`WEBHOOK_MAX_ATTEMPTS: "five" is not a valid integer`,
+			},
+		},
+		{
+			name: "out of range integers",
+			env: map[string]string{
+				"REDIS_PORT":         "70000",
+				"SMTP_PORT":          "0",
+				"WEBHOOK_WORKERS":    "0",
+				"SMTP_POOL_MAX_IDLE": "-1",
+			},
+			want: []string{
+				"REDIS_PORT: 70000 is not a valid port",
+				"SMTP_PORT: must be at least 1",
+				"WEBHOOK_WORKERS: must be at least 1",
+				"SMTP_POOL_MAX_IDLE: must be at least 0",
+			},
+		},
+		{
+			name: "invalid durations",
+			env: map[string]string{
+				"WEBHOOK_TIMEOUT":    "10",
+				"DELIVERY_RETENTION": "-1h",
+			},
+			want: []string{
+				`WEBHOOK_TIMEOUT: "10" is not a valid duration`,
+				"DELIVERY_RETENTION: must not be negative",
+			},
+		},
+		{
+			name: "unsupported values",
+			env: map[string]string{
+				"MAIL_PROVIDER":       "pigeon",
+				"SMTP_AUTH_MECHANISM": "kerberos",
+				"DELIVERY_STORE":      "disk",
+				"LOG_LEVEL":           "verbose",
+			},
+			want: []string{
+				`MAIL_PROVIDER: "pigeon" is not one of smtp, sendgrid, mailgun, ses`,
+				`SMTP_AUTH_MECHANISM: "kerberos" is not one of plain, login, cram-md5, xoauth2`,
+				`DELIVERY_STORE: "disk" is not one of redis, sql, sqlite, memory`,
+				`LOG_LEVEL: "verbose" is not one of debug, info, warn, error`,
+			},
+		},
+		{
+			name: "invalid provider list",
+			env:  map[string]string{"MAIL_PROVIDERS": "smtp:x,pigeon,smtp:1:0,smtp:1:1:1"},
+			want: []string{
+				`MAIL_PROVIDERS: "smtp:x" has an invalid priority`,
+				`MAIL_PROVIDERS: unsupported provider "pigeon"`,
+				`MAIL_PROVIDERS: "smtp:1:0" has an invalid weight, it must be a positive integer`,
+				`MAIL_PROVIDERS: "smtp:1:1:1" is not a valid "name[:priority[:weight]]" entry`,
+			},
+		},
+		{
+			name: "invalid default rule",
+			env:  map[string]string{"RULE_DEFAULT": "10 per hour"},
+			want: []string{`RULE_DEFAULT: "10 per hour" is not a valid "<maxCount>/<expiration>" rule`},
+		},
+		{
+			name: "out of range default rule",
+			env:  map[string]string{"RULE_DEFAULT": "0/1h"},
+			want: []string{"RULE_DEFAULT: invalid rate limit rule"},
+		},
+		{
+			name: "missing provider credentials",
+			env:  map[string]string{"MAIL_PROVIDERS": "mailgun,ses"},
+			want: []string{
+				"MAILGUN_API_KEY: is required by the mailgun provider",
+				"MAILGUN_DOMAIN: is required by the mailgun provider",
+				"SES_ACCESS_KEY_ID: is required by the ses provider",
+				"SES_SECRET_ACCESS_KEY: is required by the ses provider",
+			},
+		},
+		{
+			name: "missing SendGrid API key",
+			env:  map[string]string{"MAIL_PROVIDER": "sendgrid", "SMTP_AUTH_MECHANISM": "xoauth2"},
+			want: []string{
+				"SENDGRID_API_KEY: is required by the sendgrid provider",
+			},
+		},
+		{
+			name: "missing SMTP auth credentials",
+			env:  map[string]string{"SMTP_AUTH_MECHANISM": "xoauth2"},
+			want: []string{
+				"SMTP_USERNAME: is required by the xoauth2 SMTP auth mechanism",
+				"SMTP_OAUTH2_TOKEN: or SMTP_OAUTH2_TOKEN_FILE is required by the xoauth2 SMTP auth mechanism",
+			},
+		},
+		{
+			name: "missing database URL",
+			env:  map[string]string{"DELIVERY_STORE": "sql", "USER_STORE": "sql", "RULE_STORE": "sql"},
+			want: []string{
+				"DATABASE_URL: is required by the sql delivery store",
+				"DATABASE_URL: is required by the sql user store",
+				"DATABASE_URL: is required by the sql rule store",
+			},
+		},
+		{
+			name: "unreadable secret file",
+			env:  map[string]string{"SMTP_PASSWORD_FILE": "/does/not/exist"},
+			want: []string{"SMTP_PASSWORD_FILE: open /does/not/exist: no such file or directory"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := config.NewAppConfig()
+
+			assert.Nil(t, cfg)
+			require.ErrorIs(t, err, config.ErrInvalidConfig)
+			for _, want := range tt.want {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+
+	t.Run("every problem is reported at once", func(t *testing.T) {
+		t.Setenv("MAIL_FROM", "")
+		t.Setenv("SERVER_PORT", "80a")
+		t.Setenv("REDIS_PORT", "abc")
+
+		_, err := config.NewAppConfig()
+
+		require.ErrorIs(t, err, config.ErrInvalidConfig)
+		assert.Equal(t, "invalid configuration:\n"+
+			`SERVER_PORT: "80a" is not a valid integer`+"\n"+
+			"MAIL_FROM: is required\n"+
+			`REDIS_PORT: "abc" is not a valid integer`, err.Error())
+	})
+	t.Run("SMTP credentials are only required when SMTP is used", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("MAIL_PROVIDER", "sendgrid")
+		t.Setenv("SENDGRID_API_KEY", "sg-key")
+		t.Setenv("SMTP_AUTH_MECHANISM", "login")
+
+		_, err := config.NewAppConfig()
+		assert.NoError(t, err)
+	})
+}
+
+func TestAppConfig_Print(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("SERVER_PORT", "9090")
+	t.Setenv("SMTP_USERNAME", "john")
+	t.Setenv("SMTP_PASSWORD", "secret")
+	t.Setenv("DKIM_HEADERS", "From, To")
+
+	cfg, err := config.NewAppConfig()
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, cfg.Print(&out))
+
+	printed := out.String()
+	for _, want := range []string{
+		"SERVER_PORT=9090\n",
+		"REDIS_HOST=localhost\n",
+		"MAIL_FROM=no-reply@example.com\n",
+		"SMTP_AUTH_MECHANISM=plain\n",
+		"SMTP_USERNAME=[REDACTED]\n",
+		"SMTP_PASSWORD=[REDACTED]\n",
+		"SENDGRID_API_KEY=\n",
+		"DKIM_HEADERS=From, To\n",
+		"WEBHOOK_TIMEOUT=10s\n",
+	} {
+		assert.Contains(t, printed, want)
+	}
+	assert.NotContains(t, printed, "secret")
+	assert.NotContains(t, printed, "john")
+}
+
+func TestMail_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("auth mechanism defaults to plain when username is set", func(t *testing.T) {
+		t.Setenv("SMTP_USERNAME", "john")
+		t.Setenv("SMTP_PASSWORD", "secret")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "plain", cfg.SMTPAuthMechanism)
+	})
+	t.Run("auth is disabled without username", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Empty(t, cfg.SMTPAuthMechanism)
+	})
+	t.Run("credentials are read from files", func(t *testing.T) {
+		dir := t.TempDir()
+		usernameFile := filepath.Join(dir, "username")
+		passwordFile := filepath.Join(dir, "password")
+		require.NoError(t, os.WriteFile(usernameFile, []byte("john\n"), 0600))
+		require.NoError(t, os.WriteFile(passwordFile, []byte("secret\n"), 0600))
+		t.Setenv("SMTP_USERNAME_FILE", usernameFile)
+		t.Setenv("SMTP_PASSWORD_FILE", passwordFile)
+		t.Setenv("SMTP_AUTH_MECHANISM", "LOGIN")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "john", cfg.SMTPUsername)
+		assert.Equal(t, "secret", cfg.SMTPPassword)
+		assert.Equal(t, "login", cfg.SMTPAuthMechanism)
+	})
+	t.Run("env var takes precedence over file", func(t *testing.T) {
+		t.Setenv("SMTP_USERNAME", "john")
+		passwordFile := filepath.Join(t.TempDir(), "password")
+		require.NoError(t, os.WriteFile(passwordFile, []byte("from-file"), 0600))
+		t.Setenv("SMTP_PASSWORD", "from-env")
+		t.Setenv("SMTP_PASSWORD_FILE", passwordFile)
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "from-env", cfg.SMTPPassword)
+	})
+}
+
+func TestMail_parseConfig_Pool(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, cfg.SMTPPoolMaxIdle)
+		assert.Equal(t, 5*time.Minute, cfg.SMTPPoolMaxLifetime)
+		assert.Equal(t, 100, cfg.SMTPPoolMaxMessages)
+	})
+	t.Run("pooling can be disabled", func(t *testing.T) {
+		t.Setenv("SMTP_POOL_MAX_IDLE", "0")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, cfg.SMTPPoolMaxIdle)
+	})
+	t.Run("durations are parsed", func(t *testing.T) {
+		t.Setenv("SMTP_POOL_IDLE_TIMEOUT", "30s")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, 30*time.Second, cfg.SMTPPoolIdleTimeout)
+	})
+}
+
+func TestMail_parseConfig_DKIM(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Empty(t, cfg.DKIMPrivateKeyFile)
+		assert.Equal(t, "example.com", cfg.DKIMDomain)
+		assert.Equal(t, "default", cfg.DKIMSelector)
+		assert.Empty(t, cfg.DKIMHeaders)
+	})
+	t.Run("headers are parsed", func(t *testing.T) {
+		t.Setenv("DKIM_HEADERS", "From, To ,Subject,")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"From", "To", "Subject"}, cfg.DKIMHeaders)
+	})
+	t.Run("parent domain", func(t *testing.T) {
+		t.Setenv("MAIL_FROM", "no-reply@mail.example.com")
+		t.Setenv("DKIM_PRIVATE_KEY_FILE", "/etc/dkim.pem")
+		t.Setenv("DKIM_DOMAIN", "Example.com")
+
+		_, err := config.NewAppConfig()
+		assert.NoError(t, err)
+	})
+	t.Run("domain isn't aligned with the sender", func(t *testing.T) {
+		t.Setenv("DKIM_PRIVATE_KEY_FILE", "/etc/dkim.pem")
+		t.Setenv("DKIM_DOMAIN", "notexample.com")
+
+		_, err := config.NewAppConfig()
+		assert.ErrorIs(t, err, config.ErrInvalidConfig)
+		assert.ErrorContains(t, err, "DKIM_DOMAIN")
+	})
+}
+
+func TestMail_parseConfig_Providers(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("single provider by default", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "smtp", cfg.MailProvider)
+		assert.Empty(t, cfg.MailProviders)
+	})
+	t.Run("provider list is parsed", func(t *testing.T) {
+		t.Setenv("MAIL_PROVIDERS", "SMTP:1:3, sendgrid:1, ses:2,")
+		t.Setenv("SENDGRID_API_KEY", "sg-key")
+		t.Setenv("SES_ACCESS_KEY_ID", "access-key")
+		t.Setenv("SES_SECRET_ACCESS_KEY", "secret-key")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, []config.MailProviderConfig{
+			{Name: "smtp", Priority: 1, Weight: 3},
+			{Name: "sendgrid", Priority: 1, Weight: 1},
+			{Name: "ses", Priority: 2, Weight: 1},
+		}, cfg.MailProviders)
+	})
+}
+
+func TestMailThrottle_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.False(t, cfg.Throttled())
+		assert.Zero(t, cfg.MailThrottleRate)
+		assert.Zero(t, cfg.MailThrottleTenantRate)
+		assert.Empty(t, cfg.MailThrottleDomainRates)
+		assert.Equal(t, 30*time.Second, cfg.MailThrottleMaxWait)
+	})
+	t.Run("limits are parsed", func(t *testing.T) {
+		t.Setenv("MAIL_THROTTLE_RATE", "50")
+		t.Setenv("MAIL_THROTTLE_TENANT_RATE", "10")
+		t.Setenv("MAIL_THROTTLE_DOMAIN_RATES", "Example.com=5, news.example.com = 2,")
+		t.Setenv("MAIL_THROTTLE_MAX_WAIT", "1m")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.True(t, cfg.Throttled())
+		assert.Equal(t, 50, cfg.MailThrottleRate)
+		assert.Equal(t, 10, cfg.MailThrottleTenantRate)
+		assert.Equal(t, map[string]int{"example.com": 5, "news.example.com": 2}, cfg.MailThrottleDomainRates)
+		assert.Equal(t, time.Minute, cfg.MailThrottleMaxWait)
+	})
+	t.Run("invalid limits", func(t *testing.T) {
+		t.Setenv("MAIL_THROTTLE_RATE", "-1")
+		t.Setenv("MAIL_THROTTLE_DOMAIN_RATES", "example.com,=3,news.example.com=0")
+
+		_, err := config.NewAppConfig()
+
+		require.ErrorIs(t, err, config.ErrInvalidConfig)
+		assert.ErrorContains(t, err, "MAIL_THROTTLE_RATE: must be at least 0")
+		assert.ErrorContains(t, err, `MAIL_THROTTLE_DOMAIN_RATES: "example.com" is not a valid "domain=rate" entry`)
+		assert.ErrorContains(t, err, `MAIL_THROTTLE_DOMAIN_RATES: "=3" is not a valid "domain=rate" entry`)
+		assert.ErrorContains(t, err, `MAIL_THROTTLE_DOMAIN_RATES: "news.example.com=0" has an invalid rate`)
+	})
+}
+
+func TestBounce_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Empty(t, cfg.BounceWebhookToken)
+		assert.Empty(t, cfg.BounceMaildir)
+		assert.Equal(t, time.Minute, cfg.BounceMaildirPollInterval)
+		assert.Equal(t, "redis", cfg.SuppressionStore)
+	})
+	t.Run("webhook token is read from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "token")
+		require.NoError(t, os.WriteFile(path, []byte("s3cr3t\n"), 0600))
+		t.Setenv("BOUNCE_WEBHOOK_TOKEN_FILE", path)
+		t.Setenv("BOUNCE_MAILDIR", "/var/mail/bounces")
+		t.Setenv("BOUNCE_MAILDIR_POLL_INTERVAL", "30s")
+		t.Setenv("SUPPRESSION_STORE", "Memory")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "s3cr3t", cfg.BounceWebhookToken)
+		assert.Equal(t, "/var/mail/bounces", cfg.BounceMaildir)
+		assert.Equal(t, 30*time.Second, cfg.BounceMaildirPollInterval)
+		assert.Equal(t, "memory", cfg.SuppressionStore)
+	})
+}
+
+func TestDelivery_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "redis", cfg.DeliveryStore)
+		assert.Equal(t, 30*24*time.Hour, cfg.DeliveryRetention)
+	})
+	t.Run("SQL store", func(t *testing.T) {
+		t.Setenv("DELIVERY_STORE", "SQL")
+		t.Setenv("DATABASE_URL", "postgres://localhost/notification")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "sql", cfg.DeliveryStore)
+		assert.Equal(t, "postgres://localhost/notification", cfg.DatabaseURL)
+	})
+}
+
+func TestUsers_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "memory", cfg.UserStore)
+		assert.Equal(t, "notification.db", cfg.SQLitePath)
+	})
+	t.Run("SQLite store", func(t *testing.T) {
+		t.Setenv("USER_STORE", "sqlite")
+		t.Setenv("SQLITE_PATH", "/var/lib/notification/users.db")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "sqlite", cfg.UserStore)
+		assert.Equal(t, "/var/lib/notification/users.db", cfg.SQLitePath)
+	})
+}
+
+func TestTenants_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "memory", cfg.TenantStore)
+	})
+	t.Run("SQLite store", func(t *testing.T) {
+		t.Setenv("TENANT_STORE", "sqlite")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "sqlite", cfg.TenantStore)
+	})
+	t.Run("sql store without database", func(t *testing.T) {
+		t.Setenv("TENANT_STORE", "sql")
+
+		_, err := config.NewAppConfig()
+		require.ErrorIs(t, err, config.ErrInvalidConfig)
+		assert.ErrorContains(t, err, "DATABASE_URL: is required by the sql tenant store")
+	})
+}
+
+func TestRateLimit_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "redis", cfg.RuleStore)
+		assert.Equal(t, 10*time.Second, cfg.RuleCacheTTL)
+		assert.Nil(t, cfg.DefaultRule)
+		assert.Equal(t, "redis", cfg.OverrideStore)
+	})
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("RULE_STORE", "SQLite")
+		t.Setenv("OVERRIDE_STORE", "memory")
+		t.Setenv("RULE_CACHE_TTL", "0s")
+		t.Setenv("RULE_DEFAULT", "10/1h")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "sqlite", cfg.RuleStore)
+		assert.Zero(t, cfg.RuleCacheTTL)
+		assert.Equal(t, &domain.RateLimitRule{MaxCount: 10, Expiration: time.Hour}, cfg.DefaultRule)
+		assert.Equal(t, "memory", cfg.OverrideStore)
+	})
+	t.Run("unlimited default rule", func(t *testing.T) {
+		t.Setenv("RULE_DEFAULT", "Unlimited")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, &domain.RateLimitRule{Unlimited: true}, cfg.DefaultRule)
+	})
+}
+
+func TestHTTPServer_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, 8080, cfg.ServerPort)
+		assert.Equal(t, 2*time.Second, cfg.ReadinessCheckTimeout)
+		assert.Equal(t, 5*time.Second, cfg.ReadinessCacheTTL)
+	})
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("READINESS_CHECK_TIMEOUT", "500ms")
+		t.Setenv("READINESS_CACHE_TTL", "0s")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, 500*time.Millisecond, cfg.ReadinessCheckTimeout)
+		assert.Zero(t, cfg.ReadinessCacheTTL)
+	})
+	t.Run("zero timeout", func(t *testing.T) {
+		t.Setenv("READINESS_CHECK_TIMEOUT", "0s")
+
+		_, err := config.NewAppConfig()
+		assert.ErrorContains(t, err, "READINESS_CHECK_TIMEOUT: must be positive")
+	})
+}
+
+func TestLogging_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "info", cfg.LogLevel)
+		assert.Equal(t, "json", cfg.LogFormat)
+	})
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("LOG_LEVEL", "DEBUG")
+		t.Setenv("LOG_FORMAT", "console")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "debug", cfg.LogLevel)
+		assert.Equal(t, "console", cfg.LogFormat)
+	})
+}
+
+func TestTracing_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "none", cfg.TracesExporter)
+		assert.Empty(t, cfg.OTLPEndpoint)
+		assert.Equal(t, "notification", cfg.ServiceName)
+	})
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_EXPORTER", "otlp")
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+		t.Setenv("OTEL_SERVICE_NAME", "notification-eu")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "otlp", cfg.TracesExporter)
+		assert.Equal(t, "http://collector:4318", cfg.OTLPEndpoint)
+		assert.Equal(t, "notification-eu", cfg.ServiceName)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_EXPORTER", "jaeger")
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "collector:4318")
+
+		_, err := config.NewAppConfig()
+		require.ErrorIs(t, err, config.ErrInvalidConfig)
+		assert.ErrorContains(t, err, "OTEL_TRACES_EXPORTER")
+		assert.ErrorContains(t, err, "OTEL_EXPORTER_OTLP_ENDPOINT")
+	})
+}
+
+func TestAuth_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.False(t, cfg.AuthEnabled)
+		assert.Equal(t, "memory", cfg.APIKeyStore)
+		assert.Empty(t, cfg.AdminAPIKey)
+		assert.Empty(t, cfg.JWKSURL)
+		assert.Equal(t, "notification_types", cfg.JWTTypesClaim)
+		assert.Equal(t, "tenant", cfg.JWTTenantClaim)
+		assert.Equal(t, time.Hour, cfg.JWKSRefreshInterval)
+	})
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("AUTH_ENABLED", "true")
+		t.Setenv("API_KEY_STORE", "sqlite")
+		t.Setenv("ADMIN_API_KEY", "admin-key")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.True(t, cfg.AuthEnabled)
+		assert.Equal(t, "sqlite", cfg.APIKeyStore)
+		assert.Equal(t, "admin-key", cfg.AdminAPIKey)
+	})
+	t.Run("jwt", func(t *testing.T) {
+		t.Setenv("AUTH_ENABLED", "true")
+		t.Setenv("JWT_JWKS_URL", "https://auth.example.com/.well-known/jwks.json")
+		t.Setenv("JWT_ISSUER", "https://auth.example.com")
+		t.Setenv("JWT_AUDIENCE", "notification")
+		t.Setenv("JWT_TYPES_CLAIM", "allowed_types")
+		t.Setenv("JWT_TENANT_CLAIM", "org")
+		t.Setenv("JWT_JWKS_REFRESH_INTERVAL", "10m")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err, "the admin key isn't required along with JWTs")
+
+		assert.Equal(t, "https://auth.example.com/.well-known/jwks.json", cfg.JWKSURL)
+		assert.Equal(t, "https://auth.example.com", cfg.JWTIssuer)
+		assert.Equal(t, "notification", cfg.JWTAudience)
+		assert.Equal(t, "allowed_types", cfg.JWTTypesClaim)
+		assert.Equal(t, "org", cfg.JWTTenantClaim)
+		assert.Equal(t, 10*time.Minute, cfg.JWKSRefreshInterval)
+	})
+	t.Run("invalid jwt", func(t *testing.T) {
+		t.Setenv("JWT_JWKS_URL", "auth.example.com/jwks.json")
+
+		_, err := config.NewAppConfig()
+		require.ErrorIs(t, err, config.ErrInvalidConfig)
+		assert.ErrorContains(t, err, "JWT_JWKS_URL")
+		assert.ErrorContains(t, err, "JWT_ISSUER")
+		assert.ErrorContains(t, err, "JWT_AUDIENCE")
+	})
+	t.Run("memory store without admin key", func(t *testing.T) {
+		t.Setenv("AUTH_ENABLED", "true")
+
+		_, err := config.NewAppConfig()
+		require.ErrorIs(t, err, config.ErrInvalidConfig)
+		assert.ErrorContains(t, err, "ADMIN_API_KEY")
+	})
+	t.Run("sql store without database", func(t *testing.T) {
+		t.Setenv("API_KEY_STORE", "sql")
+
+		_, err := config.NewAppConfig()
+		require.ErrorIs(t, err, config.ErrInvalidConfig)
+		assert.ErrorContains(t, err, "DATABASE_URL")
+	})
+}
+
+func TestWebhook_parseConfig(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Empty(t, cfg.WebhookSigningSecret)
+		assert.Equal(t, 5, cfg.WebhookMaxAttempts)
+		assert.Equal(t, time.Second, cfg.WebhookInitialBackoff)
+		assert.Equal(t, time.Minute, cfg.WebhookMaxBackoff)
+		assert.Equal(t, 10*time.Second, cfg.WebhookTimeout)
+		assert.Equal(t, 4, cfg.WebhookWorkers)
+		assert.Equal(t, 1000, cfg.WebhookQueueSize)
+		assert.Equal(t, 10000, cfg.WebhookLogSize)
+		assert.False(t, cfg.WebhookAllowPrivateNetworks)
+	})
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("WEBHOOK_SIGNING_SECRET", "secret")
+		t.Setenv("WEBHOOK_MAX_ATTEMPTS", "3")
+		t.Setenv("WEBHOOK_MAX_BACKOFF", "30s")
+		t.Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "true")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, "secret", cfg.WebhookSigningSecret)
+		assert.Equal(t, 3, cfg.WebhookMaxAttempts)
+		assert.Equal(t, 30*time.Second, cfg.WebhookMaxBackoff)
+		assert.True(t, cfg.WebhookAllowPrivateNetworks)
+	})
+}
+
+// setRequiredEnv sets the configuration params required by NewAppConfig.
+func setRequiredEnv(t *testing.T) {
+	t.Setenv("MAIL_FROM", "no-reply@example.com")
+}
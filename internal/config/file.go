@@ -0,0 +1,403 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/mail"
+	"notification/internal/domain"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChannelEmail is the email delivery channel, the only one supported so far.
+const ChannelEmail = "email"
+
+// ErrInvalidConfigFile is the error when the configuration file can't be parsed or doesn't pass validation.
+var ErrInvalidConfigFile = errors.New("invalid configuration file")
+
+// FileConfig is the configuration file, in YAML or JSON format.
+//
+// The server, redis and mail sections are read at startup, and the matching environment
+// variables take precedence over them. The Catalog can be reloaded at runtime.
+type FileConfig struct {
+	// Server holds the HTTP server settings.
+	Server ServerFileConfig `yaml:"server"`
+	// Redis holds the Redis connection settings.
+	Redis RedisFileConfig `yaml:"redis"`
+	// Mail holds the mail provider settings.
+	Mail MailFileConfig `yaml:"mail"`
+	// Catalog holds the notification types and the users.
+	Catalog `yaml:",inline"`
+
+	hash [sha256.Size]byte
+}
+
+// Hash returns the SHA-256 hash of the content the configuration file has been parsed from.
+func (f *FileConfig) Hash() [sha256.Size]byte {
+	return f.hash
+}
+
+// ServerFileConfig is the server section of the configuration file.
+type ServerFileConfig struct {
+	// Port is the counterpart of SERVER_PORT.
+	Port int `yaml:"port"`
+}
+
+// RedisFileConfig is the redis section of the configuration file.
+type RedisFileConfig struct {
+	// Host is the counterpart of REDIS_HOST.
+	Host string `yaml:"host"`
+	// Port is the counterpart of REDIS_PORT.
+	Port int `yaml:"port"`
+}
+
+// MailFileConfig is the mail section of the configuration file.
+type MailFileConfig struct {
+	// From is the counterpart of MAIL_FROM.
+	From string `yaml:"from"`
+	// Provider is the counterpart of MAIL_PROVIDER.
+	Provider string `yaml:"provider"`
+	// Providers is the counterpart of MAIL_PROVIDERS.
+	Providers []MailProviderFileConfig `yaml:"providers"`
+	// CircuitBreaker holds the provider failover circuit breaker settings.
+	CircuitBreaker struct {
+		// FailureThreshold is the counterpart of MAIL_CIRCUIT_FAILURE_THRESHOLD.
+		FailureThreshold int `yaml:"failureThreshold"`
+		// OpenTimeout is the counterpart of MAIL_CIRCUIT_OPEN_TIMEOUT.
+		OpenTimeout time.Duration `yaml:"openTimeout"`
+	} `yaml:"circuitBreaker"`
+	// SMTP holds the SMTP provider settings.
+	SMTP struct {
+		// Host is the counterpart of SMTP_HOST.
+		Host string `yaml:"host"`
+		// Port is the counterpart of SMTP_PORT.
+		Port int `yaml:"port"`
+		// Username is the counterpart of SMTP_USERNAME.
+		Username string `yaml:"username"`
+		// Password is the counterpart of SMTP_PASSWORD.
+		Password string `yaml:"password"`
+		// AuthMechanism is the counterpart of SMTP_AUTH_MECHANISM.
+		AuthMechanism string `yaml:"authMechanism"`
+		// OAuth2TokenFile is the counterpart of SMTP_OAUTH2_TOKEN_FILE.
+		OAuth2TokenFile string `yaml:"oauth2TokenFile"`
+		// Pool holds the connection pool settings.
+		Pool struct {
+			// MaxIdle is the counterpart of SMTP_POOL_MAX_IDLE.
+			MaxIdle *int `yaml:"maxIdle"`
+			// MaxLifetime is the counterpart of SMTP_POOL_MAX_LIFETIME.
+			MaxLifetime time.Duration `yaml:"maxLifetime"`
+			// IdleTimeout is the counterpart of SMTP_POOL_IDLE_TIMEOUT.
+			IdleTimeout time.Duration `yaml:"idleTimeout"`
+			// HealthCheckAfter is the counterpart of SMTP_POOL_HEALTH_CHECK_AFTER.
+			HealthCheckAfter time.Duration `yaml:"healthCheckAfter"`
+			// MaxMessages is the counterpart of SMTP_POOL_MAX_MESSAGES.
+			MaxMessages *int `yaml:"maxMessages"`
+		} `yaml:"pool"`
+	} `yaml:"smtp"`
+	// DKIM holds the DKIM signing settings.
+	DKIM struct {
+		// PrivateKeyFile is the counterpart of DKIM_PRIVATE_KEY_FILE.
+		PrivateKeyFile string `yaml:"privateKeyFile"`
+		// Domain is the counterpart of DKIM_DOMAIN.
+		Domain string `yaml:"domain"`
+		// Selector is the counterpart of DKIM_SELECTOR.
+		Selector string `yaml:"selector"`
+		// Headers is the counterpart of DKIM_HEADERS.
+		Headers []string `yaml:"headers"`
+	} `yaml:"dkim"`
+	// SendGrid holds the SendGrid provider settings.
+	SendGrid struct {
+		// APIKey is the counterpart of SENDGRID_API_KEY.
+		APIKey string `yaml:"apiKey"`
+	} `yaml:"sendgrid"`
+	// Mailgun holds the Mailgun provider settings.
+	Mailgun struct {
+		// APIKey is the counterpart of MAILGUN_API_KEY.
+		APIKey string `yaml:"apiKey"`
+		// Domain is the counterpart of MAILGUN_DOMAIN.
+		Domain string `yaml:"domain"`
+		// BaseURL is the counterpart of MAILGUN_BASE_URL.
+		BaseURL string `yaml:"baseURL"`
+	} `yaml:"mailgun"`
+	// SES holds the Amazon SES provider settings.
+	SES struct {
+		// Region is the counterpart of SES_REGION.
+		Region string `yaml:"region"`
+		// AccessKeyID is the counterpart of SES_ACCESS_KEY_ID.
+		AccessKeyID string `yaml:"accessKeyId"`
+		// SecretAccessKey is the counterpart of SES_SECRET_ACCESS_KEY.
+		SecretAccessKey string `yaml:"secretAccessKey"`
+		// SessionToken is the counterpart of SES_SESSION_TOKEN.
+		SessionToken string `yaml:"sessionToken"`
+	} `yaml:"ses"`
+}
+
+// MailProviderFileConfig is a mail provider taking part in the provider failover.
+type MailProviderFileConfig struct {
+	// Name is the provider name: "smtp", "sendgrid", "mailgun" or "ses".
+	Name string `yaml:"name"`
+	// Priority defines the failover order, lower values first. Defaults to 1.
+	Priority int `yaml:"priority"`
+	// Weight defines the share of traffic among providers of the same priority. Defaults to 1.
+	Weight int `yaml:"weight"`
+}
+
+// Catalog is the part of the configuration file that can be reloaded at runtime.
+type Catalog struct {
+	// NotificationTypes holds the settings of each notification type, keyed by type name.
+	NotificationTypes map[string]NotificationTypeConfig `yaml:"notificationTypes"`
+	// Users lists the users to be created or updated.
+	Users []UserConfig `yaml:"users"`
+}
+
+// NotificationTypeConfig holds the settings of a notification type.
+type NotificationTypeConfig struct {
+	// Subject is the email subject of the notifications of this type.
+	Subject string `yaml:"subject"`
+	// Channels lists the delivery channels. Only "email" is supported. Defaults to ["email"].
+	Channels []string `yaml:"channels"`
+	// RateLimit is the rate limit rule of the notification type, if any.
+	RateLimit *RateLimitConfig `yaml:"rateLimit"`
+}
+
+// RateLimitConfig is the rate limit rule of a notification type.
+type RateLimitConfig struct {
+	// MaxCount is the max notification count allowed for the time span.
+	MaxCount int `yaml:"maxCount"`
+	// Expiration is the time span of the rule, e.g. "1m" or "24h".
+	Expiration time.Duration `yaml:"expiration"`
+	// Unlimited means the notifications aren't rate limited at all,
+	// in which case MaxCount and Expiration must be omitted.
+	Unlimited bool `yaml:"unlimited"`
+}
+
+// UserConfig is a user of the configuration file.
+type UserConfig struct {
+	// ID is the user unique identifier.
+	ID string `yaml:"id"`
+	// Name is the name of the user.
+	Name string `yaml:"name"`
+	// LastName is the last name of the user.
+	LastName string `yaml:"lastName"`
+	// Email is the email address the notifications are sent to.
+	Email string `yaml:"email"`
+	// Segments are the segments the user belongs to, whose rate limit overrides apply to the user.
+	Segments []string `yaml:"segments"`
+}
+
+// LoadFile reads and validates the configuration file at path.
+// It returns an error wrapping ErrInvalidConfigFile listing every problem found.
+func LoadFile(path string) (*FileConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read configuration file: %w", err)
+	}
+	return ParseFile(content)
+}
+
+// ParseFile parses and validates the content of a configuration file.
+// Unknown fields are rejected, so typos don't go unnoticed.
+func ParseFile(content []byte) (*FileConfig, error) {
+	var cfg FileConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfigFile, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("%w:\n%w", ErrInvalidConfigFile, err)
+	}
+	cfg.hash = sha256.Sum256(content)
+	return &cfg, nil
+}
+
+func (f *FileConfig) validate() error {
+	var errs []error
+
+	if f.Server.Port < 0 || f.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port: %d is not a valid port", f.Server.Port))
+	}
+	if f.Redis.Port < 0 || f.Redis.Port > 65535 {
+		errs = append(errs, fmt.Errorf("redis.port: %d is not a valid port", f.Redis.Port))
+	}
+	if f.Mail.SMTP.Port < 0 || f.Mail.SMTP.Port > 65535 {
+		errs = append(errs, fmt.Errorf("mail.smtp.port: %d is not a valid port", f.Mail.SMTP.Port))
+	}
+	if f.Mail.From != "" && !isEmailAddress(f.Mail.From) {
+		errs = append(errs, fmt.Errorf("mail.from: %q is not a valid email address", f.Mail.From))
+	}
+	if f.Mail.Provider != "" && !isMailProvider(f.Mail.Provider) {
+		errs = append(errs, fmt.Errorf("mail.provider: unsupported provider %q", f.Mail.Provider))
+	}
+	for i, p := range f.Mail.Providers {
+		if !isMailProvider(p.Name) {
+			errs = append(errs, fmt.Errorf("mail.providers[%d].name: unsupported provider %q", i, p.Name))
+		}
+		if p.Weight < 0 {
+			errs = append(errs, fmt.Errorf("mail.providers[%d].weight: must not be negative", i))
+		}
+	}
+
+	errs = append(errs, f.Catalog.validate()...)
+	return errors.Join(errs...)
+}
+
+func (c *Catalog) validate() []error {
+	var errs []error
+
+	for name, t := range c.NotificationTypes {
+		if _, err := domain.ToNotificationType(name); err != nil {
+			errs = append(errs, fmt.Errorf("notificationTypes.%s: %w", name, err))
+		}
+		for _, channel := range t.Channels {
+			if channel != ChannelEmail {
+				errs = append(errs, fmt.Errorf("notificationTypes.%s.channels: unsupported channel %q", name, channel))
+			}
+		}
+		if t.RateLimit != nil {
+			if err := t.RateLimit.Rule().Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("notificationTypes.%s.rateLimit: %w", name, err))
+			}
+		}
+	}
+
+	ids := make(map[string]bool)
+	emails := make(map[string]bool)
+	for i, u := range c.Users {
+		if u.ID == "" {
+			errs = append(errs, fmt.Errorf("users[%d].id: is empty", i))
+		} else if ids[u.ID] {
+			errs = append(errs, fmt.Errorf("users[%d].id: duplicate id %q", i, u.ID))
+		}
+		ids[u.ID] = true
+
+		if !isEmailAddress(u.Email) {
+			errs = append(errs, fmt.Errorf("users[%d].email: %q is not a valid email address", i, u.Email))
+		} else if email := strings.ToLower(u.Email); emails[email] {
+			errs = append(errs, fmt.Errorf("users[%d].email: duplicate email %q", i, u.Email))
+		} else {
+			emails[email] = true
+		}
+
+		for _, segment := range u.Segments {
+			if !domain.ValidSegment(segment) {
+				errs = append(errs, fmt.Errorf("users[%d].segments: %q is not a valid segment name", i, segment))
+			}
+		}
+	}
+
+	// map iteration order is random, keep the error messages stable.
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+	return errs
+}
+
+// Rule converts the RateLimitConfig into its domain model.
+func (r RateLimitConfig) Rule() domain.RateLimitRule {
+	return domain.RateLimitRule{
+		MaxCount:   r.MaxCount,
+		Expiration: r.Expiration,
+		Unlimited:  r.Unlimited,
+	}
+}
+
+// env returns the values of the startup settings keyed by the environment variable they override.
+func (f *FileConfig) env() map[string]string {
+	values := make(map[string]string)
+	setString := func(key, value string) {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	setInt := func(key string, value int) {
+		if value != 0 {
+			values[key] = strconv.Itoa(value)
+		}
+	}
+	setDuration := func(key string, value time.Duration) {
+		if value != 0 {
+			values[key] = value.String()
+		}
+	}
+	setIntPtr := func(key string, value *int) {
+		if value != nil {
+			values[key] = strconv.Itoa(*value)
+		}
+	}
+
+	setInt("SERVER_PORT", f.Server.Port)
+	setString("REDIS_HOST", f.Redis.Host)
+	setInt("REDIS_PORT", f.Redis.Port)
+
+	m := f.Mail
+	setString("MAIL_FROM", m.From)
+	setString("MAIL_PROVIDER", m.Provider)
+	var providers []string
+	for _, p := range m.Providers {
+		priority, weight := p.Priority, p.Weight
+		if priority == 0 {
+			priority = 1
+		}
+		if weight == 0 {
+			weight = 1
+		}
+		providers = append(providers, fmt.Sprintf("%s:%d:%d", p.Name, priority, weight))
+	}
+	setString("MAIL_PROVIDERS", strings.Join(providers, ","))
+	setInt("MAIL_CIRCUIT_FAILURE_THRESHOLD", m.CircuitBreaker.FailureThreshold)
+	setDuration("MAIL_CIRCUIT_OPEN_TIMEOUT", m.CircuitBreaker.OpenTimeout)
+	setString("SMTP_HOST", m.SMTP.Host)
+	setInt("SMTP_PORT", m.SMTP.Port)
+	setString("SMTP_USERNAME", m.SMTP.Username)
+	setString("SMTP_PASSWORD", m.SMTP.Password)
+	setString("SMTP_AUTH_MECHANISM", m.SMTP.AuthMechanism)
+	setString("SMTP_OAUTH2_TOKEN_FILE", m.SMTP.OAuth2TokenFile)
+	setIntPtr("SMTP_POOL_MAX_IDLE", m.SMTP.Pool.MaxIdle)
+	setDuration("SMTP_POOL_MAX_LIFETIME", m.SMTP.Pool.MaxLifetime)
+	setDuration("SMTP_POOL_IDLE_TIMEOUT", m.SMTP.Pool.IdleTimeout)
+	setDuration("SMTP_POOL_HEALTH_CHECK_AFTER", m.SMTP.Pool.HealthCheckAfter)
+	setIntPtr("SMTP_POOL_MAX_MESSAGES", m.SMTP.Pool.MaxMessages)
+	setString("DKIM_PRIVATE_KEY_FILE", m.DKIM.PrivateKeyFile)
+	setString("DKIM_DOMAIN", m.DKIM.Domain)
+	setString("DKIM_SELECTOR", m.DKIM.Selector)
+	setString("DKIM_HEADERS", strings.Join(m.DKIM.Headers, ","))
+	setString("SENDGRID_API_KEY", m.SendGrid.APIKey)
+	setString("MAILGUN_API_KEY", m.Mailgun.APIKey)
+	setString("MAILGUN_DOMAIN", m.Mailgun.Domain)
+	setString("MAILGUN_BASE_URL", m.Mailgun.BaseURL)
+	setString("SES_REGION", m.SES.Region)
+	setString("SES_ACCESS_KEY_ID", m.SES.AccessKeyID)
+	setString("SES_SECRET_ACCESS_KEY", m.SES.SecretAccessKey)
+	setString("SES_SESSION_TOKEN", m.SES.SessionToken)
+
+	return values
+}
+
+// mailProviders lists the supported mail providers.
+var mailProviders = []string{"smtp", "sendgrid", "mailgun", "ses"}
+
+func isMailProvider(name string) bool {
+	return slices.Contains(mailProviders, strings.ToLower(name))
+}
+
+// isEmailAddress reports whether email is a bare email address, without display name.
+func isEmailAddress(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
+
+// isAlignedDomain reports whether domain is the signing domain or one of its subdomains,
+// i.e. whether the DKIM signatures for the signing domain pass the DMARC relaxed alignment.
+func isAlignedDomain(domain, signing string) bool {
+	domain, signing = strings.ToLower(domain), strings.ToLower(signing)
+	return domain == signing || strings.HasSuffix(domain, "."+signing)
+}
@@ -0,0 +1,265 @@
+package config_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/config"
+	"notification/internal/domain"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testConfigFile = `
+server:
+  port: 9090
+redis:
+  host: redis
+mail:
+  from: no-reply@example.com
+  providers:
+    - name: smtp
+    - name: sendgrid
+      priority: 2
+  smtp:
+    host: mail_server
+    port: 1025
+    pool:
+      maxIdle: 0
+  sendgrid:
+    apiKey: sg-key
+notificationTypes:
+  status:
+    subject: "Status: there's a new status update"
+    channels: [email]
+    rateLimit:
+      maxCount: 2
+      expiration: 1m
+  news:
+    rateLimit:
+      maxCount: 1
+      expiration: 24h
+users:
+  - id: 123-abc
+    name: John
+    lastName: Doe
+    email: john@example.com
+    segments: [vip]
+`
+
+func TestParseFile(t *testing.T) {
+	t.Run("file is parsed", func(t *testing.T) {
+		cfg, err := config.ParseFile([]byte(testConfigFile))
+		require.NoError(t, err)
+
+		assert.Equal(t, 9090, cfg.Server.Port)
+		assert.Equal(t, "Status: there's a new status update", cfg.NotificationTypes["status"].Subject)
+		assert.Equal(t, &config.RateLimitConfig{MaxCount: 1, Expiration: 24 * time.Hour},
+			cfg.NotificationTypes["news"].RateLimit)
+		assert.Equal(t, []config.UserConfig{
+			{ID: "123-abc", Name: "John", LastName: "Doe", Email: "john@example.com", Segments: []string{"vip"}},
+		}, cfg.Users)
+	})
+
+	t.Run("JSON is accepted", func(t *testing.T) {
+		cfg, err := config.ParseFile([]byte(`{"notificationTypes": {"marketing": {"subject": "Offers"}}}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, "Offers", cfg.NotificationTypes["marketing"].Subject)
+	})
+
+	t.Run("unlimited rule", func(t *testing.T) {
+		cfg, err := config.ParseFile([]byte("notificationTypes:\n  marketing:\n    rateLimit:\n      unlimited: true\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.RateLimitRule{Unlimited: true}, cfg.NotificationTypes["marketing"].RateLimit.Rule())
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		_, err := config.ParseFile(nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown fields are rejected", func(t *testing.T) {
+		_, err := config.ParseFile([]byte("server:\n  prot: 80\n"))
+		assert.ErrorIs(t, err, config.ErrInvalidConfigFile)
+		assert.ErrorContains(t, err, "prot")
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		_, err := config.ParseFile([]byte(`
+mail:
+  provider: pigeon
+notificationTypes:
+  alerts: {}
+  news:
+    channels: [sms]
+    rateLimit:
+      maxCount: 0
+      expiration: 1m
+users:
+  - id: 1
+    email: john@example.com
+  - id: 1
+    email: John@example.com
+  - email: not-an-email
+    segments: [VIP]
+`))
+		require.ErrorIs(t, err, config.ErrInvalidConfigFile)
+		for _, want := range []string{
+			`mail.provider: unsupported provider "pigeon"`,
+			"notificationTypes.alerts: unknown notification type",
+			`notificationTypes.news.channels: unsupported channel "sms"`,
+			"notificationTypes.news.rateLimit: invalid rate limit rule",
+			`users[1].id: duplicate id "1"`,
+			`users[1].email: duplicate email "John@example.com"`,
+			"users[2].id: is empty",
+			`users[2].email: "not-an-email" is not a valid email address`,
+			`users[2].segments: "VIP" is not a valid segment name`,
+		} {
+			assert.ErrorContains(t, err, want)
+		}
+	})
+}
+
+func TestNewAppConfig_ConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testConfigFile), 0600))
+	t.Setenv("CONFIG_FILE", path)
+
+	t.Run("file values are used", func(t *testing.T) {
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, 9090, cfg.ServerPort)
+		assert.Equal(t, "redis", cfg.RedisHost)
+		assert.Equal(t, 6379, cfg.RedisPort)
+		assert.Equal(t, "mail_server", cfg.SMTPHost)
+		assert.Equal(t, 1025, cfg.SMTPPort)
+		assert.Equal(t, 0, cfg.SMTPPoolMaxIdle)
+		assert.Equal(t, "sg-key", cfg.SendGridAPIKey)
+		assert.Equal(t, []config.MailProviderConfig{
+			{Name: "smtp", Priority: 1, Weight: 1},
+			{Name: "sendgrid", Priority: 2, Weight: 1},
+		}, cfg.MailProviders)
+		assert.Equal(t, path, cfg.ConfigFile)
+		assert.Len(t, cfg.Catalog.NotificationTypes, 2)
+	})
+
+	t.Run("env vars take precedence", func(t *testing.T) {
+		t.Setenv("SERVER_PORT", "8081")
+		t.Setenv("SENDGRID_API_KEY", "env-key")
+
+		cfg, err := config.NewAppConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, 8081, cfg.ServerPort)
+		assert.Equal(t, "env-key", cfg.SendGridAPIKey)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(invalid, []byte("server: {port: -1}"), 0600))
+		t.Setenv("CONFIG_FILE", invalid)
+
+		_, err := config.NewAppConfig()
+		assert.ErrorIs(t, err, config.ErrInvalidConfigFile)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+
+		_, err := config.NewAppConfig()
+		assert.Error(t, err)
+	})
+}
+
+func TestNewAppConfig_DefaultCatalog(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := config.NewAppConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, config.DefaultCatalog(), cfg.Catalog)
+}
+
+func TestFileWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("notificationTypes: {status: {subject: v1}}"), 0600))
+
+	var subject atomic.Value
+	watcher := config.NewFileWatcher(path, func(cfg *config.FileConfig) {
+		subject.Store(cfg.NotificationTypes["status"].Subject)
+	})
+	require.NoError(t, watcher.Reload())
+	assert.Equal(t, "v1", subject.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	t.Run("file changes are applied", func(t *testing.T) {
+		// the watcher may not be set up yet, so the change is written until it's noticed,
+		// leaving enough time between writes for the debounce to settle.
+		require.Eventually(t, func() bool {
+			_ = os.WriteFile(path, []byte("notificationTypes: {status: {subject: v2}}"), 0600)
+			return subject.Load() == "v2"
+		}, 5*time.Second, 300*time.Millisecond)
+	})
+
+	t.Run("invalid changes are ignored", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("notificationTypes: {alerts: {}}"), 0600))
+		assert.Error(t, watcher.Reload())
+		assert.Equal(t, "v2", subject.Load())
+	})
+}
+
+func TestFileWatcher_LoadedHash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("notificationTypes: {status: {subject: v1}}"), 0600))
+	loaded, err := config.LoadFile(path)
+	require.NoError(t, err)
+
+	var reloads atomic.Int32
+	watcher := config.NewFileWatcher(path, func(*config.FileConfig) {
+		reloads.Add(1)
+	}, config.WithLoadedHash(loaded.Hash()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	t.Run("the loaded version isn't applied again", func(t *testing.T) {
+		// the events of the other files and the rewrites of the same content are noticed,
+		// but the file is unchanged.
+		for i := 0; i < 5; i++ {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("other"), 0600))
+			require.NoError(t, os.WriteFile(path, []byte("notificationTypes: {status: {subject: v1}}"), 0600))
+			time.Sleep(200 * time.Millisecond)
+		}
+		assert.Zero(t, reloads.Load())
+	})
+
+	t.Run("file changes are applied", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			_ = os.WriteFile(path, []byte("notificationTypes: {status: {subject: v2}}"), 0600)
+			return reloads.Load() == 1
+		}, 5*time.Second, 300*time.Millisecond)
+	})
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redacted replaces the value of the secrets in the printed configuration.
+const redacted = "[REDACTED]"
+
+// setting is the effective value of a configuration param.
+type setting struct {
+	value  string
+	secret bool
+}
+
+// newSource creates a new source instance on top of the values of the configuration file, if any.
+func newSource(file map[string]string) *source {
+	return &source{
+		file:     file,
+		settings: make(map[string]setting),
+	}
+}
+
+// source looks the configuration params up by their environment variable name:
+// environment variables take precedence over the values of the configuration file.
+//
+// Invalid values don't fall back to the defaults: the errors are collected instead,
+// so that every problem is reported at once. The effective value of each param is
+// recorded as well, to be printed.
+type source struct {
+	file     map[string]string
+	errs     []error
+	settings map[string]setting
+}
+
+// lookup returns the value of the param key and whether it's set. Empty values are considered unset.
+func (s *source) lookup(key string) (string, bool) {
+	if value := os.Getenv(key); value != "" {
+		return value, true
+	}
+	value, ok := s.file[key]
+	return value, ok && value != ""
+}
+
+// errorf records an error of the param key.
+func (s *source) errorf(key, format string, args ...any) {
+	s.errs = append(s.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+// set records the effective value of the param key.
+func (s *source) set(key, value string) {
+	s.settings[key] = setting{value: value}
+}
+
+// string returns the value of the param key, or def if it's not set.
+func (s *source) string(key, def string) string {
+	value, ok := s.lookup(key)
+	if !ok {
+		value = def
+	}
+	s.set(key, value)
+	return value
+}
+
+// required returns the value of the param key, recording an error if it's not set.
+func (s *source) required(key string) string {
+	value := s.string(key, "")
+	if value == "" {
+		s.errorf(key, "is required")
+	}
+	return value
+}
+
+// oneOf returns the lowercase value of the param key, or def if it's not set.
+// It records an error if the value isn't one of the allowed ones.
+func (s *source) oneOf(key, def string, allowed ...string) string {
+	value := strings.ToLower(s.string(key, def))
+	if value != "" && !slices.Contains(allowed, value) {
+		s.errorf(key, "%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+	s.set(key, value)
+	return value
+}
+
+// int parses the param key as an int no lower than min, or returns def if it's not set.
+func (s *source) int(key string, def, min int) int {
+	value, ok := s.lookup(key)
+	if !ok {
+		s.set(key, strconv.Itoa(def))
+		return def
+	}
+	s.set(key, value)
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		s.errorf(key, "%q is not a valid integer", value)
+		return def
+	}
+	if i < min {
+		s.errorf(key, "must be at least %d", min)
+	}
+	return i
+}
+
+// port parses the param key as a TCP port, or returns def if it's not set.
+func (s *source) port(key string, def int) int {
+	port := s.int(key, def, 1)
+	if port > 65535 {
+		s.errorf(key, "%d is not a valid port", port)
+	}
+	return port
+}
+
+// duration parses the param key as a non-negative time.Duration, e.g. "1m30s", or returns def if it's not set.
+func (s *source) duration(key string, def time.Duration) time.Duration {
+	value, ok := s.lookup(key)
+	if !ok {
+		s.set(key, def.String())
+		return def
+	}
+	s.set(key, value)
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		s.errorf(key, "%q is not a valid duration", value)
+		return def
+	}
+	if d < 0 {
+		s.errorf(key, "must not be negative")
+	}
+	return d
+}
+
+// bool parses the param key as a boolean, e.g. "true", "false", "1" or "0", or returns def if it's not set.
+func (s *source) bool(key string, def bool) bool {
+	value, ok := s.lookup(key)
+	if !ok {
+		s.set(key, strconv.FormatBool(def))
+		return def
+	}
+	s.set(key, value)
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		s.errorf(key, "%q is not a valid boolean", value)
+		return def
+	}
+	return b
+}
+
+// list parses the param key as a comma separated list, skipping empty entries.
+func (s *source) list(key string) []string {
+	var list []string
+	for _, entry := range strings.Split(s.string(key, ""), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// secret returns the value of the environment variable key. If it's not set,
+// but "<key>_FILE" is, the value is read from that file instead, which allows
+// mounting credentials as files (e.g. Kubernetes secrets). The configuration
+// file value is used last. Secrets are redacted when printed.
+func (s *source) secret(key string) string {
+	value := os.Getenv(key)
+	if path := os.Getenv(key + "_FILE"); value == "" && path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			s.errorf(key+"_FILE", "%v", err)
+		}
+		value = strings.TrimSpace(string(content))
+	} else if value == "" {
+		value = s.file[key]
+	}
+	s.settings[key] = setting{value: value, secret: true}
+	return value
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSource_bool(t *testing.T) {
+	t.Run("value is parsed", func(t *testing.T) {
+		t.Setenv("FEATURE_ENABLED", "true")
+		src := newSource(map[string]string{"FEATURE_DISABLED": "0"})
+
+		assert.True(t, src.bool("FEATURE_ENABLED", false))
+		assert.False(t, src.bool("FEATURE_DISABLED", true))
+		assert.Empty(t, src.errs)
+	})
+	t.Run("default is used when unset", func(t *testing.T) {
+		src := newSource(nil)
+
+		assert.True(t, src.bool("FEATURE_ENABLED", true))
+		assert.Equal(t, setting{value: "true"}, src.settings["FEATURE_ENABLED"])
+	})
+	t.Run("invalid value is reported", func(t *testing.T) {
+		t.Setenv("FEATURE_ENABLED", "yes")
+		src := newSource(nil)
+
+		assert.False(t, src.bool("FEATURE_ENABLED", false))
+		assert.EqualError(t, src.errs[0], `FEATURE_ENABLED: "yes" is not a valid boolean`)
+	})
+}
+
+func TestSource_list(t *testing.T) {
+	t.Setenv("HEADERS", " From,,To , Subject")
+	src := newSource(nil)
+
+	assert.Equal(t, []string{"From", "To", "Subject"}, src.list("HEADERS"))
+	assert.Nil(t, src.list("MISSING"))
+}
+
+func TestSource_lookup(t *testing.T) {
+	t.Setenv("EMPTY", "")
+	t.Setenv("FROM_ENV", "env")
+	src := newSource(map[string]string{"EMPTY": "file", "FROM_ENV": "file"})
+
+	assert.Equal(t, "file", src.string("EMPTY", "def"), "empty env vars are considered unset")
+	assert.Equal(t, "env", src.string("FROM_ENV", "def"))
+	assert.Equal(t, "def", src.string("MISSING", "def"))
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	"notification/internal/logging"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// reloadDebounce is how long the FileWatcher waits for the file changes to settle
+// before reloading, since editors and config map updates produce bursts of events.
+const reloadDebounce = 100 * time.Millisecond
+
+// FileWatcherOption defines the optional params for FileWatcher.
+type FileWatcherOption func(*FileWatcher)
+
+// WithLoadedHash sets the hash of the version of the file already applied, see FileConfig.Hash,
+// so that it isn't applied again until the file changes.
+func WithLoadedHash(hash [sha256.Size]byte) FileWatcherOption {
+	return func(w *FileWatcher) {
+		w.lastHash = hash
+	}
+}
+
+// NewFileWatcher creates a new FileWatcher instance for the configuration file at path,
+// calling onReload with every new valid version of the file.
+func NewFileWatcher(path string, onReload func(*FileConfig), opts ...FileWatcherOption) *FileWatcher {
+	w := &FileWatcher{
+		path:     path,
+		onReload: onReload,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// FileWatcher reloads the configuration file when it changes or when the process receives SIGHUP.
+//
+// The directory of the file is watched rather than the file itself, so that atomic
+// replacements (e.g. Kubernetes config map updates) are noticed too. The file is only applied
+// again when its content changes, or on SIGHUP, so that the events of the other files of the
+// directory don't overwrite the changes made at runtime. Invalid versions of the file are
+// logged and ignored, keeping the last valid configuration in place.
+type FileWatcher struct {
+	path     string
+	onReload func(*FileConfig)
+
+	mu       sync.Mutex
+	lastHash [sha256.Size]byte
+}
+
+// Run watches the configuration file until the context is canceled, logging through the logger of ctx.
+func (w *FileWatcher) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With().Str("file", w.path).Logger()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create file watcher: %w", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(w.path)); err != nil {
+		return fmt.Errorf("watch configuration file: %w", err)
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangup:
+			logger.Info().Msg("SIGHUP received, reloading configuration file")
+			w.reload(logger, true)
+		case <-watcher.Events:
+			debounce.Reset(reloadDebounce)
+		case <-debounce.C:
+			w.reload(logger, false)
+		case err := <-watcher.Errors:
+			logger.Error().Err(err).Msg("configuration file watcher error")
+		}
+	}
+}
+
+// Reload reads the configuration file and applies it if it's valid.
+func (w *FileWatcher) Reload() error {
+	_, err := w.load(true)
+	return err
+}
+
+func (w *FileWatcher) reload(logger zerolog.Logger, force bool) {
+	loaded, err := w.load(force)
+	switch {
+	case err != nil:
+		logger.Warn().Err(err).Msg("configuration file not reloaded, keeping the previous version")
+	case loaded:
+		logger.Info().Msg("configuration file loaded")
+	}
+}
+
+// load applies the configuration file, skipping unchanged versions unless force is set.
+// It reports whether the file has been applied.
+func (w *FileWatcher) load(force bool) (bool, error) {
+	content, err := os.ReadFile(w.path)
+	if err != nil {
+		return false, fmt.Errorf("read configuration file: %w", err)
+	}
+
+	hash := sha256.Sum256(content)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !force && bytes.Equal(hash[:], w.lastHash[:]) {
+		return false, nil
+	}
+
+	cfg, err := ParseFile(content)
+	if err != nil {
+		return false, err
+	}
+	w.lastHash = hash
+	w.onReload(cfg)
+	return true, nil
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"notification/internal/auth"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"time"
+)
+
+// NewAPIKey creates a new APIKey controller instance.
+func NewAPIKey(repo repository.APIKeyRepository) *APIKey {
+	return &APIKey{repo}
+}
+
+// APIKey is the API key controller.
+// It defines routes and handlers to manage the API keys the clients authenticate with.
+// The keys are issued for the tenant of the request, and the keys of the other tenants
+// are out of reach as if they didn't exist.
+type APIKey struct {
+	repo repository.APIKeyRepository
+}
+
+// SetRouter returns the router r with all the necessary routes for the
+// APIKey controller setup.
+func (k APIKey) SetRouter(r *mux.Router) {
+	r.HandleFunc("/api-keys", middleware.Logger(middleware.RequireScope(domain.ScopeAdminKeys,
+		middleware.SetJSONContent(k.list)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api-keys", middleware.Logger(middleware.RequireScope(domain.ScopeAdminKeys,
+		middleware.SetJSONContent(k.create)))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api-keys/{id}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminKeys,
+		middleware.SetJSONContent(k.get)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api-keys/{id}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminKeys, k.delete))).
+		Methods(http.MethodDelete)
+}
+
+// @Summary List API keys
+// @Description Lists the API keys of the tenant sorted by ID, without the keys themselves
+// @Tags api-key
+// @Produce json
+// @Success 200 {array} dto.APIKey
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /api-keys [get]
+func (k APIKey) list(w http.ResponseWriter, r *http.Request) {
+	keys, err := k.repo.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.APIKey, 0, len(keys))
+	for _, key := range keys {
+		if ownedByTenant(r.Context(), key) {
+			response = append(response, dto.NewAPIKey(key))
+		}
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Create an API key
+// @Description Creates an API key granted the given scopes, which must be granted to the caller as well.
+// @Description The key is only returned by this request: just its hash is stored
+// @Tags api-key
+// @Accept json
+// @Produce json
+// @Param apiKey body dto.APIKeyRequest true "API key to be created"
+// @Success 201 {object} dto.CreatedAPIKey
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /api-keys [post]
+func (k APIKey) create(w http.ResponseWriter, r *http.Request) {
+	var request dto.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// a client can't grant more than it's granted itself.
+	for _, scope := range request.Scopes {
+		if !middleware.Authorized(r, scope) {
+			http.Error(w, fmt.Sprintf("scope %s can't be granted", scope), http.StatusForbidden)
+			return
+		}
+	}
+
+	id, err := newAPIKeyID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	secret, err := auth.GenerateAPIKey()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	key := domain.APIKey{
+		ID:        id,
+		Name:      request.Name,
+		TenantID:  repository.TenantFromContext(r.Context()),
+		Hash:      auth.HashAPIKey(secret),
+		Scopes:    request.Scopes,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if err = k.repo.Save(r.Context(), key); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logging.AddFields(r.Context(), map[string]any{"apiKeyId": key.ID})
+
+	w.Header().Set("Location", "/api-keys/"+key.ID)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(dto.CreatedAPIKey{APIKey: dto.NewAPIKey(key), Key: secret})
+}
+
+// @Summary Get an API key
+// @Description Gets an API key by its ID, without the key itself
+// @Tags api-key
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} dto.APIKey
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /api-keys/{id} [get]
+func (k APIKey) get(w http.ResponseWriter, r *http.Request) {
+	key, err := k.getOwned(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewAPIKey(key))
+}
+
+// @Summary Revoke an API key
+// @Description Deletes an API key, which can't be used anymore
+// @Tags api-key
+// @Param id path string true "API key ID"
+// @Success 204
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /api-keys/{id} [delete]
+func (k APIKey) delete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := k.getOwned(r.Context(), id); err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	if err := k.repo.Delete(r.Context(), id); err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	logging.AddFields(r.Context(), map[string]any{"apiKeyId": id})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getOwned retrieves the API key of the given ID, which is reported as not found
+// if it belongs to another tenant than the one of ctx.
+func (k APIKey) getOwned(ctx context.Context, id string) (domain.APIKey, error) {
+	key, err := k.repo.Get(ctx, id)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	if !ownedByTenant(ctx, key) {
+		return domain.APIKey{}, repository.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// ownedByTenant reports whether the API key belongs to the tenant of ctx. The keys issued
+// before multi-tenancy have no tenant, and belong to the default one.
+func ownedByTenant(ctx context.Context, key domain.APIKey) bool {
+	tenantID := key.TenantID
+	if tenantID == "" {
+		tenantID = domain.DefaultTenantID
+	}
+	return tenantID == repository.TenantFromContext(ctx)
+}
+
+// writeAPIKeyError writes the HTTP error matching the API key repository error.
+func writeAPIKeyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// newAPIKeyID generates a random API key ID.
+func newAPIKeyID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate api key id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,152 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/auth"
+	"notification/internal/controller"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"strings"
+	"testing"
+)
+
+func TestAPIKey(t *testing.T) {
+	const adminKey = "nk_admin"
+	repo := repository.NewInMemoryAPIKeyRepository()
+	r := mux.NewRouter()
+	r.Use(middleware.Authenticate(auth.NewAPIKeyAuthenticator(repo, auth.WithBootstrapKey(adminKey))))
+	controller.NewAPIKey(repo).SetRouter(r)
+
+	serve := func(method, target, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	create := func(t *testing.T, key, body string) dto.CreatedAPIKey {
+		t.Helper()
+		rr := serve(http.MethodPost, "/api-keys", key, body)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var created dto.CreatedAPIKey
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&created))
+		assert.Equal(t, "/api-keys/"+created.ID, rr.Header().Get("Location"))
+		return created
+	}
+
+	t.Run("api key is created and only its hash is stored", func(t *testing.T) {
+		created := create(t, adminKey, `{"name":"billing","scopes":["send:status","send:news"]}`)
+
+		assert.Equal(t, "billing", created.Name)
+		assert.Equal(t, []domain.Scope{"send:status", "send:news"}, created.Scopes)
+		assert.True(t, strings.HasPrefix(created.Key, auth.APIKeyPrefix))
+		stored, err := repo.Get(context.Background(), created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, auth.HashAPIKey(created.Key), stored.Hash)
+
+		rr := serve(http.MethodGet, "/api-keys/"+created.ID, adminKey, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), created.Key)
+		assert.NotContains(t, rr.Body.String(), stored.Hash)
+	})
+
+	t.Run("api key is not created", func(t *testing.T) {
+		keysAdmin := create(t, adminKey, `{"name":"keys-admin","scopes":["admin:keys","send:status"]}`)
+
+		tests := []struct {
+			name       string
+			key        string
+			body       string
+			wantStatus int
+		}{
+			{"malformed body", adminKey, `{`, http.StatusBadRequest},
+			{"missing name", adminKey, `{"scopes":["send:status"]}`, http.StatusBadRequest},
+			{"missing scopes", adminKey, `{"name":"crm"}`, http.StatusBadRequest},
+			{"invalid scope", adminKey, `{"name":"crm","scopes":["send"]}`, http.StatusBadRequest},
+			{"missing credentials", "", `{"name":"crm","scopes":["send:status"]}`, http.StatusUnauthorized},
+			{"scope not granted to the caller", keysAdmin.Key, `{"name":"crm","scopes":["admin:rules"]}`, http.StatusForbidden},
+			{"wildcard not granted to the caller", keysAdmin.Key, `{"name":"crm","scopes":["*"]}`, http.StatusForbidden},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := serve(http.MethodPost, "/api-keys", tt.key, tt.body)
+				assert.Equal(t, tt.wantStatus, rr.Code)
+			})
+		}
+
+		t.Run("scopes granted to the caller", func(t *testing.T) {
+			create(t, keysAdmin.Key, `{"name":"crm","scopes":["send:status"]}`)
+		})
+	})
+
+	t.Run("api keys are listed without the keys", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/api-keys", adminKey, "")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var keys []map[string]any
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&keys))
+		require.NotEmpty(t, keys)
+		for _, key := range keys {
+			assert.NotContains(t, key, "key")
+			assert.NotContains(t, key, "hash")
+		}
+	})
+
+	t.Run("api key without admin:keys scope is forbidden", func(t *testing.T) {
+		sender := create(t, adminKey, `{"name":"sender","scopes":["send:*"]}`)
+
+		rr := serve(http.MethodGet, "/api-keys", sender.Key, "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("api key is revoked", func(t *testing.T) {
+		revoked := create(t, adminKey, `{"name":"revoked","scopes":["admin:keys"]}`)
+		require.Equal(t, http.StatusOK, serve(http.MethodGet, "/api-keys", revoked.Key, "").Code)
+
+		rr := serve(http.MethodDelete, "/api-keys/"+revoked.ID, adminKey, "")
+		require.Equal(t, http.StatusNoContent, rr.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/api-keys", revoked.Key, "").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/api-keys/"+revoked.ID, adminKey, "").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/api-keys/"+revoked.ID, adminKey, "").Code)
+	})
+
+	t.Run("api keys are isolated per tenant", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api-keys",
+			strings.NewReader(`{"name":"billing-admin","scopes":["admin:keys","send:*"]}`))
+		req.Header.Set("Authorization", "Bearer "+adminKey)
+		req.Header.Set(middleware.TenantHeader, "billing")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var billingAdmin dto.CreatedAPIKey
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&billingAdmin))
+		assert.Equal(t, "billing", billingAdmin.TenantID)
+
+		defaultKey := create(t, adminKey, `{"name":"default-sender","scopes":["send:*"]}`)
+		assert.Equal(t, domain.DefaultTenantID, defaultKey.TenantID)
+
+		rr = serve(http.MethodGet, "/api-keys", billingAdmin.Key, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var keys []dto.APIKey
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&keys))
+		require.Len(t, keys, 1)
+		assert.Equal(t, billingAdmin.ID, keys[0].ID)
+
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/api-keys/"+defaultKey.ID, billingAdmin.Key, "").Code)
+		assert.Equal(t, http.StatusNotFound,
+			serve(http.MethodDelete, "/api-keys/"+defaultKey.ID, billingAdmin.Key, "").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/api-keys/"+billingAdmin.ID, adminKey, "").Code)
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api-keys/"+defaultKey.ID, adminKey, "").Code)
+	})
+}
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"notification/internal/auth"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/service"
+)
+
+// maxBounceBodySize is the maximum accepted size of bounce webhook payloads.
+const maxBounceBodySize = 1 << 20
+
+// BounceOption defines the optional params for the Bounce controller.
+type BounceOption func(*Bounce)
+
+// SNSVerifier verifies that the Amazon SNS messages are signed by SNS.
+type SNSVerifier interface {
+	// Verify returns auth.ErrInvalidSNSSignature if the message isn't signed by SNS.
+	Verify(ctx context.Context, message auth.SNSMessage) error
+}
+
+// WithWebhookToken requires the bounce webhooks to be called with the given shared token,
+// either in the "X-Webhook-Token" header or as the password of the basic authentication,
+// for the providers that can only set credentials in the webhook URL.
+func WithWebhookToken(token string) BounceOption {
+	return func(b *Bounce) {
+		b.token = token
+	}
+}
+
+// WithSNSVerifier requires the SES notifications to be signed by Amazon SNS.
+func WithSNSVerifier(verifier SNSVerifier) BounceOption {
+	return func(b *Bounce) {
+		b.snsVerifier = verifier
+	}
+}
+
+// NewBounce creates a new Bounce controller instance.
+func NewBounce(handler service.BounceHandler, opts ...BounceOption) *Bounce {
+	b := &Bounce{handler: handler}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Bounce is the bounce controller.
+// It defines the webhooks receiving delivery feedback, either as raw DSN messages
+// or in the mail provider formats.
+type Bounce struct {
+	handler     service.BounceHandler
+	token       string
+	snsVerifier SNSVerifier
+}
+
+// SetRouter returns the router r with all the necessary routes for the
+// Bounce controller setup.
+func (b Bounce) SetRouter(r *mux.Router) {
+	r.HandleFunc("/webhooks/bounces/dsn", middleware.Logger(b.authorize(b.receiveDSN))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/webhooks/bounces/ses", middleware.Logger(b.authorize(b.receiveSES))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/webhooks/bounces/sendgrid", middleware.Logger(b.authorize(b.receiveSendGrid))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/webhooks/bounces/mailgun", middleware.Logger(b.authorize(b.receiveMailgun))).
+		Methods(http.MethodPost)
+}
+
+// authorize rejects the requests without the shared token, if any is configured.
+func (b Bounce) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if b.token != "" {
+			token := r.Header.Get("X-Webhook-Token")
+			if token == "" {
+				_, token, _ = r.BasicAuth()
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(b.token)) != 1 {
+				http.Error(w, "invalid webhook token", http.StatusUnauthorized)
+				return
+			}
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBounceBodySize)
+		next(w, r)
+	}
+}
+
+// @Summary Receive a bounce message
+// @Description Receives a Delivery Status Notification message (RFC 3464)
+// @Tags bounce
+// @Accept message/rfc822
+// @Success 204
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /webhooks/bounces/dsn [post]
+func (b Bounce) receiveDSN(w http.ResponseWriter, r *http.Request) {
+	events, err := service.ParseDSN(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b.handle(w, r, events)
+}
+
+// @Summary Receive SES bounce and complaint notifications
+// @Description Receives the SES bounce and complaint notifications published through Amazon SNS
+// @Tags bounce
+// @Accept json
+// @Param message body dto.SNSMessage true "SNS message"
+// @Success 204
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /webhooks/bounces/ses [post]
+func (b Bounce) receiveSES(w http.ResponseWriter, r *http.Request) {
+	var message dto.SNSMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if b.snsVerifier != nil {
+		err := b.snsVerifier.Verify(r.Context(), message.Signed())
+		if errors.Is(err, auth.ErrInvalidSNSSignature) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if message.Type == "SubscriptionConfirmation" {
+		// the subscription has to be confirmed by an operator, visiting the URL.
+		logging.FromContext(r.Context()).Warn().
+			Str("subscribeUrl", message.SubscribeURL).
+			Msg("SNS subscription confirmation received, confirm it visiting the URL")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	events, err := message.BounceEvents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b.handle(w, r, events)
+}
+
+// @Summary Receive SendGrid events
+// @Description Receives the SendGrid Event Webhook bounce and spam report events
+// @Tags bounce
+// @Accept json
+// @Param events body dto.SendGridEvents true "SendGrid events"
+// @Success 204
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /webhooks/bounces/sendgrid [post]
+func (b Bounce) receiveSendGrid(w http.ResponseWriter, r *http.Request) {
+	var events dto.SendGridEvents
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b.handle(w, r, events.BounceEvents())
+}
+
+// @Summary Receive Mailgun events
+// @Description Receives the Mailgun failed and complained webhook events
+// @Tags bounce
+// @Accept json
+// @Param event body dto.MailgunWebhook true "Mailgun webhook"
+// @Success 204
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /webhooks/bounces/mailgun [post]
+func (b Bounce) receiveMailgun(w http.ResponseWriter, r *http.Request) {
+	var webhook dto.MailgunWebhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b.handle(w, r, webhook.BounceEvents())
+}
+
+func (b Bounce) handle(w http.ResponseWriter, r *http.Request, events []domain.BounceEvent) {
+	if len(events) > 0 {
+		if err := b.handler.HandleBounces(r.Context(), events); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
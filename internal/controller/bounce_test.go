@@ -0,0 +1,247 @@
+package controller_test
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/auth"
+	"notification/internal/controller"
+	"notification/internal/domain"
+	"notification/mocks"
+	"strings"
+	"testing"
+)
+
+func TestBounce(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantEvents []domain.BounceEvent
+	}{
+		{
+			name: "SES bounce",
+			path: "/webhooks/bounces/ses",
+			body: `{"Type":"Notification","Message":"{\"notificationType\":\"Bounce\",\"mail\":{\"messageId\":\"ses-id\"},\"bounce\":{\"bounceType\":\"Permanent\",` +
+				`\"bouncedRecipients\":[{\"emailAddress\":\"john@example.com\",\"status\":\"5.1.1\",\"diagnosticCode\":\"smtp; 550 user unknown\"}]}}"}`,
+			wantEvents: []domain.BounceEvent{{
+				Email:      "john@example.com",
+				Type:       domain.HardBounce,
+				Status:     "5.1.1",
+				Diagnostic: "smtp; 550 user unknown",
+				Source:     "ses",
+				MessageID:  "ses-id",
+			}},
+		},
+		{
+			name: "SES complaint",
+			path: "/webhooks/bounces/ses",
+			body: `{"Type":"Notification","Message":"{\"eventType\":\"Complaint\",\"complaint\":{` +
+				`\"complainedRecipients\":[{\"emailAddress\":\"john@example.com\"}],\"complaintFeedbackType\":\"abuse\"}}"}`,
+			wantEvents: []domain.BounceEvent{{
+				Email:      "john@example.com",
+				Type:       domain.Complaint,
+				Diagnostic: "abuse",
+				Source:     "ses",
+			}},
+		},
+		{
+			name: "SendGrid events",
+			path: "/webhooks/bounces/sendgrid",
+			body: `[{"email":"john@example.com","event":"bounce","type":"bounce","status":"5.1.1","reason":"user unknown",
+				"sg_message_id":"sg-id.filter0001.1234.0"},
+				{"email":"jane@example.com","event":"delivered"},
+				{"email":"bob@example.com","event":"spamreport"}]`,
+			wantEvents: []domain.BounceEvent{
+				{
+					Email:      "john@example.com",
+					Type:       domain.HardBounce,
+					Status:     "5.1.1",
+					Diagnostic: "user unknown",
+					Source:     "sendgrid",
+					MessageID:  "sg-id",
+				},
+				{
+					Email:  "bob@example.com",
+					Type:   domain.Complaint,
+					Source: "sendgrid",
+				},
+			},
+		},
+		{
+			name: "Mailgun failure",
+			path: "/webhooks/bounces/mailgun",
+			body: `{"event-data":{"event":"failed","severity":"permanent","recipient":"john@example.com",` +
+				`"message":{"headers":{"message-id":"mg-id@mg.example.com"}},` +
+				`"delivery-status":{"code":550,"description":"user unknown"}}}`,
+			wantEvents: []domain.BounceEvent{{
+				Email:      "john@example.com",
+				Type:       domain.HardBounce,
+				Status:     "550",
+				Diagnostic: "user unknown",
+				Source:     "mailgun",
+				MessageID:  "<mg-id@mg.example.com>",
+			}},
+		},
+		{
+			name: "DSN message",
+			path: "/webhooks/bounces/dsn",
+			body: "Content-Type: multipart/report; report-type=delivery-status; boundary=\"B\"\r\n" +
+				"\r\n" +
+				"--B\r\n" +
+				"Content-Type: message/delivery-status\r\n" +
+				"\r\n" +
+				"Reporting-MTA: dns; mx.example.com\r\n" +
+				"\r\n" +
+				"Final-Recipient: rfc822; john@example.com\r\n" +
+				"Action: failed\r\n" +
+				"Status: 5.1.1\r\n" +
+				"\r\n" +
+				"--B--\r\n",
+			wantEvents: []domain.BounceEvent{{
+				Email:  "john@example.com",
+				Type:   domain.HardBounce,
+				Status: "5.1.1",
+				Source: "dsn",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := mocks.NewBounceHandler(t)
+			handler.On("HandleBounces", mock.Anything, tt.wantEvents).Return(nil)
+
+			r := mux.NewRouter()
+			controller.NewBounce(handler).SetRouter(r)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+		})
+	}
+
+	t.Run("SNS subscription confirmation", func(t *testing.T) {
+		handler := mocks.NewBounceHandler(t)
+
+		r := mux.NewRouter()
+		controller.NewBounce(handler).SetRouter(r)
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/bounces/ses",
+			strings.NewReader(`{"Type":"SubscriptionConfirmation","SubscribeURL":"https://sns.example.com/confirm"}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		handler.AssertNotCalled(t, "HandleBounces", mock.Anything, mock.Anything)
+	})
+
+	t.Run("webhook token", func(t *testing.T) {
+		handler := mocks.NewBounceHandler(t)
+		handler.On("HandleBounces", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+		r := mux.NewRouter()
+		controller.NewBounce(handler, controller.WithWebhookToken("s3cr3t")).SetRouter(r)
+
+		body := `[{"email":"john@example.com","event":"spamreport"}]`
+
+		t.Run("missing token is rejected", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/bounces/sendgrid", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+
+		t.Run("header token is accepted", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/bounces/sendgrid", strings.NewReader(body))
+			req.Header.Set("X-Webhook-Token", "s3cr3t")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+		})
+
+		t.Run("basic authentication password is accepted", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/bounces/sendgrid", strings.NewReader(body))
+			req.SetBasicAuth("bounces", "s3cr3t")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+		})
+
+		t.Run("query param token is rejected", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/bounces/sendgrid?token=s3cr3t", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+	})
+
+	t.Run("SNS signature", func(t *testing.T) {
+		body := `{"Type":"Notification","MessageId":"m1","Message":"{\"notificationType\":\"Complaint\"}",` +
+			`"SignatureVersion":"2","Signature":"c2ln","SigningCertURL":"https://sns.us-east-1.amazonaws.com/cert.pem"}`
+
+		t.Run("signed message is accepted", func(t *testing.T) {
+			handler := mocks.NewBounceHandler(t)
+			verifier := snsVerifierFunc(func(_ context.Context, message auth.SNSMessage) error {
+				assert.Equal(t, "m1", message.MessageID)
+				assert.Equal(t, "c2ln", message.Signature)
+				return nil
+			})
+
+			r := mux.NewRouter()
+			controller.NewBounce(handler, controller.WithSNSVerifier(verifier)).SetRouter(r)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/bounces/ses", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+		})
+
+		t.Run("forged message is rejected", func(t *testing.T) {
+			handler := mocks.NewBounceHandler(t)
+			verifier := snsVerifierFunc(func(context.Context, auth.SNSMessage) error {
+				return auth.ErrInvalidSNSSignature
+			})
+
+			r := mux.NewRouter()
+			controller.NewBounce(handler, controller.WithSNSVerifier(verifier)).SetRouter(r)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/bounces/ses", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			handler.AssertNotCalled(t, "HandleBounces", mock.Anything, mock.Anything)
+		})
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		handler := mocks.NewBounceHandler(t)
+
+		r := mux.NewRouter()
+		controller.NewBounce(handler).SetRouter(r)
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/bounces/dsn", strings.NewReader("Subject: hi\r\n\r\nhey"))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+// snsVerifierFunc is a controller.SNSVerifier verifying the messages with the function.
+type snsVerifierFunc func(ctx context.Context, message auth.SNSMessage) error
+
+func (f snsVerifierFunc) Verify(ctx context.Context, message auth.SNSMessage) error {
+	return f(ctx, message)
+}
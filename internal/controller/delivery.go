@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"strconv"
+)
+
+const (
+	// defaultHistoryLimit is the number of notifications returned by the user history by default.
+	defaultHistoryLimit = 50
+	// maxHistoryLimit is the maximum number of notifications returned by the user history.
+	maxHistoryLimit = 500
+)
+
+// DeliveryOption defines the optional params for the Delivery controller.
+type DeliveryOption func(*Delivery)
+
+// WithCallbackLog exposes the delivery event callback attempts of each notification.
+func WithCallbackLog(callbackLog repository.CallbackLogRepository) DeliveryOption {
+	return func(d *Delivery) {
+		d.callbackLog = callbackLog
+	}
+}
+
+// NewDelivery creates a new Delivery controller instance.
+func NewDelivery(repo repository.DeliveryRepository, opts ...DeliveryOption) *Delivery {
+	d := &Delivery{repo: repo}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Delivery is the delivery tracking controller.
+// It defines routes and handlers to query the delivery status of notifications.
+type Delivery struct {
+	repo        repository.DeliveryRepository
+	callbackLog repository.CallbackLogRepository
+}
+
+// SetRouter returns the router r with all the necessary routes for the
+// Delivery controller setup.
+func (d Delivery) SetRouter(r *mux.Router) {
+	r.HandleFunc("/notifications/{correlationId}", middleware.Logger(middleware.RequireScope(domain.ScopeReadNotifications,
+		middleware.SetJSONContent(d.get)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/users/{id}/notifications/history", middleware.Logger(middleware.RequireScope(domain.ScopeReadNotifications,
+		middleware.SetJSONContent(d.history)))).
+		Methods(http.MethodGet)
+	if d.callbackLog != nil {
+		r.HandleFunc("/notifications/{correlationId}/callbacks",
+			middleware.Logger(middleware.RequireScope(domain.ScopeReadNotifications,
+				middleware.SetJSONContent(d.callbacks)))).
+			Methods(http.MethodGet)
+	}
+}
+
+// @Summary Get the delivery status of a notification
+// @Description Gets the delivery status of a notification along with its lifecycle history
+// @Tags notification
+// @Produce json
+// @Param correlationId path string true "Notification correlation ID"
+// @Success 200 {object} dto.Delivery
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /notifications/{correlationId} [get]
+func (d Delivery) get(w http.ResponseWriter, r *http.Request) {
+	record, err := d.repo.Get(r.Context(), mux.Vars(r)["correlationId"])
+	if err != nil {
+		if errors.Is(err, repository.ErrDeliveryNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewDelivery(record))
+}
+
+// @Summary Get the notification history of a user
+// @Description Lists the notifications sent to a user, the most recent first
+// @Tags notification
+// @Produce json
+// @Param id path string true "User ID"
+// @Param limit query int false "Maximum number of notifications (default 50, max 500)"
+// @Success 200 {array} dto.Delivery
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id}/notifications/history [get]
+func (d Delivery) history(w http.ResponseWriter, r *http.Request) {
+	limit := defaultHistoryLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxHistoryLimit {
+			http.Error(w, "limit must be a number between 1 and 500", http.StatusBadRequest)
+			return
+		}
+	}
+
+	records, err := d.repo.ListByUser(r.Context(), mux.Vars(r)["id"], limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.Delivery, 0, len(records))
+	for _, record := range records {
+		response = append(response, dto.NewDelivery(record))
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Get the callback attempts of a notification
+// @Description Lists the attempts to post the delivery events of a notification to its callback URL, oldest first
+// @Tags notification
+// @Produce json
+// @Param correlationId path string true "Notification correlation ID"
+// @Success 200 {array} dto.CallbackAttempt
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /notifications/{correlationId}/callbacks [get]
+func (d Delivery) callbacks(w http.ResponseWriter, r *http.Request) {
+	correlationID := mux.Vars(r)["correlationId"]
+	// the notification must be one of the tenant's.
+	if _, err := d.repo.Get(r.Context(), correlationID); err != nil {
+		if errors.Is(err, repository.ErrDeliveryNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	attempts, err := d.callbackLog.ListByCorrelationID(r.Context(), correlationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.CallbackAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		response = append(response, dto.NewCallbackAttempt(attempt))
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
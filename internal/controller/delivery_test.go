@@ -0,0 +1,125 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/controller"
+	"notification/internal/controller/dto"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestDelivery(t *testing.T) {
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	repo := repository.NewInMemoryDeliveryRepository()
+	for i, id := range []string{"c1", "c2", "c3"} {
+		record := domain.DeliveryRecord{CorrelationID: id, UserID: "u1", Type: domain.Status}
+		at := start.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, repo.AddTransition(context.Background(), record,
+			domain.StatusTransition{Status: domain.DeliveryAccepted, At: at}))
+		require.NoError(t, repo.AddTransition(context.Background(), record,
+			domain.StatusTransition{Status: domain.DeliverySent, At: at.Add(time.Second)}))
+	}
+
+	callbackLog := repository.NewInMemoryCallbackLogRepository(10)
+	require.NoError(t, callbackLog.Add(context.Background(), domain.CallbackAttempt{
+		EventID:       "e1",
+		EventType:     domain.EventSent,
+		CorrelationID: "c1",
+		URL:           "https://example.com/callback",
+		Attempt:       1,
+		StatusCode:    http.StatusOK,
+		At:            start,
+	}))
+
+	r := mux.NewRouter()
+	controller.NewDelivery(repo, controller.WithCallbackLog(callbackLog)).SetRouter(r)
+
+	t.Run("notification status", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/notifications/c1", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got dto.Delivery
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, "sent", got.Status)
+		assert.Equal(t, "status", got.Type)
+		assert.Equal(t, []dto.StatusTransition{
+			{Status: "accepted", At: start},
+			{Status: "sent", At: start.Add(time.Second)},
+		}, got.Transitions)
+	})
+
+	t.Run("unknown notification", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/notifications/unknown", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("user history", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/u1/notifications/history?limit=2", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.Delivery
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		require.Len(t, got, 2)
+		assert.Equal(t, "c3", got[0].CorrelationID)
+		assert.Equal(t, "c2", got[1].CorrelationID)
+	})
+
+	t.Run("invalid history limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/u1/notifications/history?limit=0", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("callback attempts", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/notifications/c1/callbacks", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.CallbackAttempt
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, []dto.CallbackAttempt{{
+			EventID:    "e1",
+			EventType:  "sent",
+			URL:        "https://example.com/callback",
+			Attempt:    1,
+			StatusCode: http.StatusOK,
+			At:         start,
+		}}, got)
+	})
+	t.Run("notifications of other tenants are not found", func(t *testing.T) {
+		billing := repository.WithTenant(context.Background(), "billing")
+		serve := func(target string) *httptest.ResponseRecorder {
+			req := httptest.NewRequestWithContext(billing, http.MethodGet, target, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			return rr
+		}
+
+		assert.Equal(t, http.StatusNotFound, serve("/notifications/c1").Code)
+		assert.Equal(t, http.StatusNotFound, serve("/notifications/c1/callbacks").Code)
+
+		rr := serve("/users/u1/notifications/history")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.Delivery
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Empty(t, got)
+	})
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"notification/internal/domain"
+	"time"
+)
+
+// APIKeyRequest is the Data Transfer Object to create an API key.
+type APIKeyRequest struct {
+	// Name describes who the API key is issued to, e.g. "billing-service".
+	Name string `json:"name"`
+	// Scopes are the permissions granted to the API key, e.g. "send:status" or "admin:rules".
+	Scopes []domain.Scope `json:"scopes"`
+}
+
+// Validate returns an error ErrFailedValidation if APIKeyRequest
+// doesn't pass schema validation.
+func (k APIKeyRequest) Validate() error {
+	var err error
+
+	if k.Name == "" {
+		err = errors.Join(ErrFailedValidation, errors.New("name is empty"))
+	}
+
+	if len(k.Scopes) == 0 {
+		err = errors.Join(err, ErrFailedValidation, errors.New("scopes are empty"))
+	}
+	for _, scope := range k.Scopes {
+		if !scope.Valid() {
+			err = errors.Join(err, ErrFailedValidation, fmt.Errorf("scope %q is not valid", scope))
+		}
+	}
+
+	return err
+}
+
+// APIKey is the Data Transfer Object of the API key resource. The key itself is never returned,
+// except by the creation, see CreatedAPIKey.
+type APIKey struct {
+	// ID is the API key unique identifier.
+	ID string `json:"id"`
+	// Name describes who the API key was issued to.
+	Name string `json:"name"`
+	// TenantID is the ID of the tenant the API key acts for.
+	TenantID string `json:"tenantId"`
+	// Scopes are the permissions granted to the API key.
+	Scopes []domain.Scope `json:"scopes"`
+	// CreatedAt is when the API key was created.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewAPIKey converts a domain.APIKey into its Data Transfer Object.
+func NewAPIKey(k domain.APIKey) APIKey {
+	return APIKey{
+		ID:        k.ID,
+		Name:      k.Name,
+		TenantID:  k.TenantID,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt,
+	}
+}
+
+// CreatedAPIKey is the Data Transfer Object of a new API key, holding the key.
+type CreatedAPIKey struct {
+	APIKey
+	// Key is the API key the client authenticates with. It can't be retrieved afterward.
+	Key string `json:"key"`
+}
@@ -0,0 +1,233 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification/internal/auth"
+	"notification/internal/domain"
+	"strings"
+)
+
+// SNSMessage is the envelope of the Amazon SNS HTTP(S) notifications
+// used by SES to publish bounce and complaint events.
+type SNSMessage struct {
+	// Type is the SNS message type: "Notification", "SubscriptionConfirmation" or "UnsubscribeConfirmation".
+	Type      string `json:"Type"`
+	MessageID string `json:"MessageId"`
+	TopicArn  string `json:"TopicArn"`
+	Subject   string `json:"Subject"`
+	// Message is the JSON encoded SES event.
+	Message   string `json:"Message"`
+	Timestamp string `json:"Timestamp"`
+	Token     string `json:"Token"`
+	// SubscribeURL is the URL to visit in order to confirm the subscription.
+	SubscribeURL string `json:"SubscribeURL"`
+	// SignatureVersion, Signature and SigningCertURL sign the message, see auth.SNSVerifier.
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// Signed returns the fields of the message covered by its signature.
+func (m SNSMessage) Signed() auth.SNSMessage {
+	return auth.SNSMessage{
+		Type:             m.Type,
+		MessageID:        m.MessageID,
+		TopicArn:         m.TopicArn,
+		Subject:          m.Subject,
+		Message:          m.Message,
+		Timestamp:        m.Timestamp,
+		Token:            m.Token,
+		SubscribeURL:     m.SubscribeURL,
+		SignatureVersion: m.SignatureVersion,
+		Signature:        m.Signature,
+		SigningCertURL:   m.SigningCertURL,
+	}
+}
+
+// SESEvent is the SES bounce or complaint event.
+type SESEvent struct {
+	// NotificationType is set by SES notifications, e.g. "Bounce" or "Complaint".
+	NotificationType string `json:"notificationType"`
+	// EventType is set by SES event publishing, e.g. "Bounce" or "Complaint".
+	EventType string `json:"eventType"`
+	Mail      struct {
+		// MessageID is the SES message ID, as returned when the email was sent.
+		MessageID string `json:"messageId"`
+	} `json:"mail"`
+	Bounce struct {
+		// BounceType is either "Permanent", "Transient" or "Undetermined".
+		BounceType        string `json:"bounceType"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			Status         string `json:"status"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplainedRecipients []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+		ComplaintFeedbackType string `json:"complaintFeedbackType"`
+	} `json:"complaint"`
+}
+
+// BounceEvents converts the SNS message into the corresponding bounce events.
+// Messages other than SES bounces and complaints result in no events.
+func (m SNSMessage) BounceEvents() ([]domain.BounceEvent, error) {
+	if m.Type != "Notification" {
+		return nil, nil
+	}
+
+	var event SESEvent
+	if err := json.Unmarshal([]byte(m.Message), &event); err != nil {
+		return nil, errors.Join(ErrFailedValidation, fmt.Errorf("invalid SES event: %w", err))
+	}
+
+	eventType := event.NotificationType
+	if eventType == "" {
+		eventType = event.EventType
+	}
+
+	var events []domain.BounceEvent
+	switch eventType {
+	case "Bounce":
+		bounceType := domain.SoftBounce
+		if event.Bounce.BounceType == "Permanent" {
+			bounceType = domain.HardBounce
+		}
+		for _, r := range event.Bounce.BouncedRecipients {
+			events = append(events, domain.BounceEvent{
+				Email:      r.EmailAddress,
+				Type:       bounceType,
+				Status:     r.Status,
+				Diagnostic: r.DiagnosticCode,
+				Source:     "ses",
+				MessageID:  event.Mail.MessageID,
+			})
+		}
+	case "Complaint":
+		for _, r := range event.Complaint.ComplainedRecipients {
+			events = append(events, domain.BounceEvent{
+				Email:      r.EmailAddress,
+				Type:       domain.Complaint,
+				Diagnostic: event.Complaint.ComplaintFeedbackType,
+				Source:     "ses",
+				MessageID:  event.Mail.MessageID,
+			})
+		}
+	}
+	return events, nil
+}
+
+// SendGridEvent is an event of the SendGrid Event Webhook.
+type SendGridEvent struct {
+	// Email is the recipient address.
+	Email string `json:"email"`
+	// Event is the event name, e.g. "bounce", "dropped" or "spamreport".
+	Event string `json:"event"`
+	// Type is set on bounce events: "bounce" for hard bounces and "blocked" for soft bounces.
+	Type string `json:"type"`
+	// Status is the enhanced status code, e.g. "5.1.1".
+	Status string `json:"status"`
+	// Reason is the diagnostic message.
+	Reason string `json:"reason"`
+	// SGMessageID is the SendGrid message ID: the X-Message-Id returned when
+	// the email was sent, followed by a filter suffix.
+	SGMessageID string `json:"sg_message_id"`
+}
+
+// SendGridEvents is the SendGrid Event Webhook payload.
+type SendGridEvents []SendGridEvent
+
+// BounceEvents converts the SendGrid events into the corresponding bounce events,
+// ignoring the events that aren't bounces or spam reports.
+func (s SendGridEvents) BounceEvents() []domain.BounceEvent {
+	var events []domain.BounceEvent
+	for _, e := range s {
+		var bounceType domain.BounceType
+		switch {
+		case e.Event == "bounce" && e.Type == "blocked":
+			bounceType = domain.SoftBounce
+		case e.Event == "bounce":
+			bounceType = domain.HardBounce
+		case e.Event == "spamreport":
+			bounceType = domain.Complaint
+		default:
+			continue
+		}
+		events = append(events, domain.BounceEvent{
+			Email:      e.Email,
+			Type:       bounceType,
+			Status:     e.Status,
+			Diagnostic: e.Reason,
+			Source:     "sendgrid",
+			MessageID:  strings.SplitN(e.SGMessageID, ".", 2)[0],
+		})
+	}
+	return events
+}
+
+// MailgunWebhook is the Mailgun webhook payload.
+type MailgunWebhook struct {
+	EventData struct {
+		// Event is the event name, e.g. "failed" or "complained".
+		Event string `json:"event"`
+		// Severity is set on failed events: "permanent" or "temporary".
+		Severity  string `json:"severity"`
+		Recipient string `json:"recipient"`
+		Message   struct {
+			Headers struct {
+				// MessageID is the message ID, without the angle brackets
+				// of the ID returned when the email was sent.
+				MessageID string `json:"message-id"`
+			} `json:"headers"`
+		} `json:"message"`
+		DeliveryStatus struct {
+			Code        int    `json:"code"`
+			Description string `json:"description"`
+			Message     string `json:"message"`
+		} `json:"delivery-status"`
+	} `json:"event-data"`
+}
+
+// BounceEvents converts the Mailgun webhook into the corresponding bounce events,
+// ignoring the events that aren't failures or complaints.
+func (m MailgunWebhook) BounceEvents() []domain.BounceEvent {
+	data := m.EventData
+
+	var bounceType domain.BounceType
+	switch {
+	case data.Event == "failed" && data.Severity == "permanent":
+		bounceType = domain.HardBounce
+	case data.Event == "failed":
+		bounceType = domain.SoftBounce
+	case data.Event == "complained":
+		bounceType = domain.Complaint
+	default:
+		return nil
+	}
+
+	var status string
+	if data.DeliveryStatus.Code != 0 {
+		status = fmt.Sprintf("%d", data.DeliveryStatus.Code)
+	}
+	diagnostic := data.DeliveryStatus.Description
+	if diagnostic == "" {
+		diagnostic = data.DeliveryStatus.Message
+	}
+	var messageID string
+	if id := data.Message.Headers.MessageID; id != "" {
+		messageID = "<" + strings.Trim(id, "<>") + ">"
+	}
+
+	return []domain.BounceEvent{{
+		Email:      data.Recipient,
+		Type:       bounceType,
+		Status:     status,
+		Diagnostic: strings.TrimSpace(diagnostic),
+		Source:     "mailgun",
+		MessageID:  messageID,
+	}}
+}
@@ -0,0 +1,93 @@
+package dto
+
+import (
+	"notification/internal/domain"
+	"time"
+)
+
+// Delivery is the Data Transfer Object of the delivery tracking of a notification.
+type Delivery struct {
+	// CorrelationID is the notification correlation ID.
+	CorrelationID string `json:"correlationId"`
+	// UserID is the ID of the user the notification is meant to be sent to.
+	UserID string `json:"userId"`
+	// Type is the notification type.
+	Type string `json:"type"`
+	// Status is the current delivery status: "accepted", "rate_limited", "deferred",
+	// "sending", "sent", "failed" or "suppressed".
+	Status string `json:"status"`
+	// ProviderMessageID is the message ID reported by the mail provider, when available.
+	ProviderMessageID string `json:"providerMessageId,omitempty"`
+	// Caller identifies the authenticated client which sent the notification, if any.
+	Caller string `json:"caller,omitempty"`
+	// CreatedAt is when the notification was first accepted.
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt is when the last transition happened.
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Transitions is the delivery lifecycle history, oldest first.
+	Transitions []StatusTransition `json:"transitions"`
+}
+
+// StatusTransition is the Data Transfer Object of a step of the delivery lifecycle.
+type StatusTransition struct {
+	// Status is the status the notification moved into.
+	Status string `json:"status"`
+	// Detail is a free-text detail of the transition, e.g. the failure reason.
+	Detail string `json:"detail,omitempty"`
+	// At is when the transition happened.
+	At time.Time `json:"at"`
+}
+
+// NewDelivery converts a domain.DeliveryRecord into its Data Transfer Object.
+func NewDelivery(record domain.DeliveryRecord) Delivery {
+	transitions := make([]StatusTransition, 0, len(record.Transitions))
+	for _, t := range record.Transitions {
+		transitions = append(transitions, StatusTransition{
+			Status: t.Status.String(),
+			Detail: t.Detail,
+			At:     t.At,
+		})
+	}
+	return Delivery{
+		CorrelationID:     record.CorrelationID,
+		UserID:            record.UserID,
+		Type:              record.Type.String(),
+		Status:            record.Status.String(),
+		ProviderMessageID: record.ProviderMessageID,
+		Caller:            record.Caller,
+		CreatedAt:         record.CreatedAt,
+		UpdatedAt:         record.UpdatedAt,
+		Transitions:       transitions,
+	}
+}
+
+// CallbackAttempt is the Data Transfer Object of an attempt to post a delivery event to its callback URL.
+type CallbackAttempt struct {
+	// EventID is the ID of the event being posted.
+	EventID string `json:"eventId"`
+	// EventType is the kind of event: "sent", "failed", "bounced" or "rate_limited".
+	EventType string `json:"eventType"`
+	// URL is the callback URL.
+	URL string `json:"url"`
+	// Attempt is the attempt number, starting at 1.
+	Attempt int `json:"attempt"`
+	// StatusCode is the HTTP status code of the response, or 0 if no response was received.
+	StatusCode int `json:"statusCode"`
+	// Error is the failure reason, if the attempt failed.
+	Error string `json:"error,omitempty"`
+	// At is when the attempt was made.
+	At time.Time `json:"at"`
+}
+
+// NewCallbackAttempt converts a domain.CallbackAttempt into its Data Transfer Object.
+func NewCallbackAttempt(attempt domain.CallbackAttempt) CallbackAttempt {
+	return CallbackAttempt{
+		EventID:    attempt.EventID,
+		EventType:  attempt.EventType.String(),
+		URL:        attempt.URL,
+		Attempt:    attempt.Attempt,
+		StatusCode: attempt.StatusCode,
+		Error:      attempt.Error,
+		At:         attempt.At,
+	}
+}
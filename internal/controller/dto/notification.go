@@ -2,6 +2,10 @@ package dto
 
 import (
 	"errors"
+	"net/netip"
+	"net/url"
+	"notification/internal/domain"
+	"strings"
 )
 
 // Notification is the Data Transfer Object for HTTP handler operations.
@@ -15,6 +19,8 @@ type Notification struct {
 	Type string `json:"type"`
 	// Message is the message content of the notification.
 	Message string `json:"message"`
+	// CallbackURL is the optional URL where the delivery events of the notification are posted.
+	CallbackURL string `json:"callbackUrl,omitempty"`
 }
 
 // Validate returns an error ErrFailedValidation if Notification
@@ -38,5 +44,27 @@ func (n Notification) Validate() error {
 		err = errors.Join(err, ErrFailedValidation, errors.New("message is empty"))
 	}
 
+	if n.CallbackURL != "" && !isCallbackURL(n.CallbackURL) {
+		err = errors.Join(err, ErrFailedValidation, errors.New("callback URL must be an absolute http(s) URL of a public host"))
+	}
+
 	return err
 }
+
+// isCallbackURL reports whether rawURL is an absolute http or https URL whose host isn't
+// obviously internal: localhost or an IP address that isn't public. The hosts resolving to
+// internal addresses are refused when the events are posted.
+func isCallbackURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return domain.IsPublicAddress(addr)
+	}
+	return true
+}
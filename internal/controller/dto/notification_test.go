@@ -22,6 +22,50 @@ func TestNotification_Validate(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "valid with callback URL",
+			notification: dto.Notification{
+				CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+				UserID:        "123-abc",
+				Type:          "marketing",
+				Message:       "Hey there!",
+				CallbackURL:   "https://example.com/callback",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid callback URL",
+			notification: dto.Notification{
+				CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+				UserID:        "123-abc",
+				Type:          "marketing",
+				Message:       "Hey there!",
+				CallbackURL:   "ftp://example.com/callback",
+			},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name: "internal callback URL",
+			notification: dto.Notification{
+				CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+				UserID:        "123-abc",
+				Type:          "marketing",
+				Message:       "Hey there!",
+				CallbackURL:   "http://169.254.169.254/latest/meta-data/",
+			},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name: "localhost callback URL",
+			notification: dto.Notification{
+				CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+				UserID:        "123-abc",
+				Type:          "marketing",
+				Message:       "Hey there!",
+				CallbackURL:   "http://localhost:6379/callback",
+			},
+			wantErr: dto.ErrFailedValidation,
+		},
 		{
 			name: "missing correlation ID",
 			notification: dto.Notification{
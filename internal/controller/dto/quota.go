@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"notification/internal/domain"
+	"notification/internal/service"
+)
+
+// Quota is the Data Transfer Object of the notifications of a type a user can still be sent.
+type Quota struct {
+	// Type is the notification type.
+	Type string `json:"type"`
+	// Remaining is the notification count the user can still be sent, that is the one of the limit
+	// closest to exhaustion. It's null if the notifications are unlimited, and 0 if the notification
+	// type has no rate limit rule, in which case the notifications are rejected.
+	Remaining *int `json:"remaining"`
+	// Limits are the limits the notifications are subject to: the rate limit rule, unless it's
+	// unlimited, then the quota of the tenant, if any.
+	Limits []QuotaLimit `json:"limits"`
+}
+
+// QuotaLimit is the Data Transfer Object of the status of a limit.
+type QuotaLimit struct {
+	// Limit is the max notification count allowed for the time span.
+	Limit int `json:"limit"`
+	// Remaining is the notification count left in the time span.
+	Remaining int `json:"remaining"`
+	// Window is the time span of the limit, e.g. "1m0s".
+	Window string `json:"window"`
+	// Reset is how long until the count is reset, at most, e.g. "1m0s". It's omitted when nothing
+	// has been counted.
+	Reset string `json:"reset,omitempty"`
+}
+
+// NewQuota converts the limits of the notifications of the type into their Data Transfer Object.
+func NewQuota(notificationType domain.NotificationType, limits service.RateLimitLimits) Quota {
+	q := Quota{
+		Type:   notificationType.String(),
+		Limits: make([]QuotaLimit, 0, len(limits)),
+	}
+	for _, limit := range limits {
+		l := QuotaLimit{
+			Limit:     limit.Limit,
+			Remaining: limit.Remaining,
+			Window:    limit.Window.String(),
+		}
+		if limit.Reset > 0 {
+			l.Reset = limit.Reset.String()
+		}
+		q.Limits = append(q.Limits, l)
+	}
+	if closest, ok := limits.Closest(); ok {
+		q.Remaining = &closest.Remaining
+	}
+	return q
+}
+
+// NewRejectedQuota returns the Quota of a notification type without a rate limit rule,
+// whose notifications are rejected.
+func NewRejectedQuota(notificationType domain.NotificationType) Quota {
+	remaining := 0
+	return Quota{
+		Type:      notificationType.String(),
+		Remaining: &remaining,
+		Limits:    []QuotaLimit{},
+	}
+}
@@ -0,0 +1,162 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"notification/internal/domain"
+	"sort"
+	"time"
+)
+
+// RateLimitOverride is the Data Transfer Object of the rate limit override of a user or segment.
+type RateLimitOverride struct {
+	// Scope is "user" or "segment". It's ignored in requests, where the scope is taken from the path.
+	Scope string `json:"scope,omitempty"`
+	// Subject is the user ID or the segment name. It's ignored in requests, where the subject is
+	// taken from the path.
+	Subject string `json:"subject,omitempty"`
+	// Rules are the rules of the override per notification type.
+	Rules []RateLimitRule `json:"rules,omitempty"`
+	// Default is the rule of the notification types without a rule in Rules.
+	Default *RateLimitRule `json:"default,omitempty"`
+}
+
+// NewRateLimitOverride converts a domain.RateLimitOverride into its Data Transfer Object.
+func NewRateLimitOverride(override domain.RateLimitOverride) RateLimitOverride {
+	o := RateLimitOverride{
+		Scope:   string(override.Scope),
+		Subject: override.Subject,
+	}
+	for notificationType, rule := range override.Rules {
+		o.Rules = append(o.Rules, NewRateLimitRule(notificationType, rule))
+	}
+	sort.Slice(o.Rules, func(i, j int) bool {
+		return o.Rules[i].Type < o.Rules[j].Type
+	})
+	if override.Default != nil {
+		rule := NewRateLimitRule(0, *override.Default)
+		rule.Type = ""
+		o.Default = &rule
+	}
+	return o
+}
+
+// Validate returns an error ErrFailedValidation if RateLimitOverride
+// doesn't pass schema validation.
+func (o RateLimitOverride) Validate() error {
+	var err error
+
+	if len(o.Rules) == 0 && o.Default == nil {
+		err = errors.Join(ErrFailedValidation, errors.New("at least one rule is required"))
+	}
+
+	seen := make(map[domain.NotificationType]bool, len(o.Rules))
+	for _, rule := range o.Rules {
+		notificationType, typeErr := domain.ToNotificationType(rule.Type)
+		if typeErr != nil {
+			err = errors.Join(err, ErrFailedValidation, fmt.Errorf("rules: %w", typeErr))
+			continue
+		}
+		if seen[notificationType] {
+			err = errors.Join(err, ErrFailedValidation, fmt.Errorf("rules: %s is repeated", notificationType))
+		}
+		seen[notificationType] = true
+		if ruleErr := rule.Validate(); ruleErr != nil {
+			err = errors.Join(err, fmt.Errorf("rule of %s: %w", notificationType, ruleErr))
+		}
+	}
+
+	if o.Default != nil {
+		if ruleErr := o.Default.Validate(); ruleErr != nil {
+			err = errors.Join(err, fmt.Errorf("default rule: %w", ruleErr))
+		}
+	}
+
+	return err
+}
+
+// ToDomain converts the RateLimitOverride of the subject into its domain model.
+// It must be called on validated overrides only.
+func (o RateLimitOverride) ToDomain(scope domain.OverrideScope, subject string) domain.RateLimitOverride {
+	override := domain.RateLimitOverride{Scope: scope, Subject: subject}
+	if len(o.Rules) > 0 {
+		override.Rules = make(domain.RateLimitRules, len(o.Rules))
+	}
+	for _, rule := range o.Rules {
+		notificationType, _ := domain.ToNotificationType(rule.Type)
+		override.Rules[notificationType] = rule.ToDomain()
+	}
+	if o.Default != nil {
+		rule := o.Default.ToDomain()
+		override.Default = &rule
+	}
+	return override
+}
+
+// RateLimitExemptionRequest is the Data Transfer Object to grant a rate limit exemption.
+type RateLimitExemptionRequest struct {
+	// Reason explains the exemption, e.g. the incident reference.
+	Reason string `json:"reason"`
+	// Duration is how long the exemption lasts, e.g. "2h", up to 24h.
+	Duration string `json:"duration"`
+}
+
+// Validate returns an error ErrFailedValidation if RateLimitExemptionRequest
+// doesn't pass schema validation.
+func (e RateLimitExemptionRequest) Validate() error {
+	var err error
+
+	if e.Reason == "" {
+		err = errors.Join(ErrFailedValidation, errors.New("reason is empty"))
+	}
+
+	duration, parseErr := time.ParseDuration(e.Duration)
+	switch {
+	case parseErr != nil:
+		err = errors.Join(err, ErrFailedValidation, fmt.Errorf("invalid duration: %w", parseErr))
+	case duration <= 0 || duration > domain.MaxExemptionDuration:
+		err = errors.Join(err, ErrFailedValidation,
+			fmt.Errorf("duration must be positive and up to %s", domain.MaxExemptionDuration))
+	}
+
+	return err
+}
+
+// ToDomain converts the RateLimitExemptionRequest into the exemption of the notification type
+// granted by actor at now. It must be called on validated requests only.
+func (e RateLimitExemptionRequest) ToDomain(notificationType domain.NotificationType,
+	actor string, now time.Time) domain.RateLimitExemption {
+	duration, _ := time.ParseDuration(e.Duration)
+	return domain.RateLimitExemption{
+		Type:      notificationType,
+		Reason:    e.Reason,
+		Actor:     actor,
+		CreatedAt: now,
+		ExpiresAt: now.Add(duration),
+	}
+}
+
+// RateLimitExemption is the Data Transfer Object of an active rate limit exemption.
+type RateLimitExemption struct {
+	// Type is the exempted notification type.
+	Type string `json:"type"`
+	// Reason explains the exemption.
+	Reason string `json:"reason"`
+	// Actor identifies who granted the exemption.
+	Actor string `json:"actor"`
+	// CreatedAt is when the exemption was granted.
+	CreatedAt time.Time `json:"createdAt"`
+	// ExpiresAt is when the exemption ends.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewRateLimitExemption converts a domain.RateLimitExemption into its Data Transfer Object.
+func NewRateLimitExemption(e domain.RateLimitExemption) RateLimitExemption {
+	return RateLimitExemption{
+		Type:      e.Type.String(),
+		Reason:    e.Reason,
+		Actor:     e.Actor,
+		CreatedAt: e.CreatedAt,
+		ExpiresAt: e.ExpiresAt,
+	}
+}
@@ -0,0 +1,101 @@
+package dto_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/controller/dto"
+	"testing"
+)
+
+func TestRateLimitOverride_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		override dto.RateLimitOverride
+		wantErr  error
+	}{
+		{
+			name:     "valid",
+			override: dto.RateLimitOverride{Rules: []dto.RateLimitRule{{Type: "news", MaxCount: 5, Expiration: "1h"}}},
+			wantErr:  nil,
+		},
+		{
+			name:     "default rule only",
+			override: dto.RateLimitOverride{Default: &dto.RateLimitRule{Unlimited: true}},
+			wantErr:  nil,
+		},
+		{
+			name:     "without rules",
+			override: dto.RateLimitOverride{},
+			wantErr:  dto.ErrFailedValidation,
+		},
+		{
+			name:     "unknown type",
+			override: dto.RateLimitOverride{Rules: []dto.RateLimitRule{{Type: "promo", Unlimited: true}}},
+			wantErr:  dto.ErrFailedValidation,
+		},
+		{
+			name: "repeated type",
+			override: dto.RateLimitOverride{Rules: []dto.RateLimitRule{
+				{Type: "news", Unlimited: true}, {Type: "news", MaxCount: 5, Expiration: "1h"},
+			}},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:     "invalid rule",
+			override: dto.RateLimitOverride{Rules: []dto.RateLimitRule{{Type: "news", MaxCount: 5}}},
+			wantErr:  dto.ErrFailedValidation,
+		},
+		{
+			name:     "invalid default rule",
+			override: dto.RateLimitOverride{Default: &dto.RateLimitRule{MaxCount: 5, Expiration: "forever"}},
+			wantErr:  dto.ErrFailedValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.override.Validate()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRateLimitExemptionRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		exemption dto.RateLimitExemptionRequest
+		wantErr   error
+	}{
+		{
+			name:      "valid",
+			exemption: dto.RateLimitExemptionRequest{Reason: "INC-42", Duration: "2h"},
+			wantErr:   nil,
+		},
+		{
+			name:      "empty reason",
+			exemption: dto.RateLimitExemptionRequest{Duration: "2h"},
+			wantErr:   dto.ErrFailedValidation,
+		},
+		{
+			name:      "invalid duration",
+			exemption: dto.RateLimitExemptionRequest{Reason: "INC-42", Duration: "two hours"},
+			wantErr:   dto.ErrFailedValidation,
+		},
+		{
+			name:      "negative duration",
+			exemption: dto.RateLimitExemptionRequest{Reason: "INC-42", Duration: "-1h"},
+			wantErr:   dto.ErrFailedValidation,
+		},
+		{
+			name:      "duration too long",
+			exemption: dto.RateLimitExemptionRequest{Reason: "INC-42", Duration: "25h"},
+			wantErr:   dto.ErrFailedValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.exemption.Validate()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
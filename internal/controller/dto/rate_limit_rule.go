@@ -0,0 +1,91 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"notification/internal/domain"
+	"time"
+)
+
+// RateLimitRule is the Data Transfer Object of the rate limit rule of a notification type.
+type RateLimitRule struct {
+	// Type is the notification type the rule applies to. It's ignored in requests,
+	// where the type is taken from the path.
+	Type string `json:"type,omitempty"`
+	// MaxCount is the max notification count allowed for the time span.
+	MaxCount int `json:"maxCount,omitempty"`
+	// Expiration is the time span of the rule, e.g. "1m" or "24h".
+	Expiration string `json:"expiration,omitempty"`
+	// Unlimited means the notifications aren't rate limited at all,
+	// in which case MaxCount and Expiration must be omitted.
+	Unlimited bool `json:"unlimited,omitempty"`
+}
+
+// NewRateLimitRule converts a domain.RateLimitRule into its Data Transfer Object.
+func NewRateLimitRule(notificationType domain.NotificationType, rule domain.RateLimitRule) RateLimitRule {
+	r := RateLimitRule{
+		Type:      notificationType.String(),
+		MaxCount:  rule.MaxCount,
+		Unlimited: rule.Unlimited,
+	}
+	if !rule.Unlimited {
+		r.Expiration = rule.Expiration.String()
+	}
+	return r
+}
+
+// Validate returns an error ErrFailedValidation if RateLimitRule
+// doesn't pass schema validation.
+func (r RateLimitRule) Validate() error {
+	if _, err := time.ParseDuration(r.Expiration); err != nil && (r.Expiration != "" || !r.Unlimited) {
+		return errors.Join(ErrFailedValidation, fmt.Errorf("invalid expiration: %w", err))
+	}
+	if err := r.ToDomain().Validate(); err != nil {
+		return errors.Join(ErrFailedValidation, err)
+	}
+	return nil
+}
+
+// ToDomain converts the RateLimitRule into its domain model.
+// It must be called on validated rules only.
+func (r RateLimitRule) ToDomain() domain.RateLimitRule {
+	expiration, _ := time.ParseDuration(r.Expiration)
+	return domain.RateLimitRule{
+		MaxCount:   r.MaxCount,
+		Expiration: expiration,
+		Unlimited:  r.Unlimited,
+	}
+}
+
+// RateLimitRuleChange is an entry of the audit history of a rate limit rule.
+type RateLimitRuleChange struct {
+	// Type is the notification type of the changed rule.
+	Type string `json:"type"`
+	// Actor identifies who made the change.
+	Actor string `json:"actor"`
+	// At is when the change was made.
+	At time.Time `json:"at"`
+	// Old is the rule before the change, null if the rule was created.
+	Old *RateLimitRule `json:"old"`
+	// New is the rule after the change, null if the rule was deleted.
+	New *RateLimitRule `json:"new"`
+}
+
+// NewRateLimitRuleChange converts a domain.RateLimitRuleChange into its Data Transfer Object.
+func NewRateLimitRuleChange(change domain.RateLimitRuleChange) RateLimitRuleChange {
+	toDTO := func(rule *domain.RateLimitRule) *RateLimitRule {
+		if rule == nil {
+			return nil
+		}
+		r := NewRateLimitRule(change.Type, *rule)
+		r.Type = ""
+		return &r
+	}
+	return RateLimitRuleChange{
+		Type:  change.Type.String(),
+		Actor: change.Actor,
+		At:    change.At,
+		Old:   toDTO(change.Old),
+		New:   toDTO(change.New),
+	}
+}
@@ -0,0 +1,58 @@
+package dto_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/controller/dto"
+	"testing"
+)
+
+func TestRateLimitRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    dto.RateLimitRule
+		wantErr error
+	}{
+		{
+			name:    "valid",
+			rule:    dto.RateLimitRule{MaxCount: 2, Expiration: "1m"},
+			wantErr: nil,
+		},
+		{
+			name:    "zero max count",
+			rule:    dto.RateLimitRule{MaxCount: 0, Expiration: "1m"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "invalid expiration",
+			rule:    dto.RateLimitRule{MaxCount: 2, Expiration: "one minute"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "expiration too short",
+			rule:    dto.RateLimitRule{MaxCount: 2, Expiration: "500ms"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "expiration too long",
+			rule:    dto.RateLimitRule{MaxCount: 2, Expiration: "721h"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "unlimited",
+			rule:    dto.RateLimitRule{Unlimited: true},
+			wantErr: nil,
+		},
+		{
+			name:    "unlimited with expiration",
+			rule:    dto.RateLimitRule{Expiration: "1m", Unlimited: true},
+			wantErr: dto.ErrFailedValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"errors"
+	"notification/internal/domain"
+	"time"
+)
+
+// Suppression is the Data Transfer Object of an email address in the suppression list.
+type Suppression struct {
+	// Email is the suppressed email address.
+	Email string `json:"email"`
+	// Reason is why the address has been suppressed: "bounce", "complaint" or "manual".
+	Reason string `json:"reason"`
+	// Detail is a free-text detail of the reason, e.g. the bounce diagnostic.
+	Detail string `json:"detail,omitempty"`
+	// CreatedAt is when the address has been suppressed.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewSuppression converts a domain.Suppression into its Data Transfer Object.
+func NewSuppression(s domain.Suppression) Suppression {
+	return Suppression{
+		Email:     s.Email,
+		Reason:    s.Reason.String(),
+		Detail:    s.Detail,
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+// SuppressionRequest is the Data Transfer Object to add an email address to the suppression list.
+type SuppressionRequest struct {
+	// Reason is why the address is suppressed. Defaults to "manual".
+	Reason string `json:"reason"`
+	// Detail is a free-text detail of the reason.
+	Detail string `json:"detail"`
+}
+
+// Validate returns an error ErrFailedValidation if SuppressionRequest
+// doesn't pass schema validation.
+func (s SuppressionRequest) Validate() error {
+	if s.Reason == "" {
+		return nil
+	}
+	if _, err := domain.ToSuppressionReason(s.Reason); err != nil {
+		return errors.Join(ErrFailedValidation, err)
+	}
+	return nil
+}
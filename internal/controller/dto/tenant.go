@@ -0,0 +1,103 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"notification/internal/domain"
+	"time"
+)
+
+// Tenant is the Data Transfer Object of the tenant resource.
+type Tenant struct {
+	// ID is the tenant unique identifier, made of lowercase letters, digits and hyphens.
+	ID string `json:"id"`
+	// Name is the display name of the tenant.
+	Name string `json:"name"`
+	// MailFrom is the sender address of the tenant emails, e.g. "Billing <billing@example.com>".
+	// Empty means the MAIL_FROM one.
+	MailFrom string `json:"mailFrom,omitempty"`
+	// Subjects are the email subjects of the tenant per notification type, e.g. {"news": "Billing news"}.
+	Subjects map[string]string `json:"subjects,omitempty"`
+	// Quota is the rate limit of the notifications of the whole tenant, on top of the per user
+	// rate limit rules. It must be a limited rule, and null means no quota.
+	Quota *RateLimitRule `json:"quota,omitempty"`
+	// CreatedAt is when the tenant was provisioned. It's ignored in requests.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewTenant converts a domain.Tenant into its Data Transfer Object.
+func NewTenant(t domain.Tenant) Tenant {
+	tenant := Tenant{
+		ID:        t.ID,
+		Name:      t.Name,
+		MailFrom:  t.MailFrom,
+		CreatedAt: t.CreatedAt,
+	}
+	if len(t.Subjects) > 0 {
+		tenant.Subjects = make(map[string]string, len(t.Subjects))
+		for notificationType, subject := range t.Subjects {
+			tenant.Subjects[notificationType.String()] = subject
+		}
+	}
+	if t.Quota != nil {
+		tenant.Quota = &RateLimitRule{
+			MaxCount:   t.Quota.MaxCount,
+			Expiration: t.Quota.Expiration.String(),
+		}
+	}
+	return tenant
+}
+
+// Validate returns an error ErrFailedValidation if Tenant
+// doesn't pass schema validation.
+func (t Tenant) Validate() error {
+	var err error
+
+	if t.Name == "" {
+		err = errors.Join(ErrFailedValidation, errors.New("name is empty"))
+	}
+
+	for notificationType, subject := range t.Subjects {
+		if _, typeErr := domain.ToNotificationType(notificationType); typeErr != nil {
+			err = errors.Join(err, ErrFailedValidation, fmt.Errorf("subject of unknown notification type %q", notificationType))
+		}
+		if subject == "" {
+			err = errors.Join(err, ErrFailedValidation, fmt.Errorf("subject of %s is empty", notificationType))
+		}
+	}
+
+	if t.Quota != nil {
+		if quotaErr := t.Quota.Validate(); quotaErr != nil {
+			return errors.Join(err, fmt.Errorf("quota: %w", quotaErr))
+		}
+	}
+
+	if domainErr := t.ToDomain().Validate(); domainErr != nil {
+		err = errors.Join(err, ErrFailedValidation, domainErr)
+	}
+
+	return err
+}
+
+// ToDomain converts the Tenant into its domain model.
+// It must be called on validated tenants only.
+func (t Tenant) ToDomain() domain.Tenant {
+	tenant := domain.Tenant{
+		ID:       t.ID,
+		Name:     t.Name,
+		MailFrom: t.MailFrom,
+	}
+	if len(t.Subjects) > 0 {
+		tenant.Subjects = make(map[domain.NotificationType]string, len(t.Subjects))
+		for name, subject := range t.Subjects {
+			if notificationType, err := domain.ToNotificationType(name); err == nil {
+				tenant.Subjects[notificationType] = subject
+			}
+		}
+	}
+	if t.Quota != nil {
+		quota := t.Quota.ToDomain()
+		tenant.Quota = &quota
+	}
+	return tenant
+}
@@ -0,0 +1,86 @@
+package dto_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/controller/dto"
+	"notification/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestTenant_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenant  dto.Tenant
+		wantErr error
+	}{
+		{
+			name: "valid",
+			tenant: dto.Tenant{
+				ID:       "billing",
+				Name:     "Billing",
+				MailFrom: "Billing <billing@example.com>",
+				Subjects: map[string]string{"news": "Billing news"},
+				Quota:    &dto.RateLimitRule{MaxCount: 1000, Expiration: "24h"},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "invalid ID",
+			tenant:  dto.Tenant{ID: "Billing", Name: "Billing"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "missing name",
+			tenant:  dto.Tenant{ID: "billing"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "invalid mail from",
+			tenant:  dto.Tenant{ID: "billing", Name: "Billing", MailFrom: "billing"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "subject of unknown notification type",
+			tenant:  dto.Tenant{ID: "billing", Name: "Billing", Subjects: map[string]string{"invoice": "Invoice"}},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "empty subject",
+			tenant:  dto.Tenant{ID: "billing", Name: "Billing", Subjects: map[string]string{"news": ""}},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "invalid quota",
+			tenant:  dto.Tenant{ID: "billing", Name: "Billing", Quota: &dto.RateLimitRule{Expiration: "24h"}},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "unlimited quota",
+			tenant:  dto.Tenant{ID: "billing", Name: "Billing", Quota: &dto.RateLimitRule{Unlimited: true}},
+			wantErr: dto.ErrFailedValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tenant.Validate()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTenant_ToDomain(t *testing.T) {
+	tenant := domain.Tenant{
+		ID:       "billing",
+		Name:     "Billing",
+		MailFrom: "billing@example.com",
+		Subjects: map[domain.NotificationType]string{domain.News: "Billing news"},
+		Quota:    &domain.RateLimitRule{MaxCount: 1000, Expiration: 24 * time.Hour},
+	}
+
+	tenantDTO := dto.NewTenant(tenant)
+	assert.Equal(t, map[string]string{"news": "Billing news"}, tenantDTO.Subjects)
+	assert.Equal(t, &dto.RateLimitRule{MaxCount: 1000, Expiration: "24h0m0s"}, tenantDTO.Quota)
+	assert.Equal(t, tenant, tenantDTO.ToDomain())
+}
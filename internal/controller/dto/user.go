@@ -0,0 +1,151 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"notification/internal/domain"
+)
+
+// User is the Data Transfer Object of the user resource.
+type User struct {
+	// ID is the user unique identifier.
+	ID string `json:"id"`
+	// Name is the name of the user.
+	Name string `json:"name"`
+	// LastName is the last name of the user.
+	LastName string `json:"lastName"`
+	// Email is the email address the notifications are sent to.
+	Email string `json:"email"`
+	// Segments are the segments the user belongs to, e.g. ["vip"], whose rate limit overrides apply
+	// to the user in the order they're listed.
+	Segments []string `json:"segments,omitempty"`
+}
+
+// NewUser converts a domain.User into its Data Transfer Object.
+func NewUser(u domain.User) User {
+	return User{
+		ID:       u.ID,
+		Name:     u.Name,
+		LastName: u.LastName,
+		Email:    u.Email,
+		Segments: u.Segments,
+	}
+}
+
+// ToDomain converts the User into its domain model.
+func (u User) ToDomain() domain.User {
+	return domain.User{
+		ID:       u.ID,
+		Name:     u.Name,
+		LastName: u.LastName,
+		Email:    u.Email,
+		Segments: u.Segments,
+	}
+}
+
+// Validate returns an error ErrFailedValidation if User
+// doesn't pass schema validation.
+func (u User) Validate() error {
+	var err error
+
+	if u.ID == "" {
+		err = errors.Join(ErrFailedValidation, errors.New("id is empty"))
+	}
+
+	if u.Name == "" {
+		err = errors.Join(err, ErrFailedValidation, errors.New("name is empty"))
+	}
+
+	if !isEmailAddress(u.Email) {
+		err = errors.Join(err, ErrFailedValidation, errors.New("email is not a valid address"))
+	}
+
+	return errors.Join(err, validateSegments(u.Segments))
+}
+
+// UserPatch is the Data Transfer Object to partially update a user.
+// Only the fields present in the request are updated.
+type UserPatch struct {
+	// Name is the new name of the user.
+	Name *string `json:"name"`
+	// LastName is the new last name of the user.
+	LastName *string `json:"lastName"`
+	// Email is the new email address of the user.
+	Email *string `json:"email"`
+	// Segments are the new segments of the user, replacing the previous ones.
+	Segments *[]string `json:"segments"`
+}
+
+// Validate returns an error ErrFailedValidation if UserPatch
+// doesn't pass schema validation.
+func (p UserPatch) Validate() error {
+	var err error
+
+	if p.Name != nil && *p.Name == "" {
+		err = errors.Join(ErrFailedValidation, errors.New("name is empty"))
+	}
+
+	if p.Email != nil && !isEmailAddress(*p.Email) {
+		err = errors.Join(err, ErrFailedValidation, errors.New("email is not a valid address"))
+	}
+
+	if p.Segments != nil {
+		err = errors.Join(err, validateSegments(*p.Segments))
+	}
+
+	return err
+}
+
+// Apply returns the user with the patched fields.
+func (p UserPatch) Apply(u domain.User) domain.User {
+	if p.Name != nil {
+		u.Name = *p.Name
+	}
+	if p.LastName != nil {
+		u.LastName = *p.LastName
+	}
+	if p.Email != nil {
+		u.Email = *p.Email
+	}
+	if p.Segments != nil {
+		u.Segments = *p.Segments
+	}
+	return u
+}
+
+// UserList is the Data Transfer Object of a page of users.
+type UserList struct {
+	// Users is the page of users, sorted by ID.
+	Users []User `json:"users"`
+	// Total is the number of users matching the search.
+	Total int `json:"total"`
+	// Offset is the number of matching users skipped.
+	Offset int `json:"offset"`
+	// Limit is the maximum number of users in the page.
+	Limit int `json:"limit"`
+}
+
+// validateSegments returns an error ErrFailedValidation if any of the segment names is invalid or repeated.
+func validateSegments(segments []string) error {
+	var err error
+
+	seen := make(map[string]bool, len(segments))
+	for _, segment := range segments {
+		if !domain.ValidSegment(segment) {
+			err = errors.Join(err, ErrFailedValidation, fmt.Errorf("segment %q must be up to 63 lowercase letters, "+
+				"digits or hyphens, not starting with a hyphen", segment))
+		} else if seen[segment] {
+			err = errors.Join(err, ErrFailedValidation, fmt.Errorf("segment %q is repeated", segment))
+		}
+		seen[segment] = true
+	}
+
+	return err
+}
+
+// isEmailAddress reports whether email is a bare email address, without display name.
+func isEmailAddress(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
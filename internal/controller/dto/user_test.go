@@ -0,0 +1,105 @@
+package dto_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/controller/dto"
+	"notification/internal/domain"
+	"testing"
+)
+
+func TestUser_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    dto.User
+		wantErr error
+	}{
+		{
+			name:    "valid",
+			user:    dto.User{ID: "123-abc", Name: "John", Email: "john@example.com"},
+			wantErr: nil,
+		},
+		{
+			name:    "missing ID",
+			user:    dto.User{Name: "John", Email: "john@example.com"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "missing name",
+			user:    dto.User{ID: "123-abc", Email: "john@example.com"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "missing email",
+			user:    dto.User{ID: "123-abc", Name: "John"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "invalid email",
+			user:    dto.User{ID: "123-abc", Name: "John", Email: "john.example.com"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "email with display name",
+			user:    dto.User{ID: "123-abc", Name: "John", Email: "John <john@example.com>"},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "with segments",
+			user:    dto.User{ID: "123-abc", Name: "John", Email: "john@example.com", Segments: []string{"vip", "beta-2"}},
+			wantErr: nil,
+		},
+		{
+			name:    "invalid segment",
+			user:    dto.User{ID: "123-abc", Name: "John", Email: "john@example.com", Segments: []string{"VIP"}},
+			wantErr: dto.ErrFailedValidation,
+		},
+		{
+			name:    "repeated segment",
+			user:    dto.User{ID: "123-abc", Name: "John", Email: "john@example.com", Segments: []string{"vip", "vip"}},
+			wantErr: dto.ErrFailedValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.user.Validate()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUserPatch(t *testing.T) {
+	name := "Johnny"
+	empty := ""
+	invalidEmail := "johnny"
+
+	t.Run("valid patch is applied", func(t *testing.T) {
+		patch := dto.UserPatch{Name: &name}
+		assert.NoError(t, patch.Validate())
+
+		got := patch.Apply(domain.User{ID: "123-abc", Name: "John", LastName: "Doe", Email: "john@example.com"})
+		assert.Equal(t, domain.User{ID: "123-abc", Name: "Johnny", LastName: "Doe", Email: "john@example.com"}, got)
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		assert.ErrorIs(t, dto.UserPatch{Name: &empty}.Validate(), dto.ErrFailedValidation)
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		assert.ErrorIs(t, dto.UserPatch{Email: &invalidEmail}.Validate(), dto.ErrFailedValidation)
+	})
+
+	t.Run("segments are replaced", func(t *testing.T) {
+		segments := []string{"internal"}
+		patch := dto.UserPatch{Segments: &segments}
+		assert.NoError(t, patch.Validate())
+
+		got := patch.Apply(domain.User{ID: "123-abc", Segments: []string{"vip"}})
+		assert.Equal(t, domain.User{ID: "123-abc", Segments: []string{"internal"}}, got)
+	})
+
+	t.Run("invalid segment", func(t *testing.T) {
+		segments := []string{"internal users"}
+		assert.ErrorIs(t, dto.UserPatch{Segments: &segments}.Validate(), dto.ErrFailedValidation)
+	})
+}
@@ -1,33 +1,107 @@
 package controller
 
 import (
+	"encoding/json"
 	"github.com/gorilla/mux"
 	"net/http"
 	"notification/internal/controller/middleware"
+	"notification/internal/health"
 )
 
+// ReadinessReporter reports the readiness of a dependency of the application.
+type ReadinessReporter = health.Reporter
+
+// HealthCheckOption defines the optional params for the HealthCheck controller.
+type HealthCheckOption func(*HealthCheck)
+
+// WithReadinessReporter adds a dependency to the readiness probe under the given name.
+func WithReadinessReporter(name string, reporter ReadinessReporter) HealthCheckOption {
+	return func(h *HealthCheck) {
+		h.reporters = append(h.reporters, namedReporter{name: name, reporter: reporter})
+	}
+}
+
+// WithReadinessChecks makes the readiness probe run the checks of the registry,
+// where the dependencies register themselves.
+//
+// Defaults to an empty registry.
+func WithReadinessChecks(registry *health.Registry) HealthCheckOption {
+	return func(h *HealthCheck) {
+		h.registry = registry
+	}
+}
+
+// WithStartup makes the startup probe, and the readiness probe, fail until the initial
+// load of the application is complete.
+func WithStartup(startup *health.Startup) HealthCheckOption {
+	return func(h *HealthCheck) {
+		h.startup = startup
+	}
+}
+
 // NewHealthCheck creates a new HealthCheck controller instance.
-func NewHealthCheck() *HealthCheck {
-	return &HealthCheck{}
+func NewHealthCheck(opts ...HealthCheckOption) *HealthCheck {
+	h := &HealthCheck{}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.registry == nil {
+		h.registry = health.NewRegistry()
+	}
+	for _, nr := range h.reporters {
+		h.registry.RegisterReporter(nr.name, nr.reporter)
+	}
+	if h.startup != nil {
+		h.registry.RegisterReporter("startup", h.startup)
+	}
+	return h
 }
 
 // HealthCheck is the health check controller.
-// It defines routes and handlers to serve Liveness and Readiness probes
+// It defines routes and handlers to serve Liveness, Readiness and Startup probes
 // using the "z" suffix convention: https://kubernetes.io/docs/reference/using-api/health-checks/
-type HealthCheck struct{}
+type HealthCheck struct {
+	reporters []namedReporter
+	registry  *health.Registry
+	startup   *health.Startup
+}
+
+type namedReporter struct {
+	name     string
+	reporter ReadinessReporter
+}
 
 // SetRouter returns the router r with all the necessary routes for the
 // HealthCheck controller setup.
 func (h HealthCheck) SetRouter(r *mux.Router) {
 	r.HandleFunc("/healthz", middleware.Logger(h.checkHealth)).Methods(http.MethodGet)
-	r.HandleFunc("/readyz", middleware.Logger(h.checkReady)).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", middleware.Logger(middleware.SetJSONContent(h.checkReady))).Methods(http.MethodGet)
+	r.HandleFunc("/startupz", middleware.Logger(h.checkStartup)).Methods(http.MethodGet)
 }
 
+// checkHealth answers the liveness probe. It doesn't check the dependencies, which would
+// make their outages restart the application.
 func (h HealthCheck) checkHealth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// checkReady answers the readiness probe with the readiness report of every dependency.
 func (h HealthCheck) checkReady(w http.ResponseWriter, r *http.Request) {
-	// TODO: check if dependency servers are ready, such as DB servers, Messaging brokers, ...
+	report := h.registry.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// checkStartup answers the startup probe, which succeeds once the initial load is complete.
+func (h HealthCheck) checkStartup(w http.ResponseWriter, r *http.Request) {
+	if h.startup != nil && !h.startup.Completed() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
@@ -1,11 +1,14 @@
 package controller_test
 
 import (
+	"context"
+	"errors"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
 	"notification/internal/controller"
+	"notification/internal/health"
 	"testing"
 )
 
@@ -30,6 +33,11 @@ func TestHealthCheck(t *testing.T) {
 			path:       "/readyz",
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:       "startupz endpoint returns OK",
+			path:       "/startupz",
+			wantStatus: http.StatusOK,
+		},
 	}
 
 	for _, tt := range tests {
@@ -42,3 +50,99 @@ func TestHealthCheck(t *testing.T) {
 		})
 	}
 }
+
+type fakeReporter struct {
+	ready bool
+}
+
+func (f fakeReporter) Readiness() (bool, any) {
+	return f.ready, map[string]string{"state": "whatever"}
+}
+
+func TestHealthCheck_Readiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		reporters  []controller.HealthCheckOption
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "every dependency is ready",
+			reporters: []controller.HealthCheckOption{
+				controller.WithReadinessReporter("mail", fakeReporter{ready: true}),
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"mail":{"ready":true,"details":{"state":"whatever"}}}`,
+		},
+		{
+			name: "a check fails",
+			reporters: []controller.HealthCheckOption{
+				controller.WithReadinessChecks(registry(map[string]error{
+					"redis": nil,
+					"smtp":  errors.New("connection refused"),
+				})),
+				controller.WithReadinessReporter("mail", fakeReporter{ready: true}),
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody: `{"mail":{"ready":true,"details":{"state":"whatever"}},` +
+				`"redis":{"ready":true},"smtp":{"ready":false,"error":"connection refused"}}`,
+		},
+		{
+			name: "initial load isn't complete",
+			reporters: []controller.HealthCheckOption{
+				controller.WithStartup(&health.Startup{}),
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"startup":{"ready":false}}`,
+		},
+		{
+			name: "a dependency isn't ready",
+			reporters: []controller.HealthCheckOption{
+				controller.WithReadinessReporter("mail", fakeReporter{ready: false}),
+				controller.WithReadinessReporter("other", fakeReporter{ready: true}),
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody: `{"mail":{"ready":false,"details":{"state":"whatever"}},` +
+				`"other":{"ready":true,"details":{"state":"whatever"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mux.NewRouter()
+			controller.NewHealthCheck(tt.reporters...).SetRouter(r)
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.JSONEq(t, tt.wantBody, rr.Body.String())
+		})
+	}
+}
+
+// registry returns a registry whose checks fail with the given errors, by name.
+func registry(errs map[string]error) *health.Registry {
+	r := health.NewRegistry()
+	for name, err := range errs {
+		r.Register(name, health.CheckerFunc(func(ctx context.Context) error { return err }))
+	}
+	return r
+}
+
+func TestHealthCheck_Startup(t *testing.T) {
+	startup := &health.Startup{}
+	r := mux.NewRouter()
+	controller.NewHealthCheck(controller.WithStartup(startup)).SetRouter(r)
+
+	probe := func() int {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/startupz", nil))
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, probe())
+	startup.Complete()
+	assert.Equal(t, http.StatusOK, probe())
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"notification/internal/auth"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"strings"
+)
+
+const (
+	// APIKeyHeader is the header carrying the API key, as an alternative to the "Authorization: Bearer" header.
+	APIKeyHeader = "X-API-Key"
+	// TenantHeader is the header carrying the ID of the tenant the request acts on behalf of,
+	// which only the principals granted domain.ScopeAdminTenants may send.
+	TenantHeader = "X-Tenant-ID"
+)
+
+var (
+	// errMissingCredentials is the authentication error of the requests without credentials.
+	errMissingCredentials = errors.New("missing credentials")
+	// errTenantForbidden is the authentication error of the requests acting on behalf of
+	// a tenant which isn't the one of their principal, without being allowed to.
+	errTenantForbidden = errors.New("not allowed to act on behalf of another tenant")
+)
+
+// authentication is the outcome of the authentication of a request.
+type authentication struct {
+	err error
+}
+
+type authenticationKey struct{}
+
+// Authenticate authenticates the requests carrying credentials, either in the "Authorization: Bearer"
+// header or in APIKeyHeader. The authenticated principal is carried by the request context (see
+// auth.FromContext), recorded as the actor of the changes (see repository.WithActor) and logged
+// with every request, which keeps track of which client sent each notification.
+//
+// The request acts on behalf of the tenant of the principal (see repository.WithTenant), or of
+// the one of TenantHeader if the principal is granted domain.ScopeAdminTenants.
+//
+// It doesn't reject any request by itself: the routes are protected by wrapping their handlers
+// with RequireScope or Authenticated, so that the routes which aren't, such as the health probes,
+// stay public. When Authenticate isn't used, authentication is disabled and every route is public.
+func Authenticate(authenticator auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			credential := credentialFromRequest(r)
+			if credential == "" {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, authenticationKey{},
+					authentication{err: errMissingCredentials})))
+				return
+			}
+
+			principal, err := authenticator.Authenticate(ctx, credential)
+			var tenantID string
+			if err == nil {
+				tenantID, err = tenantFromRequest(r, principal)
+			}
+			ctx = context.WithValue(ctx, authenticationKey{}, authentication{err: err})
+			if err == nil {
+				ctx = auth.NewContext(ctx, principal)
+				ctx = repository.WithActor(ctx, principal.Name)
+				ctx = repository.WithTenant(ctx, tenantID)
+				ctx = logging.NewContext(ctx, logging.FromContext(ctx).With().
+					Str(logging.ClientIDKey, principal.ID).
+					Str(logging.ClientNameKey, principal.Name).
+					Str(logging.TenantIDKey, repository.TenantFromContext(ctx)).
+					Logger())
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// credentialFromRequest returns the bearer token of the Authorization header, or else the APIKeyHeader value.
+func credentialFromRequest(r *http.Request) string {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(r.Header.Get(APIKeyHeader))
+}
+
+// tenantFromRequest returns the tenant the request acts on behalf of: the one of TenantHeader, if the
+// principal is allowed to act on behalf of any tenant, or else the one of the principal.
+func tenantFromRequest(r *http.Request, principal auth.Principal) (string, error) {
+	tenantID := strings.TrimSpace(r.Header.Get(TenantHeader))
+	if tenantID == "" || tenantID == principal.TenantID {
+		return principal.TenantID, nil
+	}
+	if !principal.HasScope(domain.ScopeAdminTenants) || !domain.ValidTenantID(tenantID) {
+		return "", errTenantForbidden
+	}
+	return tenantID, nil
+}
+
+// Authenticated rejects the requests without valid credentials with 401 Unauthorized,
+// and the ones acting on behalf of a tenant they aren't allowed to with 403 Forbidden,
+// unless authentication is disabled, see Authenticate.
+func Authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, enabled := r.Context().Value(authenticationKey{}).(authentication)
+		if !enabled || a.err == nil {
+			next(w, r)
+			return
+		}
+		if errors.Is(a.err, errMissingCredentials) || errors.Is(a.err, auth.ErrInvalidCredentials) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="notification"`)
+			http.Error(w, a.err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(a.err, errTenantForbidden) {
+			http.Error(w, a.err.Error(), http.StatusForbidden)
+			return
+		}
+		logging.FromContext(r.Context()).Error().Err(a.err).Msg("Failed to authenticate the request")
+		http.Error(w, "failed to authenticate the request", http.StatusInternalServerError)
+	}
+}
+
+// RequireScope rejects the requests without valid credentials with 401 Unauthorized, and the ones
+// whose principal isn't granted the scope with 403 Forbidden, unless authentication is disabled.
+func RequireScope(scope domain.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return Authenticated(func(w http.ResponseWriter, r *http.Request) {
+		if !Authorized(r, scope) {
+			http.Error(w, "missing scope "+string(scope), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// Authorized reports whether the authenticated principal of the request is granted the scope.
+// It's always true when authentication is disabled. It's meant for the handlers whose scope
+// depends on the request content, and which are wrapped with Authenticated.
+func Authorized(r *http.Request, scope domain.Scope) bool {
+	if _, enabled := r.Context().Value(authenticationKey{}).(authentication); !enabled {
+		return true
+	}
+	principal, ok := auth.FromContext(r.Context())
+	return ok && principal.HasScope(scope)
+}
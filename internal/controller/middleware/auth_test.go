@@ -0,0 +1,179 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/auth"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"testing"
+)
+
+// stubAuthenticator authenticates the credentials of its principals.
+type stubAuthenticator map[string]auth.Principal
+
+func (a stubAuthenticator) Authenticate(_ context.Context, credential string) (auth.Principal, error) {
+	if credential == "broken" {
+		return auth.Principal{}, errors.New("store unavailable")
+	}
+	principal, ok := a[credential]
+	if !ok {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	return principal, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	authenticator := stubAuthenticator{
+		"rules-key": {ID: "k1", Name: "rules-admin", TenantID: "billing", Scopes: []domain.Scope{domain.ScopeAdminRules}},
+		"send-key":  {ID: "k2", Name: "billing", Scopes: []domain.Scope{"send:*"}},
+	}
+
+	var actor string
+	r := mux.NewRouter()
+	r.Use(middleware.Authenticate(authenticator))
+	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	r.HandleFunc("/rate-limit-rules", middleware.RequireScope(domain.ScopeAdminRules,
+		func(w http.ResponseWriter, r *http.Request) {
+			actor = repository.ActorFromContext(r.Context())
+		}))
+	r.HandleFunc("/send/{type}", middleware.Authenticated(func(w http.ResponseWriter, r *http.Request) {
+		notificationType, _ := domain.ToNotificationType(mux.Vars(r)["type"])
+		if !middleware.Authorized(r, domain.SendScope(notificationType)) {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+
+	tests := []struct {
+		name       string
+		target     string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"public route without credentials", "/healthz", "", "", http.StatusOK},
+		{"public route with invalid credentials", "/healthz", middleware.APIKeyHeader, "unknown", http.StatusOK},
+		{"missing credentials", "/rate-limit-rules", "", "", http.StatusUnauthorized},
+		{"invalid api key", "/rate-limit-rules", middleware.APIKeyHeader, "unknown", http.StatusUnauthorized},
+		{"invalid bearer token", "/rate-limit-rules", "Authorization", "Bearer unknown", http.StatusUnauthorized},
+		{"other authorization scheme", "/rate-limit-rules", "Authorization", "Basic cnVsZXMta2V5", http.StatusUnauthorized},
+		{"missing scope", "/rate-limit-rules", middleware.APIKeyHeader, "send-key", http.StatusForbidden},
+		{"scope granted through api key header", "/rate-limit-rules", middleware.APIKeyHeader, "rules-key", http.StatusOK},
+		{"scope granted through bearer token", "/rate-limit-rules", "Authorization", "Bearer rules-key", http.StatusOK},
+		{"authenticator errors out", "/rate-limit-rules", middleware.APIKeyHeader, "broken", http.StatusInternalServerError},
+		{"scope checked by the handler granted", "/send/status", middleware.APIKeyHeader, "send-key", http.StatusOK},
+		{"scope checked by the handler missing", "/send/status", middleware.APIKeyHeader, "rules-key", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	t.Run("principal is recorded as the actor", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/rate-limit-rules", nil)
+		req.Header.Set(middleware.APIKeyHeader, "rules-key")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, "rules-admin", actor)
+	})
+
+	t.Run("principal is logged", func(t *testing.T) {
+		var buf bytes.Buffer
+		logged := mux.NewRouter()
+		logged.Use(middleware.Authenticate(authenticator))
+		logged.HandleFunc("/rate-limit-rules", middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules,
+			func(w http.ResponseWriter, r *http.Request) {})))
+
+		req := httptest.NewRequest(http.MethodGet, "/rate-limit-rules", nil)
+		req = req.WithContext(logging.NewContext(req.Context(), zerolog.New(&buf)))
+		req.Header.Set(middleware.APIKeyHeader, "rules-key")
+		logged.ServeHTTP(httptest.NewRecorder(), req)
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "k1", entry[logging.ClientIDKey])
+		assert.Equal(t, "rules-admin", entry[logging.ClientNameKey])
+		assert.Equal(t, "billing", entry[logging.TenantIDKey])
+	})
+}
+
+func TestAuthenticate_Tenant(t *testing.T) {
+	authenticator := stubAuthenticator{
+		"billing-key": {ID: "k1", Name: "billing", TenantID: "billing", Scopes: []domain.Scope{domain.ScopeAll}},
+		"default-key": {ID: "k2", Name: "legacy", Scopes: []domain.Scope{"send:*"}},
+		"admin-key": {ID: "k3", Name: "platform", TenantID: domain.DefaultTenantID,
+			Scopes: []domain.Scope{domain.ScopeAdminTenants, domain.ScopeAdminUsers}},
+	}
+
+	var tenantID string
+	r := mux.NewRouter()
+	r.Use(middleware.Authenticate(authenticator))
+	r.HandleFunc("/users", middleware.Authenticated(func(w http.ResponseWriter, r *http.Request) {
+		tenantID = repository.TenantFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name       string
+		key        string
+		tenant     string
+		wantStatus int
+		wantTenant string
+	}{
+		{"tenant of the principal", "billing-key", "", http.StatusOK, "billing"},
+		{"principal without tenant", "default-key", "", http.StatusOK, domain.DefaultTenantID},
+		{"own tenant header", "billing-key", "billing", http.StatusOK, "billing"},
+		{"other tenant header", "billing-key", "shipping", http.StatusForbidden, ""},
+		{"other tenant header without scope", "default-key", "billing", http.StatusForbidden, ""},
+		{"tenant admin acting on behalf of a tenant", "admin-key", "shipping", http.StatusOK, "shipping"},
+		{"invalid tenant header", "admin-key", "Shipping:EU", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID = ""
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set(middleware.APIKeyHeader, tt.key)
+			if tt.tenant != "" {
+				req.Header.Set(middleware.TenantHeader, tt.tenant)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.Equal(t, tt.wantTenant, tenantID)
+		})
+	}
+}
+
+func TestRequireScope_AuthenticationDisabled(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/rate-limit-rules", middleware.RequireScope(domain.ScopeAdminRules,
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.True(t, middleware.Authorized(r, domain.ScopeAdminKeys))
+		}))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/rate-limit-rules", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
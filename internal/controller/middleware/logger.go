@@ -1,20 +1,122 @@
 package middleware
 
 import (
-	"log"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"notification/internal/logging"
+	"regexp"
 	"time"
 )
 
-// Logger decorates HTTP requests with logs.
+// RequestIDHeader is the header carrying the request ID. It's taken from the request
+// when it's set and valid, generated otherwise, and echoed in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern restricts the request IDs taken from the requests, so that they're safe to log.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Logger decorates HTTP requests with a request-scoped logger carrying the request ID, and the
+// trace ID when the request is traced (see Tracing), see logging.FromContext. It logs the method,
+// route, status, response size and duration of each request once it ends, along with the fields
+// the handler adds through logging.AddFields.
+//
+// The route template is logged instead of the path, which may hold personal data such as
+// email addresses.
 func Logger(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Print("new HTTP request started")
 		start := time.Now()
-		defer func() {
-			log.Printf("HTTP request ended after %s", time.Since(start))
-		}()
 
-		next.ServeHTTP(w, r)
+		requestID := r.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		loggerContext := logging.FromContext(r.Context()).With().Str(logging.RequestIDKey, requestID)
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+			loggerContext = loggerContext.Str(logging.TraceIDKey, spanContext.TraceID().String())
+		}
+		logger := loggerContext.Logger()
+		ctx := logging.NewContext(r.Context(), logger)
+		rw := &responseWriter{ResponseWriter: w}
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		status := rw.statusCode()
+		var event *zerolog.Event
+		switch logger := logging.FromContext(ctx); {
+		case status >= http.StatusInternalServerError:
+			event = logger.Error()
+		case status >= http.StatusBadRequest:
+			event = logger.Warn()
+		default:
+			event = logger.Info()
+		}
+		event.
+			Str("method", r.Method).
+			Str("route", routeTemplate(r)).
+			Int("status", status).
+			Int("bytes", rw.bytes).
+			Dur("duration", time.Since(start)).
+			Msg("HTTP request handled")
+	}
+}
+
+// responseWriter captures the status code and the size of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader captures the status code and sends it.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
 	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write counts the bytes written to the response body.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// statusCode returns the status code sent, which is 200 OK if the handler didn't send any.
+func (w *responseWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// routeTemplate returns the path template of the matched route, or the path if there's none.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// newRequestID generates a random request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
 }
@@ -0,0 +1,80 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/controller/middleware"
+	"notification/internal/logging"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	var handlerRequestID string
+	r := mux.NewRouter()
+	r.HandleFunc("/suppressions/{email}", middleware.Logger(func(w http.ResponseWriter, r *http.Request) {
+		logging.AddFields(r.Context(), map[string]any{logging.CorrelationIDKey: "abc"})
+		logging.FromContext(r.Context()).Info().Msg("handling")
+		handlerRequestID = w.Header().Get(middleware.RequestIDHeader)
+
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found")) //nolint:errcheck
+	}))
+
+	serve := func(t *testing.T, requestID string) []map[string]any {
+		t.Helper()
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/suppressions/john@example.com", nil)
+		req = req.WithContext(logging.NewContext(req.Context(), logger))
+		if requestID != "" {
+			req.Header.Set(middleware.RequestIDHeader, requestID)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		var entries []map[string]any
+		decoder := json.NewDecoder(&buf)
+		for decoder.More() {
+			var entry map[string]any
+			require.NoError(t, decoder.Decode(&entry))
+			entries = append(entries, entry)
+		}
+		require.Len(t, entries, 2)
+		return entries
+	}
+
+	t.Run("request is logged", func(t *testing.T) {
+		entries := serve(t, "req-1")
+
+		assert.Equal(t, "req-1", entries[0][logging.RequestIDKey])
+		assert.Equal(t, "handling", entries[0]["message"])
+
+		request := entries[1]
+		assert.Equal(t, "warn", request["level"])
+		assert.Equal(t, "req-1", request[logging.RequestIDKey])
+		assert.Equal(t, "abc", request[logging.CorrelationIDKey], "the fields added by the handler are logged")
+		assert.Equal(t, http.MethodGet, request["method"])
+		assert.Equal(t, "/suppressions/{email}", request["route"])
+		assert.EqualValues(t, http.StatusNotFound, request["status"])
+		assert.EqualValues(t, len("not found"), request["bytes"])
+		assert.Contains(t, request, "duration")
+		assert.NotContains(t, buf.String(), "john@example.com")
+	})
+
+	t.Run("request ID is generated", func(t *testing.T) {
+		entries := serve(t, "invalid request id")
+
+		requestID := entries[1][logging.RequestIDKey]
+		assert.Len(t, requestID, 32)
+		assert.Equal(t, requestID, handlerRequestID, "the request ID is echoed")
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestObserver records the HTTP requests handled.
+type RequestObserver interface {
+	// ObserveHTTPRequest records a request of the given method and route template,
+	// answered with status after duration.
+	ObserveHTTPRequest(method, route string, status int, duration time.Duration)
+}
+
+// Metrics returns the router middleware recording every request handled in observer.
+// Requests are recorded by route template, as in Logger, to bound the number of series.
+func Metrics(observer RequestObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w}
+
+			next.ServeHTTP(rw, r)
+
+			observer.ObserveHTTPRequest(r.Method, routeTemplate(r), rw.statusCode(), time.Since(start))
+		})
+	}
+}
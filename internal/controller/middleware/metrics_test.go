@@ -0,0 +1,46 @@
+package middleware_test
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/controller/middleware"
+	"testing"
+	"time"
+)
+
+type observedRequest struct {
+	method string
+	route  string
+	status int
+}
+
+type requestObserver struct {
+	requests []observedRequest
+}
+
+func (o *requestObserver) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	o.requests = append(o.requests, observedRequest{method: method, route: route, status: status})
+}
+
+func TestMetrics(t *testing.T) {
+	observer := &requestObserver{}
+	r := mux.NewRouter()
+	r.Use(middleware.Metrics(observer))
+	r.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods(http.MethodGet)
+	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok")) //nolint:errcheck
+	}).Methods(http.MethodGet)
+
+	for _, path := range []string{"/users/john@example.com", "/healthz"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, []observedRequest{
+		{method: http.MethodGet, route: "/users/{id}", status: http.StatusNotFound},
+		{method: http.MethodGet, route: "/healthz", status: http.StatusOK},
+	}, observer.requests)
+}
@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/controller/middleware"
+	"notification/internal/logging"
+	"notification/internal/tracing/tracingtest"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracingtest.Setup(t)
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	var handlerSpan trace.SpanContext
+	r := mux.NewRouter()
+	r.Use(middleware.Tracing)
+	r.HandleFunc("/users/{id}", middleware.Logger(func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+	})).Methods(http.MethodGet)
+	r.HandleFunc("/send", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods(http.MethodPost)
+
+	t.Run("trace of the request is continued", func(t *testing.T) {
+		exporter.Reset()
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/users/john@example.com", nil)
+		req = req.WithContext(logging.NewContext(req.Context(), logger))
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		span := spans[0]
+		assert.Equal(t, "GET /users/{id}", span.Name)
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+		assert.Equal(t, span.SpanContext, handlerSpan)
+		assert.Contains(t, span.Attributes, attribute.Int("http.response.status_code", http.StatusNotFound))
+		assert.Contains(t, span.Attributes, attribute.String("http.route", "/users/{id}"))
+		// client errors aren't server errors.
+		assert.Equal(t, codes.Unset, span.Status.Code)
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry[logging.TraceIDKey])
+	})
+
+	t.Run("new trace is started", func(t *testing.T) {
+		exporter.Reset()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/send", nil))
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "POST /send", spans[0].Name)
+		assert.False(t, spans[0].Parent.IsValid())
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+}
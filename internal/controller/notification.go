@@ -5,12 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+	"math"
 	"net/http"
+	"notification/internal/auth"
 	"notification/internal/controller/dto"
 	"notification/internal/controller/middleware"
 	"notification/internal/domain"
+	"notification/internal/logging"
 	"notification/internal/repository"
 	"notification/internal/service"
+	"notification/internal/tracing"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // NewNotification creates a new Notification controller instance.
@@ -27,7 +35,8 @@ type Notification struct {
 // SetRouter returns the router r with all the necessary routes for the
 // Notification controller setup.
 func (n Notification) SetRouter(r *mux.Router) {
-	r.HandleFunc("/send", middleware.Logger(middleware.SetJSONContent(n.send))).
+	// the scope depends on the notification type, so it's checked by the handler.
+	r.HandleFunc("/send", middleware.Logger(middleware.Authenticated(middleware.SetJSONContent(n.send)))).
 		Methods(http.MethodPost)
 }
 
@@ -39,10 +48,18 @@ func (n Notification) SetRouter(r *mux.Router) {
 // @Param notification body dto.Notification true "Notification object to be sent"
 // @Success 200
 // @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
 // @Failure 409 {object} string "Conflict"
+// @Failure 422 {object} string "Unprocessable Entity"
 // @Failure 429 {object} string "Too Many Requests"
 // @Failure 500 {object} string "Internal Server Error"
+// @Failure 503 {object} string "Service Unavailable"
 // @Header 429 {string} Retry-After "3600"
+// @Header all {string} RateLimit-Limit "2"
+// @Header all {string} RateLimit-Remaining "1"
+// @Header all {string} RateLimit-Reset "60"
+// @Header all {string} RateLimit-Policy "2;w=60, 10000;w=86400"
 // @Router /send [post]
 func (n Notification) send(w http.ResponseWriter, r *http.Request) {
 	var notificationDTO dto.Notification
@@ -62,26 +79,58 @@ func (n Notification) send(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if scope := domain.SendScope(notificationType); !middleware.Authorized(r, scope) {
+		http.Error(w, "missing scope "+string(scope), http.StatusForbidden)
+		return
+	}
+
+	// the email address isn't logged, only the user ID.
+	logging.AddFields(r.Context(), map[string]any{
+		logging.CorrelationIDKey:    notificationDTO.CorrelationID,
+		logging.UserIDKey:           notificationDTO.UserID,
+		logging.NotificationTypeKey: notificationType.String(),
+	})
+	trace.SpanFromContext(r.Context()).SetAttributes(
+		tracing.CorrelationIDKey.String(notificationDTO.CorrelationID),
+		tracing.NotificationTypeKey.String(notificationType.String()),
+	)
 
 	notification := domain.Notification{
 		CorrelationID: notificationDTO.CorrelationID,
 		Type:          notificationType,
 		Message:       notificationDTO.Message,
+		CallbackURL:   notificationDTO.CallbackURL,
+	}
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		notification.Caller = principal.ID
 	}
 
-	retryAfter, err := n.svc.Send(r.Context(), notificationDTO.UserID, notification)
+	result, err := n.svc.Send(r.Context(), notificationDTO.UserID, notification)
+	setRateLimitHeaders(w, result.Limits)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrInvalidUserID):
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
+		case errors.Is(err, service.ErrUnknownTenant):
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
 		case errors.Is(err, service.ErrRateLimitExceeded):
-			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(retryAfter.Seconds())))
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfterSeconds(result.RetryAfter)))
 			http.Error(w, err.Error(), http.StatusTooManyRequests)
 			return
+		case errors.Is(err, service.ErrNoRateLimitRule):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
 		case errors.Is(err, service.ErrIdempotencyViolation):
 			http.Error(w, err.Error(), http.StatusConflict)
 			return
+		case errors.Is(err, service.ErrRecipientSuppressed):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, service.ErrMailThrottled):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
 		default:
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -90,3 +139,34 @@ func (n Notification) send(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// setRateLimitHeaders informs the client of the rate limits the notification is subject to, with the
+// RateLimit headers of the IETF draft "RateLimit header fields for HTTP": RateLimit-Limit, RateLimit-Remaining
+// and RateLimit-Reset describe the limit closest to exhaustion, while RateLimit-Policy lists every limit.
+// Nothing is set for the unlimited notifications, nor those rejected before the rate limits are checked.
+func setRateLimitHeaders(w http.ResponseWriter, limits service.RateLimitLimits) {
+	closest, ok := limits.Closest()
+	if !ok {
+		return
+	}
+
+	policies := make([]string, 0, len(limits))
+	for _, limit := range limits {
+		policies = append(policies, fmt.Sprintf("%d;w=%d", limit.Limit, durationSeconds(limit.Window)))
+	}
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(closest.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(closest.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(durationSeconds(closest.Reset)))
+	w.Header().Set("RateLimit-Policy", strings.Join(policies, ", "))
+}
+
+// durationSeconds rounds d up to whole seconds, the granularity of the RateLimit headers.
+func durationSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// retryAfterSeconds rounds d up to whole seconds, the Retry-After header granularity.
+// It never returns less than a second, since zero would mean retrying straight away.
+func retryAfterSeconds(d time.Duration) int {
+	return max(durationSeconds(d), 1)
+}
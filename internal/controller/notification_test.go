@@ -1,6 +1,7 @@
 package controller_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
@@ -9,7 +10,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"notification/internal/auth"
 	"notification/internal/controller"
+	"notification/internal/controller/middleware"
 	"notification/internal/domain"
 	"notification/internal/repository"
 	"notification/internal/service"
@@ -32,7 +35,7 @@ func TestNotification(t *testing.T) {
 			svc := mocks.NewNotificationSender(t)
 			svc.
 				On("Send", mock.Anything, "abc-123", notification).
-				Return(time.Duration(0), nil)
+				Return(service.SendResult{}, nil)
 
 			notificationController := controller.NewNotification(svc)
 
@@ -61,7 +64,7 @@ func TestNotification(t *testing.T) {
 			svc := mocks.NewNotificationSender(t)
 			svc.
 				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-				Return(time.Duration(0), errors.New("oops"))
+				Return(service.SendResult{}, errors.New("oops"))
 
 			notificationController := controller.NewNotification(svc)
 
@@ -90,7 +93,7 @@ func TestNotification(t *testing.T) {
 			svc := mocks.NewNotificationSender(t)
 			svc.
 				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-				Return(time.Duration(0), nil).
+				Return(service.SendResult{}, nil).
 				Maybe()
 
 			notificationController := controller.NewNotification(svc)
@@ -115,7 +118,7 @@ func TestNotification(t *testing.T) {
 			svc := mocks.NewNotificationSender(t)
 			svc.
 				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-				Return(time.Duration(0), nil).
+				Return(service.SendResult{}, nil).
 				Maybe()
 
 			notificationController := controller.NewNotification(svc)
@@ -149,7 +152,7 @@ func TestNotification(t *testing.T) {
 			svc := mocks.NewNotificationSender(t)
 			svc.
 				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-				Return(time.Duration(0), nil).
+				Return(service.SendResult{}, nil).
 				Maybe()
 
 			notificationController := controller.NewNotification(svc)
@@ -183,7 +186,7 @@ func TestNotification(t *testing.T) {
 			svc := mocks.NewNotificationSender(t)
 			svc.
 				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-				Return(time.Duration(0), fmt.Errorf("oops: %w", repository.ErrInvalidUserID))
+				Return(service.SendResult{}, fmt.Errorf("oops: %w", repository.ErrInvalidUserID))
 
 			notificationController := controller.NewNotification(svc)
 
@@ -214,7 +217,7 @@ func TestNotification(t *testing.T) {
 			svc := mocks.NewNotificationSender(t)
 			svc.
 				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-				Return(retryAfter, fmt.Errorf("oops: %w", service.ErrRateLimitExceeded))
+				Return(service.SendResult{RetryAfter: retryAfter}, fmt.Errorf("oops: %w", service.ErrRateLimitExceeded))
 
 			notificationController := controller.NewNotification(svc)
 
@@ -244,11 +247,71 @@ func TestNotification(t *testing.T) {
 			})
 		})
 
+		t.Run("rate limit exceeded without retry time", func(t *testing.T) {
+			svc := mocks.NewNotificationSender(t)
+			svc.
+				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(service.SendResult{RetryAfter: 100 * time.Millisecond}, fmt.Errorf("oops: %w", service.ErrRateLimitExceeded))
+
+			notificationController := controller.NewNotification(svc)
+
+			r := mux.NewRouter()
+			notificationController.SetRouter(r)
+
+			requestBody := `
+{
+	"correlationId": "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+	"userId": "abc-123",
+	"type": "status",
+	"message": "Hey there!"
+}
+`
+
+			req := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(requestBody))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			t.Run("Retry-After header is rounded up", func(t *testing.T) {
+				assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+			})
+		})
+
+		t.Run("notification type without rate limit rule", func(t *testing.T) {
+			svc := mocks.NewNotificationSender(t)
+			svc.
+				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(service.SendResult{}, fmt.Errorf("oops: %w", service.ErrNoRateLimitRule))
+
+			notificationController := controller.NewNotification(svc)
+
+			r := mux.NewRouter()
+			notificationController.SetRouter(r)
+
+			requestBody := `
+{
+	"correlationId": "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+	"userId": "abc-123",
+	"type": "status",
+	"message": "Hey there!"
+}
+`
+
+			req := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(requestBody))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			t.Run("HTTP status is Unprocessable Entity", func(t *testing.T) {
+				assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+				assert.Empty(t, rr.Header().Get("Retry-After"))
+				assert.Contains(t, rr.Body.String(), service.ErrNoRateLimitRule.Error())
+			})
+		})
+
 		t.Run("violates idempotency check", func(t *testing.T) {
 			svc := mocks.NewNotificationSender(t)
 			svc.
 				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-				Return(time.Duration(0), fmt.Errorf("oops: %w", service.ErrIdempotencyViolation))
+				Return(service.SendResult{}, fmt.Errorf("oops: %w", service.ErrIdempotencyViolation))
 
 			notificationController := controller.NewNotification(svc)
 
@@ -272,5 +335,203 @@ func TestNotification(t *testing.T) {
 				assert.Equal(t, http.StatusConflict, rr.Code)
 			})
 		})
+
+		t.Run("recipient is suppressed", func(t *testing.T) {
+			svc := mocks.NewNotificationSender(t)
+			svc.
+				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(service.SendResult{}, fmt.Errorf("%w: bounce", service.ErrRecipientSuppressed))
+
+			notificationController := controller.NewNotification(svc)
+
+			r := mux.NewRouter()
+			notificationController.SetRouter(r)
+
+			requestBody := `
+{
+	"correlationId": "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+	"userId": "abc-123",
+	"type": "status",
+	"message": "Hey there!"
+}
+`
+
+			req := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(requestBody))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			t.Run("HTTP status is Unprocessable Entity", func(t *testing.T) {
+				assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+			})
+		})
+
+		t.Run("tenant is unknown", func(t *testing.T) {
+			svc := mocks.NewNotificationSender(t)
+			svc.
+				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(service.SendResult{}, fmt.Errorf("%w: billing", service.ErrUnknownTenant))
+
+			notificationController := controller.NewNotification(svc)
+
+			r := mux.NewRouter()
+			notificationController.SetRouter(r)
+
+			requestBody := `
+{
+	"correlationId": "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+	"userId": "abc-123",
+	"type": "status",
+	"message": "Hey there!"
+}
+`
+
+			req := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(requestBody))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			t.Run("HTTP status is Forbidden", func(t *testing.T) {
+				assert.Equal(t, http.StatusForbidden, rr.Code)
+			})
+		})
+
+		t.Run("mail throughput limit is exceeded", func(t *testing.T) {
+			svc := mocks.NewNotificationSender(t)
+			svc.
+				On("Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(service.SendResult{}, errors.Join(service.ErrMailRetryable, service.ErrMailThrottled))
+
+			notificationController := controller.NewNotification(svc)
+
+			r := mux.NewRouter()
+			notificationController.SetRouter(r)
+
+			requestBody := `
+{
+	"correlationId": "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+	"userId": "abc-123",
+	"type": "marketing",
+	"message": "Hey there!"
+}
+`
+
+			req := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(requestBody))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			t.Run("HTTP status is Service Unavailable", func(t *testing.T) {
+				assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+			})
+		})
 	})
 }
+
+func TestNotification_Scopes(t *testing.T) {
+	ctx := context.Background()
+	keys := repository.NewInMemoryAPIKeyRepository()
+	require.NoError(t, keys.Save(ctx, domain.APIKey{
+		ID: "k1", Name: "status-sender", Hash: auth.HashAPIKey("nk_status"), Scopes: []domain.Scope{"send:status"},
+	}))
+
+	svc := mocks.NewNotificationSender(t)
+	svc.
+		On("Send", mock.Anything, "abc-123", mock.MatchedBy(func(n domain.Notification) bool {
+			return n.Type == domain.Status && n.Caller == "k1"
+		})).
+		Return(service.SendResult{}, nil)
+
+	r := mux.NewRouter()
+	r.Use(middleware.Authenticate(auth.NewAPIKeyAuthenticator(keys)))
+	controller.NewNotification(svc).SetRouter(r)
+
+	tests := []struct {
+		name             string
+		key              string
+		notificationType string
+		wantStatus       int
+	}{
+		{"scope is granted", "nk_status", "status", http.StatusOK},
+		{"scope of other type", "nk_status", "marketing", http.StatusForbidden},
+		{"missing credentials", "", "status", http.StatusUnauthorized},
+		{"invalid credentials", "nk_unknown", "status", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"correlationId":"0990cc56-f1b7-4f69-bc60-08fac22d41bd",`+
+				`"userId":"abc-123","type":%q,"message":"Hey there!"}`, tt.notificationType)
+			req := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(body))
+			if tt.key != "" {
+				req.Header.Set(middleware.APIKeyHeader, tt.key)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestNotification_RateLimitHeaders(t *testing.T) {
+	limits := service.RateLimitLimits{
+		{Limit: 2, Remaining: 1, Window: time.Minute, Reset: time.Minute},
+		{Limit: 10000, Remaining: 0, Window: 24 * time.Hour, Reset: 90 * time.Minute},
+	}
+	body := `{"correlationId":"0990cc56-f1b7-4f69-bc60-08fac22d41bd","userId":"abc-123","type":"status","message":"Hey there!"}`
+
+	tests := []struct {
+		name       string
+		result     service.SendResult
+		err        error
+		wantStatus int
+		wantHeader http.Header
+	}{
+		{
+			name:       "notification is sent",
+			result:     service.SendResult{Limits: limits[:1]},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{
+				"Ratelimit-Limit":     {"2"},
+				"Ratelimit-Remaining": {"1"},
+				"Ratelimit-Reset":     {"60"},
+				"Ratelimit-Policy":    {"2;w=60"},
+			},
+		},
+		{
+			name:       "closest limit is informed",
+			result:     service.SendResult{RetryAfter: 24 * time.Hour, Limits: limits},
+			err:        errors.Join(service.ErrRateLimitExceeded, service.ErrTenantQuotaExceeded),
+			wantStatus: http.StatusTooManyRequests,
+			wantHeader: http.Header{
+				"Ratelimit-Limit":     {"10000"},
+				"Ratelimit-Remaining": {"0"},
+				"Ratelimit-Reset":     {"5400"},
+				"Ratelimit-Policy":    {"2;w=60, 10000;w=86400"},
+			},
+		},
+		{
+			name:       "unlimited notification",
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewNotificationSender(t)
+			svc.
+				On("Send", mock.Anything, "abc-123", mock.Anything).
+				Return(tt.result, tt.err)
+
+			r := mux.NewRouter()
+			controller.NewNotification(svc).SetRouter(r)
+
+			req := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+			for _, name := range []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"} {
+				assert.Equal(t, tt.wantHeader.Get(name), rr.Header().Get(name), name)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"time"
+)
+
+// overrideScopes maps the scope path segments of the override routes to the override scopes.
+var overrideScopes = map[string]domain.OverrideScope{
+	"users":    domain.OverrideUser,
+	"segments": domain.OverrideSegment,
+}
+
+// NewRateLimitOverride creates a new RateLimitOverride controller instance.
+func NewRateLimitOverride(overrides repository.RateLimitOverrideRepository,
+	exemptions repository.RateLimitExemptionRepository) *RateLimitOverride {
+	return &RateLimitOverride{overrides: overrides, exemptions: exemptions}
+}
+
+// RateLimitOverride is the rate limit override controller.
+// It defines routes and handlers to manage the rate limit overrides of the users and segments,
+// and the rate limit exemptions of the notification types.
+type RateLimitOverride struct {
+	overrides  repository.RateLimitOverrideRepository
+	exemptions repository.RateLimitExemptionRepository
+}
+
+// SetRouter returns the router r with all the necessary routes for the
+// RateLimitOverride controller setup.
+func (c RateLimitOverride) SetRouter(r *mux.Router) {
+	r.HandleFunc("/rate-limit-overrides", middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules,
+		middleware.SetJSONContent(c.list)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/rate-limit-overrides/{scope:users|segments}/{subject}",
+		middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules, middleware.SetJSONContent(c.get)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/rate-limit-overrides/{scope:users|segments}/{subject}",
+		middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules, middleware.SetJSONContent(c.put)))).
+		Methods(http.MethodPut)
+	r.HandleFunc("/rate-limit-overrides/{scope:users|segments}/{subject}",
+		middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules, c.delete))).
+		Methods(http.MethodDelete)
+	r.HandleFunc("/rate-limit-exemptions", middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules,
+		middleware.SetJSONContent(c.listExemptions)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/rate-limit-exemptions/{type}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules,
+		middleware.SetJSONContent(c.putExemption)))).
+		Methods(http.MethodPut)
+	r.HandleFunc("/rate-limit-exemptions/{type}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules,
+		c.deleteExemption))).
+		Methods(http.MethodDelete)
+}
+
+// @Summary List rate limit overrides
+// @Description Lists the rate limit overrides, the user ones first, then the segment ones, each sorted by subject
+// @Tags rate-limit-override
+// @Produce json
+// @Success 200 {array} dto.RateLimitOverride
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-overrides [get]
+func (c RateLimitOverride) list(w http.ResponseWriter, r *http.Request) {
+	overrides, err := c.overrides.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.RateLimitOverride, 0, len(overrides))
+	for _, override := range overrides {
+		response = append(response, dto.NewRateLimitOverride(override))
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Get a rate limit override
+// @Description Gets the rate limit override of a user or segment
+// @Tags rate-limit-override
+// @Produce json
+// @Param scope path string true "users or segments"
+// @Param subject path string true "User ID or segment name"
+// @Success 200 {object} dto.RateLimitOverride
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-overrides/{scope}/{subject} [get]
+func (c RateLimitOverride) get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	override, err := c.overrides.Get(r.Context(), overrideScopes[vars["scope"]], vars["subject"])
+	if errors.Is(err, repository.ErrOverrideNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewRateLimitOverride(override))
+}
+
+// @Summary Set a rate limit override
+// @Description Creates or replaces the rate limit override of a user or segment, effective immediately
+// @Tags rate-limit-override
+// @Accept json
+// @Produce json
+// @Param scope path string true "users or segments"
+// @Param subject path string true "User ID or segment name"
+// @Param override body dto.RateLimitOverride true "Rate limit override"
+// @Success 200 {object} dto.RateLimitOverride
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-overrides/{scope}/{subject} [put]
+func (c RateLimitOverride) put(w http.ResponseWriter, r *http.Request) {
+	var overrideDTO dto.RateLimitOverride
+	if err := json.NewDecoder(r.Body).Decode(&overrideDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := overrideDTO.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	override := overrideDTO.ToDomain(overrideScopes[vars["scope"]], vars["subject"])
+	if err := override.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.overrides.Save(r.Context(), override); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewRateLimitOverride(override))
+}
+
+// @Summary Delete a rate limit override
+// @Description Deletes the rate limit override of a user or segment
+// @Tags rate-limit-override
+// @Param scope path string true "users or segments"
+// @Param subject path string true "User ID or segment name"
+// @Success 204
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-overrides/{scope}/{subject} [delete]
+func (c RateLimitOverride) delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := c.overrides.Delete(r.Context(), overrideScopes[vars["scope"]], vars["subject"]); err != nil {
+		if errors.Is(err, repository.ErrOverrideNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary List rate limit exemptions
+// @Description Lists the active rate limit exemptions sorted by notification type
+// @Tags rate-limit-override
+// @Produce json
+// @Success 200 {array} dto.RateLimitExemption
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-exemptions [get]
+func (c RateLimitOverride) listExemptions(w http.ResponseWriter, r *http.Request) {
+	exemptions, err := c.exemptions.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.RateLimitExemption, 0, len(exemptions))
+	for _, exemption := range exemptions {
+		response = append(response, dto.NewRateLimitExemption(exemption))
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Grant a rate limit exemption
+// @Description Lifts the rate limits of a notification type for a while, e.g. during an incident.
+// @Description Only status notifications can be exempted, for up to 24h. Granting an exemption again replaces it.
+// @Tags rate-limit-override
+// @Accept json
+// @Produce json
+// @Param type path string true "Notification type"
+// @Param X-Actor header string false "Who grants the exemption"
+// @Param exemption body dto.RateLimitExemptionRequest true "Rate limit exemption"
+// @Success 200 {object} dto.RateLimitExemption
+// @Failure 400 {object} string "Bad Request"
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-exemptions/{type} [put]
+func (c RateLimitOverride) putExemption(w http.ResponseWriter, r *http.Request) {
+	notificationType, err := domain.ToNotificationType(mux.Vars(r)["type"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var exemptionDTO dto.RateLimitExemptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&exemptionDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := exemptionDTO.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := withActor(r)
+	exemption := exemptionDTO.ToDomain(notificationType, repository.ActorFromContext(ctx), time.Now().UTC())
+	if err := exemption.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.exemptions.Save(ctx, exemption); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewRateLimitExemption(exemption))
+}
+
+// @Summary End a rate limit exemption
+// @Description Ends the rate limit exemption of a notification type before it expires
+// @Tags rate-limit-override
+// @Param type path string true "Notification type"
+// @Success 204
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-exemptions/{type} [delete]
+func (c RateLimitOverride) deleteExemption(w http.ResponseWriter, r *http.Request) {
+	notificationType, err := domain.ToNotificationType(mux.Vars(r)["type"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := c.exemptions.Delete(r.Context(), notificationType); err != nil {
+		if errors.Is(err, repository.ErrExemptionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,183 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/controller"
+	"notification/internal/controller/dto"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitOverride(t *testing.T) {
+	overrides := repository.NewInMemoryRateLimitOverrideRepository()
+	require.NoError(t, overrides.Save(context.Background(), domain.RateLimitOverride{
+		Scope:   domain.OverrideSegment,
+		Subject: "vip",
+		Default: &domain.RateLimitRule{MaxCount: 10, Expiration: time.Hour},
+	}))
+
+	r := mux.NewRouter()
+	controller.NewRateLimitOverride(overrides, repository.NewInMemoryRateLimitExemptionRepository()).SetRouter(r)
+
+	t.Run("override is found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/rate-limit-overrides/segments/vip", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got dto.RateLimitOverride
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, dto.RateLimitOverride{
+			Scope:   "segment",
+			Subject: "vip",
+			Default: &dto.RateLimitRule{MaxCount: 10, Expiration: "1h0m0s"},
+		}, got)
+	})
+
+	t.Run("override is not found", func(t *testing.T) {
+		for _, target := range []string{
+			"/rate-limit-overrides/users/vip", "/rate-limit-overrides/groups/vip",
+		} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code, target)
+		}
+	})
+
+	t.Run("override is set", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/rate-limit-overrides/users/abc-123",
+			strings.NewReader(`{"rules":[{"type":"news","maxCount":5,"expiration":"1h"},{"type":"status","unlimited":true}]}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		override, err := overrides.Get(context.Background(), domain.OverrideUser, "abc-123")
+		require.NoError(t, err)
+		assert.Equal(t, domain.RateLimitOverride{
+			Scope:   domain.OverrideUser,
+			Subject: "abc-123",
+			Rules: domain.RateLimitRules{
+				domain.News:   {MaxCount: 5, Expiration: time.Hour},
+				domain.Status: {Unlimited: true},
+			},
+		}, override)
+	})
+
+	t.Run("invalid override", func(t *testing.T) {
+		for target, body := range map[string]string{
+			"/rate-limit-overrides/users/abc-123":      `{}`,
+			"/rate-limit-overrides/segments/VIP":       `{"default":{"unlimited":true}}`,
+			"/rate-limit-overrides/segments/beta":      `{"rules":[{"type":"unknown","unlimited":true}]}`,
+			"/rate-limit-overrides/segments/test-beta": `{"default":{"maxCount":0,"expiration":"1h"}}`,
+		} {
+			req := httptest.NewRequest(http.MethodPut, target, strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+		}
+	})
+
+	t.Run("overrides are listed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/rate-limit-overrides", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.RateLimitOverride
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		require.Len(t, got, 2)
+		assert.Equal(t, "abc-123", got[0].Subject)
+		assert.Equal(t, []dto.RateLimitRule{
+			{Type: "news", MaxCount: 5, Expiration: "1h0m0s"},
+			{Type: "status", Unlimited: true},
+		}, got[0].Rules)
+		assert.Equal(t, "vip", got[1].Subject)
+	})
+
+	t.Run("override is deleted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/rate-limit-overrides/users/abc-123", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		req = httptest.NewRequest(http.MethodDelete, "/rate-limit-overrides/users/abc-123", nil)
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestRateLimitOverride_Exemptions(t *testing.T) {
+	exemptions := repository.NewInMemoryRateLimitExemptionRepository()
+	r := mux.NewRouter()
+	controller.NewRateLimitOverride(repository.NewInMemoryRateLimitOverrideRepository(), exemptions).SetRouter(r)
+
+	t.Run("exemption is granted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/rate-limit-exemptions/status",
+			strings.NewReader(`{"reason":"INC-42","duration":"2h"}`))
+		req.Header.Set(controller.ActorHeader, "jane")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		exemption, err := exemptions.Get(context.Background(), domain.Status)
+		require.NoError(t, err)
+		assert.Equal(t, "INC-42", exemption.Reason)
+		assert.Equal(t, "jane", exemption.Actor)
+		assert.Equal(t, 2*time.Hour, exemption.ExpiresAt.Sub(exemption.CreatedAt))
+	})
+
+	t.Run("invalid exemption", func(t *testing.T) {
+		for target, tc := range map[string]struct {
+			body string
+			code int
+		}{
+			"/rate-limit-exemptions/marketing": {`{"reason":"INC-42","duration":"2h"}`, http.StatusBadRequest},
+			"/rate-limit-exemptions/status":    {`{"reason":"INC-42","duration":"25h"}`, http.StatusBadRequest},
+			"/rate-limit-exemptions/unknown":   {`{"reason":"INC-42","duration":"2h"}`, http.StatusNotFound},
+		} {
+			req := httptest.NewRequest(http.MethodPut, target, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code, target)
+		}
+	})
+
+	t.Run("exemptions are listed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/rate-limit-exemptions", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.RateLimitExemption
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		require.Len(t, got, 1)
+		assert.Equal(t, "status", got[0].Type)
+		assert.Equal(t, "jane", got[0].Actor)
+	})
+
+	t.Run("exemption is ended", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/rate-limit-exemptions/status", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		req = httptest.NewRequest(http.MethodDelete, "/rate-limit-exemptions/status", nil)
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
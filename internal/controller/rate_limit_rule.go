@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"notification/internal/auth"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"sort"
+	"strconv"
+)
+
+const (
+	// ActorHeader is the request header identifying who makes the changes recorded in the audit history,
+	// when the request isn't authenticated.
+	ActorHeader = "X-Actor"
+	// anonymousActor is the actor of the changes made without ActorHeader.
+	anonymousActor = "anonymous"
+
+	defaultRuleHistoryPageSize = 50
+	maxRuleHistoryPageSize     = 500
+)
+
+// RateLimitRuleOption defines the optional params for RateLimitRule.
+type RateLimitRuleOption func(*RateLimitRule)
+
+// WithRuleHistory exposes the audit history of the rate limit rule changes.
+func WithRuleHistory(history repository.RateLimitRuleHistory) RateLimitRuleOption {
+	return func(c *RateLimitRule) {
+		c.history = history
+	}
+}
+
+// NewRateLimitRule creates a new RateLimitRule controller instance.
+func NewRateLimitRule(repo repository.RateLimitRuleRepository, opts ...RateLimitRuleOption) *RateLimitRule {
+	c := &RateLimitRule{repo: repo}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RateLimitRule is the rate limit rule controller.
+// It defines routes and handlers to manage the rate limit rules of each notification type.
+type RateLimitRule struct {
+	repo    repository.RateLimitRuleRepository
+	history repository.RateLimitRuleHistory
+}
+
+// SetRouter returns the router r with all the necessary routes for the
+// RateLimitRule controller setup.
+func (c RateLimitRule) SetRouter(r *mux.Router) {
+	r.HandleFunc("/rate-limit-rules", middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules,
+		middleware.SetJSONContent(c.list)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/rate-limit-rules/{type}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules,
+		middleware.SetJSONContent(c.get)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/rate-limit-rules/{type}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules,
+		middleware.SetJSONContent(c.put)))).
+		Methods(http.MethodPut)
+	r.HandleFunc("/rate-limit-rules/{type}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules,
+		c.delete))).
+		Methods(http.MethodDelete)
+	if c.history != nil {
+		r.HandleFunc("/rate-limit-rules/{type}/history", middleware.Logger(middleware.RequireScope(domain.ScopeAdminRules,
+			middleware.SetJSONContent(c.changes)))).
+			Methods(http.MethodGet)
+	}
+}
+
+// @Summary List rate limit rules
+// @Description Lists the rate limit rules of every notification type
+// @Tags rate-limit-rule
+// @Produce json
+// @Success 200 {array} dto.RateLimitRule
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-rules [get]
+func (c RateLimitRule) list(w http.ResponseWriter, r *http.Request) {
+	rules, err := c.repo.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.RateLimitRule, 0, len(rules))
+	for notificationType, rule := range rules {
+		response = append(response, dto.NewRateLimitRule(notificationType, rule))
+	}
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].Type < response[j].Type
+	})
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Get a rate limit rule
+// @Description Gets the rate limit rule of a notification type
+// @Tags rate-limit-rule
+// @Produce json
+// @Param type path string true "Notification type"
+// @Success 200 {object} dto.RateLimitRule
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-rules/{type} [get]
+func (c RateLimitRule) get(w http.ResponseWriter, r *http.Request) {
+	notificationType, err := domain.ToNotificationType(mux.Vars(r)["type"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	rule, err := c.repo.GetByNotificationType(r.Context(), notificationType)
+	if errors.Is(err, repository.ErrRuleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewRateLimitRule(notificationType, rule))
+}
+
+// @Summary Set a rate limit rule
+// @Description Creates or replaces the rate limit rule of a notification type, effective immediately
+// @Tags rate-limit-rule
+// @Accept json
+// @Produce json
+// @Param type path string true "Notification type"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit history"
+// @Param rule body dto.RateLimitRule true "Rate limit rule"
+// @Success 200 {object} dto.RateLimitRule
+// @Failure 400 {object} string "Bad Request"
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-rules/{type} [put]
+func (c RateLimitRule) put(w http.ResponseWriter, r *http.Request) {
+	notificationType, err := domain.ToNotificationType(mux.Vars(r)["type"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var ruleDTO dto.RateLimitRule
+	if err := json.NewDecoder(r.Body).Decode(&ruleDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ruleDTO.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule := ruleDTO.ToDomain()
+	if err := c.repo.Update(withActor(r), notificationType, rule); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewRateLimitRule(notificationType, rule))
+}
+
+// @Summary Delete a rate limit rule
+// @Description Deletes the rate limit rule of a notification type
+// @Tags rate-limit-rule
+// @Param type path string true "Notification type"
+// @Param X-Actor header string false "Who makes the change, recorded in the audit history"
+// @Success 204
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-rules/{type} [delete]
+func (c RateLimitRule) delete(w http.ResponseWriter, r *http.Request) {
+	notificationType, err := domain.ToNotificationType(mux.Vars(r)["type"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := c.repo.Delete(withActor(r), notificationType); err != nil {
+		if errors.Is(err, repository.ErrRuleNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get the history of a rate limit rule
+// @Description Lists the changes of the rate limit rule of a notification type, the most recent first
+// @Tags rate-limit-rule
+// @Produce json
+// @Param type path string true "Notification type"
+// @Param limit query int false "Maximum number of changes (default 50, max 500)"
+// @Success 200 {array} dto.RateLimitRuleChange
+// @Failure 400 {object} string "Bad Request"
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /rate-limit-rules/{type}/history [get]
+func (c RateLimitRule) changes(w http.ResponseWriter, r *http.Request) {
+	notificationType, err := domain.ToNotificationType(mux.Vars(r)["type"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	limit := defaultRuleHistoryPageSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxRuleHistoryPageSize {
+			http.Error(w, "limit must be a number between 1 and 500", http.StatusBadRequest)
+			return
+		}
+	}
+
+	changes, err := c.history.History(r.Context(), notificationType, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.RateLimitRuleChange, 0, len(changes))
+	for _, change := range changes {
+		response = append(response, dto.NewRateLimitRuleChange(change))
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// withActor returns the request context carrying the actor of the changes: the name of the
+// authenticated client, see middleware.Authenticate, or else the value of ActorHeader.
+func withActor(r *http.Request) context.Context {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		return repository.WithActor(r.Context(), principal.Name)
+	}
+	actor := r.Header.Get(ActorHeader)
+	if actor == "" {
+		actor = anonymousActor
+	}
+	return repository.WithActor(r.Context(), actor)
+}
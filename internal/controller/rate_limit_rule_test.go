@@ -0,0 +1,190 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/auth"
+	"notification/internal/controller"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitRule(t *testing.T) {
+	repo := repository.NewInMemoryRateLimitRuleRepository()
+	require.NoError(t, repo.Save(context.Background(), domain.Status,
+		domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute}))
+
+	r := mux.NewRouter()
+	controller.NewRateLimitRule(repo).SetRouter(r)
+
+	t.Run("rule is found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/rate-limit-rules/status", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got dto.RateLimitRule
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, dto.RateLimitRule{Type: "status", MaxCount: 2, Expiration: "1m0s"}, got)
+	})
+
+	t.Run("rule is not found", func(t *testing.T) {
+		for _, target := range []string{"/rate-limit-rules/news", "/rate-limit-rules/unknown"} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code, target)
+		}
+	})
+
+	t.Run("rule is set", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/rate-limit-rules/news",
+			strings.NewReader(`{"maxCount":1,"expiration":"24h"}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		rule, err := repo.GetByNotificationType(context.Background(), domain.News)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RateLimitRule{MaxCount: 1, Expiration: 24 * time.Hour}, rule)
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/rate-limit-rules/news",
+			strings.NewReader(`{"maxCount":0,"expiration":"24h"}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("rules are listed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/rate-limit-rules", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.RateLimitRule
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, []dto.RateLimitRule{
+			{Type: "news", MaxCount: 1, Expiration: "24h0m0s"},
+			{Type: "status", MaxCount: 2, Expiration: "1m0s"},
+		}, got)
+	})
+
+	t.Run("rule is deleted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/rate-limit-rules/news", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		req = httptest.NewRequest(http.MethodDelete, "/rate-limit-rules/news", nil)
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestRateLimitRule_History(t *testing.T) {
+	repo := repository.NewInMemoryRateLimitRuleRepository()
+
+	t.Run("history isn't exposed by default", func(t *testing.T) {
+		r := mux.NewRouter()
+		controller.NewRateLimitRule(repo).SetRouter(r)
+
+		req := httptest.NewRequest(http.MethodGet, "/rate-limit-rules/news/history", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	r := mux.NewRouter()
+	controller.NewRateLimitRule(repo, controller.WithRuleHistory(repo)).SetRouter(r)
+
+	req := httptest.NewRequest(http.MethodPut, "/rate-limit-rules/news",
+		strings.NewReader(`{"maxCount":1,"expiration":"24h"}`))
+	req.Header.Set(controller.ActorHeader, "jane")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	req = httptest.NewRequest(http.MethodDelete, "/rate-limit-rules/news", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	t.Run("changes are listed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/rate-limit-rules/news/history", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.RateLimitRuleChange
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		require.Len(t, got, 2)
+
+		assert.Equal(t, "anonymous", got[0].Actor)
+		assert.Equal(t, &dto.RateLimitRule{MaxCount: 1, Expiration: "24h0m0s"}, got[0].Old)
+		assert.Nil(t, got[0].New)
+
+		assert.Equal(t, "news", got[1].Type)
+		assert.Equal(t, "jane", got[1].Actor)
+		assert.Nil(t, got[1].Old)
+		assert.Equal(t, &dto.RateLimitRule{MaxCount: 1, Expiration: "24h0m0s"}, got[1].New)
+	})
+	t.Run("changes are limited", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/rate-limit-rules/news/history?limit=1", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.RateLimitRuleChange
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Len(t, got, 1)
+	})
+	t.Run("invalid requests", func(t *testing.T) {
+		for target, code := range map[string]int{
+			"/rate-limit-rules/news/history?limit=0":   http.StatusBadRequest,
+			"/rate-limit-rules/news/history?limit=501": http.StatusBadRequest,
+			"/rate-limit-rules/unknown/history":        http.StatusNotFound,
+		} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, code, rr.Code, target)
+		}
+	})
+}
+
+func TestRateLimitRule_AuthenticatedActor(t *testing.T) {
+	repo := repository.NewInMemoryRateLimitRuleRepository()
+	keys := repository.NewInMemoryAPIKeyRepository()
+	require.NoError(t, keys.Save(context.Background(), domain.APIKey{
+		ID: "k1", Name: "rules-admin", Hash: auth.HashAPIKey("nk_rules"), Scopes: []domain.Scope{domain.ScopeAdminRules},
+	}))
+	r := mux.NewRouter()
+	r.Use(middleware.Authenticate(auth.NewAPIKeyAuthenticator(keys)))
+	controller.NewRateLimitRule(repo, controller.WithRuleHistory(repo)).SetRouter(r)
+
+	req := httptest.NewRequest(http.MethodPut, "/rate-limit-rules/news",
+		strings.NewReader(`{"maxCount":1,"expiration":"24h"}`))
+	req.Header.Set(middleware.APIKeyHeader, "nk_rules")
+	// the actor header can't impersonate anyone once authenticated.
+	req.Header.Set(controller.ActorHeader, "jane")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	changes, err := repo.History(context.Background(), domain.News, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "rules-admin", changes[0].Actor)
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"time"
+)
+
+// NewSuppression creates a new Suppression controller instance.
+func NewSuppression(repo repository.SuppressionRepository) *Suppression {
+	return &Suppression{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// Suppression is the suppression list controller.
+// It defines routes and handlers to manage the email addresses that must not receive notifications.
+type Suppression struct {
+	repo repository.SuppressionRepository
+	now  func() time.Time
+}
+
+// SetRouter returns the router r with all the necessary routes for the
+// Suppression controller setup.
+func (s Suppression) SetRouter(r *mux.Router) {
+	r.HandleFunc("/suppressions", middleware.Logger(middleware.RequireScope(domain.ScopeAdminSuppressions,
+		middleware.SetJSONContent(s.list)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/suppressions/{email}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminSuppressions,
+		middleware.SetJSONContent(s.get)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/suppressions/{email}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminSuppressions,
+		middleware.SetJSONContent(s.put)))).
+		Methods(http.MethodPut)
+	r.HandleFunc("/suppressions/{email}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminSuppressions,
+		s.delete))).
+		Methods(http.MethodDelete)
+}
+
+// @Summary List suppressed email addresses
+// @Description Lists the email addresses that won't receive notifications
+// @Tags suppression
+// @Produce json
+// @Success 200 {array} dto.Suppression
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /suppressions [get]
+func (s Suppression) list(w http.ResponseWriter, r *http.Request) {
+	suppressions, err := s.repo.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.Suppression, 0, len(suppressions))
+	for _, suppression := range suppressions {
+		response = append(response, dto.NewSuppression(suppression))
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Get a suppressed email address
+// @Description Gets the suppression of an email address
+// @Tags suppression
+// @Produce json
+// @Param email path string true "Email address"
+// @Success 200 {object} dto.Suppression
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /suppressions/{email} [get]
+func (s Suppression) get(w http.ResponseWriter, r *http.Request) {
+	suppression, err := s.repo.Get(r.Context(), mux.Vars(r)["email"])
+	if err != nil {
+		if errors.Is(err, repository.ErrSuppressionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewSuppression(suppression))
+}
+
+// @Summary Suppress an email address
+// @Description Adds an email address to the suppression list, replacing any existing suppression
+// @Tags suppression
+// @Accept json
+// @Produce json
+// @Param email path string true "Email address"
+// @Param suppression body dto.SuppressionRequest false "Suppression reason"
+// @Success 200 {object} dto.Suppression
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /suppressions/{email} [put]
+func (s Suppression) put(w http.ResponseWriter, r *http.Request) {
+	var request dto.SuppressionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := request.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reason := domain.SuppressedManually
+	if request.Reason != "" {
+		reason, _ = domain.ToSuppressionReason(request.Reason)
+	}
+	suppression := domain.Suppression{
+		Email:     domain.NormalizeEmail(mux.Vars(r)["email"]),
+		Reason:    reason,
+		Detail:    request.Detail,
+		CreatedAt: s.now(),
+	}
+	if err := s.repo.Save(r.Context(), suppression); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewSuppression(suppression))
+}
+
+// @Summary Remove a suppressed email address
+// @Description Removes an email address from the suppression list, so it receives notifications again
+// @Tags suppression
+// @Param email path string true "Email address"
+// @Success 204
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /suppressions/{email} [delete]
+func (s Suppression) delete(w http.ResponseWriter, r *http.Request) {
+	if err := s.repo.Delete(r.Context(), mux.Vars(r)["email"]); err != nil {
+		if errors.Is(err, repository.ErrSuppressionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
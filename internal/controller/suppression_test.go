@@ -0,0 +1,110 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/controller"
+	"notification/internal/controller/dto"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"strings"
+	"testing"
+)
+
+func TestSuppression(t *testing.T) {
+	repo := repository.NewInMemorySuppressionRepository()
+	r := mux.NewRouter()
+	controller.NewSuppression(repo).SetRouter(r)
+
+	t.Run("address is suppressed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/suppressions/John@example.com",
+			strings.NewReader(`{"reason":"complaint","detail":"reported by support"}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		suppression, err := repo.Get(context.Background(), "john@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, domain.SuppressedByComplaint, suppression.Reason)
+		assert.Equal(t, "reported by support", suppression.Detail)
+	})
+
+	t.Run("reason defaults to manual", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/suppressions/jane@example.com", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got dto.Suppression
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, "manual", got.Reason)
+	})
+
+	t.Run("invalid reason", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/suppressions/bob@example.com",
+			strings.NewReader(`{"reason":"boredom"}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("suppressions are listed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/suppressions", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.Suppression
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		require.Len(t, got, 2)
+		assert.Equal(t, "jane@example.com", got[0].Email)
+		assert.Equal(t, "john@example.com", got[1].Email)
+	})
+
+	t.Run("address is removed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/suppressions/john@example.com", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/suppressions/john@example.com", nil)
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("missing address", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/suppressions/nobody@example.com", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+	t.Run("suppressions of other tenants are not visible", func(t *testing.T) {
+		billing := repository.WithTenant(context.Background(), "billing")
+		serve := func(method, target string) *httptest.ResponseRecorder {
+			req := httptest.NewRequestWithContext(billing, method, target, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			return rr
+		}
+
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/suppressions/jane@example.com").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/suppressions/jane@example.com").Code)
+		rr := serve(http.MethodGet, "/suppressions")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, "[]", rr.Body.String())
+
+		require.Equal(t, http.StatusOK, serve(http.MethodPut, "/suppressions/jane@example.com").Code)
+		_, err := repo.Get(billing, "jane@example.com")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"time"
+)
+
+// NewTenant creates a new Tenant controller instance.
+func NewTenant(repo repository.TenantRepository) *Tenant {
+	return &Tenant{repo}
+}
+
+// Tenant is the tenant controller.
+// It defines routes and handlers to provision the tenants and their settings. The users,
+// rate limit rules and API keys of a tenant are managed through their own routes, acting
+// for the tenant with the middleware.TenantHeader.
+type Tenant struct {
+	repo repository.TenantRepository
+}
+
+// SetRouter returns the router r with all the necessary routes for the
+// Tenant controller setup.
+func (c Tenant) SetRouter(r *mux.Router) {
+	r.HandleFunc("/tenants", middleware.Logger(middleware.RequireScope(domain.ScopeAdminTenants,
+		middleware.SetJSONContent(c.list)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/tenants", middleware.Logger(middleware.RequireScope(domain.ScopeAdminTenants,
+		middleware.SetJSONContent(c.create)))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/tenants/{id}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminTenants,
+		middleware.SetJSONContent(c.get)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/tenants/{id}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminTenants,
+		middleware.SetJSONContent(c.replace)))).
+		Methods(http.MethodPut)
+	r.HandleFunc("/tenants/{id}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminTenants, c.delete))).
+		Methods(http.MethodDelete)
+}
+
+// @Summary List tenants
+// @Description Lists the tenants sorted by ID
+// @Tags tenant
+// @Produce json
+// @Success 200 {array} dto.Tenant
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /tenants [get]
+func (c Tenant) list(w http.ResponseWriter, r *http.Request) {
+	tenants, err := c.repo.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.Tenant, 0, len(tenants))
+	for _, tenant := range tenants {
+		response = append(response, dto.NewTenant(tenant))
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Provision a tenant
+// @Description Creates a tenant with its sender address, subjects and quota. Its users, rate limit rules
+// @Description and API keys are then managed acting for it with the X-Tenant-ID header
+// @Tags tenant
+// @Accept json
+// @Produce json
+// @Param tenant body dto.Tenant true "Tenant to be created"
+// @Success 201 {object} dto.Tenant
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 409 {object} string "Conflict"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /tenants [post]
+func (c Tenant) create(w http.ResponseWriter, r *http.Request) {
+	var tenantDTO dto.Tenant
+	if err := json.NewDecoder(r.Body).Decode(&tenantDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := tenantDTO.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tenant := tenantDTO.ToDomain()
+	tenant.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	if err := c.repo.Save(r.Context(), tenant); err != nil {
+		writeTenantError(w, err)
+		return
+	}
+	logging.AddFields(r.Context(), map[string]any{logging.TenantIDKey: tenant.ID})
+
+	w.Header().Set("Location", "/tenants/"+tenant.ID)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(dto.NewTenant(tenant))
+}
+
+// @Summary Get a tenant
+// @Description Gets a tenant by its ID
+// @Tags tenant
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} dto.Tenant
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /tenants/{id} [get]
+func (c Tenant) get(w http.ResponseWriter, r *http.Request) {
+	tenant, err := c.repo.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeTenantError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewTenant(tenant))
+}
+
+// @Summary Replace a tenant
+// @Description Replaces the settings of an existing tenant, effective immediately
+// @Tags tenant
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param tenant body dto.Tenant true "Tenant settings, the ID is taken from the path"
+// @Success 200 {object} dto.Tenant
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /tenants/{id} [put]
+func (c Tenant) replace(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var tenantDTO dto.Tenant
+	if err := json.NewDecoder(r.Body).Decode(&tenantDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if tenantDTO.ID != "" && tenantDTO.ID != id {
+		http.Error(w, "the tenant ID can't be changed", http.StatusBadRequest)
+		return
+	}
+	tenantDTO.ID = id
+	if err := tenantDTO.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.repo.Update(r.Context(), tenantDTO.ToDomain()); err != nil {
+		writeTenantError(w, err)
+		return
+	}
+	tenant, err := c.repo.Get(r.Context(), id)
+	if err != nil {
+		writeTenantError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewTenant(tenant))
+}
+
+// @Summary Delete a tenant
+// @Description Deletes a tenant, whose notifications are rejected from then on. Its users,
+// @Description rate limit rules and API keys are kept, so that it can be provisioned again
+// @Tags tenant
+// @Param id path string true "Tenant ID"
+// @Success 204
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /tenants/{id} [delete]
+func (c Tenant) delete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := c.repo.Delete(r.Context(), id); err != nil {
+		writeTenantError(w, err)
+		return
+	}
+	logging.AddFields(r.Context(), map[string]any{logging.TenantIDKey: id})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTenantError writes the HTTP error matching the tenant repository error.
+func writeTenantError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrTenantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrTenantAlreadyExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
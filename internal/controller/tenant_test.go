@@ -0,0 +1,126 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/auth"
+	"notification/internal/controller"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTenant(t *testing.T) {
+	const adminKey = "nk_admin"
+	keysRepo := repository.NewInMemoryAPIKeyRepository()
+	require.NoError(t, keysRepo.Save(context.Background(), domain.APIKey{
+		ID:       "billing-admin",
+		Name:     "billing-admin",
+		TenantID: "billing",
+		Hash:     auth.HashAPIKey("nk_billing"),
+		Scopes:   []domain.Scope{domain.ScopeAll},
+	}))
+	repo := repository.NewInMemoryTenantRepository()
+	r := mux.NewRouter()
+	r.Use(middleware.Authenticate(auth.NewAPIKeyAuthenticator(keysRepo, auth.WithBootstrapKey(adminKey))))
+	controller.NewTenant(repo).SetRouter(r)
+
+	serve := func(method, target, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("tenant is provisioned", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/tenants", adminKey,
+			`{"id":"billing","name":"Billing","mailFrom":"billing@example.com",`+
+				`"subjects":{"news":"Billing news"},"quota":{"maxCount":1000,"expiration":"24h"}}`)
+
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		assert.Equal(t, "/tenants/billing", rr.Header().Get("Location"))
+		tenant, err := repo.Get(context.Background(), "billing")
+		require.NoError(t, err)
+		assert.Equal(t, "billing@example.com", tenant.MailFrom)
+		assert.Equal(t, map[domain.NotificationType]string{domain.News: "Billing news"}, tenant.Subjects)
+		assert.Equal(t, &domain.RateLimitRule{MaxCount: 1000, Expiration: 24 * time.Hour}, tenant.Quota)
+		assert.False(t, tenant.CreatedAt.IsZero())
+	})
+
+	t.Run("tenant is not provisioned", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			key        string
+			body       string
+			wantStatus int
+		}{
+			{"malformed body", adminKey, `{`, http.StatusBadRequest},
+			{"invalid ID", adminKey, `{"id":"Marketing","name":"Marketing"}`, http.StatusBadRequest},
+			{"conflicting ID", adminKey, `{"id":"billing","name":"Billing"}`, http.StatusConflict},
+			{"tenant API key", "nk_billing", `{"id":"marketing","name":"Marketing"}`, http.StatusForbidden},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := serve(http.MethodPost, "/tenants", tt.key, tt.body)
+				assert.Equal(t, tt.wantStatus, rr.Code, rr.Body.String())
+			})
+		}
+	})
+
+	t.Run("tenant is found", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/tenants/billing", adminKey, "")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var tenant dto.Tenant
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&tenant))
+		assert.Equal(t, "Billing", tenant.Name)
+		assert.Equal(t, &dto.RateLimitRule{MaxCount: 1000, Expiration: "24h0m0s"}, tenant.Quota)
+	})
+
+	t.Run("tenant is not found", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/tenants/marketing", adminKey, "").Code)
+	})
+
+	t.Run("tenant is replaced", func(t *testing.T) {
+		rr := serve(http.MethodPut, "/tenants/billing", adminKey, `{"name":"Billing team"}`)
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		tenant, err := repo.Get(context.Background(), "billing")
+		require.NoError(t, err)
+		assert.Equal(t, "Billing team", tenant.Name)
+		assert.Nil(t, tenant.Quota)
+		assert.False(t, tenant.CreatedAt.IsZero())
+	})
+
+	t.Run("tenant ID can't be replaced", func(t *testing.T) {
+		rr := serve(http.MethodPut, "/tenants/billing", adminKey, `{"id":"marketing","name":"Marketing"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("tenants are listed", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/tenants", adminKey, "")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var tenants []dto.Tenant
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&tenants))
+		require.Len(t, tenants, 1)
+		assert.Equal(t, "billing", tenants[0].ID)
+	})
+
+	t.Run("tenant is deleted", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/tenants/billing", adminKey, "").Code)
+
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/tenants/billing", adminKey, "").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/tenants/billing", adminKey, "").Code)
+	})
+}
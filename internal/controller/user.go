@@ -0,0 +1,325 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"notification/internal/service"
+	"strconv"
+)
+
+const (
+	// defaultUserPageSize is the number of users returned by the user listing by default.
+	defaultUserPageSize = 50
+	// maxUserPageSize is the maximum number of users returned by the user listing.
+	maxUserPageSize = 500
+)
+
+// UserOption defines the optional params for User.
+type UserOption func(*User)
+
+// WithQuotas exposes the notifications each user can still be sent, as enforced by limiter.
+func WithQuotas(limiter service.RateLimitHandler) UserOption {
+	return func(u *User) {
+		u.limiter = limiter
+	}
+}
+
+// NewUser creates a new User controller instance.
+func NewUser(repo repository.UserRepository, opts ...UserOption) *User {
+	u := &User{repo: repo}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// User is the user controller.
+// It defines routes and handlers to manage the users notifications are sent to.
+type User struct {
+	repo    repository.UserRepository
+	limiter service.RateLimitHandler
+}
+
+// SetRouter returns the router r with all the necessary routes for the
+// User controller setup.
+func (u User) SetRouter(r *mux.Router) {
+	r.HandleFunc("/users", middleware.Logger(middleware.RequireScope(domain.ScopeAdminUsers,
+		middleware.SetJSONContent(u.list)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/users", middleware.Logger(middleware.RequireScope(domain.ScopeAdminUsers,
+		middleware.SetJSONContent(u.create)))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/users/{id}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminUsers,
+		middleware.SetJSONContent(u.get)))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/users/{id}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminUsers,
+		middleware.SetJSONContent(u.replace)))).
+		Methods(http.MethodPut)
+	r.HandleFunc("/users/{id}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminUsers,
+		middleware.SetJSONContent(u.patch)))).
+		Methods(http.MethodPatch)
+	r.HandleFunc("/users/{id}", middleware.Logger(middleware.RequireScope(domain.ScopeAdminUsers, u.delete))).
+		Methods(http.MethodDelete)
+	if u.limiter != nil {
+		// the scope depends on the notification types, so it's checked by the handler.
+		r.HandleFunc("/users/{id}/quota", middleware.Logger(middleware.Authenticated(
+			middleware.SetJSONContent(u.quota)))).
+			Methods(http.MethodGet)
+	}
+}
+
+// @Summary List users
+// @Description Lists the users sorted by ID, optionally searching by email
+// @Tags user
+// @Produce json
+// @Param email query string false "Text the user email must contain, case-insensitively"
+// @Param offset query int false "Number of users to skip (default 0)"
+// @Param limit query int false "Maximum number of users (default 50, max 500)"
+// @Success 200 {object} dto.UserList
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users [get]
+func (u User) list(w http.ResponseWriter, r *http.Request) {
+	query := repository.UserQuery{
+		Email: r.URL.Query().Get("email"),
+		Limit: defaultUserPageSize,
+	}
+	if value := r.URL.Query().Get("offset"); value != "" {
+		var err error
+		query.Offset, err = strconv.Atoi(value)
+		if err != nil || query.Offset < 0 {
+			http.Error(w, "offset must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(value)
+		if err != nil || query.Limit <= 0 || query.Limit > maxUserPageSize {
+			http.Error(w, "limit must be a number between 1 and 500", http.StatusBadRequest)
+			return
+		}
+	}
+
+	users, total, err := u.repo.List(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.UserList{
+		Users:  make([]dto.User, 0, len(users)),
+		Total:  total,
+		Offset: query.Offset,
+		Limit:  query.Limit,
+	}
+	for _, user := range users {
+		response.Users = append(response.Users, dto.NewUser(user))
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Create a user
+// @Description Creates a user. Both the ID and the email must be unique
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param user body dto.User true "User to be created"
+// @Success 201 {object} dto.User
+// @Failure 400 {object} string "Bad Request"
+// @Failure 409 {object} string "Conflict"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users [post]
+func (u User) create(w http.ResponseWriter, r *http.Request) {
+	var userDTO dto.User
+	if err := json.NewDecoder(r.Body).Decode(&userDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := userDTO.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := u.repo.Save(r.Context(), userDTO.ToDomain()); err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/users/"+userDTO.ID)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(userDTO)
+}
+
+// @Summary Get a user
+// @Description Gets a user by its ID
+// @Tags user
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.User
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id} [get]
+func (u User) get(w http.ResponseWriter, r *http.Request) {
+	user, err := u.repo.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewUser(user))
+}
+
+// @Summary Get the quota of a user
+// @Description Lists the notifications the user can still be sent per notification type, without
+// @Description consuming any. Only the types the client can send are listed, unless it can manage the users
+// @Tags user
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} dto.Quota
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id}/quota [get]
+func (u User) quota(w http.ResponseWriter, r *http.Request) {
+	var notificationTypes []domain.NotificationType
+	for _, notificationType := range domain.NotificationTypes() {
+		if middleware.Authorized(r, domain.ScopeAdminUsers) || middleware.Authorized(r, domain.SendScope(notificationType)) {
+			notificationTypes = append(notificationTypes, notificationType)
+		}
+	}
+	if len(notificationTypes) == 0 {
+		http.Error(w, "missing scope "+string(domain.ScopeAdminUsers), http.StatusForbidden)
+		return
+	}
+
+	user, err := u.repo.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	response := make([]dto.Quota, 0, len(notificationTypes))
+	for _, notificationType := range notificationTypes {
+		limits, err := u.limiter.Quota(r.Context(), user.ID, notificationType)
+		if errors.Is(err, service.ErrNoRateLimitRule) {
+			response = append(response, dto.NewRejectedQuota(notificationType))
+			continue
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, dto.NewQuota(notificationType, limits))
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Replace a user
+// @Description Replaces every field of an existing user
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param user body dto.User true "User fields, the ID is taken from the path"
+// @Success 200 {object} dto.User
+// @Failure 400 {object} string "Bad Request"
+// @Failure 404 {object} string "Not Found"
+// @Failure 409 {object} string "Conflict"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id} [put]
+func (u User) replace(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var userDTO dto.User
+	if err := json.NewDecoder(r.Body).Decode(&userDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if userDTO.ID != "" && userDTO.ID != id {
+		http.Error(w, "the user ID can't be changed", http.StatusBadRequest)
+		return
+	}
+	userDTO.ID = id
+	if err := userDTO.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := u.repo.Update(r.Context(), userDTO.ToDomain()); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(userDTO)
+}
+
+// @Summary Update a user
+// @Description Updates the given fields of an existing user
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param user body dto.UserPatch true "User fields to be updated"
+// @Success 200 {object} dto.User
+// @Failure 400 {object} string "Bad Request"
+// @Failure 404 {object} string "Not Found"
+// @Failure 409 {object} string "Conflict"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id} [patch]
+func (u User) patch(w http.ResponseWriter, r *http.Request) {
+	var patch dto.UserPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := patch.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := u.repo.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	user = patch.Apply(user)
+	if err := u.repo.Update(r.Context(), user); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.NewUser(user))
+}
+
+// @Summary Delete a user
+// @Description Deletes a user by its ID
+// @Tags user
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id} [delete]
+func (u User) delete(w http.ResponseWriter, r *http.Request) {
+	if err := u.repo.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeUserError maps the user repository errors to their HTTP status codes.
+func writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidUserID):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrUserAlreadyExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,263 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/auth"
+	"notification/internal/controller"
+	"notification/internal/controller/dto"
+	"notification/internal/controller/middleware"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"notification/internal/service"
+	"notification/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUser(t *testing.T) {
+	repo := repository.NewInMemoryUserRepository()
+	r := mux.NewRouter()
+	controller.NewUser(repo).SetRouter(r)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		var req *http.Request
+		if body == "" {
+			req = httptest.NewRequest(method, target, nil)
+		} else {
+			req = httptest.NewRequest(method, target, strings.NewReader(body))
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("user is created", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/users",
+			`{"id":"123-abc","name":"John","lastName":"Doe","email":"john@example.com"}`)
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "/users/123-abc", rr.Header().Get("Location"))
+		user, err := repo.Get(context.Background(), "123-abc")
+		require.NoError(t, err)
+		assert.Equal(t, domain.User{ID: "123-abc", Name: "John", LastName: "Doe", Email: "john@example.com"}, user)
+	})
+
+	t.Run("user is not created", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			body       string
+			wantStatus int
+		}{
+			{
+				name:       "malformed body",
+				body:       `{`,
+				wantStatus: http.StatusBadRequest,
+			},
+			{
+				name:       "invalid email",
+				body:       `{"id":"456-bbb","name":"Jane","email":"jane"}`,
+				wantStatus: http.StatusBadRequest,
+			},
+			{
+				name:       "conflicting ID",
+				body:       `{"id":"123-abc","name":"Jane","email":"jane@example.com"}`,
+				wantStatus: http.StatusConflict,
+			},
+			{
+				name:       "conflicting email",
+				body:       `{"id":"456-bbb","name":"Jane","email":"john@example.com"}`,
+				wantStatus: http.StatusConflict,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := serve(http.MethodPost, "/users", tt.body)
+				assert.Equal(t, tt.wantStatus, rr.Code)
+			})
+		}
+	})
+
+	t.Run("user is found", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/users/123-abc", "")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got dto.User
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, "john@example.com", got.Email)
+	})
+
+	t.Run("user is not found", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/users/invalid", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("user is replaced", func(t *testing.T) {
+		rr := serve(http.MethodPut, "/users/123-abc", `{"name":"Johnny","email":"johnny@example.com"}`)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		user, err := repo.Get(context.Background(), "123-abc")
+		require.NoError(t, err)
+		assert.Equal(t, domain.User{ID: "123-abc", Name: "Johnny", Email: "johnny@example.com"}, user)
+	})
+
+	t.Run("user ID can't be replaced", func(t *testing.T) {
+		rr := serve(http.MethodPut, "/users/123-abc", `{"id":"789","name":"Johnny","email":"johnny@example.com"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("unknown user is not replaced", func(t *testing.T) {
+		rr := serve(http.MethodPut, "/users/invalid", `{"name":"Johnny","email":"someone@example.com"}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("user is patched", func(t *testing.T) {
+		rr := serve(http.MethodPatch, "/users/123-abc", `{"lastName":"Doe"}`)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got dto.User
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, dto.User{ID: "123-abc", Name: "Johnny", LastName: "Doe", Email: "johnny@example.com"}, got)
+	})
+
+	t.Run("patch with conflicting email", func(t *testing.T) {
+		require.NoError(t, repo.Save(context.Background(), domain.User{ID: "456-bbb", Name: "Jane", Email: "jane@example.com"}))
+
+		rr := serve(http.MethodPatch, "/users/123-abc", `{"email":"jane@example.com"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("users are listed", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/users?limit=1&offset=1", "")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got dto.UserList
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, 2, got.Total)
+		assert.Equal(t, 1, got.Offset)
+		assert.Equal(t, 1, got.Limit)
+		require.Len(t, got.Users, 1)
+		assert.Equal(t, "456-bbb", got.Users[0].ID)
+	})
+
+	t.Run("users are searched by email", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/users?email=JANE", "")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got dto.UserList
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, 1, got.Total)
+		require.Len(t, got.Users, 1)
+		assert.Equal(t, "456-bbb", got.Users[0].ID)
+	})
+
+	t.Run("invalid pagination", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/users?limit=501", "").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/users?offset=-1", "").Code)
+	})
+
+	t.Run("user is deleted", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/users/456-bbb", "").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/users/456-bbb", "").Code)
+	})
+}
+
+func TestUser_Quota(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryUserRepository()
+	require.NoError(t, repo.Save(ctx, domain.User{ID: "abc-123", Email: "john@example.com"}))
+
+	limiter := mocks.NewRateLimitHandler(t)
+	limiter.
+		On("Quota", mock.Anything, "abc-123", domain.Status).
+		Return(service.RateLimitLimits{
+			{Limit: 2, Remaining: 1, Window: time.Minute, Reset: time.Minute},
+			{Limit: 100, Remaining: 50, Window: 24 * time.Hour, Reset: 24 * time.Hour},
+		}, nil).
+		Maybe()
+	limiter.
+		On("Quota", mock.Anything, "abc-123", domain.News).
+		Return(service.RateLimitLimits{}, nil).
+		Maybe()
+	limiter.
+		On("Quota", mock.Anything, "abc-123", domain.Marketing).
+		Return(service.RateLimitLimits(nil), service.ErrNoRateLimitRule).
+		Maybe()
+
+	t.Run("quota isn't exposed by default", func(t *testing.T) {
+		r := mux.NewRouter()
+		controller.NewUser(repo).SetRouter(r)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/abc-123/quota", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	r := mux.NewRouter()
+	controller.NewUser(repo, controller.WithQuotas(limiter)).SetRouter(r)
+
+	t.Run("quota of every type", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/abc-123/quota", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.Quota
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		one, zero := 1, 0
+		assert.Equal(t, []dto.Quota{
+			{Type: "status", Remaining: &one, Limits: []dto.QuotaLimit{
+				{Limit: 2, Remaining: 1, Window: "1m0s", Reset: "1m0s"},
+				{Limit: 100, Remaining: 50, Window: "24h0m0s", Reset: "24h0m0s"},
+			}},
+			{Type: "news", Limits: []dto.QuotaLimit{}},
+			{Type: "marketing", Remaining: &zero, Limits: []dto.QuotaLimit{}},
+		}, got)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/unknown/quota", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("quota of the types the client can send", func(t *testing.T) {
+		keys := repository.NewInMemoryAPIKeyRepository()
+		require.NoError(t, keys.Save(ctx, domain.APIKey{
+			ID: "k1", Name: "status-sender", Hash: auth.HashAPIKey("nk_status"), Scopes: []domain.Scope{"send:status"},
+		}))
+		require.NoError(t, keys.Save(ctx, domain.APIKey{
+			ID: "k2", Name: "suppressions", Hash: auth.HashAPIKey("nk_other"),
+			Scopes: []domain.Scope{domain.ScopeAdminSuppressions},
+		}))
+		r := mux.NewRouter()
+		r.Use(middleware.Authenticate(auth.NewAPIKeyAuthenticator(keys)))
+		controller.NewUser(repo, controller.WithQuotas(limiter)).SetRouter(r)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/abc-123/quota", nil)
+		req.Header.Set(middleware.APIKeyHeader, "nk_status")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []dto.Quota
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		require.Len(t, got, 1)
+		assert.Equal(t, "status", got[0].Type)
+
+		req = httptest.NewRequest(http.MethodGet, "/users/abc-123/quota", nil)
+		req.Header.Set(middleware.APIKeyHeader, "nk_other")
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	limiter.AssertNotCalled(t, "LockIfAvailable", mock.Anything, mock.Anything, mock.Anything)
+}
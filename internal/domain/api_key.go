@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Scope is a permission granted to an API client, written as "<action>:<resource>", e.g. "send:status".
+type Scope string
+
+const (
+	// ScopeAll grants every permission.
+	ScopeAll Scope = "*"
+	// ScopeAdminRules allows managing the rate limit rules.
+	ScopeAdminRules Scope = "admin:rules"
+	// ScopeAdminUsers allows managing the users.
+	ScopeAdminUsers Scope = "admin:users"
+	// ScopeAdminSuppressions allows managing the suppression list.
+	ScopeAdminSuppressions Scope = "admin:suppressions"
+	// ScopeAdminKeys allows managing the API keys.
+	ScopeAdminKeys Scope = "admin:keys"
+	// ScopeReadNotifications allows reading the delivery status and history of the notifications.
+	ScopeReadNotifications Scope = "read:notifications"
+	// ScopeAdminTenants allows provisioning the tenants, and acting on behalf of any of them.
+	ScopeAdminTenants Scope = "admin:tenants"
+)
+
+// SendScope returns the scope allowing to send notifications of the given type, e.g. "send:status".
+func SendScope(t NotificationType) Scope {
+	return Scope("send:" + t.String())
+}
+
+// Grants reports whether s grants the required scope: either they're the same scope,
+// s is ScopeAll, or s is an action wildcard such as "send:*".
+func (s Scope) Grants(required Scope) bool {
+	if s == ScopeAll || s == required {
+		return true
+	}
+	action, ok := strings.CutSuffix(string(s), ":*")
+	return ok && strings.HasPrefix(string(required), action+":")
+}
+
+// Valid reports whether s is ScopeAll or has the "<action>:<resource>" format.
+func (s Scope) Valid() bool {
+	if s == ScopeAll {
+		return true
+	}
+	action, resource, ok := strings.Cut(string(s), ":")
+	return ok && action != "" && resource != "" && !strings.ContainsAny(string(s), " ,")
+}
+
+// APIKey represents an API key clients authenticate with. Only the hash of the key is stored.
+type APIKey struct {
+	// ID is the API key unique identifier, which isn't secret.
+	ID string
+	// Name describes who the API key was issued to, e.g. "billing-service".
+	Name string
+	// TenantID is the tenant the API key acts on behalf of.
+	TenantID string
+	// Hash is the SHA-256 hash of the key, hex encoded.
+	Hash string
+	// Scopes are the permissions granted to the API key.
+	Scopes []Scope
+	// CreatedAt is when the API key was created.
+	CreatedAt time.Time
+}
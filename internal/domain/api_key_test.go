@@ -0,0 +1,42 @@
+package domain_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/domain"
+	"testing"
+)
+
+func TestScope_Grants(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    domain.Scope
+		required domain.Scope
+		want     bool
+	}{
+		{"same scope", "send:status", "send:status", true},
+		{"other resource", "send:status", "send:marketing", false},
+		{"other action", "admin:rules", "send:status", false},
+		{"all scopes", domain.ScopeAll, "admin:rules", true},
+		{"action wildcard", "send:*", "send:marketing", true},
+		{"wildcard of other action", "send:*", "admin:rules", false},
+		{"wildcard prefix", "send:*", "sender:status", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.scope.Grants(tt.required))
+		})
+	}
+}
+
+func TestScope_Valid(t *testing.T) {
+	for _, scope := range []domain.Scope{domain.ScopeAll, "send:status", "send:*", domain.ScopeAdminKeys} {
+		assert.True(t, scope.Valid(), scope)
+	}
+	for _, scope := range []domain.Scope{"", "send", ":status", "send:", "send:status admin:rules"} {
+		assert.False(t, scope.Valid(), scope)
+	}
+}
+
+func TestSendScope(t *testing.T) {
+	assert.Equal(t, domain.Scope("send:marketing"), domain.SendScope(domain.Marketing))
+}
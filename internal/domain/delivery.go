@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"errors"
+	"net/netip"
+	"time"
+)
+
+// sharedAddressSpace is the address space of the carrier-grade NATs (RFC 6598).
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+const (
+	// DeliveryAccepted means the notification has been accepted for delivery.
+	DeliveryAccepted DeliveryStatus = iota + 1
+	// DeliveryRateLimited means the notification has been rejected because of the rate limit rules.
+	DeliveryRateLimited
+	// DeliveryDeferred means the mail provider failed temporarily and the notification may be retried.
+	DeliveryDeferred
+	// DeliverySending means the notification is being handed over to the mail provider.
+	DeliverySending
+	// DeliverySent means the mail provider accepted the notification.
+	DeliverySent
+	// DeliveryFailed means the notification couldn't be delivered and mustn't be retried.
+	DeliveryFailed
+	// DeliverySuppressed means the recipient is in the suppression list.
+	DeliverySuppressed
+)
+
+const (
+	// EventSent is emitted when the mail provider accepts the notification.
+	EventSent DeliveryEventType = iota + 1
+	// EventFailed is emitted when the notification can't be delivered.
+	EventFailed
+	// EventBounced is emitted when the recipient's mail server bounces the notification
+	// or the recipient complains about it.
+	EventBounced
+	// EventRateLimited is emitted when the notification is rejected because of the rate limit rules.
+	EventRateLimited
+)
+
+var (
+	// ErrInvalidDeliveryStatus is the error when the provided delivery status is invalid.
+	ErrInvalidDeliveryStatus = errors.New("unknown delivery status")
+)
+
+// DeliveryStatus defines the stages of the notification delivery lifecycle.
+type DeliveryStatus int
+
+// String returns the string equivalent of DeliveryStatus.
+// It returns an empty string if the delivery status is invalid.
+func (s DeliveryStatus) String() string {
+	switch s {
+	case DeliveryAccepted:
+		return "accepted"
+	case DeliveryRateLimited:
+		return "rate_limited"
+	case DeliveryDeferred:
+		return "deferred"
+	case DeliverySending:
+		return "sending"
+	case DeliverySent:
+		return "sent"
+	case DeliveryFailed:
+		return "failed"
+	case DeliverySuppressed:
+		return "suppressed"
+	default:
+		return ""
+	}
+}
+
+// ToDeliveryStatus converts a string into a corresponding DeliveryStatus.
+// It will error out if the string doesn't match any pre-defined delivery status.
+func ToDeliveryStatus(s string) (DeliveryStatus, error) {
+	switch s {
+	case "accepted":
+		return DeliveryAccepted, nil
+	case "rate_limited":
+		return DeliveryRateLimited, nil
+	case "deferred":
+		return DeliveryDeferred, nil
+	case "sending":
+		return DeliverySending, nil
+	case "sent":
+		return DeliverySent, nil
+	case "failed":
+		return DeliveryFailed, nil
+	case "suppressed":
+		return DeliverySuppressed, nil
+	default:
+		return 0, ErrInvalidDeliveryStatus
+	}
+}
+
+// StatusTransition is a step of the notification delivery lifecycle.
+type StatusTransition struct {
+	// Status is the status the notification moved into.
+	Status DeliveryStatus
+	// Detail is a free-text detail of the transition, e.g. the failure reason.
+	Detail string
+	// At is when the transition happened.
+	At time.Time
+}
+
+// DeliveryRecord is the delivery tracking of a notification.
+type DeliveryRecord struct {
+	// CorrelationID is the notification correlation ID.
+	CorrelationID string
+	// TenantID is the tenant the notification was sent on behalf of.
+	TenantID string
+	// UserID is the ID of the user the notification is meant to be sent to.
+	UserID string
+	// Type is the notification type.
+	Type NotificationType
+	// Status is the current delivery status, i.e. the status of the last transition.
+	Status DeliveryStatus
+	// ProviderMessageID is the message ID reported by the mail provider, when available.
+	ProviderMessageID string
+	// CallbackURL is the URL where the delivery events are posted, if any.
+	CallbackURL string
+	// Caller identifies the authenticated client which sent the notification, if any.
+	Caller string
+	// CreatedAt is when the notification was first accepted.
+	CreatedAt time.Time
+	// UpdatedAt is when the last transition happened.
+	UpdatedAt time.Time
+	// Transitions is the delivery lifecycle history, oldest first.
+	Transitions []StatusTransition
+}
+
+// DeliveryEventType defines the kinds of delivery events posted to the callback URLs.
+type DeliveryEventType int
+
+// String returns the string equivalent of DeliveryEventType.
+// It returns an empty string if the event type is invalid.
+func (t DeliveryEventType) String() string {
+	switch t {
+	case EventSent:
+		return "sent"
+	case EventFailed:
+		return "failed"
+	case EventBounced:
+		return "bounced"
+	case EventRateLimited:
+		return "rate_limited"
+	default:
+		return ""
+	}
+}
+
+// DeliveryEvent is a delivery outcome of a notification reported to its producer.
+type DeliveryEvent struct {
+	// ID is the unique identifier of the event, allowing the receivers to deduplicate deliveries.
+	ID string
+	// Type is the kind of event.
+	Type DeliveryEventType
+	// CorrelationID is the notification correlation ID.
+	CorrelationID string
+	// UserID is the ID of the user the notification is meant to be sent to.
+	UserID string
+	// CallbackURL is the URL the event is posted to.
+	CallbackURL string
+	// Detail is a free-text detail of the event, e.g. the failure reason.
+	Detail string
+	// OccurredAt is when the event happened.
+	OccurredAt time.Time
+}
+
+// IsPublicAddress reports whether the IP address is routable on the internet, as opposed to the
+// loopback, private, shared, link-local (e.g. the 169.254.169.254 cloud metadata endpoint),
+// multicast and unspecified addresses, which the callback URLs mustn't reach.
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// CallbackAttempt is the record of an attempt to post a DeliveryEvent to its callback URL.
+type CallbackAttempt struct {
+	// EventID is the ID of the event being posted.
+	EventID string
+	// EventType is the kind of event being posted.
+	EventType DeliveryEventType
+	// CorrelationID is the notification correlation ID.
+	CorrelationID string
+	// URL is the callback URL.
+	URL string
+	// Attempt is the attempt number, starting at 1.
+	Attempt int
+	// StatusCode is the HTTP status code of the response, or 0 if no response was received.
+	StatusCode int
+	// Error is the failure reason, if the attempt failed.
+	Error string
+	// At is when the attempt was made.
+	At time.Time
+}
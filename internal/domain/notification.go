@@ -36,6 +36,11 @@ func (t NotificationType) String() string {
 	}
 }
 
+// NotificationTypes returns every notification type.
+func NotificationTypes() []NotificationType {
+	return []NotificationType{Status, News, Marketing}
+}
+
 // ToNotificationType converts a string into a corresponding NotificationType.
 // It will error out if the string doesn't match any pre-defined notification type.
 func ToNotificationType(s string) (NotificationType, error) {
@@ -60,4 +65,9 @@ type Notification struct {
 	Type NotificationType
 	// Message is the content of the notification itself.
 	Message string
+	// CallbackURL is the optional URL where the delivery events of the notification are posted.
+	CallbackURL string
+	// Caller identifies the authenticated client which sent the notification, e.g. the API key ID
+	// or the JWT subject. It's empty when authentication is disabled.
+	Caller string
 }
@@ -35,3 +35,25 @@ func TestToNotificationType(t *testing.T) {
 		})
 	}
 }
+
+func TestNotificationTypes(t *testing.T) {
+	for _, notificationType := range domain.NotificationTypes() {
+		got, err := domain.ToNotificationType(notificationType.String())
+		require.NoError(t, err)
+		assert.Equal(t, notificationType, got)
+	}
+}
+
+func TestToDeliveryStatus(t *testing.T) {
+	t.Run("every status converts back", func(t *testing.T) {
+		for s := domain.DeliveryAccepted; s <= domain.DeliverySuppressed; s++ {
+			got, err := domain.ToDeliveryStatus(s.String())
+			require.NoError(t, err)
+			assert.Equal(t, s, got)
+		}
+	})
+	t.Run("invalid status", func(t *testing.T) {
+		_, err := domain.ToDeliveryStatus("invalid")
+		assert.ErrorIs(t, err, domain.ErrInvalidDeliveryStatus)
+	})
+}
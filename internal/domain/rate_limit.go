@@ -1,6 +1,22 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// MinRuleExpiration is the shortest time span accepted for a rate limit rule.
+	MinRuleExpiration = time.Second
+	// MaxRuleExpiration is the longest time span accepted for a rate limit rule.
+	MaxRuleExpiration = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidRateLimitRule is the error when a rate limit rule is out of the accepted bounds.
+	ErrInvalidRateLimitRule = errors.New("invalid rate limit rule")
+)
 
 // RateLimitRules defines the rate limit rules for a given notification type.
 type RateLimitRules map[NotificationType]RateLimitRule
@@ -11,4 +27,47 @@ type RateLimitRule struct {
 	MaxCount int
 	// Expiration is the time span defined for limiting a certain number of messages.
 	Expiration time.Duration
+	// Unlimited means the notifications aren't rate limited at all,
+	// in which case MaxCount and Expiration must be zero.
+	Unlimited bool
+}
+
+// Validate returns an error ErrInvalidRateLimitRule if MaxCount isn't positive or
+// Expiration isn't between MinRuleExpiration and MaxRuleExpiration.
+// Unlimited rules mustn't set MaxCount or Expiration instead.
+func (r RateLimitRule) Validate() error {
+	var err error
+
+	if r.Unlimited {
+		if r.MaxCount != 0 || r.Expiration != 0 {
+			err = errors.Join(ErrInvalidRateLimitRule,
+				errors.New("an unlimited rule must not set max count or expiration"))
+		}
+		return err
+	}
+
+	if r.MaxCount <= 0 {
+		err = errors.Join(ErrInvalidRateLimitRule, errors.New("max count must be greater than 0"))
+	}
+
+	if r.Expiration < MinRuleExpiration || r.Expiration > MaxRuleExpiration {
+		err = errors.Join(err, ErrInvalidRateLimitRule,
+			fmt.Errorf("expiration must be between %s and %s", MinRuleExpiration, MaxRuleExpiration))
+	}
+
+	return err
+}
+
+// RateLimitRuleChange is an entry of the audit history of the rate limit rules.
+type RateLimitRuleChange struct {
+	// Type is the notification type of the changed rule.
+	Type NotificationType
+	// Actor identifies who made the change.
+	Actor string
+	// At is when the change was made.
+	At time.Time
+	// Old is the rule before the change, nil if the rule was created.
+	Old *RateLimitRule
+	// New is the rule after the change, nil if the rule was deleted.
+	New *RateLimitRule
 }
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	// OverrideUser is the scope of the overrides of a single user, keyed by user ID.
+	OverrideUser OverrideScope = "user"
+	// OverrideSegment is the scope of the overrides of the users of a segment, keyed by segment name.
+	OverrideSegment OverrideScope = "segment"
+
+	// MaxExemptionDuration is the longest time span a rate limit exemption can be granted for,
+	// so that an exemption forgotten after an incident expires on its own.
+	MaxExemptionDuration = 24 * time.Hour
+)
+
+var (
+	// ErrInvalidRateLimitOverride is the error when a rate limit override has an invalid subject or rule.
+	ErrInvalidRateLimitOverride = errors.New("invalid rate limit override")
+	// ErrInvalidRateLimitExemption is the error when a rate limit exemption has an invalid type,
+	// reason or time span.
+	ErrInvalidRateLimitExemption = errors.New("invalid rate limit exemption")
+)
+
+// segmentPattern is the format of the segment names, which are part of cache keys and URLs.
+var segmentPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// OverrideScope is the kind of subject a rate limit override applies to.
+type OverrideScope string
+
+// Valid reports whether s is OverrideUser or OverrideSegment.
+func (s OverrideScope) Valid() bool {
+	return s == OverrideUser || s == OverrideSegment
+}
+
+// RateLimitOverride replaces the rate limit rules of the notifications sent to a user, or to the
+// users of a segment, e.g. to raise the limits of VIP users or lift the ones of test accounts.
+type RateLimitOverride struct {
+	// Scope is the kind of subject of the override.
+	Scope OverrideScope
+	// Subject is the user ID, or the segment name, the override applies to.
+	Subject string
+	// Rules are the rules of the override per notification type.
+	Rules RateLimitRules
+	// Default is the rule of the notification types without a rule in Rules.
+	// Nil means the other types aren't overridden.
+	Default *RateLimitRule
+}
+
+// Rule returns the rule of the override for the notification type, and whether there's one.
+func (o RateLimitOverride) Rule(t NotificationType) (RateLimitRule, bool) {
+	if rule, ok := o.Rules[t]; ok {
+		return rule, true
+	}
+	if o.Default != nil {
+		return *o.Default, true
+	}
+	return RateLimitRule{}, false
+}
+
+// Validate returns an error ErrInvalidRateLimitOverride if the scope is unknown, the subject is
+// empty or isn't a valid segment name, the override has no rule or any of its rules is invalid.
+func (o RateLimitOverride) Validate() error {
+	var err error
+
+	switch {
+	case !o.Scope.Valid():
+		err = errors.Join(ErrInvalidRateLimitOverride, fmt.Errorf("unknown scope %q", o.Scope))
+	case o.Subject == "":
+		err = errors.Join(ErrInvalidRateLimitOverride, errors.New("subject is empty"))
+	case o.Scope == OverrideSegment && !ValidSegment(o.Subject):
+		err = errors.Join(ErrInvalidRateLimitOverride, errInvalidSegment)
+	}
+
+	if len(o.Rules) == 0 && o.Default == nil {
+		err = errors.Join(err, ErrInvalidRateLimitOverride, errors.New("at least one rule is required"))
+	}
+	for t, rule := range o.Rules {
+		if ruleErr := rule.Validate(); ruleErr != nil {
+			err = errors.Join(err, ErrInvalidRateLimitOverride, fmt.Errorf("rule of %s: %w", t, ruleErr))
+		}
+	}
+	if o.Default != nil {
+		if ruleErr := o.Default.Validate(); ruleErr != nil {
+			err = errors.Join(err, ErrInvalidRateLimitOverride, fmt.Errorf("default rule: %w", ruleErr))
+		}
+	}
+
+	return err
+}
+
+// errInvalidSegment details the format of the segment names.
+var errInvalidSegment = errors.New("segment must be up to 63 lowercase letters, digits or hyphens, not starting with a hyphen")
+
+// ValidSegment reports whether name has the format of the segment names.
+func ValidSegment(name string) bool {
+	return segmentPattern.MatchString(name)
+}
+
+// RateLimitExemption lifts the rate limits of a notification type for a while, e.g. so that every
+// status update reaches the users during an incident. The overrides don't apply to exempted types.
+type RateLimitExemption struct {
+	// Type is the exempted notification type. Only Status notifications can be exempted.
+	Type NotificationType
+	// Reason explains the exemption, e.g. the incident reference.
+	Reason string
+	// Actor identifies who granted the exemption.
+	Actor string
+	// CreatedAt is when the exemption was granted.
+	CreatedAt time.Time
+	// ExpiresAt is when the exemption ends.
+	ExpiresAt time.Time
+}
+
+// Active reports whether the exemption is in effect at the given time.
+func (e RateLimitExemption) Active(now time.Time) bool {
+	return now.Before(e.ExpiresAt)
+}
+
+// Validate returns an error ErrInvalidRateLimitExemption if the type isn't Status, the reason is
+// empty or the exemption doesn't end within MaxExemptionDuration of its creation.
+func (e RateLimitExemption) Validate() error {
+	var err error
+
+	if e.Type != Status {
+		err = errors.Join(ErrInvalidRateLimitExemption, errors.New("only status notifications can be exempted"))
+	}
+
+	if e.Reason == "" {
+		err = errors.Join(err, ErrInvalidRateLimitExemption, errors.New("reason is empty"))
+	}
+
+	if duration := e.ExpiresAt.Sub(e.CreatedAt); duration <= 0 || duration > MaxExemptionDuration {
+		err = errors.Join(err, ErrInvalidRateLimitExemption,
+			fmt.Errorf("duration must be positive and up to %s", MaxExemptionDuration))
+	}
+
+	return err
+}
@@ -0,0 +1,147 @@
+package domain_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestRateLimitOverride_Validate(t *testing.T) {
+	hourly := domain.RateLimitRule{MaxCount: 10, Expiration: time.Hour}
+
+	tests := []struct {
+		name     string
+		override domain.RateLimitOverride
+		wantErr  error
+	}{
+		{
+			name: "user override",
+			override: domain.RateLimitOverride{Scope: domain.OverrideUser, Subject: "Abc-123",
+				Rules: domain.RateLimitRules{domain.News: hourly}},
+			wantErr: nil,
+		},
+		{
+			name: "segment override with default rule only",
+			override: domain.RateLimitOverride{Scope: domain.OverrideSegment, Subject: "vip",
+				Default: &domain.RateLimitRule{Unlimited: true}},
+			wantErr: nil,
+		},
+		{
+			name:     "unknown scope",
+			override: domain.RateLimitOverride{Scope: "group", Subject: "vip", Default: &hourly},
+			wantErr:  domain.ErrInvalidRateLimitOverride,
+		},
+		{
+			name:     "empty subject",
+			override: domain.RateLimitOverride{Scope: domain.OverrideUser, Default: &hourly},
+			wantErr:  domain.ErrInvalidRateLimitOverride,
+		},
+		{
+			name:     "invalid segment name",
+			override: domain.RateLimitOverride{Scope: domain.OverrideSegment, Subject: "VIP users", Default: &hourly},
+			wantErr:  domain.ErrInvalidRateLimitOverride,
+		},
+		{
+			name:     "without rules",
+			override: domain.RateLimitOverride{Scope: domain.OverrideUser, Subject: "abc-123"},
+			wantErr:  domain.ErrInvalidRateLimitOverride,
+		},
+		{
+			name: "invalid rule",
+			override: domain.RateLimitOverride{Scope: domain.OverrideUser, Subject: "abc-123",
+				Rules: domain.RateLimitRules{domain.News: {MaxCount: 0, Expiration: time.Hour}}},
+			wantErr: domain.ErrInvalidRateLimitRule,
+		},
+		{
+			name: "invalid default rule",
+			override: domain.RateLimitOverride{Scope: domain.OverrideUser, Subject: "abc-123",
+				Default: &domain.RateLimitRule{MaxCount: 1}},
+			wantErr: domain.ErrInvalidRateLimitRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.override.Validate()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRateLimitOverride_Rule(t *testing.T) {
+	news := domain.RateLimitRule{MaxCount: 5, Expiration: time.Hour}
+	fallback := domain.RateLimitRule{MaxCount: 50, Expiration: time.Hour}
+
+	t.Run("rule of the type", func(t *testing.T) {
+		override := domain.RateLimitOverride{Rules: domain.RateLimitRules{domain.News: news}, Default: &fallback}
+		rule, ok := override.Rule(domain.News)
+		assert.True(t, ok)
+		assert.Equal(t, news, rule)
+	})
+	t.Run("default rule", func(t *testing.T) {
+		override := domain.RateLimitOverride{Rules: domain.RateLimitRules{domain.News: news}, Default: &fallback}
+		rule, ok := override.Rule(domain.Marketing)
+		assert.True(t, ok)
+		assert.Equal(t, fallback, rule)
+	})
+	t.Run("type not overridden", func(t *testing.T) {
+		override := domain.RateLimitOverride{Rules: domain.RateLimitRules{domain.News: news}}
+		_, ok := override.Rule(domain.Marketing)
+		assert.False(t, ok)
+	})
+}
+
+func TestRateLimitExemption_Validate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		exemption domain.RateLimitExemption
+		wantErr   error
+	}{
+		{
+			name: "valid",
+			exemption: domain.RateLimitExemption{Type: domain.Status, Reason: "INC-42",
+				CreatedAt: now, ExpiresAt: now.Add(2 * time.Hour)},
+			wantErr: nil,
+		},
+		{
+			name: "not a status type",
+			exemption: domain.RateLimitExemption{Type: domain.Marketing, Reason: "INC-42",
+				CreatedAt: now, ExpiresAt: now.Add(2 * time.Hour)},
+			wantErr: domain.ErrInvalidRateLimitExemption,
+		},
+		{
+			name:      "empty reason",
+			exemption: domain.RateLimitExemption{Type: domain.Status, CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+			wantErr:   domain.ErrInvalidRateLimitExemption,
+		},
+		{
+			name:      "already expired",
+			exemption: domain.RateLimitExemption{Type: domain.Status, Reason: "INC-42", CreatedAt: now, ExpiresAt: now},
+			wantErr:   domain.ErrInvalidRateLimitExemption,
+		},
+		{
+			name: "too long",
+			exemption: domain.RateLimitExemption{Type: domain.Status, Reason: "INC-42",
+				CreatedAt: now, ExpiresAt: now.Add(domain.MaxExemptionDuration + time.Second)},
+			wantErr: domain.ErrInvalidRateLimitExemption,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.exemption.Validate()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRateLimitExemption_Active(t *testing.T) {
+	now := time.Now()
+	exemption := domain.RateLimitExemption{Type: domain.Status, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	assert.True(t, exemption.Active(now))
+	assert.False(t, exemption.Active(now.Add(time.Hour)))
+}
@@ -0,0 +1,54 @@
+package domain_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestRateLimitRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    domain.RateLimitRule
+		wantErr error
+	}{
+		{
+			name:    "valid",
+			rule:    domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute},
+			wantErr: nil,
+		},
+		{
+			name:    "zero max count",
+			rule:    domain.RateLimitRule{MaxCount: 0, Expiration: time.Minute},
+			wantErr: domain.ErrInvalidRateLimitRule,
+		},
+		{
+			name:    "expiration too short",
+			rule:    domain.RateLimitRule{MaxCount: 2, Expiration: time.Millisecond},
+			wantErr: domain.ErrInvalidRateLimitRule,
+		},
+		{
+			name:    "expiration too long",
+			rule:    domain.RateLimitRule{MaxCount: 2, Expiration: domain.MaxRuleExpiration + time.Hour},
+			wantErr: domain.ErrInvalidRateLimitRule,
+		},
+		{
+			name:    "unlimited",
+			rule:    domain.RateLimitRule{Unlimited: true},
+			wantErr: nil,
+		},
+		{
+			name:    "unlimited with max count",
+			rule:    domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute, Unlimited: true},
+			wantErr: domain.ErrInvalidRateLimitRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// HardBounce represents a permanent delivery failure, e.g. the mailbox doesn't exist.
+	HardBounce BounceType = iota + 1
+	// SoftBounce represents a temporary delivery failure, e.g. the mailbox is full.
+	SoftBounce
+	// Complaint represents the recipient marking the message as spam.
+	Complaint
+)
+
+const (
+	// SuppressedByBounce means the address has been suppressed because of a hard bounce.
+	SuppressedByBounce SuppressionReason = iota + 1
+	// SuppressedByComplaint means the address has been suppressed because of a spam complaint.
+	SuppressedByComplaint
+	// SuppressedManually means the address has been suppressed by an administrator.
+	SuppressedManually
+)
+
+var (
+	// ErrInvalidSuppressionReason is the error when the provided suppression reason is invalid.
+	ErrInvalidSuppressionReason = errors.New("unknown suppression reason")
+)
+
+// BounceType defines the different kinds of delivery feedback.
+type BounceType int
+
+// String returns the string equivalent of BounceType.
+// It returns an empty string if the bounce type is invalid.
+func (t BounceType) String() string {
+	switch t {
+	case HardBounce:
+		return "hard_bounce"
+	case SoftBounce:
+		return "soft_bounce"
+	case Complaint:
+		return "complaint"
+	default:
+		return ""
+	}
+}
+
+// BounceEvent is the representation of a delivery feedback about a recipient,
+// either from a DSN message or from a provider webhook.
+type BounceEvent struct {
+	// Email is the address of the recipient.
+	Email string
+	// Type is the kind of feedback.
+	Type BounceType
+	// Status is the enhanced status code (RFC 3463), e.g. "5.1.1", when available.
+	Status string
+	// Diagnostic is the diagnostic message reported by the receiving server, when available.
+	Diagnostic string
+	// Source identifies where the feedback came from, e.g. "dsn" or "ses".
+	Source string
+	// MessageID is the message ID of the notification the feedback is about, as reported by
+	// the mail provider when it was sent, if available.
+	MessageID string
+}
+
+// SuppressionReason defines why an address has been suppressed.
+type SuppressionReason int
+
+// String returns the string equivalent of SuppressionReason.
+// It returns an empty string if the suppression reason is invalid.
+func (r SuppressionReason) String() string {
+	switch r {
+	case SuppressedByBounce:
+		return "bounce"
+	case SuppressedByComplaint:
+		return "complaint"
+	case SuppressedManually:
+		return "manual"
+	default:
+		return ""
+	}
+}
+
+// ToSuppressionReason converts a string into a corresponding SuppressionReason.
+// It will error out if the string doesn't match any pre-defined suppression reason.
+func ToSuppressionReason(s string) (SuppressionReason, error) {
+	switch s {
+	case "bounce":
+		return SuppressedByBounce, nil
+	case "complaint":
+		return SuppressedByComplaint, nil
+	case "manual":
+		return SuppressedManually, nil
+	default:
+		return 0, ErrInvalidSuppressionReason
+	}
+}
+
+// Suppression is the representation of an email address that must not receive notifications.
+type Suppression struct {
+	// Email is the suppressed address, normalized with NormalizeEmail.
+	Email string
+	// Reason is why the address has been suppressed.
+	Reason SuppressionReason
+	// Detail is a free-text detail of the reason, e.g. the bounce diagnostic.
+	Detail string
+	// CreatedAt is when the address has been suppressed.
+	CreatedAt time.Time
+}
+
+// NormalizeEmail returns the canonical form of an email address used for comparisons.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
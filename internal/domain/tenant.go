@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"time"
+)
+
+// DefaultTenantID is the tenant of the requests which don't carry one, e.g. when authentication is
+// disabled, and of the data stored before tenants existed. Its rate limit rules apply to the
+// notification types the other tenants don't define a rule for.
+const DefaultTenantID = "default"
+
+var (
+	// ErrInvalidTenant is the error when a tenant has an invalid ID, sender address or quota.
+	ErrInvalidTenant = errors.New("invalid tenant")
+)
+
+// tenantIDPattern is the format of the tenant IDs, which are part of cache keys and URLs.
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Tenant represents a product team the notifications are sent for. The users, rate limit rules
+// and rate limit counters of each tenant are isolated from the other tenants'.
+type Tenant struct {
+	// ID is the tenant unique identifier, made of lowercase letters, digits and hyphens.
+	ID string
+	// Name is the display name of the tenant.
+	Name string
+	// MailFrom is the sender address of the tenant emails. Empty means the MAIL_FROM one.
+	MailFrom string
+	// Subjects are the email subjects of the tenant per notification type,
+	// overriding the ones of the catalog.
+	Subjects map[NotificationType]string
+	// Quota is the rate limit of the notifications of the whole tenant, on top of the
+	// per user rate limit rules. Nil means no quota.
+	Quota *RateLimitRule
+	// CreatedAt is when the tenant was provisioned.
+	CreatedAt time.Time
+}
+
+// Validate returns an error ErrInvalidTenant if the ID doesn't have the expected format,
+// MailFrom isn't a valid address or Quota isn't a valid limited rule.
+func (t Tenant) Validate() error {
+	var err error
+
+	if !ValidTenantID(t.ID) {
+		err = errors.Join(ErrInvalidTenant,
+			errors.New("id must be up to 63 lowercase letters, digits or hyphens, not starting with a hyphen"))
+	}
+
+	if t.MailFrom != "" {
+		if _, parseErr := mail.ParseAddress(t.MailFrom); parseErr != nil {
+			err = errors.Join(err, ErrInvalidTenant, fmt.Errorf("invalid mail from: %w", parseErr))
+		}
+	}
+
+	if t.Quota != nil {
+		if t.Quota.Unlimited {
+			err = errors.Join(err, ErrInvalidTenant, errors.New("quota must not be unlimited"))
+		} else if quotaErr := t.Quota.Validate(); quotaErr != nil {
+			err = errors.Join(err, ErrInvalidTenant, fmt.Errorf("quota: %w", quotaErr))
+		}
+	}
+
+	return err
+}
+
+// ValidTenantID reports whether id has the format of the tenant IDs.
+func ValidTenantID(id string) bool {
+	return tenantIDPattern.MatchString(id)
+}
@@ -0,0 +1,70 @@
+package domain_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestTenant_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenant  domain.Tenant
+		wantErr error
+	}{
+		{
+			name: "valid",
+			tenant: domain.Tenant{ID: "billing-2", MailFrom: "Billing <billing@example.com>",
+				Quota: &domain.RateLimitRule{MaxCount: 1000, Expiration: time.Hour}},
+			wantErr: nil,
+		},
+		{
+			name:    "without sender or quota",
+			tenant:  domain.Tenant{ID: "billing"},
+			wantErr: nil,
+		},
+		{
+			name:    "empty id",
+			tenant:  domain.Tenant{},
+			wantErr: domain.ErrInvalidTenant,
+		},
+		{
+			name:    "uppercase id",
+			tenant:  domain.Tenant{ID: "Billing"},
+			wantErr: domain.ErrInvalidTenant,
+		},
+		{
+			name:    "id with separator",
+			tenant:  domain.Tenant{ID: "billing:eu"},
+			wantErr: domain.ErrInvalidTenant,
+		},
+		{
+			name:    "id starting with hyphen",
+			tenant:  domain.Tenant{ID: "-billing"},
+			wantErr: domain.ErrInvalidTenant,
+		},
+		{
+			name:    "invalid mail from",
+			tenant:  domain.Tenant{ID: "billing", MailFrom: "billing"},
+			wantErr: domain.ErrInvalidTenant,
+		},
+		{
+			name:    "invalid quota",
+			tenant:  domain.Tenant{ID: "billing", Quota: &domain.RateLimitRule{MaxCount: 0, Expiration: time.Hour}},
+			wantErr: domain.ErrInvalidRateLimitRule,
+		},
+		{
+			name:    "unlimited quota",
+			tenant:  domain.Tenant{ID: "billing", Quota: &domain.RateLimitRule{Unlimited: true}},
+			wantErr: domain.ErrInvalidTenant,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tenant.Validate()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -10,4 +10,7 @@ type User struct {
 	LastName string
 	// Email is the email of the user.
 	Email string
+	// Segments are the names of the segments the user belongs to, e.g. "vip", whose rate limit
+	// overrides apply to the user in the order they're listed.
+	Segments []string
 }
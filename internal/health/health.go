@@ -0,0 +1,240 @@
+// Package health checks the dependencies of the application for the readiness and startup probes.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Checker checks whether a dependency of the application is available, e.g. by pinging it.
+type Checker interface {
+	// Check returns an error if the dependency isn't available. It must honor ctx cancellation.
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to a Checker, e.g. (*sql.DB).PingContext.
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f.
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Reporter reports the readiness of a dependency from its in-memory state, e.g. the circuit
+// breakers of service.FailoverMailer. Reports are expected to be cheap, so they're neither
+// timed out nor cached.
+type Reporter interface {
+	// Readiness returns whether the dependency is ready to serve traffic,
+	// along with details about its state.
+	Readiness() (ready bool, details any)
+}
+
+// Status is the readiness status of a dependency.
+type Status struct {
+	Ready   bool   `json:"ready"`
+	Details any    `json:"details,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// DurationMs is how long the check took, in milliseconds.
+	DurationMs int64 `json:"durationMs,omitempty"`
+}
+
+// Report is the readiness status of every dependency, by name.
+type Report map[string]Status
+
+// Ready reports whether every dependency is ready.
+func (r Report) Ready() bool {
+	for _, status := range r {
+		if !status.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+// RegistryOption defines the optional params for the Registry.
+type RegistryOption func(*Registry)
+
+// WithTimeout sets how long the checks may take before their dependency is considered
+// unavailable, unless they're registered with their own timeout.
+//
+// Defaults to 2s.
+func WithTimeout(timeout time.Duration) RegistryOption {
+	return func(r *Registry) {
+		r.timeout = timeout
+	}
+}
+
+// WithCacheTTL sets how long the result of a check is reused, so that frequent probes from
+// several sources don't overload the dependencies. Zero disables the caching.
+//
+// Defaults to 5s.
+func WithCacheTTL(ttl time.Duration) RegistryOption {
+	return func(r *Registry) {
+		r.ttl = ttl
+	}
+}
+
+// CheckOption defines the optional params of a registered check.
+type CheckOption func(*check)
+
+// WithCheckTimeout overrides the timeout of the registry for the check.
+func WithCheckTimeout(timeout time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// NewRegistry creates a new Registry instance.
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{
+		timeout:   2 * time.Second,
+		ttl:       5 * time.Second,
+		now:       time.Now,
+		checks:    make(map[string]*check),
+		reporters: make(map[string]Reporter),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Registry holds the checkers and reporters of the dependencies of the application,
+// which register themselves as they're created. It's safe for concurrent use.
+type Registry struct {
+	timeout time.Duration
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu        sync.RWMutex
+	checks    map[string]*check
+	reporters map[string]Reporter
+}
+
+// check is a registered Checker along with its cached result.
+type check struct {
+	checker Checker
+	timeout time.Duration
+
+	// mu serializes the runs of the check, so that concurrent probes share the same result.
+	mu        sync.Mutex
+	status    Status
+	checkedAt time.Time
+}
+
+// Register adds the checker of the dependency name, replacing the previous one, if any.
+func (r *Registry) Register(name string, checker Checker, opts ...CheckOption) {
+	c := &check{checker: checker, timeout: r.timeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = c
+}
+
+// RegisterReporter adds the reporter of the dependency name, replacing the previous one, if any.
+func (r *Registry) RegisterReporter(name string, reporter Reporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reporters[name] = reporter
+}
+
+// Check runs the checks concurrently, reusing the results cached within the TTL, and returns
+// the readiness report of every dependency.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make(map[string]*check, len(r.checks))
+	for name, c := range r.checks {
+		checks[name] = c
+	}
+	reporters := make(map[string]Reporter, len(r.reporters))
+	for name, reporter := range r.reporters {
+		reporters[name] = reporter
+	}
+	r.mu.RUnlock()
+
+	report := make(Report, len(checks)+len(reporters))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := r.run(ctx, c)
+			mu.Lock()
+			report[name] = status
+			mu.Unlock()
+		}()
+	}
+	for name, reporter := range reporters {
+		ready, details := reporter.Readiness()
+		mu.Lock()
+		report[name] = Status{Ready: ready, Details: details}
+		mu.Unlock()
+	}
+	wg.Wait()
+	return report
+}
+
+// run returns the cached result of the check, or runs it if the result expired.
+func (r *Registry) run(ctx context.Context, c *check) Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checkedAt.IsZero() && r.now().Sub(c.checkedAt) < r.ttl {
+		return c.status
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	// the checker runs apart, so that the timeout applies even if it ignores ctx.
+	done := make(chan error, 1)
+	go func() {
+		done <- c.checker.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", c.timeout)
+	}
+
+	status := Status{Ready: err == nil, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	// a probe that went away doesn't tell anything about the dependency.
+	if parent.Err() == nil {
+		c.status, c.checkedAt = status, r.now()
+	}
+	return status
+}
+
+// Startup tracks the initial load of the application for the startup probe. It's a Reporter,
+// so that the readiness probe fails until the initial load is complete as well.
+type Startup struct {
+	complete atomic.Bool
+}
+
+// Complete marks the initial load as complete.
+func (s *Startup) Complete() {
+	s.complete.Store(true)
+}
+
+// Completed reports whether the initial load is complete.
+func (s *Startup) Completed() bool {
+	return s.complete.Load()
+}
+
+// Readiness reports the Startup as ready once the initial load is complete.
+func (s *Startup) Readiness() (bool, any) {
+	return s.Completed(), nil
+}
@@ -0,0 +1,133 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"notification/internal/health"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingChecker counts the checks and fails with err.
+type countingChecker struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (c *countingChecker) Check(ctx context.Context) error {
+	c.calls.Add(1)
+	return c.err
+}
+
+type fakeReporter struct {
+	ready bool
+}
+
+func (f fakeReporter) Readiness() (bool, any) {
+	return f.ready, map[string]string{"state": "whatever"}
+}
+
+func TestRegistry_Check(t *testing.T) {
+	t.Run("every dependency is reported", func(t *testing.T) {
+		registry := health.NewRegistry()
+		registry.Register("redis", &countingChecker{})
+		registry.Register("smtp", &countingChecker{err: errors.New("connection refused")})
+		registry.RegisterReporter("mail", fakeReporter{ready: true})
+
+		report := registry.Check(context.Background())
+
+		assert.False(t, report.Ready())
+		assert.True(t, report["redis"].Ready)
+		assert.Empty(t, report["redis"].Error)
+		assert.False(t, report["smtp"].Ready)
+		assert.Equal(t, "connection refused", report["smtp"].Error)
+		assert.Equal(t, health.Status{Ready: true, Details: map[string]string{"state": "whatever"}}, report["mail"])
+	})
+
+	t.Run("checks run concurrently", func(t *testing.T) {
+		registry := health.NewRegistry()
+		slow := health.CheckerFunc(func(ctx context.Context) error {
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+		registry.Register("first", slow)
+		registry.Register("second", slow)
+		registry.Register("third", slow)
+
+		start := time.Now()
+		report := registry.Check(context.Background())
+
+		assert.True(t, report.Ready())
+		assert.Less(t, time.Since(start), 250*time.Millisecond)
+	})
+
+	t.Run("checks time out", func(t *testing.T) {
+		registry := health.NewRegistry(health.WithTimeout(time.Hour))
+		blocked := make(chan struct{})
+		defer close(blocked)
+		// the checker ignores ctx on purpose.
+		registry.Register("stuck", health.CheckerFunc(func(ctx context.Context) error {
+			<-blocked
+			return nil
+		}), health.WithCheckTimeout(20*time.Millisecond))
+
+		report := registry.Check(context.Background())
+
+		assert.False(t, report["stuck"].Ready)
+		assert.Contains(t, report["stuck"].Error, "timed out after 20ms")
+	})
+
+	t.Run("results are cached", func(t *testing.T) {
+		registry := health.NewRegistry(health.WithCacheTTL(time.Hour))
+		checker := &countingChecker{}
+		registry.Register("redis", checker)
+
+		for range 3 {
+			assert.True(t, registry.Check(context.Background()).Ready())
+		}
+		assert.EqualValues(t, 1, checker.calls.Load())
+	})
+
+	t.Run("caching is disabled", func(t *testing.T) {
+		registry := health.NewRegistry(health.WithCacheTTL(0))
+		checker := &countingChecker{}
+		registry.Register("redis", checker)
+
+		for range 3 {
+			registry.Check(context.Background())
+		}
+		assert.EqualValues(t, 3, checker.calls.Load())
+	})
+
+	t.Run("canceled probes aren't cached", func(t *testing.T) {
+		registry := health.NewRegistry(health.WithCacheTTL(time.Hour))
+		checker := &countingChecker{}
+		registry.Register("redis", health.CheckerFunc(func(ctx context.Context) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return checker.Check(ctx)
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.False(t, registry.Check(ctx).Ready())
+		assert.True(t, registry.Check(context.Background()).Ready())
+		assert.EqualValues(t, 1, checker.calls.Load())
+	})
+}
+
+func TestStartup(t *testing.T) {
+	startup := &health.Startup{}
+	ready, _ := startup.Readiness()
+	assert.False(t, ready)
+	assert.False(t, startup.Completed())
+
+	startup.Complete()
+
+	ready, _ = startup.Readiness()
+	assert.True(t, ready)
+	assert.True(t, startup.Completed())
+}
@@ -0,0 +1,121 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"strings"
+	"time"
+)
+
+// NewSQLAPIKeyRepository creates a new SQLAPIKeyRepository instance.
+func NewSQLAPIKeyRepository(db *sql.DB, dialect SQLDialect) *SQLAPIKeyRepository {
+	return &SQLAPIKeyRepository{db: db, dialect: dialect}
+}
+
+// SQLAPIKeyRepository is the SQL implementation of the API key repository, running on PostgreSQL or SQLite.
+// The scopes of each key are stored space separated.
+type SQLAPIKeyRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// Migrate applies the pending schema migrations.
+func (r SQLAPIKeyRepository) Migrate(ctx context.Context) error {
+	return migrate(ctx, r.db, r.dialect)
+}
+
+// Get retrieves an API key by its ID.
+func (r SQLAPIKeyRepository) Get(ctx context.Context, id string) (domain.APIKey, error) {
+	return r.get(ctx, "id", id)
+}
+
+// GetByHash retrieves an API key by the hash of the key.
+func (r SQLAPIKeyRepository) GetByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	return r.get(ctx, "hash", hash)
+}
+
+// get retrieves the API key whose column matches value.
+func (r SQLAPIKeyRepository) get(ctx context.Context, column, value string) (domain.APIKey, error) {
+	row := r.db.QueryRowContext(ctx,
+		r.dialect.rebind("SELECT id, name, tenant_id, hash, scopes, created_at FROM api_keys WHERE "+column+" = $1"), value)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIKey{}, repository.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return domain.APIKey{}, fmt.Errorf("query api key: %w", err)
+	}
+	return key, nil
+}
+
+// Save stores a given API key in the repository.
+func (r SQLAPIKeyRepository) Save(ctx context.Context, key domain.APIKey) error {
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	_, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(`
+INSERT INTO api_keys (id, name, tenant_id, hash, scopes, created_at) VALUES ($1, $2, $3, $4, $5, $6)`),
+		key.ID, key.Name, key.TenantID, key.Hash, strings.Join(scopes, " "), key.CreatedAt.UTC())
+	if isUniqueViolation(err) {
+		return repository.ErrAPIKeyAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("insert api key: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an API key by its ID, revoking it.
+func (r SQLAPIKeyRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM api_keys WHERE id = $1"), id)
+	if err != nil {
+		return fmt.Errorf("delete api key: %w", err)
+	}
+	return requireAffected(result, repository.ErrAPIKeyNotFound)
+}
+
+// List retrieves every API key, sorted by ID.
+func (r SQLAPIKeyRepository) List(ctx context.Context) ([]domain.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, name, tenant_id, hash, scopes, created_at FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]domain.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("read api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// scanAPIKey scans the id, name, tenant_id, hash, scopes and created_at columns of the row.
+func scanAPIKey(row interface{ Scan(...any) error }) (domain.APIKey, error) {
+	var (
+		key       domain.APIKey
+		scopes    string
+		createdAt time.Time
+	)
+	if err := row.Scan(&key.ID, &key.Name, &key.TenantID, &key.Hash, &scopes, &createdAt); err != nil {
+		return domain.APIKey{}, err
+	}
+	for _, scope := range strings.Fields(scopes) {
+		key.Scopes = append(key.Scopes, domain.Scope(scope))
+	}
+	key.CreatedAt = createdAt.UTC()
+	return key, nil
+}
@@ -0,0 +1,84 @@
+package infra_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/internal/repository"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newSQLiteAPIKeyRepository creates a SQLAPIKeyRepository on a fresh SQLite database.
+func newSQLiteAPIKeyRepository(t *testing.T) *infra.SQLAPIKeyRepository {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notification.db")
+	db, err := infra.OpenSQLDatabase(context.Background(), infra.SQLDialectSQLite, path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	repo := infra.NewSQLAPIKeyRepository(db, infra.SQLDialectSQLite)
+	require.NoError(t, repo.Migrate(context.Background()))
+	return repo
+}
+
+func TestSQLAPIKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	key := domain.APIKey{
+		ID:        "a1",
+		Name:      "billing-service",
+		TenantID:  "billing",
+		Hash:      "hash-1",
+		Scopes:    []domain.Scope{"send:status", "admin:rules"},
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("api key is saved", func(t *testing.T) {
+		repo := newSQLiteAPIKeyRepository(t)
+		require.NoError(t, repo.Save(ctx, key))
+
+		got, err := repo.Get(ctx, "a1")
+		require.NoError(t, err)
+		assert.Equal(t, key, got)
+		got, err = repo.GetByHash(ctx, "hash-1")
+		require.NoError(t, err)
+		assert.Equal(t, key, got)
+	})
+
+	t.Run("api key is not saved: conflicting ID or hash", func(t *testing.T) {
+		repo := newSQLiteAPIKeyRepository(t)
+		require.NoError(t, repo.Save(ctx, key))
+
+		sameID := key
+		sameID.Hash = "hash-2"
+		assert.ErrorIs(t, repo.Save(ctx, sameID), repository.ErrAPIKeyAlreadyExists)
+		sameHash := key
+		sameHash.ID = "a2"
+		assert.ErrorIs(t, repo.Save(ctx, sameHash), repository.ErrAPIKeyAlreadyExists)
+	})
+
+	t.Run("api keys are listed by ID", func(t *testing.T) {
+		repo := newSQLiteAPIKeyRepository(t)
+		other := domain.APIKey{ID: "a0", Name: "crm", Hash: "hash-0",
+			Scopes: []domain.Scope{domain.ScopeAll}, CreatedAt: key.CreatedAt}
+		require.NoError(t, repo.Save(ctx, key))
+		require.NoError(t, repo.Save(ctx, other))
+
+		keys, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.APIKey{other, key}, keys)
+	})
+
+	t.Run("api key is deleted", func(t *testing.T) {
+		repo := newSQLiteAPIKeyRepository(t)
+		require.NoError(t, repo.Save(ctx, key))
+
+		require.NoError(t, repo.Delete(ctx, "a1"))
+		_, err := repo.GetByHash(ctx, "hash-1")
+		assert.ErrorIs(t, err, repository.ErrAPIKeyNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, "a1"), repository.ErrAPIKeyNotFound)
+	})
+}
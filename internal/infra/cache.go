@@ -2,8 +2,12 @@ package infra
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"notification/internal/tracing"
 	"time"
 )
 
@@ -72,8 +76,11 @@ type RedisCache struct {
 }
 
 // Incr increments the integer in key by 1 with a TTL defined by expiration on Redis.
-func (r RedisCache) Incr(ctx context.Context, key string, expiration time.Duration) error {
-	_, err := r.client.Incr(ctx, key).Result()
+func (r RedisCache) Incr(ctx context.Context, key string, expiration time.Duration) (err error) {
+	ctx, span := startRedisSpan(ctx, "INCR")
+	defer func() { tracing.End(span, err) }()
+
+	_, err = r.client.Incr(ctx, key).Result()
 	if err != nil {
 		return fmt.Errorf("redis incr: %w", err)
 	}
@@ -87,7 +94,10 @@ func (r RedisCache) Incr(ctx context.Context, key string, expiration time.Durati
 }
 
 // Decr decrements the integer in key by 1 on Redis.
-func (r RedisCache) Decr(ctx context.Context, key string) error {
+func (r RedisCache) Decr(ctx context.Context, key string) (err error) {
+	ctx, span := startRedisSpan(ctx, "DECR")
+	defer func() { tracing.End(span, err) }()
+
 	count, err := r.client.Decr(ctx, key).Result()
 	if err != nil {
 		return fmt.Errorf("redis decr: %w", err)
@@ -106,15 +116,49 @@ func (r RedisCache) Decr(ctx context.Context, key string) error {
 
 // Get retrieves the value for the given cache key on Redis.
 func (r RedisCache) Get(ctx context.Context, key string) string {
-	return r.client.Get(ctx, key).Val()
+	ctx, span := startRedisSpan(ctx, "GET")
+	value, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		// a missing key isn't a failure.
+		err = nil
+	}
+	tracing.End(span, err)
+	return value
 }
 
 // Set sets a new key/value pair to the Redis cache.
-func (r RedisCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+func (r RedisCache) Set(ctx context.Context, key string, value string, expiration time.Duration) (err error) {
+	ctx, span := startRedisSpan(ctx, "SET")
+	defer func() { tracing.End(span, err) }()
+
 	return r.client.Set(ctx, key, value, expiration).Err()
 }
 
+// TTL retrieves the time to live left of the given cache key on Redis. It's zero if the key
+// doesn't exist or doesn't expire.
+func (r RedisCache) TTL(ctx context.Context, key string) (ttl time.Duration, err error) {
+	ctx, span := startRedisSpan(ctx, "PTTL")
+	defer func() { tracing.End(span, err) }()
+
+	ttl, err = r.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("redis pttl: %w", err)
+	}
+	// the negative values report a missing key or a key without expiration.
+	return max(ttl, 0), nil
+}
+
 // Ping checks if Redis connection is healthy.
-func (r RedisCache) Ping(ctx context.Context) error {
+func (r RedisCache) Ping(ctx context.Context) (err error) {
+	ctx, span := startRedisSpan(ctx, "PING")
+	defer func() { tracing.End(span, err) }()
+
 	return r.client.Ping(ctx).Err()
 }
+
+// startRedisSpan starts the client span of a Redis operation. The keys aren't recorded,
+// as they hold user IDs and correlation IDs.
+func startRedisSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(operation)))
+}
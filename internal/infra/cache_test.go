@@ -2,10 +2,15 @@ package infra_test
 
 import (
 	"context"
+	"errors"
 	"github.com/go-redis/redismock/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"notification/internal/infra"
+	"notification/internal/tracing/tracingtest"
 	"testing"
 	"time"
 )
@@ -79,3 +84,59 @@ func TestRedisCache_Decr(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestRedisCache_TTL(t *testing.T) {
+	t.Run("key expiring", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectPTTL("foo").SetVal(40 * time.Second)
+
+		ttl, err := infra.NewRedisCache(infra.WithClient(db)).TTL(context.Background(), "foo")
+		require.NoError(t, err)
+		assert.Equal(t, 40*time.Second, ttl)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		// Redis replies -2 to a missing key, which go-redis returns as is.
+		mock.ExpectPTTL("foo").SetVal(-2)
+
+		ttl, err := infra.NewRedisCache(infra.WithClient(db)).TTL(context.Background(), "foo")
+		require.NoError(t, err)
+		assert.Zero(t, ttl)
+	})
+
+	t.Run("redis failure", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectPTTL("foo").SetErr(errors.New("connection refused"))
+
+		_, err := infra.NewRedisCache(infra.WithClient(db)).TTL(context.Background(), "foo")
+		assert.Error(t, err)
+	})
+}
+
+func TestRedisCache_Tracing(t *testing.T) {
+	exporter := tracingtest.Setup(t)
+	db, mock := redismock.NewClientMock()
+	defer db.Close()
+	redisCache := infra.NewRedisCache(infra.WithClient(db))
+
+	mock.ExpectGet("missing").RedisNil()
+	mock.ExpectIncr("foo").SetErr(errors.New("connection refused"))
+
+	assert.Empty(t, redisCache.Get(context.Background(), "missing"))
+	assert.Error(t, redisCache.Incr(context.Background(), "foo", time.Hour))
+
+	spans := exporter.GetSpans()
+	require.Equal(t, []string{"GET", "INCR"}, tracingtest.SpanNames(spans))
+	for _, span := range spans {
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+		assert.Contains(t, span.Attributes, attribute.String("db.system", "redis"))
+	}
+	// a missing key isn't a failure.
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,301 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewRedisDeliveryRepository creates a new RedisDeliveryRepository instance keeping
+// the delivery records for the given retention period.
+func NewRedisDeliveryRepository(client *redis.Client, retention time.Duration) *RedisDeliveryRepository {
+	return &RedisDeliveryRepository{
+		client:    client,
+		retention: retention,
+	}
+}
+
+// RedisDeliveryRepository is the Redis implementation of the delivery tracking store.
+//
+// Each record is stored in a hash, along with a list of its transitions, and indexed
+// by user in a sorted set scored by the record creation time, and by provider message ID.
+// Every key expires after the retention period.
+//
+// The records of each tenant, the default one included, are under the "delivery:tenant:<tenant ID>:"
+// prefix. The records of the default tenant stored before the tenants were introduced, under the
+// "delivery:" prefix, are still read until they expire. The provider message IDs are global, so their
+// index holds the tenant along with the correlation ID.
+type RedisDeliveryRepository struct {
+	client    *redis.Client
+	retention time.Duration
+}
+
+// redisTransition is the JSON representation of a transition in the transitions list.
+type redisTransition struct {
+	Status string    `json:"status"`
+	Detail string    `json:"detail,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// AddTransition appends a transition to the delivery record of a notification,
+// creating the record if it doesn't exist yet.
+func (r RedisDeliveryRepository) AddTransition(ctx context.Context, record domain.DeliveryRecord,
+	transition domain.StatusTransition) error {
+	encoded, err := json.Marshal(redisTransition{
+		Status: transition.Status.String(),
+		Detail: transition.Detail,
+		At:     transition.At.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("encode transition: %w", err)
+	}
+
+	tenantID := repository.TenantFromContext(ctx)
+	key := deliveryKey(tenantID, record.CorrelationID)
+	transitionsKey := deliveryTransitionsKey(tenantID, record.CorrelationID)
+	at := transition.At.UTC().Format(time.RFC3339Nano)
+	fields := []any{
+		"userId", record.UserID,
+		"type", record.Type.String(),
+		"status", transition.Status.String(),
+		"updatedAt", at,
+	}
+	if record.ProviderMessageID != "" {
+		fields = append(fields, "providerMessageId", record.ProviderMessageID)
+	}
+	if record.CallbackURL != "" {
+		fields = append(fields, "callbackUrl", record.CallbackURL)
+	}
+	if record.Caller != "" {
+		fields = append(fields, "caller", record.Caller)
+	}
+	userKey := deliveryUserKey(tenantID, record.UserID)
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSetNX(ctx, key, "createdAt", at)
+		pipe.HSet(ctx, key, fields...)
+		pipe.RPush(ctx, transitionsKey, encoded)
+		pipe.ZAddNX(ctx, userKey, redis.Z{
+			Score:  float64(transition.At.UnixMilli()),
+			Member: record.CorrelationID,
+		})
+		// drop the index entries of the records which have already expired.
+		pipe.ZRemRangeByScore(ctx, userKey, "-inf",
+			strconv.FormatInt(transition.At.Add(-r.retention).UnixMilli(), 10))
+		pipe.Expire(ctx, key, r.retention)
+		pipe.Expire(ctx, transitionsKey, r.retention)
+		pipe.Expire(ctx, userKey, r.retention)
+		if record.ProviderMessageID != "" {
+			pipe.Set(ctx, deliveryMessageKey(record.ProviderMessageID),
+				encodeMessageIndex(tenantID, record.CorrelationID), r.retention)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis add transition: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves the delivery record of a notification by its correlation ID.
+func (r RedisDeliveryRepository) Get(ctx context.Context, correlationID string) (domain.DeliveryRecord, error) {
+	var (
+		fields      *redis.MapStringStringCmd
+		transitions *redis.StringSliceCmd
+	)
+	tenantID := repository.TenantFromContext(ctx)
+	prefixes := []string{deliveryPrefix(tenantID)}
+	if tenantID == domain.DefaultTenantID {
+		prefixes = append(prefixes, legacyDeliveryPrefix)
+	}
+	for _, prefix := range prefixes {
+		_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			fields = pipe.HGetAll(ctx, prefix+"record:"+correlationID)
+			transitions = pipe.LRange(ctx, prefix+"transitions:"+correlationID, 0, -1)
+			return nil
+		})
+		if err != nil {
+			return domain.DeliveryRecord{}, fmt.Errorf("redis get delivery: %w", err)
+		}
+		if len(fields.Val()) > 0 {
+			return decodeRedisDelivery(tenantID, correlationID, fields.Val(), transitions.Val())
+		}
+	}
+	return domain.DeliveryRecord{}, repository.ErrDeliveryNotFound
+}
+
+// GetByProviderMessageID retrieves the delivery record of a notification by the message ID
+// reported by the mail provider, whatever its tenant.
+func (r RedisDeliveryRepository) GetByProviderMessageID(ctx context.Context,
+	messageID string) (domain.DeliveryRecord, error) {
+	index, err := r.client.Get(ctx, deliveryMessageKey(messageID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return domain.DeliveryRecord{}, repository.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return domain.DeliveryRecord{}, fmt.Errorf("redis get delivery by message id: %w", err)
+	}
+	tenantID, correlationID := decodeMessageIndex(index)
+	return r.Get(repository.WithTenant(ctx, tenantID), correlationID)
+}
+
+// ListByUser retrieves up to limit delivery records of a user, the most recent first.
+func (r RedisDeliveryRepository) ListByUser(ctx context.Context, userID string,
+	limit int) ([]domain.DeliveryRecord, error) {
+	stop := int64(limit) - 1
+	if limit <= 0 {
+		stop = -1
+	}
+	tenantID := repository.TenantFromContext(ctx)
+	var ids []string
+	var err error
+	if tenantID == domain.DefaultTenantID {
+		ids, err = r.listDefaultTenantIDs(ctx, userID, stop)
+	} else {
+		ids, err = r.client.ZRevRange(ctx, deliveryUserKey(tenantID, userID), 0, stop).Result()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis list deliveries: %w", err)
+	}
+
+	records := make([]domain.DeliveryRecord, 0, len(ids))
+	for _, id := range ids {
+		record, err := r.Get(ctx, id)
+		if errors.Is(err, repository.ErrDeliveryNotFound) {
+			// the record expired before its index entry.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// listDefaultTenantIDs returns the correlation IDs of up to stop+1 records of a user of the default
+// tenant, the most recent first, merging the ones indexed before the tenants were introduced.
+func (r RedisDeliveryRepository) listDefaultTenantIDs(ctx context.Context, userID string, stop int64) ([]string, error) {
+	var current, legacy *redis.ZSliceCmd
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		current = pipe.ZRevRangeWithScores(ctx, deliveryUserKey(domain.DefaultTenantID, userID), 0, stop)
+		legacy = pipe.ZRevRangeWithScores(ctx, legacyDeliveryPrefix+"user:"+userID, 0, stop)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := append(current.Val(), legacy.Val()...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Score > entries[j].Score
+	})
+	ids := make([]string, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if stop >= 0 && int64(len(ids)) > stop {
+			break
+		}
+		id, _ := entry.Member.(string)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func decodeRedisDelivery(tenantID, correlationID string, fields map[string]string,
+	transitions []string) (domain.DeliveryRecord, error) {
+	record := domain.DeliveryRecord{
+		CorrelationID:     correlationID,
+		TenantID:          tenantID,
+		UserID:            fields["userId"],
+		ProviderMessageID: fields["providerMessageId"],
+		CallbackURL:       fields["callbackUrl"],
+		Caller:            fields["caller"],
+	}
+	// the notification type is informative, so an unknown value isn't an error.
+	record.Type, _ = domain.ToNotificationType(fields["type"])
+
+	var err error
+	if record.Status, err = domain.ToDeliveryStatus(fields["status"]); err != nil {
+		return domain.DeliveryRecord{}, err
+	}
+	if record.CreatedAt, err = time.Parse(time.RFC3339Nano, fields["createdAt"]); err != nil {
+		return domain.DeliveryRecord{}, fmt.Errorf("decode createdAt: %w", err)
+	}
+	if record.UpdatedAt, err = time.Parse(time.RFC3339Nano, fields["updatedAt"]); err != nil {
+		return domain.DeliveryRecord{}, fmt.Errorf("decode updatedAt: %w", err)
+	}
+
+	for _, encoded := range transitions {
+		var t redisTransition
+		if err := json.Unmarshal([]byte(encoded), &t); err != nil {
+			return domain.DeliveryRecord{}, fmt.Errorf("decode transition: %w", err)
+		}
+		status, err := domain.ToDeliveryStatus(t.Status)
+		if err != nil {
+			return domain.DeliveryRecord{}, err
+		}
+		record.Transitions = append(record.Transitions, domain.StatusTransition{
+			Status: status,
+			Detail: t.Detail,
+			At:     t.At,
+		})
+	}
+	return record, nil
+}
+
+const (
+	// messageIndexSeparator separates the tenant from the correlation ID in the provider message ID index.
+	messageIndexSeparator = "\x1f"
+	// legacyDeliveryPrefix is the prefix of the delivery keys stored before the tenants were introduced,
+	// which are the ones of the default tenant.
+	legacyDeliveryPrefix = "delivery:"
+)
+
+// deliveryPrefix returns the prefix of the delivery keys of the tenant. The tenant IDs have no
+// colons, so the keys of a tenant can't be addressed by the IDs chosen by the clients of another.
+func deliveryPrefix(tenantID string) string {
+	return "delivery:tenant:" + tenantID + ":"
+}
+
+func deliveryKey(tenantID, correlationID string) string {
+	return deliveryPrefix(tenantID) + "record:" + correlationID
+}
+
+func deliveryTransitionsKey(tenantID, correlationID string) string {
+	return deliveryPrefix(tenantID) + "transitions:" + correlationID
+}
+
+func deliveryUserKey(tenantID, userID string) string {
+	return deliveryPrefix(tenantID) + "user:" + userID
+}
+
+func deliveryMessageKey(messageID string) string {
+	return "delivery:message:" + messageID
+}
+
+// encodeMessageIndex returns the provider message ID index entry of the record of the tenant.
+func encodeMessageIndex(tenantID, correlationID string) string {
+	return tenantID + messageIndexSeparator + correlationID
+}
+
+// decodeMessageIndex returns the tenant and correlation ID of a provider message ID index entry.
+// The entries stored before the tenants were introduced are the bare correlation IDs of the
+// records of the default tenant.
+func decodeMessageIndex(index string) (tenantID, correlationID string) {
+	if tenantID, correlationID, ok := strings.Cut(index, messageIndexSeparator); ok {
+		return tenantID, correlationID
+	}
+	return domain.DefaultTenantID, index
+}
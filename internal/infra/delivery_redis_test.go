@@ -0,0 +1,303 @@
+package infra_test
+
+import (
+	"context"
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/internal/repository"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRedisDeliveryRepository_AddTransition(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	defer db.Close()
+
+	at := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	retention := 24 * time.Hour
+
+	mock.ExpectTxPipeline()
+	mock.ExpectHSetNX("delivery:tenant:default:record:c1", "createdAt", "2026-10-19T10:00:00Z").SetVal(true)
+	mock.ExpectHSet("delivery:tenant:default:record:c1",
+		"userId", "u1",
+		"type", "news",
+		"status", "sent",
+		"updatedAt", "2026-10-19T10:00:00Z",
+		"providerMessageId", "m1",
+		"callbackUrl", "https://example.com/callback",
+		"caller", "k1").SetVal(7)
+	mock.ExpectRPush("delivery:tenant:default:transitions:c1",
+		[]byte(`{"status":"sent","at":"2026-10-19T10:00:00Z"}`)).SetVal(1)
+	mock.ExpectZAddNX("delivery:tenant:default:user:u1", redis.Z{Score: float64(at.UnixMilli()), Member: "c1"}).SetVal(1)
+	mock.ExpectZRemRangeByScore("delivery:tenant:default:user:u1", "-inf",
+		strconv.FormatInt(at.Add(-retention).UnixMilli(), 10)).SetVal(0)
+	mock.ExpectExpire("delivery:tenant:default:record:c1", retention).SetVal(true)
+	mock.ExpectExpire("delivery:tenant:default:transitions:c1", retention).SetVal(true)
+	mock.ExpectExpire("delivery:tenant:default:user:u1", retention).SetVal(true)
+	mock.ExpectSet("delivery:message:m1", "default\x1fc1", retention).SetVal("OK")
+	mock.ExpectTxPipelineExec()
+
+	repo := infra.NewRedisDeliveryRepository(db, retention)
+	err := repo.AddTransition(context.Background(),
+		domain.DeliveryRecord{
+			CorrelationID:     "c1",
+			UserID:            "u1",
+			Type:              domain.News,
+			ProviderMessageID: "m1",
+			CallbackURL:       "https://example.com/callback",
+			Caller:            "k1",
+		},
+		domain.StatusTransition{Status: domain.DeliverySent, At: at})
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedisDeliveryRepository_AddTransition_Tenant(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	defer db.Close()
+
+	at := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	retention := 24 * time.Hour
+
+	mock.ExpectTxPipeline()
+	mock.ExpectHSetNX("delivery:tenant:billing:record:c1", "createdAt", "2026-10-19T10:00:00Z").SetVal(true)
+	mock.ExpectHSet("delivery:tenant:billing:record:c1",
+		"userId", "u1",
+		"type", "news",
+		"status", "sent",
+		"updatedAt", "2026-10-19T10:00:00Z",
+		"providerMessageId", "m1").SetVal(5)
+	mock.ExpectRPush("delivery:tenant:billing:transitions:c1",
+		[]byte(`{"status":"sent","at":"2026-10-19T10:00:00Z"}`)).SetVal(1)
+	mock.ExpectZAddNX("delivery:tenant:billing:user:u1", redis.Z{Score: float64(at.UnixMilli()), Member: "c1"}).SetVal(1)
+	mock.ExpectZRemRangeByScore("delivery:tenant:billing:user:u1", "-inf",
+		strconv.FormatInt(at.Add(-retention).UnixMilli(), 10)).SetVal(0)
+	mock.ExpectExpire("delivery:tenant:billing:record:c1", retention).SetVal(true)
+	mock.ExpectExpire("delivery:tenant:billing:transitions:c1", retention).SetVal(true)
+	mock.ExpectExpire("delivery:tenant:billing:user:u1", retention).SetVal(true)
+	mock.ExpectSet("delivery:message:m1", "billing\x1fc1", retention).SetVal("OK")
+	mock.ExpectTxPipelineExec()
+
+	repo := infra.NewRedisDeliveryRepository(db, retention)
+	err := repo.AddTransition(repository.WithTenant(context.Background(), "billing"),
+		domain.DeliveryRecord{CorrelationID: "c1", UserID: "u1", Type: domain.News, ProviderMessageID: "m1"},
+		domain.StatusTransition{Status: domain.DeliverySent, At: at})
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedisDeliveryRepository_Get(t *testing.T) {
+	t.Run("record is decoded", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+
+		mock.ExpectHGetAll("delivery:tenant:default:record:c1").SetVal(map[string]string{
+			"userId":            "u1",
+			"type":              "news",
+			"status":            "sent",
+			"providerMessageId": "m1",
+			"createdAt":         "2026-10-19T10:00:00Z",
+			"updatedAt":         "2026-10-19T10:00:01Z",
+		})
+		mock.ExpectLRange("delivery:tenant:default:transitions:c1", 0, -1).SetVal([]string{
+			`{"status":"accepted","at":"2026-10-19T10:00:00Z"}`,
+			`{"status":"sent","at":"2026-10-19T10:00:01Z"}`,
+		})
+
+		repo := infra.NewRedisDeliveryRepository(db, time.Hour)
+		got, err := repo.Get(context.Background(), "c1")
+		require.NoError(t, err)
+
+		start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+		assert.Equal(t, domain.DeliveryRecord{
+			CorrelationID:     "c1",
+			TenantID:          "default",
+			UserID:            "u1",
+			Type:              domain.News,
+			Status:            domain.DeliverySent,
+			ProviderMessageID: "m1",
+			CreatedAt:         start,
+			UpdatedAt:         start.Add(time.Second),
+			Transitions: []domain.StatusTransition{
+				{Status: domain.DeliveryAccepted, At: start},
+				{Status: domain.DeliverySent, At: start.Add(time.Second)},
+			},
+		}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("record of another tenant", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+
+		mock.ExpectHGetAll("delivery:tenant:billing:record:c1").SetVal(map[string]string{})
+		mock.ExpectLRange("delivery:tenant:billing:transitions:c1", 0, -1).SetVal(nil)
+
+		repo := infra.NewRedisDeliveryRepository(db, time.Hour)
+		_, err := repo.Get(repository.WithTenant(context.Background(), "billing"), "c1")
+		assert.ErrorIs(t, err, repository.ErrDeliveryNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing record", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+
+		mock.ExpectHGetAll("delivery:tenant:default:record:c1").SetVal(map[string]string{})
+		mock.ExpectLRange("delivery:tenant:default:transitions:c1", 0, -1).SetVal(nil)
+		mock.ExpectHGetAll("delivery:record:c1").SetVal(map[string]string{})
+		mock.ExpectLRange("delivery:transitions:c1", 0, -1).SetVal(nil)
+
+		repo := infra.NewRedisDeliveryRepository(db, time.Hour)
+		_, err := repo.Get(context.Background(), "c1")
+		assert.ErrorIs(t, err, repository.ErrDeliveryNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("tenant named after a key kind", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+
+		// the record c1 of the default tenant isn't reachable through the tenant "record"
+		mock.ExpectHGetAll("delivery:tenant:record:record:c1").SetVal(map[string]string{})
+		mock.ExpectLRange("delivery:tenant:record:transitions:c1", 0, -1).SetVal(nil)
+
+		repo := infra.NewRedisDeliveryRepository(db, time.Hour)
+		_, err := repo.Get(repository.WithTenant(context.Background(), "record"), "c1")
+		assert.ErrorIs(t, err, repository.ErrDeliveryNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRedisDeliveryRepository_GetByProviderMessageID(t *testing.T) {
+	t.Run("record stored before the tenants is found", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+
+		mock.ExpectGet("delivery:message:m1").SetVal("c1")
+		mock.ExpectHGetAll("delivery:tenant:default:record:c1").SetVal(map[string]string{})
+		mock.ExpectLRange("delivery:tenant:default:transitions:c1", 0, -1).SetVal(nil)
+		mock.ExpectHGetAll("delivery:record:c1").SetVal(map[string]string{
+			"userId":      "u1",
+			"status":      "sent",
+			"callbackUrl": "https://example.com/callback",
+			"caller":      "k1",
+			"createdAt":   "2026-10-19T10:00:00Z",
+			"updatedAt":   "2026-10-19T10:00:00Z",
+		})
+		mock.ExpectLRange("delivery:transitions:c1", 0, -1).SetVal(nil)
+
+		repo := infra.NewRedisDeliveryRepository(db, time.Hour)
+		got, err := repo.GetByProviderMessageID(context.Background(), "m1")
+		require.NoError(t, err)
+		assert.Equal(t, "c1", got.CorrelationID)
+		assert.Equal(t, "https://example.com/callback", got.CallbackURL)
+		assert.Equal(t, "k1", got.Caller)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("record of another tenant is found", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+
+		mock.ExpectGet("delivery:message:m1").SetVal("billing\x1fc1")
+		mock.ExpectHGetAll("delivery:tenant:billing:record:c1").SetVal(map[string]string{
+			"userId":    "u1",
+			"status":    "sent",
+			"createdAt": "2026-10-19T10:00:00Z",
+			"updatedAt": "2026-10-19T10:00:00Z",
+		})
+		mock.ExpectLRange("delivery:tenant:billing:transitions:c1", 0, -1).SetVal(nil)
+
+		repo := infra.NewRedisDeliveryRepository(db, time.Hour)
+		got, err := repo.GetByProviderMessageID(context.Background(), "m1")
+		require.NoError(t, err)
+		assert.Equal(t, "c1", got.CorrelationID)
+		assert.Equal(t, "billing", got.TenantID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown message ID", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+
+		mock.ExpectGet("delivery:message:m1").RedisNil()
+
+		repo := infra.NewRedisDeliveryRepository(db, time.Hour)
+		_, err := repo.GetByProviderMessageID(context.Background(), "m1")
+		assert.ErrorIs(t, err, repository.ErrDeliveryNotFound)
+	})
+}
+
+func TestRedisDeliveryRepository_ListByUser(t *testing.T) {
+	t.Run("records of the default tenant", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+
+		mock.ExpectZRevRangeWithScores("delivery:tenant:default:user:u1", 0, 1).SetVal([]redis.Z{
+			{Score: 3, Member: "c3"},
+			{Score: 1, Member: "c1"},
+		})
+		// c2 was stored before the tenants were introduced
+		mock.ExpectZRevRangeWithScores("delivery:user:u1", 0, 1).SetVal([]redis.Z{
+			{Score: 2, Member: "c2"},
+		})
+		mock.ExpectHGetAll("delivery:tenant:default:record:c3").SetVal(map[string]string{
+			"userId":    "u1",
+			"status":    "accepted",
+			"createdAt": "2026-10-19T10:00:00Z",
+			"updatedAt": "2026-10-19T10:00:00Z",
+		})
+		mock.ExpectLRange("delivery:tenant:default:transitions:c3", 0, -1).SetVal(nil)
+		mock.ExpectHGetAll("delivery:tenant:default:record:c2").SetVal(map[string]string{})
+		mock.ExpectLRange("delivery:tenant:default:transitions:c2", 0, -1).SetVal(nil)
+		mock.ExpectHGetAll("delivery:record:c2").SetVal(map[string]string{
+			"userId":    "u1",
+			"status":    "sent",
+			"createdAt": "2026-10-19T09:00:00Z",
+			"updatedAt": "2026-10-19T09:00:00Z",
+		})
+		mock.ExpectLRange("delivery:transitions:c2", 0, -1).SetVal(nil)
+
+		repo := infra.NewRedisDeliveryRepository(db, time.Hour)
+		got, err := repo.ListByUser(context.Background(), "u1", 2)
+		require.NoError(t, err)
+
+		require.Len(t, got, 2)
+		assert.Equal(t, "c3", got[0].CorrelationID)
+		assert.Equal(t, "c2", got[1].CorrelationID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("records of another tenant", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+
+		mock.ExpectZRevRange("delivery:tenant:billing:user:u1", 0, 9).SetVal([]string{"c2", "c1"})
+		mock.ExpectHGetAll("delivery:tenant:billing:record:c2").SetVal(map[string]string{
+			"userId":    "u1",
+			"status":    "accepted",
+			"createdAt": "2026-10-19T10:00:00Z",
+			"updatedAt": "2026-10-19T10:00:00Z",
+		})
+		mock.ExpectLRange("delivery:tenant:billing:transitions:c2", 0, -1).SetVal(nil)
+		// c1 has already expired
+		mock.ExpectHGetAll("delivery:tenant:billing:record:c1").SetVal(map[string]string{})
+		mock.ExpectLRange("delivery:tenant:billing:transitions:c1", 0, -1).SetVal(nil)
+
+		repo := infra.NewRedisDeliveryRepository(db, time.Hour)
+		got, err := repo.ListByUser(repository.WithTenant(context.Background(), "billing"), "u1", 10)
+		require.NoError(t, err)
+
+		require.Len(t, got, 1)
+		assert.Equal(t, "c2", got[0].CorrelationID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
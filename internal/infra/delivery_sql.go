@@ -0,0 +1,189 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"time"
+)
+
+// deliveryColumns are the columns selected by the delivery queries, see scanDeliveries.
+const deliveryColumns = `d.correlation_id, d.tenant_id, d.user_id, d.type, d.status, d.provider_message_id, d.callback_url,
+	d.caller, d.created_at, d.updated_at, t.status, t.detail, t.at`
+
+// NewSQLDeliveryRepository creates a new SQLDeliveryRepository instance.
+func NewSQLDeliveryRepository(db *sql.DB, dialect SQLDialect) *SQLDeliveryRepository {
+	return &SQLDeliveryRepository{db: db, dialect: dialect}
+}
+
+// SQLDeliveryRepository is the SQL implementation of the delivery tracking store, running on PostgreSQL
+// or SQLite. The records are keyed by tenant and correlation ID.
+type SQLDeliveryRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// Migrate applies the pending schema migrations.
+func (r SQLDeliveryRepository) Migrate(ctx context.Context) error {
+	return migrate(ctx, r.db, r.dialect)
+}
+
+// AddTransition appends a transition to the delivery record of a notification,
+// creating the record if it doesn't exist yet.
+func (r SQLDeliveryRepository) AddTransition(ctx context.Context, record domain.DeliveryRecord,
+	transition domain.StatusTransition) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	tenantID := repository.TenantFromContext(ctx)
+	_, err = tx.ExecContext(ctx, r.dialect.rebind(`
+INSERT INTO notification_deliveries
+	(correlation_id, tenant_id, user_id, type, status, provider_message_id, callback_url, caller, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+ON CONFLICT (tenant_id, correlation_id) DO UPDATE SET
+	status = EXCLUDED.status,
+	updated_at = EXCLUDED.updated_at,
+	provider_message_id = CASE
+		WHEN EXCLUDED.provider_message_id <> '' THEN EXCLUDED.provider_message_id
+		ELSE notification_deliveries.provider_message_id
+	END,
+	callback_url = CASE
+		WHEN EXCLUDED.callback_url <> '' THEN EXCLUDED.callback_url
+		ELSE notification_deliveries.callback_url
+	END,
+	caller = CASE
+		WHEN EXCLUDED.caller <> '' THEN EXCLUDED.caller
+		ELSE notification_deliveries.caller
+	END`),
+		record.CorrelationID, tenantID, record.UserID, record.Type.String(),
+		transition.Status.String(), record.ProviderMessageID, record.CallbackURL, record.Caller, transition.At.UTC())
+	if err != nil {
+		return fmt.Errorf("upsert delivery: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, r.dialect.rebind(`
+INSERT INTO notification_delivery_transitions (tenant_id, correlation_id, status, detail, at)
+VALUES ($1, $2, $3, $4, $5)`),
+		tenantID, record.CorrelationID, transition.Status.String(), transition.Detail, transition.At.UTC())
+	if err != nil {
+		return fmt.Errorf("insert transition: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves the delivery record of a notification by its correlation ID.
+func (r SQLDeliveryRepository) Get(ctx context.Context, correlationID string) (domain.DeliveryRecord, error) {
+	return r.getWhere(ctx, "d.correlation_id = $1 AND d.tenant_id = $2",
+		correlationID, repository.TenantFromContext(ctx))
+}
+
+// GetByProviderMessageID retrieves the delivery record of a notification by the message ID
+// reported by the mail provider, whatever its tenant.
+func (r SQLDeliveryRepository) GetByProviderMessageID(ctx context.Context,
+	messageID string) (domain.DeliveryRecord, error) {
+	return r.getWhere(ctx, "d.provider_message_id = $1", messageID)
+}
+
+// getWhere retrieves the delivery record matching the given condition.
+func (r SQLDeliveryRepository) getWhere(ctx context.Context, condition string,
+	args ...any) (domain.DeliveryRecord, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(`
+SELECT `+deliveryColumns+`
+FROM notification_deliveries d
+JOIN notification_delivery_transitions t ON t.tenant_id = d.tenant_id AND t.correlation_id = d.correlation_id
+WHERE `+condition+`
+ORDER BY d.tenant_id, t.id`), args...)
+	if err != nil {
+		return domain.DeliveryRecord{}, fmt.Errorf("query delivery: %w", err)
+	}
+
+	records, err := scanDeliveries(rows)
+	if err != nil {
+		return domain.DeliveryRecord{}, err
+	}
+	if len(records) == 0 {
+		return domain.DeliveryRecord{}, repository.ErrDeliveryNotFound
+	}
+	return records[0], nil
+}
+
+// ListByUser retrieves up to limit delivery records of a user, the most recent first.
+func (r SQLDeliveryRepository) ListByUser(ctx context.Context, userID string,
+	limit int) ([]domain.DeliveryRecord, error) {
+	sqlLimit := r.dialect.noLimit()
+	if limit > 0 {
+		sqlLimit = fmt.Sprint(limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(`
+SELECT `+deliveryColumns+`
+FROM (
+	SELECT * FROM notification_deliveries
+	WHERE tenant_id = $1 AND user_id = $2
+	ORDER BY created_at DESC
+	LIMIT `+sqlLimit+`
+) d
+JOIN notification_delivery_transitions t ON t.tenant_id = d.tenant_id AND t.correlation_id = d.correlation_id
+ORDER BY d.created_at DESC, d.correlation_id, t.id`), repository.TenantFromContext(ctx), userID)
+	if err != nil {
+		return nil, fmt.Errorf("query deliveries: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+// scanDeliveries groups the rows of the delivery/transition join into delivery records,
+// expecting the rows of the same record to be contiguous.
+func scanDeliveries(rows *sql.Rows) ([]domain.DeliveryRecord, error) {
+	defer rows.Close()
+
+	var records []domain.DeliveryRecord
+	for rows.Next() {
+		var (
+			record                   domain.DeliveryRecord
+			transition               domain.StatusTransition
+			recordType, recordStatus string
+			transitionStatus         string
+			createdAt, updatedAt     time.Time
+			transitionAt             time.Time
+		)
+		err := rows.Scan(&record.CorrelationID, &record.TenantID, &record.UserID, &recordType, &recordStatus,
+			&record.ProviderMessageID, &record.CallbackURL, &record.Caller, &createdAt, &updatedAt,
+			&transitionStatus, &transition.Detail, &transitionAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan delivery: %w", err)
+		}
+		if transition.Status, err = domain.ToDeliveryStatus(transitionStatus); err != nil {
+			return nil, err
+		}
+		transition.At = transitionAt.UTC()
+
+		if n := len(records); n > 0 && records[n-1].TenantID == record.TenantID &&
+			records[n-1].CorrelationID == record.CorrelationID {
+			records[n-1].Transitions = append(records[n-1].Transitions, transition)
+			continue
+		}
+
+		// the notification type is informative, so an unknown value isn't an error.
+		record.Type, _ = domain.ToNotificationType(recordType)
+		if record.Status, err = domain.ToDeliveryStatus(recordStatus); err != nil {
+			return nil, err
+		}
+		record.CreatedAt = createdAt.UTC()
+		record.UpdatedAt = updatedAt.UTC()
+		record.Transitions = []domain.StatusTransition{transition}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read deliveries: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,216 @@
+package infra_test
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/internal/repository"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var deliveryColumns = []string{
+	"correlation_id", "tenant_id", "user_id", "type", "status", "provider_message_id", "callback_url",
+	"caller", "created_at", "updated_at", "status", "detail", "at",
+}
+
+func TestSQLDeliveryRepository_AddTransition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	at := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO notification_deliveries").
+		WithArgs("c1", "default", "u1", "news", "failed", "", "https://example.com/callback", "k1", at).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO notification_delivery_transitions").
+		WithArgs("default", "c1", "failed", "mailbox unavailable", at).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	repo := infra.NewSQLDeliveryRepository(db, infra.SQLDialectPostgres)
+	err = repo.AddTransition(context.Background(),
+		domain.DeliveryRecord{
+			CorrelationID: "c1",
+			UserID:        "u1",
+			Type:          domain.News,
+			CallbackURL:   "https://example.com/callback",
+			Caller:        "k1",
+		},
+		domain.StatusTransition{Status: domain.DeliveryFailed, Detail: "mailbox unavailable", At: at})
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLDeliveryRepository_AddTransition_Tenant(t *testing.T) {
+	at := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	record := domain.DeliveryRecord{CorrelationID: "c1", UserID: "u1", Type: domain.News}
+	transition := domain.StatusTransition{Status: domain.DeliveryAccepted, At: at}
+
+	t.Run("record is stored for the tenant", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO notification_deliveries").
+			WithArgs("c1", "billing", "u1", "news", "accepted", "", "", "", at).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO notification_delivery_transitions").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		repo := infra.NewSQLDeliveryRepository(db, infra.SQLDialectPostgres)
+		require.NoError(t, repo.AddTransition(repository.WithTenant(context.Background(), "billing"), record, transition))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("correlation ID is used by another tenant", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notification.db")
+		db, err := infra.OpenSQLDatabase(context.Background(), infra.SQLDialectSQLite, path)
+		require.NoError(t, err)
+		defer db.Close()
+
+		repo := infra.NewSQLDeliveryRepository(db, infra.SQLDialectSQLite)
+		require.NoError(t, repo.Migrate(context.Background()))
+		billing := repository.WithTenant(context.Background(), "billing")
+		require.NoError(t, repo.AddTransition(context.Background(), record, transition))
+		require.NoError(t, repo.AddTransition(billing, record, transition))
+		require.NoError(t, repo.AddTransition(billing, record,
+			domain.StatusTransition{Status: domain.DeliverySent, At: at.Add(time.Second)}))
+
+		got, err := repo.Get(context.Background(), "c1")
+		require.NoError(t, err)
+		assert.Equal(t, domain.DeliveryAccepted, got.Status)
+		assert.Len(t, got.Transitions, 1)
+
+		got, err = repo.Get(billing, "c1")
+		require.NoError(t, err)
+		assert.Equal(t, "billing", got.TenantID)
+		assert.Equal(t, domain.DeliverySent, got.Status)
+		assert.Len(t, got.Transitions, 2)
+
+		records, err := repo.ListByUser(billing, "u1", 0)
+		require.NoError(t, err)
+		assert.Len(t, records, 1)
+	})
+}
+
+func TestSQLDeliveryRepository_Get(t *testing.T) {
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	t.Run("record is grouped with its transitions", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT (.+) FROM notification_deliveries d").
+			WithArgs("c1", "default").
+			WillReturnRows(sqlmock.NewRows(deliveryColumns).
+				AddRow("c1", "default", "u1", "news", "sent", "m1", "", "k1", start, start.Add(time.Second), "accepted", "", start).
+				AddRow("c1", "default", "u1", "news", "sent", "m1", "", "k1", start, start.Add(time.Second), "sent", "", start.Add(time.Second)))
+
+		repo := infra.NewSQLDeliveryRepository(db, infra.SQLDialectPostgres)
+		got, err := repo.Get(context.Background(), "c1")
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.DeliveryRecord{
+			CorrelationID:     "c1",
+			TenantID:          "default",
+			UserID:            "u1",
+			Type:              domain.News,
+			Status:            domain.DeliverySent,
+			ProviderMessageID: "m1",
+			Caller:            "k1",
+			CreatedAt:         start,
+			UpdatedAt:         start.Add(time.Second),
+			Transitions: []domain.StatusTransition{
+				{Status: domain.DeliveryAccepted, At: start},
+				{Status: domain.DeliverySent, At: start.Add(time.Second)},
+			},
+		}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("record of another tenant", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery("WHERE d.correlation_id = \\$1 AND d.tenant_id = \\$2").
+			WithArgs("c1", "billing").
+			WillReturnRows(sqlmock.NewRows(deliveryColumns))
+
+		repo := infra.NewSQLDeliveryRepository(db, infra.SQLDialectPostgres)
+		_, err = repo.Get(repository.WithTenant(context.Background(), "billing"), "c1")
+		assert.ErrorIs(t, err, repository.ErrDeliveryNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing record", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT (.+) FROM notification_deliveries d").
+			WithArgs("c1", "default").
+			WillReturnRows(sqlmock.NewRows(deliveryColumns))
+
+		repo := infra.NewSQLDeliveryRepository(db, infra.SQLDialectPostgres)
+		_, err = repo.Get(context.Background(), "c1")
+		assert.ErrorIs(t, err, repository.ErrDeliveryNotFound)
+	})
+}
+
+func TestSQLDeliveryRepository_GetByProviderMessageID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("WHERE d.provider_message_id = \\$1").
+		WithArgs("m1").
+		WillReturnRows(sqlmock.NewRows(deliveryColumns).
+			AddRow("c1", "billing", "u1", "news", "sent", "m1", "https://example.com/callback", "", start, start, "sent", "", start))
+
+	repo := infra.NewSQLDeliveryRepository(db, infra.SQLDialectPostgres)
+	got, err := repo.GetByProviderMessageID(context.Background(), "m1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "c1", got.CorrelationID)
+	assert.Equal(t, "billing", got.TenantID)
+	assert.Equal(t, "https://example.com/callback", got.CallbackURL)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLDeliveryRepository_ListByUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM \\(").
+		WithArgs("default", "u1").
+		WillReturnRows(sqlmock.NewRows(deliveryColumns).
+			AddRow("c2", "default", "u1", "news", "accepted", "", "", "", start.Add(time.Minute), start.Add(time.Minute), "accepted", "", start.Add(time.Minute)).
+			AddRow("c1", "default", "u1", "news", "sent", "", "", "", start, start, "accepted", "", start).
+			AddRow("c1", "default", "u1", "news", "sent", "", "", "", start, start, "sent", "", start))
+
+	repo := infra.NewSQLDeliveryRepository(db, infra.SQLDialectPostgres)
+	got, err := repo.ListByUser(context.Background(), "u1", 10)
+	require.NoError(t, err)
+
+	require.Len(t, got, 2)
+	assert.Equal(t, "c2", got[0].CorrelationID)
+	assert.Len(t, got[0].Transitions, 1)
+	assert.Equal(t, "c1", got[1].CorrelationID)
+	assert.Len(t, got[1].Transitions, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
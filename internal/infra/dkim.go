@@ -0,0 +1,282 @@
+package infra
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultDKIMHeaders is the default list of headers covered by the DKIM signature.
+var DefaultDKIMHeaders = []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"}
+
+// ErrUnsupportedKey is the error when the DKIM private key type is neither RSA nor Ed25519.
+var ErrUnsupportedKey = errors.New("unsupported DKIM key type")
+
+// WithDKIMSigner signs every outgoing message with the given DKIMSigner.
+func WithDKIMSigner(signer *DKIMSigner) SMTPMailerOption {
+	return func(mailer *SMTPMailer) {
+		mailer.dkimSigner = signer
+	}
+}
+
+// NewDKIMSigner creates a new DKIMSigner instance for the given signing domain and selector.
+// The key must be either an *rsa.PrivateKey (rsa-sha256) or an ed25519.PrivateKey (ed25519-sha256).
+// If headers is empty, DefaultDKIMHeaders is used.
+func NewDKIMSigner(domain, selector string, key crypto.Signer, headers []string) (*DKIMSigner, error) {
+	if domain == "" || selector == "" {
+		return nil, errors.New("DKIM domain and selector are required")
+	}
+
+	var algorithm string
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		algorithm = "rsa-sha256"
+	case ed25519.PrivateKey:
+		algorithm = "ed25519-sha256"
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	if len(headers) == 0 {
+		headers = DefaultDKIMHeaders
+	}
+	if !containsHeader(headers, "From") {
+		// RFC 6376 section 5.4: the From header field MUST be signed.
+		headers = append([]string{"From"}, headers...)
+	}
+
+	return &DKIMSigner{
+		domain:    domain,
+		selector:  selector,
+		key:       key,
+		algorithm: algorithm,
+		headers:   headers,
+		now:       time.Now,
+	}, nil
+}
+
+// DKIMSigner signs email messages according to RFC 6376 using
+// the relaxed/relaxed canonicalization.
+type DKIMSigner struct {
+	domain    string
+	selector  string
+	key       crypto.Signer
+	algorithm string
+	headers   []string
+	now       func() time.Time
+}
+
+// Aligned reports whether the domain of the sender address is aligned with the signing domain,
+// i.e. it's the signing domain or one of its subdomains (DMARC relaxed alignment), so that the
+// signature authenticates the sender.
+func (s DKIMSigner) Aligned(from string) bool {
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return false
+	}
+	_, domain, _ := strings.Cut(strings.ToLower(address.Address), "@")
+	signing := strings.ToLower(s.domain)
+	return domain == signing || strings.HasSuffix(domain, "."+signing)
+}
+
+// LoadDKIMPrivateKey reads a PEM encoded RSA (PKCS#1 or PKCS#8) or Ed25519 (PKCS#8) private key file.
+func LoadDKIMPrivateKey(path string) (crypto.Signer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read DKIM key file: %w", err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("no PEM block found in DKIM key file")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse DKIM key: %w", err)
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, ErrUnsupportedKey
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKey, block.Type)
+	}
+}
+
+// Sign returns the message with a DKIM-Signature header prepended.
+// The message must use CRLF line endings.
+func (s DKIMSigner) Sign(msg []byte) ([]byte, error) {
+	rawHeaders, body, ok := bytes.Cut(msg, []byte("\r\n\r\n"))
+	if !ok {
+		return nil, errors.New("message has no header/body separator")
+	}
+	headers := splitHeaders(string(rawHeaders) + "\r\n")
+
+	bodyHash := sha256.Sum256(canonicalizeBodyRelaxed(body))
+
+	signedNames := make([]string, 0, len(s.headers))
+	var signedData strings.Builder
+	// headers with multiple instances are signed from the bottom up (RFC 6376 section 5.4.2).
+	used := make(map[string]int)
+	for _, name := range s.headers {
+		key := strings.ToLower(name)
+		header, found := pickHeader(headers, key, used[key])
+		if !found {
+			continue
+		}
+		used[key]++
+		signedNames = append(signedNames, key)
+		signedData.WriteString(canonicalizeHeaderRelaxed(header))
+	}
+
+	dkimHeader := fmt.Sprintf(
+		"DKIM-Signature: v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d;\r\n"+
+			"\th=%s;\r\n"+
+			"\tbh=%s;\r\n"+
+			"\tb=",
+		s.algorithm, s.domain, s.selector, s.now().Unix(),
+		strings.Join(signedNames, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]),
+	)
+	// the DKIM-Signature header itself is signed with an empty "b=" tag
+	// and without the trailing CRLF.
+	signedData.WriteString(strings.TrimSuffix(canonicalizeHeaderRelaxed(dkimHeader), "\r\n"))
+
+	signature, err := s.signData([]byte(signedData.String()))
+	if err != nil {
+		return nil, fmt.Errorf("dkim sign: %w", err)
+	}
+
+	var signed bytes.Buffer
+	signed.WriteString(dkimHeader)
+	signed.WriteString(foldBase64(base64.StdEncoding.EncodeToString(signature)))
+	signed.WriteString("\r\n")
+	signed.Write(msg)
+	return signed.Bytes(), nil
+}
+
+func (s DKIMSigner) signData(data []byte) ([]byte, error) {
+	hash := sha256.Sum256(data)
+	switch s.algorithm {
+	case "ed25519-sha256":
+		// RFC 8463: the SHA-256 hash is signed with PureEd25519.
+		return s.key.Sign(rand.Reader, hash[:], crypto.Hash(0))
+	default:
+		return s.key.Sign(rand.Reader, hash[:], crypto.SHA256)
+	}
+}
+
+// splitHeaders splits the raw header block into header fields, keeping
+// folded continuation lines together with their header.
+func splitHeaders(raw string) []string {
+	var headers []string
+	for _, line := range strings.SplitAfter(raw, "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(headers) > 0 {
+			headers[len(headers)-1] += line
+			continue
+		}
+		headers = append(headers, line)
+	}
+	return headers
+}
+
+// pickHeader returns the instance of the header named key, counting from the
+// bottom of the header block and skipping the first skip instances.
+func pickHeader(headers []string, key string, skip int) (string, bool) {
+	for i := len(headers) - 1; i >= 0; i-- {
+		name, _, ok := strings.Cut(headers[i], ":")
+		if !ok || strings.ToLower(strings.TrimSpace(name)) != key {
+			continue
+		}
+		if skip == 0 {
+			return headers[i], true
+		}
+		skip--
+	}
+	return "", false
+}
+
+// canonicalizeHeaderRelaxed applies the "relaxed" header canonicalization (RFC 6376 section 3.4.2).
+func canonicalizeHeaderRelaxed(header string) string {
+	name, value, _ := strings.Cut(header, ":")
+	name = strings.ToLower(strings.TrimSpace(name))
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = strings.Join(strings.FieldsFunc(value, isWSP), " ")
+	return name + ":" + value + "\r\n"
+}
+
+// canonicalizeBodyRelaxed applies the "relaxed" body canonicalization (RFC 6376 section 3.4.4).
+func canonicalizeBodyRelaxed(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		// reduce whitespace sequences to a single space and drop trailing whitespace.
+		var b strings.Builder
+		inWSP := false
+		for _, r := range line {
+			if isWSP(r) {
+				inWSP = true
+				continue
+			}
+			if inWSP {
+				b.WriteByte(' ')
+			}
+			inWSP = false
+			b.WriteRune(r)
+		}
+		lines[i] = b.String()
+	}
+	// ignore all empty lines at the end of the body.
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+func isWSP(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func containsHeader(headers []string, name string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// foldBase64 folds long base64 values so the header lines stay within the recommended length.
+func foldBase64(s string) string {
+	const width = 72
+	var b strings.Builder
+	for len(s) > width {
+		b.WriteString(s[:width])
+		b.WriteString("\r\n\t")
+		s = s[width:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
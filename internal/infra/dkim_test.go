@@ -0,0 +1,289 @@
+package infra_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/infra"
+	"notification/internal/service"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const testMessage = "From: no-reply@example.com\r\n" +
+	"To: john@example.com\r\n" +
+	"Subject:  Status:   there's a new status update\r\n" +
+	"Date: Mon, 19 Oct 2026 10:00:00 +0000\r\n" +
+	"\r\n" +
+	"Hey   there!  \r\n" +
+	"\r\n" +
+	"\r\n"
+
+func TestDKIMSigner_Sign(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublicKey, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		key           crypto.Signer
+		publicKey     crypto.PublicKey
+		wantAlgorithm string
+	}{
+		{
+			name:          "RSA-SHA256",
+			key:           rsaKey,
+			publicKey:     &rsaKey.PublicKey,
+			wantAlgorithm: "rsa-sha256",
+		},
+		{
+			name:          "Ed25519-SHA256",
+			key:           edKey,
+			publicKey:     edPublicKey,
+			wantAlgorithm: "ed25519-sha256",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := infra.NewDKIMSigner("example.com", "mail", tt.key, []string{"To", "Subject", "Date"})
+			require.NoError(t, err)
+
+			signed, err := signer.Sign([]byte(testMessage))
+			require.NoError(t, err)
+
+			tags := parseDKIMSignature(t, string(signed))
+			assert.Equal(t, tt.wantAlgorithm, tags["a"])
+			assert.Equal(t, "example.com", tags["d"])
+			assert.Equal(t, "mail", tags["s"])
+
+			t.Run("from header is always signed", func(t *testing.T) {
+				assert.Equal(t, "from:to:subject:date", tags["h"])
+			})
+
+			t.Run("signature is valid", func(t *testing.T) {
+				assert.NoError(t, verifyDKIM(string(signed), tt.publicKey))
+			})
+
+			t.Run("tampered body is detected", func(t *testing.T) {
+				tampered := strings.Replace(string(signed), "Hey", "Bye", 1)
+				assert.Error(t, verifyDKIM(tampered, tt.publicKey))
+			})
+
+			t.Run("tampered header is detected", func(t *testing.T) {
+				tampered := strings.Replace(string(signed), "To: john@", "To: jane@", 1)
+				assert.Error(t, verifyDKIM(tampered, tt.publicKey))
+			})
+		})
+	}
+
+	t.Run("unsupported key", func(t *testing.T) {
+		_, err := infra.NewDKIMSigner("example.com", "mail", nil, nil)
+		assert.ErrorIs(t, err, infra.ErrUnsupportedKey)
+	})
+}
+
+func TestDKIMSigner_Aligned(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := infra.NewDKIMSigner("example.com", "mail", edKey, nil)
+	require.NoError(t, err)
+
+	assert.True(t, signer.Aligned("no-reply@example.com"))
+	assert.True(t, signer.Aligned("Billing <billing@Billing.Example.com>"))
+	assert.False(t, signer.Aligned("no-reply@notexample.com"))
+	assert.False(t, signer.Aligned("no-reply@example.com.attacker.io"))
+	assert.False(t, signer.Aligned("not an address"))
+}
+
+func TestLoadDKIMPrivateKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	rsaPath := filepath.Join(dir, "rsa.pem")
+	require.NoError(t, os.WriteFile(rsaPath, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+	}), 0600))
+	edPath := filepath.Join(dir, "ed25519.pem")
+	require.NoError(t, os.WriteFile(edPath, pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: edDER,
+	}), 0600))
+
+	t.Run("PKCS#1 RSA key", func(t *testing.T) {
+		key, err := infra.LoadDKIMPrivateKey(rsaPath)
+		require.NoError(t, err)
+		assert.IsType(t, &rsa.PrivateKey{}, key)
+	})
+
+	t.Run("PKCS#8 Ed25519 key", func(t *testing.T) {
+		key, err := infra.LoadDKIMPrivateKey(edPath)
+		require.NoError(t, err)
+		assert.IsType(t, ed25519.PrivateKey{}, key)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := infra.LoadDKIMPrivateKey(filepath.Join(dir, "missing.pem"))
+		assert.Error(t, err)
+	})
+}
+
+func TestSMTPMailer_SendEmail_DKIM(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := infra.NewDKIMSigner("example.com", "mail", edKey, nil)
+	require.NoError(t, err)
+
+	server := newFakeSMTPServer(t)
+	mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com",
+		infra.WithDKIMSigner(signer),
+		infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1}))
+	defer mailer.Close()
+
+	require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Len(t, server.data, 1)
+	assert.True(t, strings.HasPrefix(server.data[0], "DKIM-Signature: "))
+	assert.NoError(t, verifyDKIM(server.data[0], edKey.Public()))
+}
+
+func TestSMTPMailer_SendEmail_DKIMNotAligned(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := infra.NewDKIMSigner("example.com", "mail", edKey, nil)
+	require.NoError(t, err)
+
+	server := newFakeSMTPServer(t)
+	mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com", infra.WithDKIMSigner(signer))
+
+	ctx := service.WithSender(context.Background(), "Billing <billing@example.org>")
+	_, err = mailer.SendEmailContext(ctx, "john@example.com", "Hi", "Hey there!")
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Len(t, server.data, 1)
+	assert.NotContains(t, server.data[0], "DKIM-Signature:")
+}
+
+// parseDKIMSignature returns the tags of the DKIM-Signature header.
+func parseDKIMSignature(t *testing.T, msg string) map[string]string {
+	header, _ := splitSignatureHeader(msg)
+	require.NotEmpty(t, header)
+
+	_, value, _ := strings.Cut(header, ":")
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(value, ";") {
+		k, v, ok := strings.Cut(tag, "=")
+		if !ok {
+			continue
+		}
+		tags[strings.TrimSpace(k)] = strings.Join(strings.Fields(v), "")
+	}
+	return tags
+}
+
+// splitSignatureHeader returns the (folded) DKIM-Signature header and the rest of the message.
+func splitSignatureHeader(msg string) (string, string) {
+	if !strings.HasPrefix(msg, "DKIM-Signature:") {
+		return "", msg
+	}
+	end := 0
+	for {
+		i := strings.Index(msg[end:], "\r\n")
+		end += i + 2
+		if end >= len(msg) || (msg[end] != ' ' && msg[end] != '\t') {
+			break
+		}
+	}
+	return msg[:end], msg[end:]
+}
+
+var wsp = regexp.MustCompile(`[ \t]+`)
+
+// verifyDKIM is a minimal relaxed/relaxed DKIM verifier used to check the signer's output.
+func verifyDKIM(msg string, publicKey crypto.PublicKey) error {
+	sigHeader, rest := splitSignatureHeader(msg)
+	if sigHeader == "" {
+		return assert.AnError
+	}
+	tags := make(map[string]string)
+	_, value, _ := strings.Cut(sigHeader, ":")
+	for _, tag := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(tag, "=")
+		tags[strings.TrimSpace(k)] = strings.Join(strings.Fields(v), "")
+	}
+
+	rawHeaders, body, _ := strings.Cut(rest, "\r\n\r\n")
+
+	// relaxed body
+	lines := strings.Split(body, "\r\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(wsp.ReplaceAllString(lines[i], " "), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	canonicalBody := strings.Join(lines, "\r\n") + "\r\n"
+	bodyHash := sha256.Sum256([]byte(canonicalBody))
+	if base64.StdEncoding.EncodeToString(bodyHash[:]) != tags["bh"] {
+		return assert.AnError
+	}
+
+	relaxedHeader := func(h string) string {
+		name, value, _ := strings.Cut(h, ":")
+		value = strings.TrimSpace(wsp.ReplaceAllString(strings.ReplaceAll(value, "\r\n", ""), " "))
+		return strings.ToLower(strings.TrimSpace(name)) + ":" + value
+	}
+
+	var data strings.Builder
+	headerLines := strings.Split(rawHeaders, "\r\n")
+	for _, name := range strings.Split(tags["h"], ":") {
+		for i := len(headerLines) - 1; i >= 0; i-- {
+			if strings.HasPrefix(strings.ToLower(headerLines[i]), name+":") {
+				data.WriteString(relaxedHeader(headerLines[i]) + "\r\n")
+				break
+			}
+		}
+	}
+	// the signature header is hashed with an empty b= value.
+	unsigned := regexp.MustCompile(`b=[A-Za-z0-9+/=\s]*$`).ReplaceAllString(strings.TrimRight(sigHeader, "\r\n"), "b=")
+	data.WriteString(relaxedHeader(unsigned))
+
+	signature, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(data.String()))
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, hash[:], signature) {
+			return assert.AnError
+		}
+		return nil
+	default:
+		return assert.AnError
+	}
+}
@@ -1,9 +1,27 @@
 package infra
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"mime"
+	"net"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"notification/internal/logging"
+	"notification/internal/service"
+	"notification/internal/tracing"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // NewSMTPMailer instantiates a new SMTPMailer.
@@ -11,11 +29,16 @@ func NewSMTPMailer(address, from string, opts ...SMTPMailerOption) *SMTPMailer {
 	mailer := SMTPMailer{
 		address: address,
 		from:    from,
+		timeout: 30 * time.Second,
+		logger:  logging.Default(),
 	}
 
 	for _, opt := range opts {
 		opt(&mailer)
 	}
+	if mailer.poolConfig != nil {
+		mailer.pool = newSMTPPool(*mailer.poolConfig, mailer.timeout, mailer.dial, mailer.logger)
+	}
 
 	return &mailer
 }
@@ -25,33 +48,215 @@ type SMTPMailer struct {
 	address string
 	from    string
 	auth    smtp.Auth
+	timeout time.Duration
+
+	dkimSigner *DKIMSigner
+
+	poolConfig *PoolConfig
+	pool       *smtpPool
+
+	logger zerolog.Logger
 }
 
 // SendEmail sends the email message through SMTP integration.
 func (m SMTPMailer) SendEmail(to string, subject string, msg string) error {
-	log.Print("sending email through SMTP")
-	defer log.Print("email sending finished")
+	_, err := m.SendEmailWithID(to, subject, msg)
+	return err
+}
+
+// SendEmailWithID sends the email message through SMTP integration
+// and returns the Message-ID header of the message.
+func (m SMTPMailer) SendEmailWithID(to string, subject string, msg string) (string, error) {
+	return m.SendEmailContext(context.Background(), to, subject, msg)
+}
+
+// SendEmailContext is SendEmailWithID tracing the SMTP exchange as a child span of ctx.
+// The message is sent from the sender address carried by ctx, if any, see service.WithSender.
+func (m SMTPMailer) SendEmailContext(ctx context.Context,
+	to string, subject string, msg string) (messageID string, err error) {
+	_, span := tracing.Tracer().Start(ctx, "SMTP send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(smtpServerAttributes(m.address)...),
+		trace.WithAttributes(attribute.Bool("smtp.pooled", m.pool != nil)))
+	defer func() { tracing.End(span, err) }()
+
+	m.logger.Debug().Str("recipient", logging.RedactEmail(to)).Msg("sending email through SMTP")
+
+	from := service.SenderFromContext(ctx, m.from)
+	composedMsg, messageID, err := m.composeMessage(from, to, subject, msg)
+	if err != nil {
+		return "", fmt.Errorf("compose message: %w", err)
+	}
+
+	if m.dkimSigner != nil && m.dkimSigner.Aligned(from) {
+		composedMsg, err = m.dkimSigner.Sign(composedMsg)
+		if err != nil {
+			return "", err
+		}
+	} else if m.dkimSigner != nil {
+		// a signature for another domain doesn't pass DMARC, and could be taken as spoofing.
+		m.logger.Warn().Str("sender", logging.RedactEmail(envelopeAddress(from))).
+			Msg("the sender domain isn't aligned with the DKIM domain, sending the email unsigned")
+	}
+
+	if m.pool != nil {
+		return messageID, classifySMTPError(m.sendPooled(envelopeAddress(from), to, composedMsg))
+	}
 
-	composedMsg := []byte(fmt.Sprintf(
-		`
-To: %s
-Subject: %s
+	return messageID, classifySMTPError(m.sendOnce(envelopeAddress(from), to, composedMsg))
+}
+
+// classifySMTPError classifies the failure of an SMTP exchange: the 5xx replies to the RCPT
+// and DATA commands, which reject the recipient or the message, are permanent failures
+// (service.ErrMailPermanent). Anything else, 4xx replies, I/O errors and the failures of the
+// server such as rejected credentials included, are failures of the provider (service.ErrMailRetryable).
+func classifySMTPError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var rejection *messageRejection
+	var protoErr *textproto.Error
+	if errors.As(err, &rejection) && errors.As(err, &protoErr) &&
+		protoErr.Code >= 500 && !isAuthFailureCode(protoErr.Code) {
+		return errors.Join(service.ErrMailPermanent, err)
+	}
+	return errors.Join(service.ErrMailRetryable, err)
+}
+
+// messageRejection is the failure of the RCPT or DATA command, which is about the recipient
+// or the message rather than the session.
+type messageRejection struct {
+	err error
+}
 
-%s
-`, to, subject, msg,
-	))
+func (e *messageRejection) Error() string {
+	return e.err.Error()
+}
 
-	var auth smtp.Auth
-	if m.auth != nil {
-		auth = m.auth
+func (e *messageRejection) Unwrap() error {
+	return e.err
+}
+
+// isAuthFailureCode reports whether the SMTP reply code means the session isn't authenticated
+// as required (530), the mechanism is too weak (534) or the credentials are invalid (535).
+func isAuthFailureCode(code int) bool {
+	return code == 530 || code == 534 || code == 535
+}
+
+// envelopeAddress returns the bare address of the From header value, which may have a display name,
+// for the SMTP envelope.
+func envelopeAddress(from string) string {
+	if address, err := mail.ParseAddress(from); err == nil {
+		return address.Address
 	}
+	return from
+}
 
-	return smtp.SendMail(m.address, auth, m.from, []string{to}, composedMsg)
+// smtpServerAttributes returns the span attributes of the SMTP server address.
+func smtpServerAttributes(address string) []attribute.KeyValue {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return []attribute.KeyValue{semconv.ServerAddress(address)}
+	}
+	attrs := []attribute.KeyValue{semconv.ServerAddress(host)}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, semconv.ServerPort(p))
+	}
+	return attrs
+}
+
+// composeMessage builds the MIME message with CRLF line endings, returning it along with its Message-ID.
+func (m SMTPMailer) composeMessage(from, to, subject, msg string) ([]byte, string, error) {
+	messageID, err := newMessageID(from)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: %s\r\n", messageID)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return b.Bytes(), messageID, nil
+}
+
+// newMessageID generates a unique Message-ID using the domain of the from address.
+func newMessageID(from string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("generate message id: %w", err)
+	}
+	domain := "localhost"
+	if _, d, ok := strings.Cut(from, "@"); ok && d != "" {
+		domain = strings.TrimSuffix(d, ">")
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), domain), nil
+}
+
+// Check probes the SMTP server without sending anything: it opens a session, greets the server
+// with EHLO and sends NOOP, so that the readiness probe detects unreachable or unhealthy servers.
+// It doesn't authenticate, to keep the probe cheap.
+func (m SMTPMailer) Check(ctx context.Context) error {
+	host, _, err := net.SplitHostPort(m.address)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address: %w", err)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.address)
+	if err != nil {
+		return fmt.Errorf("smtp dial: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("smtp greeting: %w", err)
+	}
+	defer client.Close()
+	if err = client.Hello("localhost"); err != nil {
+		return fmt.Errorf("smtp ehlo: %w", err)
+	}
+	if err = client.Noop(); err != nil {
+		return fmt.Errorf("smtp noop: %w", err)
+	}
+	return client.Quit()
+}
+
+// Close quits the pooled SMTP connections, if any.
+func (m SMTPMailer) Close() {
+	if m.pool != nil {
+		m.pool.close()
+	}
 }
 
 // SMTPMailerOption defines the optional params for SMTPMailer.
 type SMTPMailerOption func(*SMTPMailer)
 
+// WithSMTPLogger sets the logger of the SMTPMailer. Defaults to logging.Default().
+func WithSMTPLogger(logger zerolog.Logger) SMTPMailerOption {
+	return func(mailer *SMTPMailer) {
+		mailer.logger = logger
+	}
+}
+
+// WithSMTPTimeout sets how long the SMTPMailer waits for the connection to the server and for each
+// exchange with it, e.g. a whole message transaction, before giving up. Zero means no timeout.
+// Defaults to 30 seconds.
+func WithSMTPTimeout(timeout time.Duration) SMTPMailerOption {
+	return func(mailer *SMTPMailer) {
+		mailer.timeout = timeout
+	}
+}
+
 // WithAuth optionally adds authentication capabilities to the mail sending mechanism.
 // It's basically a wrapper for smtp.PlainAuth so refer to its documentation as reference on
 // how to configure.
@@ -0,0 +1,166 @@
+package infra
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrUnencryptedConnection is the error when an authentication mechanism that sends
+	// credentials in the clear is attempted over a connection without TLS.
+	ErrUnencryptedConnection = errors.New("unencrypted connection")
+	// ErrWrongHostName is the error when the SMTP server name doesn't match the
+	// host the authentication mechanism was configured for.
+	ErrWrongHostName = errors.New("wrong host name")
+)
+
+// TokenSource supplies OAuth2 access tokens for the XOAUTH2 authentication mechanism.
+type TokenSource interface {
+	// Token returns a valid access token.
+	Token() (string, error)
+}
+
+// StaticTokenSource is a TokenSource that always returns the same token.
+type StaticTokenSource string
+
+// Token returns the static token.
+func (s StaticTokenSource) Token() (string, error) {
+	if s == "" {
+		return "", errors.New("empty token")
+	}
+	return string(s), nil
+}
+
+// FileTokenSource is a TokenSource that reads the token from a file on every call,
+// so that tokens rotated on disk (e.g. Kubernetes secrets) are picked up without a restart.
+type FileTokenSource string
+
+// Token reads the token from the file.
+func (f FileTokenSource) Token() (string, error) {
+	content, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", fmt.Errorf("read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", f)
+	}
+	return token, nil
+}
+
+// WithCRAMMD5Auth authenticates using the CRAM-MD5 mechanism.
+// It's a wrapper for smtp.CRAMMD5Auth so refer to its documentation as reference on
+// how to configure.
+func WithCRAMMD5Auth(username, secret string) SMTPMailerOption {
+	return func(mailer *SMTPMailer) {
+		mailer.auth = smtp.CRAMMD5Auth(username, secret)
+	}
+}
+
+// WithLoginAuth authenticates using the LOGIN mechanism, required by
+// some relays that don't support PLAIN.
+//
+// It only works over TLS, so make sure that the SMTP connection is encrypted before using this option.
+func WithLoginAuth(username, password, host string) SMTPMailerOption {
+	return func(mailer *SMTPMailer) {
+		mailer.auth = &loginAuth{
+			username: username,
+			password: password,
+			host:     host,
+		}
+	}
+}
+
+// WithXOAUTH2Auth authenticates using the XOAUTH2 mechanism, fetching
+// a fresh access token from tokenSource on every authentication attempt.
+//
+// It only works over TLS, so make sure that the SMTP connection is encrypted before using this option.
+func WithXOAUTH2Auth(username string, tokenSource TokenSource, host string) SMTPMailerOption {
+	return func(mailer *SMTPMailer) {
+		mailer.auth = &xoauth2Auth{
+			username:    username,
+			tokenSource: tokenSource,
+			host:        host,
+		}
+	}
+}
+
+// loginAuth implements the LOGIN authentication mechanism.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+// Start begins the LOGIN authentication with the server.
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if err := checkServer(server, a.host); err != nil {
+		return "", nil, err
+	}
+	return "LOGIN", nil, nil
+}
+
+// Next answers the username and password prompts of the server.
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	prompt := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.HasPrefix(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "password"):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
+}
+
+// xoauth2Auth implements the XOAUTH2 authentication mechanism.
+type xoauth2Auth struct {
+	username    string
+	tokenSource TokenSource
+	host        string
+}
+
+// Start begins the XOAUTH2 authentication sending the initial client response.
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if err := checkServer(server, a.host); err != nil {
+		return "", nil, err
+	}
+	token, err := a.tokenSource.Token()
+	if err != nil {
+		return "", nil, fmt.Errorf("get oauth2 token: %w", err)
+	}
+	resp := fmt.Sprintf("user=%s\x01auth=Bearer %s\x01\x01", a.username, token)
+	return "XOAUTH2", []byte(resp), nil
+}
+
+// Next handles the server challenge. When authentication fails the server
+// sends an error payload, which must be acknowledged with an empty response
+// so that the server can reply with the final error status.
+func (a *xoauth2Auth) Next(_ []byte, more bool) ([]byte, error) {
+	if more {
+		return []byte{}, nil
+	}
+	return nil, nil
+}
+
+// checkServer ensures credentials are only sent over TLS (or to localhost)
+// and to the expected host, mirroring the checks done by smtp.PlainAuth.
+func checkServer(server *smtp.ServerInfo, host string) error {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return ErrUnencryptedConnection
+	}
+	if server.Name != host {
+		return ErrWrongHostName
+	}
+	return nil
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
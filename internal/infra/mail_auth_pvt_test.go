@@ -0,0 +1,92 @@
+package infra
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithLoginAuth(t *testing.T) {
+	mailer := NewSMTPMailer("mail.example.com:587", "no-reply@example.com",
+		WithLoginAuth("john", "secret", "mail.example.com"))
+	require.IsType(t, &loginAuth{}, mailer.auth)
+
+	t.Run("start requires TLS", func(t *testing.T) {
+		_, _, err := mailer.auth.Start(&smtp.ServerInfo{Name: "mail.example.com"})
+		assert.ErrorIs(t, err, ErrUnencryptedConnection)
+	})
+
+	t.Run("start requires the configured host", func(t *testing.T) {
+		_, _, err := mailer.auth.Start(&smtp.ServerInfo{Name: "evil.example.com", TLS: true})
+		assert.ErrorIs(t, err, ErrWrongHostName)
+	})
+
+	t.Run("answers the server prompts", func(t *testing.T) {
+		proto, resp, err := mailer.auth.Start(&smtp.ServerInfo{Name: "mail.example.com", TLS: true})
+		require.NoError(t, err)
+		assert.Equal(t, "LOGIN", proto)
+		assert.Nil(t, resp)
+
+		username, err := mailer.auth.Next([]byte("Username:"), true)
+		require.NoError(t, err)
+		assert.Equal(t, "john", string(username))
+
+		password, err := mailer.auth.Next([]byte("Password:"), true)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(password))
+
+		_, err = mailer.auth.Next([]byte("Foo:"), true)
+		assert.Error(t, err)
+	})
+}
+
+func TestWithXOAUTH2Auth(t *testing.T) {
+	t.Run("sends the bearer token as initial response", func(t *testing.T) {
+		mailer := NewSMTPMailer("localhost:587", "no-reply@example.com",
+			WithXOAUTH2Auth("john@example.com", StaticTokenSource("abc123"), "localhost"))
+
+		proto, resp, err := mailer.auth.Start(&smtp.ServerInfo{Name: "localhost"})
+		require.NoError(t, err)
+		assert.Equal(t, "XOAUTH2", proto)
+		assert.Equal(t, "user=john@example.com\x01auth=Bearer abc123\x01\x01", string(resp))
+
+		// the error challenge must be acknowledged with an empty response.
+		next, err := mailer.auth.Next([]byte(`{"status":"401"}`), true)
+		require.NoError(t, err)
+		assert.Empty(t, next)
+	})
+
+	t.Run("token source fails", func(t *testing.T) {
+		mailer := NewSMTPMailer("localhost:587", "no-reply@example.com",
+			WithXOAUTH2Auth("john@example.com", StaticTokenSource(""), "localhost"))
+
+		_, _, err := mailer.auth.Start(&smtp.ServerInfo{Name: "localhost"})
+		assert.Error(t, err)
+	})
+}
+
+func TestFileTokenSource_Token(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0600))
+
+	tokenSource := FileTokenSource(path)
+	token, err := tokenSource.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "first", token)
+
+	t.Run("rotated token is picked up", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("second"), 0600))
+
+		token, err := tokenSource.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "second", token)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := FileTokenSource(filepath.Join(t.TempDir(), "missing")).Token()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,101 @@
+package infra
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	"notification/internal/logging"
+	"notification/internal/service"
+	"time"
+)
+
+// maxErrorBodySize is the max number of bytes of a provider error response kept in the error message.
+const maxErrorBodySize = 512
+
+// HTTPMailerOption defines the optional params for the HTTP API mailers.
+type HTTPMailerOption func(*httpMailer)
+
+// WithBaseURL overrides the provider API base URL, e.g. to use a regional endpoint.
+func WithBaseURL(baseURL string) HTTPMailerOption {
+	return func(m *httpMailer) {
+		m.baseURL = baseURL
+	}
+}
+
+// WithHTTPMailerLogger sets the logger of the mailer. Defaults to logging.Default().
+func WithHTTPMailerLogger(logger zerolog.Logger) HTTPMailerOption {
+	return func(m *httpMailer) {
+		m.logger = logger
+	}
+}
+
+// WithHTTPClient defines a custom HTTP client.
+//
+// Defaults to an http.Client with a 10 seconds timeout.
+func WithHTTPClient(client *http.Client) HTTPMailerOption {
+	return func(m *httpMailer) {
+		m.client = client
+	}
+}
+
+// httpMailer holds the settings shared by the HTTP API mailers.
+type httpMailer struct {
+	baseURL string
+	from    string
+	client  *http.Client
+	logger  zerolog.Logger
+}
+
+func newHTTPMailer(defaultBaseURL, from string, opts []HTTPMailerOption) httpMailer {
+	m := httpMailer{
+		baseURL: defaultBaseURL,
+		from:    from,
+		logger:  logging.Default(),
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	if m.client == nil {
+		m.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return m
+}
+
+// do sends the request, classifying transport failures as retryable
+// and unsuccessful responses according to their status code: only the rejections
+// of the message itself are permanent, while the failures of the provider, such as
+// revoked credentials, are retryable so that another provider is tried.
+// The caller is responsible for closing the response body.
+func (m httpMailer) do(provider string, req *http.Request) (*http.Response, error) {
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, errors.Join(service.ErrMailRetryable, fmt.Errorf("%s request: %w", provider, err))
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	err = fmt.Errorf("%s responded with status %d: %s", provider, resp.StatusCode, body)
+	if isRejectedStatus(resp.StatusCode) {
+		return nil, errors.Join(service.ErrMailPermanent, err)
+	}
+	return nil, errors.Join(service.ErrMailRetryable, err)
+}
+
+// isRejectedStatus reports whether the HTTP status means the provider rejected the message
+// itself, e.g. an invalid recipient, so that any other provider would reject it too.
+func isRejectedStatus(status int) bool {
+	return status == http.StatusBadRequest || status == http.StatusUnprocessableEntity
+}
+
+// isRetryableStatus reports whether the HTTP status means a temporary failure:
+// throttling, request timeouts or server errors.
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests ||
+		status == http.StatusRequestTimeout ||
+		status >= http.StatusInternalServerError
+}
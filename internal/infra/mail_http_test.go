@@ -0,0 +1,216 @@
+package infra_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/infra"
+	"notification/internal/service"
+	"strings"
+	"testing"
+)
+
+func TestSendGridMailer_SendEmailWithID(t *testing.T) {
+	t.Run("email is sent", func(t *testing.T) {
+		var gotBody map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v3/mail/send", r.URL.Path)
+			assert.Equal(t, "Bearer key-123", r.Header.Get("Authorization"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+			w.Header().Set("X-Message-Id", "sg-message-id")
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		mailer := infra.NewSendGridMailer("key-123", "no-reply@example.com", infra.WithBaseURL(server.URL))
+		messageID, err := mailer.SendEmailWithID("john@example.com", "Hi", "Hey there!")
+		require.NoError(t, err)
+
+		assert.Equal(t, "sg-message-id", messageID)
+		assert.Equal(t, "Hi", gotBody["subject"])
+		assert.Equal(t, map[string]any{"email": "no-reply@example.com"}, gotBody["from"])
+	})
+
+	t.Run("email is sent from the sender of the context", func(t *testing.T) {
+		var gotBody map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		mailer := infra.NewSendGridMailer("key-123", "no-reply@example.com", infra.WithBaseURL(server.URL))
+		ctx := service.WithSender(context.Background(), "Billing <billing@example.com>")
+		_, err := mailer.SendEmailContext(ctx, "john@example.com", "Hi", "Hey there!")
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]any{"email": "billing@example.com", "name": "Billing"}, gotBody["from"])
+	})
+
+	assertErrorMapping(t, func(baseURL string) service.Mailer {
+		return infra.NewSendGridMailer("key-123", "no-reply@example.com", infra.WithBaseURL(baseURL))
+	})
+}
+
+func TestMailgunMailer_SendEmailWithID(t *testing.T) {
+	t.Run("email is sent", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v3/mg.example.com/messages", r.URL.Path)
+			user, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "api", user)
+			assert.Equal(t, "key-123", password)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "john@example.com", r.PostForm.Get("to"))
+			assert.Equal(t, "Hey there!", r.PostForm.Get("text"))
+			_, _ = w.Write([]byte(`{"id":"<mg-message-id@mg.example.com>","message":"Queued. Thank you."}`))
+		}))
+		defer server.Close()
+
+		mailer := infra.NewMailgunMailer("key-123", "mg.example.com", "no-reply@example.com",
+			infra.WithBaseURL(server.URL))
+		messageID, err := mailer.SendEmailWithID("john@example.com", "Hi", "Hey there!")
+		require.NoError(t, err)
+
+		assert.Equal(t, "<mg-message-id@mg.example.com>", messageID)
+	})
+
+	t.Run("email is sent from the sender of the context", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "billing@example.com", r.PostForm.Get("from"))
+			_, _ = w.Write([]byte(`{"id":"<mg-message-id@mg.example.com>","message":"Queued. Thank you."}`))
+		}))
+		defer server.Close()
+
+		mailer := infra.NewMailgunMailer("key-123", "mg.example.com", "no-reply@example.com",
+			infra.WithBaseURL(server.URL))
+		ctx := service.WithSender(context.Background(), "billing@example.com")
+		_, err := mailer.SendEmailContext(ctx, "john@example.com", "Hi", "Hey there!")
+		require.NoError(t, err)
+	})
+
+	assertErrorMapping(t, func(baseURL string) service.Mailer {
+		return infra.NewMailgunMailer("key-123", "mg.example.com", "no-reply@example.com",
+			infra.WithBaseURL(baseURL))
+	})
+}
+
+func TestSESMailer_SendEmailWithID(t *testing.T) {
+	credentials := infra.SESCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "session-token",
+	}
+
+	t.Run("email is sent", func(t *testing.T) {
+		var gotBody map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v2/email/outbound-emails", r.URL.Path)
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+				"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+			assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/ses/aws4_request")
+			assert.Contains(t, r.Header.Get("Authorization"),
+				"SignedHeaders=content-type;host;x-amz-date;x-amz-security-token")
+			assert.Equal(t, "session-token", r.Header.Get("X-Amz-Security-Token"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+			_, _ = w.Write([]byte(`{"MessageId":"ses-message-id"}`))
+		}))
+		defer server.Close()
+
+		mailer := infra.NewSESMailer("eu-west-1", credentials, "no-reply@example.com",
+			infra.WithBaseURL(server.URL))
+		messageID, err := mailer.SendEmailWithID("john@example.com", "Hi", "Hey there!")
+		require.NoError(t, err)
+
+		assert.Equal(t, "ses-message-id", messageID)
+		assert.Equal(t, "no-reply@example.com", gotBody["FromEmailAddress"])
+		assert.Equal(t, map[string]any{"ToAddresses": []any{"john@example.com"}}, gotBody["Destination"])
+	})
+
+	t.Run("email is sent from the sender of the context", func(t *testing.T) {
+		var gotBody map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+			_, _ = w.Write([]byte(`{"MessageId":"ses-message-id"}`))
+		}))
+		defer server.Close()
+
+		mailer := infra.NewSESMailer("eu-west-1", credentials, "no-reply@example.com",
+			infra.WithBaseURL(server.URL))
+		ctx := service.WithSender(context.Background(), "billing@example.com")
+		_, err := mailer.SendEmailContext(ctx, "john@example.com", "Hi", "Hey there!")
+		require.NoError(t, err)
+
+		assert.Equal(t, "billing@example.com", gotBody["FromEmailAddress"])
+	})
+
+	assertErrorMapping(t, func(baseURL string) service.Mailer {
+		return infra.NewSESMailer("eu-west-1", credentials, "no-reply@example.com",
+			infra.WithBaseURL(baseURL))
+	})
+}
+
+// assertErrorMapping checks that provider failures are mapped to
+// retryable or permanent errors.
+func assertErrorMapping(t *testing.T, newMailer func(baseURL string) service.Mailer) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+	}{
+		{
+			name:    "throttling is retryable",
+			status:  http.StatusTooManyRequests,
+			wantErr: service.ErrMailRetryable,
+		},
+		{
+			name:    "server error is retryable",
+			status:  http.StatusServiceUnavailable,
+			wantErr: service.ErrMailRetryable,
+		},
+		{
+			name:    "bad request is permanent",
+			status:  http.StatusBadRequest,
+			wantErr: service.ErrMailPermanent,
+		},
+		{
+			name:    "invalid message is permanent",
+			status:  http.StatusUnprocessableEntity,
+			wantErr: service.ErrMailPermanent,
+		},
+		{
+			name:    "unauthorized is a provider failure",
+			status:  http.StatusUnauthorized,
+			wantErr: service.ErrMailRetryable,
+		},
+		{
+			name:    "forbidden is a provider failure",
+			status:  http.StatusForbidden,
+			wantErr: service.ErrMailRetryable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"message":"oops"}`, tt.status)
+			}))
+			defer server.Close()
+
+			err := newMailer(server.URL).SendEmail("john@example.com", "Hi", "Hey there!")
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("unreachable provider is retryable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		err := newMailer(server.URL).SendEmail("john@example.com", "Hi", "Hey there!")
+		assert.ErrorIs(t, err, service.ErrMailRetryable)
+	})
+}
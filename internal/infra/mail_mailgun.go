@@ -0,0 +1,79 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"notification/internal/logging"
+	"notification/internal/service"
+	"strings"
+)
+
+// NewMailgunMailer instantiates a new MailgunMailer sending from the given Mailgun domain.
+func NewMailgunMailer(apiKey, domain, from string, opts ...HTTPMailerOption) *MailgunMailer {
+	return &MailgunMailer{
+		httpMailer: newHTTPMailer("https://api.mailgun.net", from, opts),
+		apiKey:     apiKey,
+		domain:     domain,
+	}
+}
+
+// MailgunMailer defines the Mailgun Messages API Mailer implementation.
+type MailgunMailer struct {
+	httpMailer
+	apiKey string
+	domain string
+}
+
+type mailgunResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// SendEmail sends the email message through the Mailgun API.
+func (m MailgunMailer) SendEmail(to string, subject string, msg string) error {
+	_, err := m.SendEmailWithID(to, subject, msg)
+	return err
+}
+
+// SendEmailWithID sends the email message through the Mailgun API
+// and returns the Mailgun message ID.
+func (m MailgunMailer) SendEmailWithID(to string, subject string, msg string) (string, error) {
+	return m.SendEmailContext(context.Background(), to, subject, msg)
+}
+
+// SendEmailContext is SendEmailWithID bound to ctx, sending the message from the sender address
+// carried by ctx, if any, see service.WithSender.
+func (m MailgunMailer) SendEmailContext(ctx context.Context, to string, subject string, msg string) (string, error) {
+	m.logger.Debug().Str("recipient", logging.RedactEmail(to)).Msg("sending email through Mailgun")
+
+	form := url.Values{}
+	form.Set("from", service.SenderFromContext(ctx, m.from))
+	form.Set("to", to)
+	form.Set("subject", subject)
+	form.Set("text", msg)
+
+	endpoint := fmt.Sprintf("%s/v3/%s/messages", m.baseURL, url.PathEscape(m.domain))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("create mailgun request: %w", err)
+	}
+	req.SetBasicAuth("api", m.apiKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := m.do("mailgun", req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result mailgunResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		// the message has been accepted anyway, so it must not be reported as a failure.
+		m.logger.Warn().Err(err).Msg("failed to decode mailgun response")
+		return "", nil
+	}
+	return result.ID, nil
+}
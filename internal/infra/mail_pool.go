@@ -0,0 +1,346 @@
+package infra
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sync"
+	"time"
+)
+
+// codeServiceNotAvailable is the SMTP reply code the server uses when it's closing
+// the transmission channel, e.g. on shutdown or after too many messages in a session.
+const codeServiceNotAvailable = 421
+
+// ErrPoolClosed is the error when a connection is requested from a closed pool.
+var ErrPoolClosed = errors.New("smtp connection pool closed")
+
+// PoolConfig defines the settings of the SMTP connection pool.
+type PoolConfig struct {
+	// MaxIdle is the max number of idle connections kept open for reuse.
+	MaxIdle int
+	// MaxLifetime is the max amount of time a connection may be reused.
+	// Zero means connections are reused forever.
+	MaxLifetime time.Duration
+	// IdleTimeout is the max amount of time a connection may stay idle before being closed.
+	// Zero means idle connections are kept forever.
+	IdleTimeout time.Duration
+	// HealthCheckAfter is the idle time after which a connection is checked with
+	// a NOOP command before being reused. Zero means connections are always checked.
+	HealthCheckAfter time.Duration
+	// MaxMessagesPerConn is the max number of messages sent through a single connection
+	// before it's closed. Zero means no limit.
+	MaxMessagesPerConn int
+}
+
+// WithConnectionPool makes the SMTPMailer reuse authenticated SMTP sessions
+// instead of dialing a new connection for every email.
+//
+// Make sure to call SMTPMailer.Close on shutdown to quit the idle connections.
+func WithConnectionPool(cfg PoolConfig) SMTPMailerOption {
+	return func(mailer *SMTPMailer) {
+		mailer.poolConfig = &cfg
+	}
+}
+
+// pooledConn is an SMTP session managed by the smtpPool.
+type pooledConn struct {
+	client *smtp.Client
+	// conn is the connection of the session, whose deadline bounds each exchange.
+	conn      net.Conn
+	createdAt time.Time
+	lastUsed  time.Time
+	messages  int
+}
+
+// smtpPool keeps authenticated SMTP sessions open for reuse.
+type smtpPool struct {
+	cfg PoolConfig
+	// timeout bounds each exchange with the server, see WithSMTPTimeout.
+	timeout time.Duration
+	dial    func() (*smtp.Client, net.Conn, error)
+	now     func() time.Time
+	logger  zerolog.Logger
+
+	mu     sync.Mutex
+	idle   []*pooledConn
+	closed bool
+}
+
+func newSMTPPool(cfg PoolConfig, timeout time.Duration,
+	dial func() (*smtp.Client, net.Conn, error), logger zerolog.Logger) *smtpPool {
+	return &smtpPool{
+		cfg:     cfg,
+		timeout: timeout,
+		dial:    dial,
+		now:     time.Now,
+		logger:  logger,
+	}
+}
+
+// get returns a healthy connection, reusing an idle one when possible.
+func (p *smtpPool) get() (*pooledConn, error) {
+	for {
+		conn, err := p.popIdle()
+		if err != nil {
+			return nil, err
+		}
+		if conn == nil {
+			break
+		}
+		if p.now().Sub(conn.lastUsed) < p.cfg.HealthCheckAfter {
+			return conn, nil
+		}
+		extendDeadline(conn.conn, p.timeout)
+		if err := conn.client.Noop(); err != nil {
+			p.logger.Warn().Err(err).Msg("discarding unhealthy SMTP connection")
+			_ = conn.client.Close()
+			continue
+		}
+		return conn, nil
+	}
+
+	client, netConn, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	now := p.now()
+	return &pooledConn{
+		client:    client,
+		conn:      netConn,
+		createdAt: now,
+		lastUsed:  now,
+	}, nil
+}
+
+// popIdle returns the most recently used idle connection that hasn't expired,
+// or nil if there's none.
+func (p *smtpPool) popIdle() (*pooledConn, error) {
+	var expired []*pooledConn
+	defer func() {
+		for _, conn := range expired {
+			p.quit(conn)
+		}
+	}()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	for len(p.idle) > 0 {
+		conn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if p.isExpired(conn) {
+			expired = append(expired, conn)
+			continue
+		}
+		return conn, nil
+	}
+	return nil, nil
+}
+
+// put gives the connection back to the pool after a successful send.
+// The session is reset with RSET so that it can carry the next message.
+func (p *smtpPool) put(conn *pooledConn) {
+	conn.messages++
+	conn.lastUsed = p.now()
+
+	if p.cfg.MaxMessagesPerConn > 0 && conn.messages >= p.cfg.MaxMessagesPerConn {
+		p.quit(conn)
+		return
+	}
+	extendDeadline(conn.conn, p.timeout)
+	if err := conn.client.Reset(); err != nil {
+		_ = conn.client.Close()
+		return
+	}
+
+	p.mu.Lock()
+	reusable := !p.closed && len(p.idle) < p.cfg.MaxIdle && !p.isExpired(conn)
+	if reusable {
+		p.idle = append(p.idle, conn)
+	}
+	p.mu.Unlock()
+
+	if !reusable {
+		p.quit(conn)
+	}
+}
+
+// discard closes a connection that must not be reused.
+func (p *smtpPool) discard(conn *pooledConn) {
+	_ = conn.client.Close()
+}
+
+// drain quits every idle connection.
+func (p *smtpPool) drain() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, conn := range idle {
+		p.quit(conn)
+	}
+}
+
+// close drains the pool and prevents new connections from being handed out.
+func (p *smtpPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.drain()
+}
+
+func (p *smtpPool) isExpired(conn *pooledConn) bool {
+	now := p.now()
+	if p.cfg.MaxLifetime > 0 && now.Sub(conn.createdAt) >= p.cfg.MaxLifetime {
+		return true
+	}
+	if p.cfg.IdleTimeout > 0 && now.Sub(conn.lastUsed) >= p.cfg.IdleTimeout {
+		return true
+	}
+	return false
+}
+
+func (p *smtpPool) quit(conn *pooledConn) {
+	extendDeadline(conn.conn, p.timeout)
+	if err := conn.client.Quit(); err != nil {
+		_ = conn.client.Close()
+	}
+}
+
+// dial opens a new SMTP session, upgrading it to TLS and authenticating
+// whenever the server supports it. The connection and the greeting are bounded
+// by the timeout of the mailer, and so is each later exchange, whose deadline
+// is extended before it starts.
+func (m SMTPMailer) dial() (*smtp.Client, net.Conn, error) {
+	host, _, err := net.SplitHostPort(m.address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid smtp address: %w", err)
+	}
+	conn, err := net.DialTimeout("tcp", m.address, m.timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("smtp dial: %w", err)
+	}
+	extendDeadline(conn, m.timeout)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("smtp greeting: %w", err)
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			_ = client.Close()
+			return nil, nil, fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if m.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			_ = client.Close()
+			return nil, nil, errors.New("smtp server doesn't support AUTH")
+		}
+		if err = client.Auth(m.auth); err != nil {
+			_ = client.Close()
+			return nil, nil, fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	return client, conn, nil
+}
+
+// sendOnce sends the message through a new SMTP session, quitting it afterwards.
+func (m SMTPMailer) sendOnce(from, to string, msg []byte) error {
+	client, conn, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	extendDeadline(conn, m.timeout)
+	if err = transmit(client, from, to, msg); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// sendPooled sends the message through a pooled connection. If the server
+// answers with 421, or a reused connection fails with an I/O error because the
+// server closed it meanwhile, the idle connections are dropped and the message
+// is retried once on a fresh connection.
+func (m SMTPMailer) sendPooled(from, to string, msg []byte) error {
+	reused, err := m.sendWithPoolConn(from, to, msg)
+	switch {
+	case isServiceNotAvailable(err):
+		m.logger.Info().Msg("SMTP server closed the session, reconnecting")
+	case reused && isConnectionError(err):
+		m.logger.Info().Err(err).Msg("SMTP connection closed while idle, reconnecting")
+	default:
+		return err
+	}
+	m.pool.drain()
+	_, err = m.sendWithPoolConn(from, to, msg)
+	return err
+}
+
+// sendWithPoolConn sends the message through a pooled connection, and reports whether
+// the connection had already been used.
+func (m SMTPMailer) sendWithPoolConn(from, to string, msg []byte) (reused bool, err error) {
+	conn, err := m.pool.get()
+	if err != nil {
+		return false, err
+	}
+	reused = conn.messages > 0
+	extendDeadline(conn.conn, m.pool.timeout)
+	if err = transmit(conn.client, from, to, msg); err != nil {
+		m.pool.discard(conn)
+		return reused, err
+	}
+	m.pool.put(conn)
+	return reused, nil
+}
+
+// transmit runs the MAIL, RCPT and DATA commands for a single message.
+func transmit(client *smtp.Client, from, to string, msg []byte) error {
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("smtp mail: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return &messageRejection{fmt.Errorf("smtp rcpt: %w", err)}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return &messageRejection{fmt.Errorf("smtp data: %w", err)}
+	}
+	if _, err = w.Write(msg); err != nil {
+		return fmt.Errorf("smtp write: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return &messageRejection{fmt.Errorf("smtp data close: %w", err)}
+	}
+	return nil
+}
+
+// extendDeadline bounds the next exchange through conn by timeout. Zero means no deadline.
+func extendDeadline(conn net.Conn, timeout time.Duration) {
+	if timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(timeout))
+	}
+}
+
+func isServiceNotAvailable(err error) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code == codeServiceNotAvailable
+}
+
+// isConnectionError reports whether err is an I/O error, such as EOF or a broken pipe,
+// rather than a reply of the server.
+func isConnectionError(err error) bool {
+	var protoErr *textproto.Error
+	return err != nil && !errors.As(err, &protoErr)
+}
@@ -0,0 +1,439 @@
+package infra_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net"
+	"notification/internal/infra"
+	"notification/internal/service"
+	"notification/internal/tracing"
+	"notification/internal/tracing/tracingtest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer is a minimal SMTP server accepting every message.
+type fakeSMTPServer struct {
+	listener net.Listener
+	// connections is the number of sessions opened by clients.
+	connections atomic.Int32
+	// messages is the number of messages accepted.
+	messages atomic.Int32
+	// noops is the number of NOOP commands received.
+	noops atomic.Int32
+	// closeAfterMessages makes the server answer 421 to the next MAIL command
+	// once a session has delivered that many messages. Zero disables it.
+	closeAfterMessages atomic.Int32
+	// closeWhenIdle makes the server close the session without notice once a message
+	// is delivered and the session reset, as the servers closing idle connections do.
+	closeWhenIdle atomic.Bool
+	// rcptReply is the reply to the RCPT commands, e.g. "550 no such user". Defaults to "250 OK".
+	rcptReply atomic.Value
+	// mailReply is the reply to the MAIL commands, e.g. "553 sender not allowed". Defaults to "250 OK".
+	mailReply atomic.Value
+	// stallData makes the server stop answering once it has received a message, as the
+	// overloaded or blackholing relays do.
+	stallData atomic.Bool
+
+	mu   sync.Mutex
+	data []string
+	// senders are the MAIL commands received.
+	senders []string
+}
+
+func newFakeSMTPServer(t testing.TB) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTPServer{listener: listener}
+	go s.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return s
+}
+
+func (s *fakeSMTPServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.connections.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP fake")
+	var sessionMessages int
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(cmd, "MAIL"):
+			if limit := int(s.closeAfterMessages.Load()); limit > 0 && sessionMessages >= limit {
+				reply("421 closing transmission channel")
+				return
+			}
+			s.mu.Lock()
+			s.senders = append(s.senders, strings.TrimSpace(line))
+			s.mu.Unlock()
+			if mailReply, ok := s.mailReply.Load().(string); ok {
+				reply(mailReply)
+				continue
+			}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 go ahead")
+			var body strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				body.WriteString(dataLine)
+			}
+			if s.stallData.Load() {
+				_, _ = io.Copy(io.Discard, r)
+				return
+			}
+			sessionMessages++
+			s.messages.Add(1)
+			s.mu.Lock()
+			s.data = append(s.data, body.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "NOOP"):
+			s.noops.Add(1)
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		case strings.HasPrefix(cmd, "RSET"):
+			reply("250 OK")
+			if s.closeWhenIdle.Load() {
+				return
+			}
+		case strings.HasPrefix(cmd, "RCPT"):
+			if rcptReply, ok := s.rcptReply.Load().(string); ok {
+				reply(rcptReply)
+				continue
+			}
+			reply("250 OK")
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailer_SendEmail_Pooled(t *testing.T) {
+	t.Run("connection is reused", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com",
+			infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1}))
+		defer mailer.Close()
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+		}
+
+		assert.EqualValues(t, 5, server.messages.Load())
+		assert.EqualValues(t, 1, server.connections.Load())
+	})
+
+	t.Run("idle connection is health checked", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com",
+			infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1}))
+		defer mailer.Close()
+
+		require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+		require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+
+		assert.EqualValues(t, 1, server.noops.Load())
+	})
+
+	t.Run("connection is replaced after max messages", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com",
+			infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1, MaxMessagesPerConn: 2}))
+		defer mailer.Close()
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+		}
+
+		assert.EqualValues(t, 4, server.messages.Load())
+		assert.EqualValues(t, 2, server.connections.Load())
+	})
+
+	t.Run("reconnects on 421", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		server.closeAfterMessages.Store(1)
+		mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com",
+			infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1}))
+		defer mailer.Close()
+
+		require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+		require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+
+		assert.EqualValues(t, 2, server.messages.Load())
+		assert.EqualValues(t, 2, server.connections.Load())
+	})
+
+	t.Run("reconnects when an idle connection was closed", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		server.closeWhenIdle.Store(true)
+		// the connection is reused without health check.
+		mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com",
+			infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1, HealthCheckAfter: time.Hour}))
+		defer mailer.Close()
+
+		require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+		require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+
+		assert.EqualValues(t, 2, server.messages.Load())
+		assert.EqualValues(t, 2, server.connections.Load())
+		assert.Zero(t, server.noops.Load())
+	})
+
+	t.Run("closed pool", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com",
+			infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1}))
+		mailer.Close()
+
+		err := mailer.SendEmail("john@example.com", "Hi", "Hey there!")
+		assert.ErrorIs(t, err, infra.ErrPoolClosed)
+	})
+}
+
+func TestSMTPMailer_SendEmail_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		mailReply string
+		rcptReply string
+		pooled    bool
+		want      error
+	}{
+		{name: "5xx reply is permanent", rcptReply: "550 no such user", want: service.ErrMailPermanent},
+		{name: "4xx reply is retryable", rcptReply: "451 try again later", want: service.ErrMailRetryable},
+		{name: "5xx reply is permanent when pooled", rcptReply: "550 no such user", pooled: true,
+			want: service.ErrMailPermanent},
+		{name: "4xx reply is retryable when pooled", rcptReply: "451 try again later", pooled: true,
+			want: service.ErrMailRetryable},
+		{name: "authentication required is a provider failure", rcptReply: "530 authentication required",
+			want: service.ErrMailRetryable},
+		{name: "rejected sender is a provider failure", mailReply: "553 sender not allowed",
+			want: service.ErrMailRetryable},
+		{name: "rejected sender is a provider failure when pooled", mailReply: "553 sender not allowed",
+			pooled: true, want: service.ErrMailRetryable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t)
+			reply := tt.rcptReply
+			if tt.mailReply != "" {
+				server.mailReply.Store(tt.mailReply)
+				reply = tt.mailReply
+			}
+			if tt.rcptReply != "" {
+				server.rcptReply.Store(tt.rcptReply)
+			}
+			var opts []infra.SMTPMailerOption
+			if tt.pooled {
+				opts = append(opts, infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1}))
+			}
+			mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com", opts...)
+			defer mailer.Close()
+
+			err := mailer.SendEmail("john@example.com", "Hi", "Hey there!")
+			assert.ErrorIs(t, err, tt.want)
+			assert.ErrorContains(t, err, reply[4:])
+		})
+	}
+
+	t.Run("I/O error is retryable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+		require.NoError(t, listener.Close())
+		mailer := infra.NewSMTPMailer(addr, "no-reply@example.com")
+
+		err = mailer.SendEmail("john@example.com", "Hi", "Hey there!")
+		assert.ErrorIs(t, err, service.ErrMailRetryable)
+		assert.NotErrorIs(t, err, service.ErrMailPermanent)
+	})
+}
+
+func TestSMTPMailer_SendEmail_Timeout(t *testing.T) {
+	for _, pooled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("server stalls, pooled: %v", pooled), func(t *testing.T) {
+			server := newFakeSMTPServer(t)
+			server.stallData.Store(true)
+			opts := []infra.SMTPMailerOption{infra.WithSMTPTimeout(50 * time.Millisecond)}
+			if pooled {
+				opts = append(opts, infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1}))
+			}
+			mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com", opts...)
+			defer mailer.Close()
+
+			start := time.Now()
+			err := mailer.SendEmail("john@example.com", "Hi", "Hey there!")
+			assert.ErrorIs(t, err, service.ErrMailRetryable)
+			assert.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+
+	t.Run("server doesn't greet", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = listener.Close() })
+		go func() {
+			// accepts the connection and stays silent.
+			conn, err := listener.Accept()
+			if err == nil {
+				t.Cleanup(func() { _ = conn.Close() })
+			}
+		}()
+		mailer := infra.NewSMTPMailer(listener.Addr().String(), "no-reply@example.com",
+			infra.WithSMTPTimeout(50*time.Millisecond))
+
+		err = mailer.SendEmail("john@example.com", "Hi", "Hey there!")
+		assert.ErrorIs(t, err, service.ErrMailRetryable)
+		assert.ErrorContains(t, err, "smtp greeting")
+	})
+}
+
+func TestSMTPMailer_SendEmailContext(t *testing.T) {
+	exporter := tracingtest.Setup(t)
+	server := newFakeSMTPServer(t)
+	mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com",
+		infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1}))
+	defer mailer.Close()
+
+	ctx, parent := tracing.Tracer().Start(context.Background(), "parent")
+	messageID, err := mailer.SendEmailContext(ctx, "john@example.com", "Hi", "Hey there!")
+	parent.End()
+	require.NoError(t, err)
+	assert.NotEmpty(t, messageID)
+
+	spans := exporter.GetSpans()
+	require.Equal(t, []string{"SMTP send", "parent"}, tracingtest.SpanNames(spans))
+	span := spans[0]
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+	assert.Contains(t, span.Attributes, attribute.String("server.address", "127.0.0.1"))
+	assert.Contains(t, span.Attributes, attribute.Bool("smtp.pooled", true))
+}
+
+func TestSMTPMailer_SendEmailContext_Sender(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com")
+
+	ctx := service.WithSender(context.Background(), "Billing <billing@billing.example.com>")
+	messageID, err := mailer.SendEmailContext(ctx, "john@example.com", "Hi", "Hey there!")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasSuffix(messageID, "@billing.example.com>"))
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, []string{"MAIL FROM:<billing@billing.example.com> BODY=8BITMIME"}, server.senders)
+	require.Len(t, server.data, 1)
+	assert.Contains(t, server.data[0], "From: Billing <billing@billing.example.com>\r\n")
+}
+
+func TestSMTPMailer_Check(t *testing.T) {
+	t.Run("server is healthy", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com")
+
+		require.NoError(t, mailer.Check(context.Background()))
+		assert.EqualValues(t, 1, server.noops.Load())
+		assert.EqualValues(t, 0, server.messages.Load())
+	})
+
+	t.Run("server is unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+		require.NoError(t, listener.Close())
+		mailer := infra.NewSMTPMailer(addr, "no-reply@example.com")
+
+		assert.ErrorContains(t, mailer.Check(context.Background()), "smtp dial")
+	})
+
+	t.Run("server doesn't greet", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = listener.Close() })
+		go func() {
+			// accepts the connection and stays silent.
+			conn, err := listener.Accept()
+			if err == nil {
+				t.Cleanup(func() { _ = conn.Close() })
+			}
+		}()
+		mailer := infra.NewSMTPMailer(listener.Addr().String(), "no-reply@example.com")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.ErrorContains(t, mailer.Check(ctx), "smtp greeting")
+	})
+}
+
+func BenchmarkSMTPMailer_SendEmail(b *testing.B) {
+	b.Run("dial per message", func(b *testing.B) {
+		server := newFakeSMTPServer(b)
+		mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com")
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := mailer.SendEmail("john@example.com", "Hi", "Hey there!"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		server := newFakeSMTPServer(b)
+		mailer := infra.NewSMTPMailer(server.addr(), "no-reply@example.com",
+			infra.WithConnectionPool(infra.PoolConfig{MaxIdle: 1, HealthCheckAfter: time.Second}))
+		defer mailer.Close()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := mailer.SendEmail("john@example.com", "Hi", "Hey there!"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
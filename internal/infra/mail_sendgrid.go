@@ -0,0 +1,99 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"notification/internal/logging"
+	"notification/internal/service"
+)
+
+// NewSendGridMailer instantiates a new SendGridMailer using the SendGrid v3 Mail Send API.
+func NewSendGridMailer(apiKey, from string, opts ...HTTPMailerOption) *SendGridMailer {
+	return &SendGridMailer{
+		httpMailer: newHTTPMailer("https://api.sendgrid.com", from, opts),
+		apiKey:     apiKey,
+	}
+}
+
+// SendGridMailer defines the SendGrid v3 API Mailer implementation.
+type SendGridMailer struct {
+	httpMailer
+	apiKey string
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// newSendGridAddress splits the display name from the address, which SendGrid expects separately.
+func newSendGridAddress(address string) sendGridAddress {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return sendGridAddress{Email: address}
+	}
+	return sendGridAddress{Email: parsed.Address, Name: parsed.Name}
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+// SendEmail sends the email message through the SendGrid API.
+func (m SendGridMailer) SendEmail(to string, subject string, msg string) error {
+	_, err := m.SendEmailWithID(to, subject, msg)
+	return err
+}
+
+// SendEmailWithID sends the email message through the SendGrid API
+// and returns the SendGrid message ID.
+func (m SendGridMailer) SendEmailWithID(to string, subject string, msg string) (string, error) {
+	return m.SendEmailContext(context.Background(), to, subject, msg)
+}
+
+// SendEmailContext is SendEmailWithID bound to ctx, sending the message from the sender address
+// carried by ctx, if any, see service.WithSender.
+func (m SendGridMailer) SendEmailContext(ctx context.Context, to string, subject string, msg string) (string, error) {
+	m.logger.Debug().Str("recipient", logging.RedactEmail(to)).Msg("sending email through SendGrid")
+
+	payload := sendGridRequest{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: to}}}},
+		From:             newSendGridAddress(service.SenderFromContext(ctx, m.from)),
+		Subject:          subject,
+		Content:          []sendGridContent{{Type: "text/plain", Value: msg}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshal sendgrid request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create sendgrid request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.do("sendgrid", req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return resp.Header.Get("X-Message-Id"), nil
+}
@@ -0,0 +1,190 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"notification/internal/logging"
+	"notification/internal/service"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SESCredentials are the AWS credentials used to sign the SES API requests.
+type SESCredentials struct {
+	// AccessKeyID is the AWS access key ID.
+	AccessKeyID string
+	// SecretAccessKey is the AWS secret access key.
+	SecretAccessKey string
+	// SessionToken is the optional session token of temporary credentials.
+	SessionToken string
+}
+
+// NewSESMailer instantiates a new SESMailer using the SES v2 API of the given AWS region.
+func NewSESMailer(region string, credentials SESCredentials, from string, opts ...HTTPMailerOption) *SESMailer {
+	return &SESMailer{
+		httpMailer:  newHTTPMailer(fmt.Sprintf("https://email.%s.amazonaws.com", region), from, opts),
+		region:      region,
+		credentials: credentials,
+		now:         time.Now,
+	}
+}
+
+// SESMailer defines the Amazon SES v2 API Mailer implementation.
+// Requests are signed with AWS Signature Version 4.
+type SESMailer struct {
+	httpMailer
+	region      string
+	credentials SESCredentials
+	now         func() time.Time
+}
+
+type sesContent struct {
+	Data string `json:"Data"`
+}
+
+type sesRequest struct {
+	FromEmailAddress string `json:"FromEmailAddress"`
+	Destination      struct {
+		ToAddresses []string `json:"ToAddresses"`
+	} `json:"Destination"`
+	Content struct {
+		Simple struct {
+			Subject sesContent `json:"Subject"`
+			Body    struct {
+				Text sesContent `json:"Text"`
+			} `json:"Body"`
+		} `json:"Simple"`
+	} `json:"Content"`
+}
+
+type sesResponse struct {
+	MessageID string `json:"MessageId"`
+}
+
+// SendEmail sends the email message through the SES API.
+func (m SESMailer) SendEmail(to string, subject string, msg string) error {
+	_, err := m.SendEmailWithID(to, subject, msg)
+	return err
+}
+
+// SendEmailWithID sends the email message through the SES API
+// and returns the SES message ID.
+func (m SESMailer) SendEmailWithID(to string, subject string, msg string) (string, error) {
+	return m.SendEmailContext(context.Background(), to, subject, msg)
+}
+
+// SendEmailContext is SendEmailWithID bound to ctx, sending the message from the sender address
+// carried by ctx, if any, see service.WithSender.
+func (m SESMailer) SendEmailContext(ctx context.Context, to string, subject string, msg string) (string, error) {
+	m.logger.Debug().Str("recipient", logging.RedactEmail(to)).Msg("sending email through SES")
+
+	var payload sesRequest
+	payload.FromEmailAddress = service.SenderFromContext(ctx, m.from)
+	payload.Destination.ToAddresses = []string{to}
+	payload.Content.Simple.Subject.Data = subject
+	payload.Content.Simple.Body.Text.Data = msg
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshal ses request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/v2/email/outbound-emails",
+		bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create ses request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signV4(req, body, m.credentials, m.region, "ses", m.now())
+
+	resp, err := m.do("ses", req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result sesResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		// the message has been accepted anyway, so it must not be reported as a failure.
+		m.logger.Warn().Err(err).Msg("failed to decode ses response")
+		return "", nil
+	}
+	return result.MessageID, nil
+}
+
+// signV4 signs the request with AWS Signature Version 4, setting the
+// X-Amz-Date, X-Amz-Security-Token and Authorization headers.
+func signV4(req *http.Request, body []byte, credentials SESCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,100 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification/internal/logging"
+	"notification/internal/service"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NewMaildirBounceReader creates a new MaildirBounceReader instance reading
+// the Maildir at dir every interval.
+func NewMaildirBounceReader(dir string, handler service.BounceHandler, interval time.Duration) *MaildirBounceReader {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &MaildirBounceReader{
+		dir:      dir,
+		handler:  handler,
+		interval: interval,
+	}
+}
+
+// MaildirBounceReader feeds the bounce messages delivered to a local Maildir,
+// e.g. the mailbox of the envelope sender address, into a BounceHandler.
+//
+// Processed messages are moved from "new" to "cur". Messages that fail to be
+// handled are left in "new" so they are retried on the next poll.
+type MaildirBounceReader struct {
+	dir      string
+	handler  service.BounceHandler
+	interval time.Duration
+}
+
+// Run polls the Maildir until the context is canceled, logging through the logger of ctx.
+func (m MaildirBounceReader) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Poll(ctx); err != nil {
+			logging.FromContext(ctx).Error().Err(err).Str("dir", m.dir).Msg("failed to read bounces from maildir")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll processes every message in the "new" folder of the Maildir.
+func (m MaildirBounceReader) Poll(ctx context.Context) error {
+	newDir := filepath.Join(m.dir, "new")
+	entries, err := os.ReadDir(newDir)
+	if err != nil {
+		return fmt.Errorf("read maildir: %w", err)
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if err := m.process(ctx, entry.Name()); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m MaildirBounceReader) process(ctx context.Context, name string) error {
+	path := filepath.Join(m.dir, "new", name)
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	events, err := service.ParseDSN(f)
+	_ = f.Close()
+
+	switch {
+	case errors.Is(err, service.ErrNotDSN):
+		logging.FromContext(ctx).Debug().Err(err).Str("message", name).Msg("skipping maildir message")
+	case err != nil:
+		return err
+	case len(events) > 0:
+		if err := m.handler.HandleBounces(ctx, events); err != nil {
+			return err
+		}
+	}
+
+	// the ":2," suffix marks the message as seen without flags (https://cr.yp.to/proto/maildir.html).
+	return os.Rename(path, filepath.Join(m.dir, "cur", name+":2,"))
+}
@@ -0,0 +1,74 @@
+package infra_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/mocks"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testBounce = "Content-Type: multipart/report; report-type=delivery-status; boundary=\"B\"\r\n" +
+	"\r\n" +
+	"--B\r\n" +
+	"Content-Type: message/delivery-status\r\n" +
+	"\r\n" +
+	"Reporting-MTA: dns; mx.example.com\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; john@example.com\r\n" +
+	"Action: failed\r\n" +
+	"Status: 5.1.1\r\n" +
+	"\r\n" +
+	"--B--\r\n"
+
+func TestMaildirBounceReader_Poll(t *testing.T) {
+	newMaildir := func(t *testing.T, messages map[string]string) string {
+		dir := t.TempDir()
+		for _, sub := range []string{"new", "cur", "tmp"} {
+			require.NoError(t, os.Mkdir(filepath.Join(dir, sub), 0700))
+		}
+		for name, content := range messages {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "new", name), []byte(content), 0600))
+		}
+		return dir
+	}
+
+	t.Run("bounces are handled and messages are moved to cur", func(t *testing.T) {
+		dir := newMaildir(t, map[string]string{
+			"1.bounce":  testBounce,
+			"2.regular": "Subject: hi\r\n\r\nHey there!\r\n",
+		})
+
+		handler := mocks.NewBounceHandler(t)
+		handler.On("HandleBounces", mock.Anything, []domain.BounceEvent{{
+			Email:  "john@example.com",
+			Type:   domain.HardBounce,
+			Status: "5.1.1",
+			Source: "dsn",
+		}}).Return(nil).Once()
+
+		reader := infra.NewMaildirBounceReader(dir, handler, time.Minute)
+		require.NoError(t, reader.Poll(context.Background()))
+
+		assert.NoFileExists(t, filepath.Join(dir, "new", "1.bounce"))
+		assert.FileExists(t, filepath.Join(dir, "cur", "1.bounce:2,"))
+		assert.FileExists(t, filepath.Join(dir, "cur", "2.regular:2,"))
+	})
+
+	t.Run("failed messages are retried", func(t *testing.T) {
+		dir := newMaildir(t, map[string]string{"1.bounce": testBounce})
+
+		handler := mocks.NewBounceHandler(t)
+		handler.On("HandleBounces", mock.Anything, mock.Anything).Return(assert.AnError)
+
+		reader := infra.NewMaildirBounceReader(dir, handler, time.Minute)
+		assert.ErrorIs(t, reader.Poll(context.Background()), assert.AnError)
+		assert.FileExists(t, filepath.Join(dir, "new", "1.bounce"))
+	})
+}
@@ -0,0 +1,7 @@
+CREATE TABLE users (
+	id        TEXT PRIMARY KEY,
+	name      TEXT NOT NULL,
+	last_name TEXT NOT NULL,
+	email     TEXT NOT NULL
+);
+CREATE UNIQUE INDEX users_email_idx ON users (LOWER(email));
@@ -0,0 +1,16 @@
+CREATE TABLE rate_limit_rules (
+	type       TEXT PRIMARY KEY,
+	max_count  INTEGER NOT NULL,
+	expiration BIGINT NOT NULL
+);
+CREATE TABLE rate_limit_rule_history (
+	id             BIGSERIAL PRIMARY KEY,
+	type           TEXT NOT NULL,
+	actor          TEXT NOT NULL,
+	changed_at     TIMESTAMP NOT NULL,
+	old_max_count  INTEGER,
+	old_expiration BIGINT,
+	new_max_count  INTEGER,
+	new_expiration BIGINT
+);
+CREATE INDEX rate_limit_rule_history_type_idx ON rate_limit_rule_history (type, id DESC);
@@ -0,0 +1,16 @@
+CREATE TABLE rate_limit_rules (
+	type       TEXT PRIMARY KEY,
+	max_count  INTEGER NOT NULL,
+	expiration INTEGER NOT NULL
+);
+CREATE TABLE rate_limit_rule_history (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	type           TEXT NOT NULL,
+	actor          TEXT NOT NULL,
+	changed_at     TIMESTAMP NOT NULL,
+	old_max_count  INTEGER,
+	old_expiration INTEGER,
+	new_max_count  INTEGER,
+	new_expiration INTEGER
+);
+CREATE INDEX rate_limit_rule_history_type_idx ON rate_limit_rule_history (type, id DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE rate_limit_rules ADD COLUMN unlimited BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE rate_limit_rule_history ADD COLUMN old_unlimited BOOLEAN;
+ALTER TABLE rate_limit_rule_history ADD COLUMN new_unlimited BOOLEAN;
@@ -0,0 +1,7 @@
+CREATE TABLE api_keys (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	hash       TEXT NOT NULL UNIQUE,
+	scopes     TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
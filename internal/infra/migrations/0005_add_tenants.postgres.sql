@@ -0,0 +1,24 @@
+CREATE TABLE tenants (
+	id               TEXT PRIMARY KEY,
+	name             TEXT NOT NULL,
+	mail_from        TEXT NOT NULL,
+	subjects         TEXT NOT NULL,
+	quota_max_count  INTEGER,
+	quota_expiration BIGINT,
+	created_at       TIMESTAMP NOT NULL
+);
+
+ALTER TABLE users ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE users DROP CONSTRAINT users_pkey;
+ALTER TABLE users ADD PRIMARY KEY (tenant_id, id);
+DROP INDEX users_email_idx;
+CREATE UNIQUE INDEX users_email_idx ON users (tenant_id, LOWER(email));
+
+ALTER TABLE rate_limit_rules ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE rate_limit_rules DROP CONSTRAINT rate_limit_rules_pkey;
+ALTER TABLE rate_limit_rules ADD PRIMARY KEY (tenant_id, type);
+ALTER TABLE rate_limit_rule_history ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+DROP INDEX rate_limit_rule_history_type_idx;
+CREATE INDEX rate_limit_rule_history_type_idx ON rate_limit_rule_history (tenant_id, type, id DESC);
+
+ALTER TABLE api_keys ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
@@ -0,0 +1,41 @@
+CREATE TABLE tenants (
+	id               TEXT PRIMARY KEY,
+	name             TEXT NOT NULL,
+	mail_from        TEXT NOT NULL,
+	subjects         TEXT NOT NULL,
+	quota_max_count  INTEGER,
+	quota_expiration INTEGER,
+	created_at       TIMESTAMP NOT NULL
+);
+
+-- SQLite can't change the primary key of a table, so the tables are rebuilt.
+CREATE TABLE users_new (
+	tenant_id TEXT NOT NULL DEFAULT 'default',
+	id        TEXT NOT NULL,
+	name      TEXT NOT NULL,
+	last_name TEXT NOT NULL,
+	email     TEXT NOT NULL,
+	PRIMARY KEY (tenant_id, id)
+);
+INSERT INTO users_new (id, name, last_name, email) SELECT id, name, last_name, email FROM users;
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+CREATE UNIQUE INDEX users_email_idx ON users (tenant_id, LOWER(email));
+
+CREATE TABLE rate_limit_rules_new (
+	tenant_id  TEXT NOT NULL DEFAULT 'default',
+	type       TEXT NOT NULL,
+	max_count  INTEGER NOT NULL,
+	expiration INTEGER NOT NULL,
+	unlimited  BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (tenant_id, type)
+);
+INSERT INTO rate_limit_rules_new (type, max_count, expiration, unlimited)
+SELECT type, max_count, expiration, unlimited FROM rate_limit_rules;
+DROP TABLE rate_limit_rules;
+ALTER TABLE rate_limit_rules_new RENAME TO rate_limit_rules;
+ALTER TABLE rate_limit_rule_history ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+DROP INDEX rate_limit_rule_history_type_idx;
+CREATE INDEX rate_limit_rule_history_type_idx ON rate_limit_rule_history (tenant_id, type, id DESC);
+
+ALTER TABLE api_keys ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
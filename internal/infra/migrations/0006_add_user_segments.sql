@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN segments TEXT NOT NULL DEFAULT '';
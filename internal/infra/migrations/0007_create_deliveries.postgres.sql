@@ -0,0 +1,48 @@
+-- The delivery tables were created by the delivery store itself before it used the migrations,
+-- so they may already exist, keyed by the correlation ID alone.
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+	tenant_id           TEXT NOT NULL DEFAULT 'default',
+	correlation_id      TEXT NOT NULL,
+	user_id             TEXT NOT NULL,
+	type                TEXT NOT NULL,
+	status              TEXT NOT NULL,
+	provider_message_id TEXT NOT NULL DEFAULT '',
+	callback_url        TEXT NOT NULL DEFAULT '',
+	caller              TEXT NOT NULL DEFAULT '',
+	created_at          TIMESTAMPTZ NOT NULL,
+	updated_at          TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (tenant_id, correlation_id)
+);
+ALTER TABLE notification_deliveries ADD COLUMN IF NOT EXISTS callback_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE notification_deliveries ADD COLUMN IF NOT EXISTS caller TEXT NOT NULL DEFAULT '';
+ALTER TABLE notification_deliveries ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE TABLE IF NOT EXISTS notification_delivery_transitions (
+	id             BIGSERIAL PRIMARY KEY,
+	tenant_id      TEXT NOT NULL DEFAULT 'default',
+	correlation_id TEXT NOT NULL,
+	status         TEXT NOT NULL,
+	detail         TEXT NOT NULL DEFAULT '',
+	at             TIMESTAMPTZ NOT NULL
+);
+ALTER TABLE notification_delivery_transitions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+UPDATE notification_delivery_transitions t SET tenant_id = d.tenant_id
+FROM notification_deliveries d
+WHERE t.correlation_id = d.correlation_id AND t.tenant_id <> d.tenant_id;
+
+ALTER TABLE notification_delivery_transitions
+	DROP CONSTRAINT IF EXISTS notification_delivery_transitions_correlation_id_fkey;
+ALTER TABLE notification_deliveries DROP CONSTRAINT notification_deliveries_pkey;
+ALTER TABLE notification_deliveries ADD PRIMARY KEY (tenant_id, correlation_id);
+ALTER TABLE notification_delivery_transitions ADD CONSTRAINT notification_delivery_transitions_delivery_fkey
+	FOREIGN KEY (tenant_id, correlation_id)
+	REFERENCES notification_deliveries (tenant_id, correlation_id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS notification_deliveries_user_idx;
+CREATE INDEX IF NOT EXISTS notification_deliveries_tenant_user_idx
+	ON notification_deliveries (tenant_id, user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS notification_deliveries_provider_message_idx
+	ON notification_deliveries (provider_message_id) WHERE provider_message_id <> '';
+DROP INDEX IF EXISTS notification_delivery_transitions_correlation_idx;
+CREATE INDEX notification_delivery_transitions_delivery_idx
+	ON notification_delivery_transitions (tenant_id, correlation_id, id);
@@ -0,0 +1,30 @@
+CREATE TABLE notification_deliveries (
+	tenant_id           TEXT NOT NULL DEFAULT 'default',
+	correlation_id      TEXT NOT NULL,
+	user_id             TEXT NOT NULL,
+	type                TEXT NOT NULL,
+	status              TEXT NOT NULL,
+	provider_message_id TEXT NOT NULL DEFAULT '',
+	callback_url        TEXT NOT NULL DEFAULT '',
+	caller              TEXT NOT NULL DEFAULT '',
+	created_at          TIMESTAMP NOT NULL,
+	updated_at          TIMESTAMP NOT NULL,
+	PRIMARY KEY (tenant_id, correlation_id)
+);
+CREATE INDEX notification_deliveries_tenant_user_idx
+	ON notification_deliveries (tenant_id, user_id, created_at DESC);
+CREATE INDEX notification_deliveries_provider_message_idx
+	ON notification_deliveries (provider_message_id) WHERE provider_message_id <> '';
+
+CREATE TABLE notification_delivery_transitions (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	tenant_id      TEXT NOT NULL DEFAULT 'default',
+	correlation_id TEXT NOT NULL,
+	status         TEXT NOT NULL,
+	detail         TEXT NOT NULL DEFAULT '',
+	at             TIMESTAMP NOT NULL,
+	FOREIGN KEY (tenant_id, correlation_id)
+		REFERENCES notification_deliveries (tenant_id, correlation_id) ON DELETE CASCADE
+);
+CREATE INDEX notification_delivery_transitions_delivery_idx
+	ON notification_delivery_transitions (tenant_id, correlation_id, id);
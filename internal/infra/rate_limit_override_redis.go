@@ -0,0 +1,303 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	// rateLimitOverridesKey is the hash holding the rate limit overrides of the default tenant, keyed by
+	// "<scope>:<subject>". The overrides of the other tenants are held by the hash of the same name
+	// suffixed with ":<tenant ID>".
+	rateLimitOverridesKey = "ratelimit:overrides"
+	// rateLimitExemptionKey prefixes the keys holding the rate limit exemptions, which are
+	// "ratelimit:exemption:<type>" for the default tenant and "ratelimit:exemption:<tenant ID>:<type>"
+	// for the other tenants. The keys expire along with the exemptions.
+	rateLimitExemptionKey = "ratelimit:exemption"
+)
+
+// NewRedisRateLimitOverrideRepository creates a new RedisRateLimitOverrideRepository instance.
+func NewRedisRateLimitOverrideRepository(client *redis.Client) *RedisRateLimitOverrideRepository {
+	return &RedisRateLimitOverrideRepository{client}
+}
+
+// RedisRateLimitOverrideRepository is the Redis implementation of the rate limit override repository.
+//
+// The overrides are read from Redis on every lookup, so changes take effect immediately
+// on every replica sharing the same Redis instance. Each tenant has its own overrides hash.
+type RedisRateLimitOverrideRepository struct {
+	client *redis.Client
+}
+
+// redisRateLimitOverride is the JSON representation of an override in the overrides hash.
+type redisRateLimitOverride struct {
+	Rules   map[string]redisRateLimitRule `json:"rules,omitempty"`
+	Default *redisRateLimitRule           `json:"default,omitempty"`
+}
+
+// Get retrieves the override of a user or segment.
+// It returns repository.ErrOverrideNotFound if the subject has no override.
+func (r RedisRateLimitOverrideRepository) Get(ctx context.Context,
+	scope domain.OverrideScope, subject string) (domain.RateLimitOverride, error) {
+	encoded, err := r.client.HGet(ctx, tenantOverridesKey(ctx), overrideField(scope, subject)).Result()
+	if errors.Is(err, redis.Nil) {
+		return domain.RateLimitOverride{}, repository.ErrOverrideNotFound
+	}
+	if err != nil {
+		return domain.RateLimitOverride{}, fmt.Errorf("redis get rate limit override: %w", err)
+	}
+	return decodeRedisRateLimitOverride(ctx, scope, subject, encoded)
+}
+
+// List retrieves every override, sorted as repository.SortOverrides does.
+func (r RedisRateLimitOverrideRepository) List(ctx context.Context) ([]domain.RateLimitOverride, error) {
+	fields, err := r.client.HGetAll(ctx, tenantOverridesKey(ctx)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis list rate limit overrides: %w", err)
+	}
+
+	overrides := make([]domain.RateLimitOverride, 0, len(fields))
+	for field, encoded := range fields {
+		scope, subject, _ := strings.Cut(field, ":")
+		override, err := decodeRedisRateLimitOverride(ctx, domain.OverrideScope(scope), subject, encoded)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, override)
+	}
+	repository.SortOverrides(overrides)
+	return overrides, nil
+}
+
+// Save stores an override, replacing the one of the same subject if any.
+func (r RedisRateLimitOverrideRepository) Save(ctx context.Context, override domain.RateLimitOverride) error {
+	encoded, err := encodeRedisRateLimitOverride(override)
+	if err != nil {
+		return err
+	}
+	field := overrideField(override.Scope, override.Subject)
+	if err := r.client.HSet(ctx, tenantOverridesKey(ctx), field, encoded).Err(); err != nil {
+		return fmt.Errorf("redis save rate limit override: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the override of a user or segment.
+// It returns repository.ErrOverrideNotFound if the subject has no override.
+func (r RedisRateLimitOverrideRepository) Delete(ctx context.Context, scope domain.OverrideScope, subject string) error {
+	deleted, err := r.client.HDel(ctx, tenantOverridesKey(ctx), overrideField(scope, subject)).Result()
+	if err != nil {
+		return fmt.Errorf("redis delete rate limit override: %w", err)
+	}
+	if deleted == 0 {
+		return repository.ErrOverrideNotFound
+	}
+	return nil
+}
+
+// tenantOverridesKey returns the hash holding the rate limit overrides of the tenant of ctx.
+func tenantOverridesKey(ctx context.Context) string {
+	if tenantID := repository.TenantFromContext(ctx); tenantID != domain.DefaultTenantID {
+		return rateLimitOverridesKey + ":" + tenantID
+	}
+	return rateLimitOverridesKey
+}
+
+// overrideField returns the field of the override of the subject in the overrides hash.
+func overrideField(scope domain.OverrideScope, subject string) string {
+	return string(scope) + ":" + subject
+}
+
+func encodeRedisRateLimitOverride(override domain.RateLimitOverride) (string, error) {
+	toRedis := func(rule domain.RateLimitRule) redisRateLimitRule {
+		return redisRateLimitRule{
+			MaxCount:   rule.MaxCount,
+			Expiration: rule.Expiration,
+			Unlimited:  rule.Unlimited,
+		}
+	}
+
+	o := redisRateLimitOverride{Rules: make(map[string]redisRateLimitRule, len(override.Rules))}
+	for notificationType, rule := range override.Rules {
+		o.Rules[notificationType.String()] = toRedis(rule)
+	}
+	if override.Default != nil {
+		rule := toRedis(*override.Default)
+		o.Default = &rule
+	}
+
+	encoded, err := json.Marshal(o)
+	if err != nil {
+		return "", fmt.Errorf("encode rate limit override: %w", err)
+	}
+	return string(encoded), nil
+}
+
+func decodeRedisRateLimitOverride(ctx context.Context,
+	scope domain.OverrideScope, subject, encoded string) (domain.RateLimitOverride, error) {
+	var o redisRateLimitOverride
+	if err := json.Unmarshal([]byte(encoded), &o); err != nil {
+		return domain.RateLimitOverride{}, fmt.Errorf("decode rate limit override: %w", err)
+	}
+	fromRedis := func(rule redisRateLimitRule) domain.RateLimitRule {
+		return domain.RateLimitRule{
+			MaxCount:   rule.MaxCount,
+			Expiration: rule.Expiration,
+			Unlimited:  rule.Unlimited,
+		}
+	}
+
+	override := domain.RateLimitOverride{Scope: scope, Subject: subject}
+	if len(o.Rules) > 0 {
+		override.Rules = make(domain.RateLimitRules, len(o.Rules))
+	}
+	for field, rule := range o.Rules {
+		notificationType, err := domain.ToNotificationType(field)
+		if err != nil {
+			logging.FromContext(ctx).Warn().Str("type", field).Msg("skipping rate limit override of unknown notification type")
+			continue
+		}
+		override.Rules[notificationType] = fromRedis(rule)
+	}
+	if o.Default != nil {
+		rule := fromRedis(*o.Default)
+		override.Default = &rule
+	}
+	return override, nil
+}
+
+// NewRedisRateLimitExemptionRepository creates a new RedisRateLimitExemptionRepository instance.
+func NewRedisRateLimitExemptionRepository(client *redis.Client) *RedisRateLimitExemptionRepository {
+	return &RedisRateLimitExemptionRepository{client}
+}
+
+// RedisRateLimitExemptionRepository is the Redis implementation of the rate limit exemption repository.
+//
+// Each exemption is held by its own key, which Redis expires along with the exemption, so that
+// every replica sharing the same Redis instance stops applying it at the same time.
+type RedisRateLimitExemptionRepository struct {
+	client *redis.Client
+}
+
+// redisRateLimitExemption is the JSON representation of an exemption.
+type redisRateLimitExemption struct {
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Get retrieves the active exemption of the notification type.
+// It returns repository.ErrExemptionNotFound if the notification type has none.
+func (r RedisRateLimitExemptionRepository) Get(ctx context.Context,
+	notificationType domain.NotificationType) (domain.RateLimitExemption, error) {
+	encoded, err := r.client.Get(ctx, tenantExemptionKey(ctx, notificationType)).Result()
+	if errors.Is(err, redis.Nil) {
+		return domain.RateLimitExemption{}, repository.ErrExemptionNotFound
+	}
+	if err != nil {
+		return domain.RateLimitExemption{}, fmt.Errorf("redis get rate limit exemption: %w", err)
+	}
+
+	exemption, err := decodeRedisRateLimitExemption(notificationType, encoded)
+	if err != nil {
+		return domain.RateLimitExemption{}, err
+	}
+	if !exemption.Active(time.Now()) {
+		return domain.RateLimitExemption{}, repository.ErrExemptionNotFound
+	}
+	return exemption, nil
+}
+
+// List retrieves every active exemption, sorted by notification type.
+func (r RedisRateLimitExemptionRepository) List(ctx context.Context) ([]domain.RateLimitExemption, error) {
+	notificationTypes := domain.NotificationTypes()
+	keys := make([]string, 0, len(notificationTypes))
+	for _, notificationType := range notificationTypes {
+		keys = append(keys, tenantExemptionKey(ctx, notificationType))
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis list rate limit exemptions: %w", err)
+	}
+
+	now := time.Now()
+	exemptions := make([]domain.RateLimitExemption, 0)
+	for i, value := range values {
+		encoded, ok := value.(string)
+		if !ok {
+			continue
+		}
+		exemption, err := decodeRedisRateLimitExemption(notificationTypes[i], encoded)
+		if err != nil {
+			return nil, err
+		}
+		if exemption.Active(now) {
+			exemptions = append(exemptions, exemption)
+		}
+	}
+	return exemptions, nil
+}
+
+// Save stores an exemption, replacing the one of the same notification type if any.
+func (r RedisRateLimitExemptionRepository) Save(ctx context.Context, exemption domain.RateLimitExemption) error {
+	encoded, err := json.Marshal(redisRateLimitExemption{
+		Reason:    exemption.Reason,
+		Actor:     exemption.Actor,
+		CreatedAt: exemption.CreatedAt,
+		ExpiresAt: exemption.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("encode rate limit exemption: %w", err)
+	}
+	err = r.client.SetArgs(ctx, tenantExemptionKey(ctx, exemption.Type), string(encoded),
+		redis.SetArgs{ExpireAt: exemption.ExpiresAt}).Err()
+	if err != nil {
+		return fmt.Errorf("redis save rate limit exemption: %w", err)
+	}
+	return nil
+}
+
+// Delete ends the exemption of the notification type.
+// It returns repository.ErrExemptionNotFound if the notification type has no active exemption.
+func (r RedisRateLimitExemptionRepository) Delete(ctx context.Context, notificationType domain.NotificationType) error {
+	deleted, err := r.client.Del(ctx, tenantExemptionKey(ctx, notificationType)).Result()
+	if err != nil {
+		return fmt.Errorf("redis delete rate limit exemption: %w", err)
+	}
+	if deleted == 0 {
+		return repository.ErrExemptionNotFound
+	}
+	return nil
+}
+
+// tenantExemptionKey returns the key holding the exemption of the notification type for the tenant of ctx.
+func tenantExemptionKey(ctx context.Context, notificationType domain.NotificationType) string {
+	if tenantID := repository.TenantFromContext(ctx); tenantID != domain.DefaultTenantID {
+		return rateLimitExemptionKey + ":" + tenantID + ":" + notificationType.String()
+	}
+	return rateLimitExemptionKey + ":" + notificationType.String()
+}
+
+func decodeRedisRateLimitExemption(notificationType domain.NotificationType,
+	encoded string) (domain.RateLimitExemption, error) {
+	var e redisRateLimitExemption
+	if err := json.Unmarshal([]byte(encoded), &e); err != nil {
+		return domain.RateLimitExemption{}, fmt.Errorf("decode rate limit exemption: %w", err)
+	}
+	return domain.RateLimitExemption{
+		Type:      notificationType,
+		Reason:    e.Reason,
+		Actor:     e.Actor,
+		CreatedAt: e.CreatedAt,
+		ExpiresAt: e.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,186 @@
+package infra_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestRedisRateLimitOverrideRepository_Get(t *testing.T) {
+	t.Run("override is decoded", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHGet("ratelimit:overrides", "segment:vip").
+			SetVal(`{"rules":{"news":{"maxCount":5,"expiration":3600000000000}},"default":{"maxCount":0,"expiration":0,"unlimited":true}}`)
+
+		repo := infra.NewRedisRateLimitOverrideRepository(db)
+		override, err := repo.Get(context.Background(), domain.OverrideSegment, "vip")
+		require.NoError(t, err)
+		assert.Equal(t, domain.RateLimitOverride{
+			Scope:   domain.OverrideSegment,
+			Subject: "vip",
+			Rules:   domain.RateLimitRules{domain.News: {MaxCount: 5, Expiration: time.Hour}},
+			Default: &domain.RateLimitRule{Unlimited: true},
+		}, override)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing override", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHGet("ratelimit:overrides:billing", "user:abc-123").RedisNil()
+
+		repo := infra.NewRedisRateLimitOverrideRepository(db)
+		_, err := repo.Get(repository.WithTenant(context.Background(), "billing"), domain.OverrideUser, "abc-123")
+		assert.ErrorIs(t, err, repository.ErrOverrideNotFound)
+	})
+
+	t.Run("redis failure", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHGet("ratelimit:overrides", "user:abc-123").SetErr(errors.New("connection refused"))
+
+		repo := infra.NewRedisRateLimitOverrideRepository(db)
+		_, err := repo.Get(context.Background(), domain.OverrideUser, "abc-123")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, repository.ErrOverrideNotFound)
+	})
+}
+
+func TestRedisRateLimitOverrideRepository_List(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	defer db.Close()
+	mock.ExpectHGetAll("ratelimit:overrides").SetVal(map[string]string{
+		"segment:vip":  `{"default":{"maxCount":10,"expiration":3600000000000}}`,
+		"user:abc-123": `{"rules":{"status":{"maxCount":0,"expiration":0,"unlimited":true}}}`,
+	})
+
+	repo := infra.NewRedisRateLimitOverrideRepository(db)
+	overrides, err := repo.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []domain.RateLimitOverride{
+		{
+			Scope:   domain.OverrideUser,
+			Subject: "abc-123",
+			Rules:   domain.RateLimitRules{domain.Status: {Unlimited: true}},
+		},
+		{
+			Scope:   domain.OverrideSegment,
+			Subject: "vip",
+			Default: &domain.RateLimitRule{MaxCount: 10, Expiration: time.Hour},
+		},
+	}, overrides)
+}
+
+func TestRedisRateLimitOverrideRepository_Save(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	defer db.Close()
+	mock.ExpectHSet("ratelimit:overrides", "user:abc-123",
+		`{"rules":{"news":{"maxCount":5,"expiration":3600000000000}}}`).SetVal(1)
+
+	repo := infra.NewRedisRateLimitOverrideRepository(db)
+	err := repo.Save(context.Background(), domain.RateLimitOverride{
+		Scope:   domain.OverrideUser,
+		Subject: "abc-123",
+		Rules:   domain.RateLimitRules{domain.News: {MaxCount: 5, Expiration: time.Hour}},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedisRateLimitOverrideRepository_Delete(t *testing.T) {
+	t.Run("override is deleted", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHDel("ratelimit:overrides", "segment:vip").SetVal(1)
+
+		repo := infra.NewRedisRateLimitOverrideRepository(db)
+		assert.NoError(t, repo.Delete(context.Background(), domain.OverrideSegment, "vip"))
+	})
+
+	t.Run("missing override", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHDel("ratelimit:overrides", "segment:vip").SetVal(0)
+
+		repo := infra.NewRedisRateLimitOverrideRepository(db)
+		assert.ErrorIs(t, repo.Delete(context.Background(), domain.OverrideSegment, "vip"), repository.ErrOverrideNotFound)
+	})
+}
+
+func TestRedisRateLimitExemptionRepository(t *testing.T) {
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	expiresAt := createdAt.Add(2 * time.Hour)
+	exemption := domain.RateLimitExemption{
+		Type:      domain.Status,
+		Reason:    "INC-42",
+		Actor:     "ops",
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	}
+	encoded := `{"reason":"INC-42","actor":"ops","createdAt":"` + createdAt.Format(time.RFC3339) +
+		`","expiresAt":"` + expiresAt.Format(time.RFC3339) + `"}`
+
+	t.Run("exemption is saved until it expires", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectSetArgs("ratelimit:exemption:billing:status", encoded,
+			redis.SetArgs{ExpireAt: expiresAt}).SetVal("OK")
+
+		repo := infra.NewRedisRateLimitExemptionRepository(db)
+		require.NoError(t, repo.Save(repository.WithTenant(context.Background(), "billing"), exemption))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("exemption is decoded", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectGet("ratelimit:exemption:status").SetVal(encoded)
+
+		repo := infra.NewRedisRateLimitExemptionRepository(db)
+		got, err := repo.Get(context.Background(), domain.Status)
+		require.NoError(t, err)
+		assert.Equal(t, exemption, got)
+	})
+
+	t.Run("missing exemption", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectGet("ratelimit:exemption:status").RedisNil()
+
+		repo := infra.NewRedisRateLimitExemptionRepository(db)
+		_, err := repo.Get(context.Background(), domain.Status)
+		assert.ErrorIs(t, err, repository.ErrExemptionNotFound)
+	})
+
+	t.Run("exemptions are listed", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectMGet("ratelimit:exemption:status", "ratelimit:exemption:news", "ratelimit:exemption:marketing").
+			SetVal([]interface{}{encoded, nil, nil})
+
+		repo := infra.NewRedisRateLimitExemptionRepository(db)
+		exemptions, err := repo.List(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []domain.RateLimitExemption{exemption}, exemptions)
+	})
+
+	t.Run("exemption is ended", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectDel("ratelimit:exemption:status").SetVal(1)
+		mock.ExpectDel("ratelimit:exemption:status").SetVal(0)
+
+		repo := infra.NewRedisRateLimitExemptionRepository(db)
+		assert.NoError(t, repo.Delete(context.Background(), domain.Status))
+		assert.ErrorIs(t, repo.Delete(context.Background(), domain.Status), repository.ErrExemptionNotFound)
+	})
+}
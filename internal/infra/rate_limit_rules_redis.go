@@ -0,0 +1,148 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"time"
+)
+
+// rateLimitRulesKey is the hash holding the rate limit rules of the default tenant, keyed by notification type.
+// The rules of the other tenants are held by the hash of the same name suffixed with ":<tenant ID>".
+const rateLimitRulesKey = "ratelimit:rules"
+
+// NewRedisRateLimitRuleRepository creates a new RedisRateLimitRuleRepository instance.
+func NewRedisRateLimitRuleRepository(client *redis.Client) *RedisRateLimitRuleRepository {
+	return &RedisRateLimitRuleRepository{client}
+}
+
+// RedisRateLimitRuleRepository is the Redis implementation of the rate limit rule repository.
+//
+// The rules are read from Redis on every lookup, so changes take effect immediately
+// on every replica sharing the same Redis instance. Each tenant has its own rules hash.
+type RedisRateLimitRuleRepository struct {
+	client *redis.Client
+}
+
+// redisRateLimitRule is the JSON representation of a rule in the rules hash.
+type redisRateLimitRule struct {
+	MaxCount   int           `json:"maxCount"`
+	Expiration time.Duration `json:"expiration"`
+	Unlimited  bool          `json:"unlimited,omitempty"`
+}
+
+// GetByNotificationType retrieves a rate limit rule by notification type.
+// It returns repository.ErrRuleNotFound if the notification type has no rule.
+func (r RedisRateLimitRuleRepository) GetByNotificationType(ctx context.Context,
+	notificationType domain.NotificationType) (domain.RateLimitRule, error) {
+	encoded, err := r.client.HGet(ctx, tenantRulesKey(ctx), notificationType.String()).Result()
+	if errors.Is(err, redis.Nil) {
+		return domain.RateLimitRule{}, repository.ErrRuleNotFound
+	}
+	if err != nil {
+		return domain.RateLimitRule{}, fmt.Errorf("redis get rate limit rule: %w", err)
+	}
+	return decodeRedisRateLimitRule(encoded)
+}
+
+// List retrieves every rate limit rule.
+func (r RedisRateLimitRuleRepository) List(ctx context.Context) (domain.RateLimitRules, error) {
+	fields, err := r.client.HGetAll(ctx, tenantRulesKey(ctx)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis list rate limit rules: %w", err)
+	}
+
+	rules := make(domain.RateLimitRules, len(fields))
+	for field, encoded := range fields {
+		notificationType, err := domain.ToNotificationType(field)
+		if err != nil {
+			logging.FromContext(ctx).Warn().Str("type", field).Msg("skipping rate limit rule of unknown notification type")
+			continue
+		}
+		if rules[notificationType], err = decodeRedisRateLimitRule(encoded); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// Save stores a rate limit rule in the repository.
+// It returns repository.ErrRuleAlreadyExists if the notification type already has a rule.
+func (r RedisRateLimitRuleRepository) Save(ctx context.Context, notificationType domain.NotificationType,
+	rule domain.RateLimitRule) error {
+	encoded, err := encodeRedisRateLimitRule(rule)
+	if err != nil {
+		return err
+	}
+	created, err := r.client.HSetNX(ctx, tenantRulesKey(ctx), notificationType.String(), encoded).Result()
+	if err != nil {
+		return fmt.Errorf("redis save rate limit rule: %w", err)
+	}
+	if !created {
+		return repository.ErrRuleAlreadyExists
+	}
+	return nil
+}
+
+// Update stores a rate limit rule in the repository, replacing the existing one if any.
+func (r RedisRateLimitRuleRepository) Update(ctx context.Context, notificationType domain.NotificationType,
+	rule domain.RateLimitRule) error {
+	encoded, err := encodeRedisRateLimitRule(rule)
+	if err != nil {
+		return err
+	}
+	if err := r.client.HSet(ctx, tenantRulesKey(ctx), notificationType.String(), encoded).Err(); err != nil {
+		return fmt.Errorf("redis update rate limit rule: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the rate limit rule of the notification type.
+// It returns repository.ErrRuleNotFound if the notification type has no rule.
+func (r RedisRateLimitRuleRepository) Delete(ctx context.Context, notificationType domain.NotificationType) error {
+	deleted, err := r.client.HDel(ctx, tenantRulesKey(ctx), notificationType.String()).Result()
+	if err != nil {
+		return fmt.Errorf("redis delete rate limit rule: %w", err)
+	}
+	if deleted == 0 {
+		return repository.ErrRuleNotFound
+	}
+	return nil
+}
+
+// tenantRulesKey returns the hash holding the rate limit rules of the tenant of ctx.
+func tenantRulesKey(ctx context.Context) string {
+	if tenantID := repository.TenantFromContext(ctx); tenantID != domain.DefaultTenantID {
+		return rateLimitRulesKey + ":" + tenantID
+	}
+	return rateLimitRulesKey
+}
+
+func encodeRedisRateLimitRule(rule domain.RateLimitRule) (string, error) {
+	encoded, err := json.Marshal(redisRateLimitRule{
+		MaxCount:   rule.MaxCount,
+		Expiration: rule.Expiration,
+		Unlimited:  rule.Unlimited,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode rate limit rule: %w", err)
+	}
+	return string(encoded), nil
+}
+
+func decodeRedisRateLimitRule(encoded string) (domain.RateLimitRule, error) {
+	var rule redisRateLimitRule
+	if err := json.Unmarshal([]byte(encoded), &rule); err != nil {
+		return domain.RateLimitRule{}, fmt.Errorf("decode rate limit rule: %w", err)
+	}
+	return domain.RateLimitRule{
+		MaxCount:   rule.MaxCount,
+		Expiration: rule.Expiration,
+		Unlimited:  rule.Unlimited,
+	}, nil
+}
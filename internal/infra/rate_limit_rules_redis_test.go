@@ -0,0 +1,134 @@
+package infra_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestRedisRateLimitRuleRepository_GetByNotificationType(t *testing.T) {
+	t.Run("rule is decoded", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHGet("ratelimit:rules", "status").SetVal(`{"maxCount":2,"expiration":60000000000}`)
+
+		repo := infra.NewRedisRateLimitRuleRepository(db)
+		rule, err := repo.GetByNotificationType(context.Background(), domain.Status)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute}, rule)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing rule", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHGet("ratelimit:rules", "status").RedisNil()
+
+		repo := infra.NewRedisRateLimitRuleRepository(db)
+		_, err := repo.GetByNotificationType(context.Background(), domain.Status)
+		assert.ErrorIs(t, err, repository.ErrRuleNotFound)
+	})
+
+	t.Run("rule of a tenant", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHGet("ratelimit:rules:billing", "status").SetVal(`{"maxCount":2,"expiration":60000000000}`)
+
+		repo := infra.NewRedisRateLimitRuleRepository(db)
+		rule, err := repo.GetByNotificationType(repository.WithTenant(context.Background(), "billing"), domain.Status)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute}, rule)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("redis failure", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHGet("ratelimit:rules", "status").SetErr(errors.New("connection refused"))
+
+		repo := infra.NewRedisRateLimitRuleRepository(db)
+		_, err := repo.GetByNotificationType(context.Background(), domain.Status)
+		assert.Error(t, err)
+	})
+}
+
+func TestRedisRateLimitRuleRepository_List(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	defer db.Close()
+	mock.ExpectHGetAll("ratelimit:rules").SetVal(map[string]string{
+		"status":  `{"maxCount":2,"expiration":60000000000}`,
+		"news":    `{"maxCount":1,"expiration":86400000000000}`,
+		"unknown": `{"maxCount":1,"expiration":1}`,
+	})
+
+	repo := infra.NewRedisRateLimitRuleRepository(db)
+	rules, err := repo.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, domain.RateLimitRules{
+		domain.Status: {MaxCount: 2, Expiration: time.Minute},
+		domain.News:   {MaxCount: 1, Expiration: 24 * time.Hour},
+	}, rules)
+}
+
+func TestRedisRateLimitRuleRepository_Save(t *testing.T) {
+	rule := domain.RateLimitRule{MaxCount: 3, Expiration: time.Hour}
+	encoded := `{"maxCount":3,"expiration":3600000000000}`
+
+	t.Run("rule is saved", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHSetNX("ratelimit:rules", "marketing", encoded).SetVal(true)
+
+		repo := infra.NewRedisRateLimitRuleRepository(db)
+		require.NoError(t, repo.Save(context.Background(), domain.Marketing, rule))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rule already exists", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHSetNX("ratelimit:rules", "marketing", encoded).SetVal(false)
+
+		repo := infra.NewRedisRateLimitRuleRepository(db)
+		err := repo.Save(context.Background(), domain.Marketing, rule)
+		assert.ErrorIs(t, err, repository.ErrRuleAlreadyExists)
+	})
+}
+
+func TestRedisRateLimitRuleRepository_Update(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	defer db.Close()
+	mock.ExpectHSet("ratelimit:rules", "marketing", `{"maxCount":3,"expiration":3600000000000}`).SetVal(0)
+
+	repo := infra.NewRedisRateLimitRuleRepository(db)
+	err := repo.Update(context.Background(), domain.Marketing, domain.RateLimitRule{MaxCount: 3, Expiration: time.Hour})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedisRateLimitRuleRepository_Delete(t *testing.T) {
+	t.Run("rule is deleted", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHDel("ratelimit:rules", "news").SetVal(1)
+
+		repo := infra.NewRedisRateLimitRuleRepository(db)
+		require.NoError(t, repo.Delete(context.Background(), domain.News))
+	})
+
+	t.Run("rule is not found", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHDel("ratelimit:rules", "news").SetVal(0)
+
+		repo := infra.NewRedisRateLimitRuleRepository(db)
+		assert.ErrorIs(t, repo.Delete(context.Background(), domain.News), repository.ErrRuleNotFound)
+	})
+}
@@ -0,0 +1,271 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"time"
+)
+
+// NewSQLRateLimitRuleRepository creates a new SQLRateLimitRuleRepository instance.
+func NewSQLRateLimitRuleRepository(db *sql.DB, dialect SQLDialect) *SQLRateLimitRuleRepository {
+	return &SQLRateLimitRuleRepository{db: db, dialect: dialect}
+}
+
+// SQLRateLimitRuleRepository is the SQL implementation of the rate limit rule repository,
+// running on PostgreSQL or SQLite. Every change is recorded in the rule history along with
+// its actor and tenant, in the same transaction.
+//
+// The rules are read from the database on every lookup, so it's meant to be used behind
+// a repository.CachedRateLimitRuleRepository.
+type SQLRateLimitRuleRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// Migrate applies the pending schema migrations.
+func (r SQLRateLimitRuleRepository) Migrate(ctx context.Context) error {
+	return migrate(ctx, r.db, r.dialect)
+}
+
+// GetByNotificationType retrieves a rate limit rule by notification type.
+// It returns repository.ErrRuleNotFound if the notification type has no rule.
+func (r SQLRateLimitRuleRepository) GetByNotificationType(ctx context.Context,
+	notificationType domain.NotificationType) (domain.RateLimitRule, error) {
+	rule, err := getRateLimitRule(ctx, r.db, r.dialect, notificationType, "")
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.RateLimitRule{}, repository.ErrRuleNotFound
+	}
+	return rule, err
+}
+
+// List retrieves every rate limit rule.
+func (r SQLRateLimitRuleRepository) List(ctx context.Context) (domain.RateLimitRules, error) {
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind("SELECT type, max_count, expiration, unlimited FROM rate_limit_rules WHERE tenant_id = $1"),
+		repository.TenantFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("query rate limit rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make(domain.RateLimitRules)
+	for rows.Next() {
+		var (
+			field string
+			rule  domain.RateLimitRule
+		)
+		if err = rows.Scan(&field, &rule.MaxCount, &rule.Expiration, &rule.Unlimited); err != nil {
+			return nil, fmt.Errorf("scan rate limit rule: %w", err)
+		}
+		notificationType, err := domain.ToNotificationType(field)
+		if err != nil {
+			logging.FromContext(ctx).Warn().Str("type", field).Msg("skipping rate limit rule of unknown notification type")
+			continue
+		}
+		rules[notificationType] = rule
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("read rate limit rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Save stores a rate limit rule in the repository.
+// It returns repository.ErrRuleAlreadyExists if the notification type already has a rule.
+func (r SQLRateLimitRuleRepository) Save(ctx context.Context, notificationType domain.NotificationType,
+	rule domain.RateLimitRule) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`
+INSERT INTO rate_limit_rules (tenant_id, type, max_count, expiration, unlimited) VALUES ($1, $2, $3, $4, $5)`),
+			repository.TenantFromContext(ctx), notificationType.String(), rule.MaxCount, int64(rule.Expiration),
+			rule.Unlimited)
+		if isUniqueViolation(err) {
+			return repository.ErrRuleAlreadyExists
+		}
+		if err != nil {
+			return fmt.Errorf("insert rate limit rule: %w", err)
+		}
+		return r.record(ctx, tx, notificationType, nil, &rule)
+	})
+}
+
+// Update stores a rate limit rule in the repository, replacing the existing one if any.
+// Updates that don't change the rule aren't recorded in the history.
+func (r SQLRateLimitRuleRepository) Update(ctx context.Context, notificationType domain.NotificationType,
+	rule domain.RateLimitRule) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var before *domain.RateLimitRule
+		old, err := getRateLimitRule(ctx, tx, r.dialect, notificationType, r.dialect.forUpdate())
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return err
+		case old == rule:
+			return nil
+		default:
+			before = &old
+		}
+
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`
+INSERT INTO rate_limit_rules (tenant_id, type, max_count, expiration, unlimited) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (tenant_id, type) DO UPDATE
+SET max_count = EXCLUDED.max_count, expiration = EXCLUDED.expiration, unlimited = EXCLUDED.unlimited`),
+			repository.TenantFromContext(ctx), notificationType.String(), rule.MaxCount, int64(rule.Expiration),
+			rule.Unlimited)
+		if err != nil {
+			return fmt.Errorf("upsert rate limit rule: %w", err)
+		}
+		return r.record(ctx, tx, notificationType, before, &rule)
+	})
+}
+
+// Delete removes the rate limit rule of the notification type.
+// It returns repository.ErrRuleNotFound if the notification type has no rule.
+func (r SQLRateLimitRuleRepository) Delete(ctx context.Context, notificationType domain.NotificationType) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		old, err := getRateLimitRule(ctx, tx, r.dialect, notificationType, r.dialect.forUpdate())
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrRuleNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, r.dialect.rebind("DELETE FROM rate_limit_rules WHERE tenant_id = $1 AND type = $2"),
+			repository.TenantFromContext(ctx), notificationType.String())
+		if err != nil {
+			return fmt.Errorf("delete rate limit rule: %w", err)
+		}
+		return r.record(ctx, tx, notificationType, &old, nil)
+	})
+}
+
+// History retrieves up to limit changes of the rule of the notification type,
+// the most recent first. Zero means no limit.
+func (r SQLRateLimitRuleRepository) History(ctx context.Context, notificationType domain.NotificationType,
+	limit int) ([]domain.RateLimitRuleChange, error) {
+	sqlLimit := r.dialect.noLimit()
+	if limit > 0 {
+		sqlLimit = fmt.Sprint(limit)
+	}
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(`
+SELECT actor, changed_at, old_max_count, old_expiration, old_unlimited,
+	new_max_count, new_expiration, new_unlimited
+FROM rate_limit_rule_history
+WHERE tenant_id = $1 AND type = $2
+ORDER BY id DESC
+LIMIT `+sqlLimit), repository.TenantFromContext(ctx), notificationType.String())
+	if err != nil {
+		return nil, fmt.Errorf("query rate limit rule history: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]domain.RateLimitRuleChange, 0)
+	for rows.Next() {
+		var (
+			change                     domain.RateLimitRuleChange
+			oldMaxCount, oldExpiration sql.NullInt64
+			newMaxCount, newExpiration sql.NullInt64
+			oldUnlimited, newUnlimited sql.NullBool
+		)
+		err = rows.Scan(&change.Actor, &change.At, &oldMaxCount, &oldExpiration, &oldUnlimited,
+			&newMaxCount, &newExpiration, &newUnlimited)
+		if err != nil {
+			return nil, fmt.Errorf("scan rate limit rule change: %w", err)
+		}
+		change.Type = notificationType
+		change.At = change.At.UTC()
+		change.Old = nullableRule(oldMaxCount, oldExpiration, oldUnlimited)
+		change.New = nullableRule(newMaxCount, newExpiration, newUnlimited)
+		changes = append(changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("read rate limit rule history: %w", err)
+	}
+	return changes, nil
+}
+
+// inTx runs fn in a transaction, committing it if fn succeeds.
+func (r SQLRateLimitRuleRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// record appends a change to the rule history.
+func (r SQLRateLimitRuleRepository) record(ctx context.Context, tx *sql.Tx, notificationType domain.NotificationType,
+	before, after *domain.RateLimitRule) error {
+	oldMaxCount, oldExpiration, oldUnlimited := nullRule(before)
+	newMaxCount, newExpiration, newUnlimited := nullRule(after)
+	_, err := tx.ExecContext(ctx, r.dialect.rebind(`
+INSERT INTO rate_limit_rule_history
+	(tenant_id, type, actor, changed_at, old_max_count, old_expiration, old_unlimited,
+	new_max_count, new_expiration, new_unlimited)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`),
+		repository.TenantFromContext(ctx), notificationType.String(), repository.ActorFromContext(ctx), time.Now().UTC(),
+		oldMaxCount, oldExpiration, oldUnlimited, newMaxCount, newExpiration, newUnlimited)
+	if err != nil {
+		return fmt.Errorf("insert rate limit rule change: %w", err)
+	}
+	return nil
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// getRateLimitRule retrieves the rule of the notification type of the tenant of ctx,
+// returning sql.ErrNoRows if there's none.
+func getRateLimitRule(ctx context.Context, q queryer, dialect SQLDialect,
+	notificationType domain.NotificationType, lock string) (domain.RateLimitRule, error) {
+	var rule domain.RateLimitRule
+	err := q.QueryRowContext(ctx,
+		dialect.rebind(
+			"SELECT max_count, expiration, unlimited FROM rate_limit_rules WHERE tenant_id = $1 AND type = $2"+lock),
+		repository.TenantFromContext(ctx), notificationType.String()).
+		Scan(&rule.MaxCount, &rule.Expiration, &rule.Unlimited)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return rule, fmt.Errorf("query rate limit rule: %w", err)
+	}
+	return rule, err
+}
+
+// nullRule converts an optional rule into its nullable columns.
+func nullRule(rule *domain.RateLimitRule) (maxCount, expiration sql.NullInt64, unlimited sql.NullBool) {
+	if rule == nil {
+		return maxCount, expiration, unlimited
+	}
+	return sql.NullInt64{Int64: int64(rule.MaxCount), Valid: true},
+		sql.NullInt64{Int64: int64(rule.Expiration), Valid: true},
+		sql.NullBool{Bool: rule.Unlimited, Valid: true}
+}
+
+// nullableRule converts the nullable columns of a rule into an optional rule.
+// The changes recorded before the unlimited rules existed have no unlimited column.
+func nullableRule(maxCount, expiration sql.NullInt64, unlimited sql.NullBool) *domain.RateLimitRule {
+	if !maxCount.Valid {
+		return nil
+	}
+	return &domain.RateLimitRule{
+		MaxCount:   int(maxCount.Int64),
+		Expiration: time.Duration(expiration.Int64),
+		Unlimited:  unlimited.Bool,
+	}
+}
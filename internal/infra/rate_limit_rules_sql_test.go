@@ -0,0 +1,164 @@
+package infra_test
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/internal/repository"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newSQLiteRateLimitRuleRepository creates a SQLRateLimitRuleRepository on a fresh SQLite database.
+func newSQLiteRateLimitRuleRepository(t *testing.T) *infra.SQLRateLimitRuleRepository {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notification.db")
+	db, err := infra.OpenSQLDatabase(context.Background(), infra.SQLDialectSQLite, path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	repo := infra.NewSQLRateLimitRuleRepository(db, infra.SQLDialectSQLite)
+	require.NoError(t, repo.Migrate(context.Background()))
+	return repo
+}
+
+func TestSQLRateLimitRuleRepository(t *testing.T) {
+	ctx := repository.WithActor(context.Background(), "jane")
+	rule := domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute}
+	updated := domain.RateLimitRule{MaxCount: 5, Expiration: time.Hour}
+
+	repo := newSQLiteRateLimitRuleRepository(t)
+
+	t.Run("missing rule", func(t *testing.T) {
+		_, err := repo.GetByNotificationType(ctx, domain.News)
+		assert.ErrorIs(t, err, repository.ErrRuleNotFound)
+	})
+	t.Run("rule is saved", func(t *testing.T) {
+		require.NoError(t, repo.Save(ctx, domain.News, rule))
+
+		got, err := repo.GetByNotificationType(ctx, domain.News)
+		require.NoError(t, err)
+		assert.Equal(t, rule, got)
+
+		err = repo.Save(ctx, domain.News, updated)
+		assert.ErrorIs(t, err, repository.ErrRuleAlreadyExists)
+	})
+	t.Run("rule is updated", func(t *testing.T) {
+		require.NoError(t, repo.Update(context.Background(), domain.News, updated))
+		require.NoError(t, repo.Update(ctx, domain.News, updated))
+		require.NoError(t, repo.Update(ctx, domain.Status, rule))
+
+		rules, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RateLimitRules{domain.News: updated, domain.Status: rule}, rules)
+	})
+	t.Run("rule is deleted", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, domain.News))
+		assert.ErrorIs(t, repo.Delete(ctx, domain.News), repository.ErrRuleNotFound)
+
+		_, err := repo.GetByNotificationType(ctx, domain.News)
+		assert.ErrorIs(t, err, repository.ErrRuleNotFound)
+	})
+	t.Run("changes are recorded", func(t *testing.T) {
+		changes, err := repo.History(ctx, domain.News, 0)
+		require.NoError(t, err)
+		require.Len(t, changes, 3, "unchanged rules and failed changes aren't recorded")
+
+		assert.Equal(t, "jane", changes[0].Actor)
+		assert.Equal(t, &updated, changes[0].Old)
+		assert.Nil(t, changes[0].New)
+
+		assert.Equal(t, repository.UnknownActor, changes[1].Actor)
+		assert.Equal(t, &rule, changes[1].Old)
+		assert.Equal(t, &updated, changes[1].New)
+
+		assert.Equal(t, domain.News, changes[2].Type)
+		assert.Equal(t, "jane", changes[2].Actor)
+		assert.Nil(t, changes[2].Old)
+		assert.Equal(t, &rule, changes[2].New)
+		assert.WithinDuration(t, time.Now(), changes[2].At, time.Minute)
+
+		changes, err = repo.History(ctx, domain.News, 1)
+		require.NoError(t, err)
+		assert.Len(t, changes, 1)
+
+		changes, err = repo.History(ctx, domain.Marketing, 0)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+	t.Run("unlimited rule", func(t *testing.T) {
+		unlimited := domain.RateLimitRule{Unlimited: true}
+		require.NoError(t, repo.Update(ctx, domain.Marketing, unlimited))
+
+		got, err := repo.GetByNotificationType(ctx, domain.Marketing)
+		require.NoError(t, err)
+		assert.Equal(t, unlimited, got)
+
+		changes, err := repo.History(ctx, domain.Marketing, 0)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, &unlimited, changes[0].New)
+	})
+}
+
+func TestSQLRateLimitRuleRepository_Postgres(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(
+		`SELECT max_count, expiration, unlimited FROM rate_limit_rules WHERE tenant_id = \$1 AND type = \$2 FOR UPDATE`).
+		WithArgs("default", "status").
+		WillReturnRows(sqlmock.NewRows([]string{"max_count", "expiration", "unlimited"}).
+			AddRow(2, int64(time.Minute), false))
+	mock.ExpectExec(`INSERT INTO rate_limit_rules (.+) ON CONFLICT \(tenant_id, type\) DO UPDATE`).
+		WithArgs("default", "status", 3, int64(time.Hour), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO rate_limit_rule_history").
+		WithArgs("default", "status", "jane", sqlmock.AnyArg(), int64(2), int64(time.Minute), false,
+			int64(3), int64(time.Hour), false).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	repo := infra.NewSQLRateLimitRuleRepository(db, infra.SQLDialectPostgres)
+	err = repo.Update(repository.WithActor(context.Background(), "jane"), domain.Status,
+		domain.RateLimitRule{MaxCount: 3, Expiration: time.Hour})
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLRateLimitRuleRepository_Tenants(t *testing.T) {
+	billing := repository.WithActor(repository.WithTenant(context.Background(), "billing"), "jane")
+	shipping := repository.WithTenant(context.Background(), "shipping")
+	rule := domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute}
+
+	repo := newSQLiteRateLimitRuleRepository(t)
+	require.NoError(t, repo.Save(billing, domain.News, rule))
+
+	_, err := repo.GetByNotificationType(shipping, domain.News)
+	assert.ErrorIs(t, err, repository.ErrRuleNotFound)
+	rules, err := repo.List(shipping)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+	assert.ErrorIs(t, repo.Delete(shipping, domain.News), repository.ErrRuleNotFound)
+	changes, err := repo.History(shipping, domain.News, 0)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	require.NoError(t, repo.Save(shipping, domain.News, domain.RateLimitRule{Unlimited: true}),
+		"each tenant has its own rules")
+	require.NoError(t, repo.Update(shipping, domain.News, rule))
+	got, err := repo.GetByNotificationType(billing, domain.News)
+	require.NoError(t, err)
+	assert.Equal(t, rule, got)
+	changes, err = repo.History(billing, domain.News, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "jane", changes[0].Actor)
+}
@@ -0,0 +1,172 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"io/fs"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SQLDialect is the SQL database flavour the SQL repositories run on.
+// Its value is the name of the database/sql driver.
+type SQLDialect string
+
+const (
+	// SQLDialectPostgres is PostgreSQL, through the lib/pq driver.
+	SQLDialectPostgres SQLDialect = "postgres"
+	// SQLDialectSQLite is SQLite, through the pure-Go modernc.org/sqlite driver.
+	SQLDialectSQLite SQLDialect = "sqlite"
+)
+
+// migrationsLockID is the PostgreSQL advisory lock held while migrating,
+// so that replicas starting at the same time don't apply the same migration twice.
+const migrationsLockID = 7242001
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// placeholder matches the PostgreSQL query placeholders, e.g. $1.
+var placeholder = regexp.MustCompile(`\$(\d+)`)
+
+// OpenSQLDatabase opens the database of the given dialect and checks the connection.
+func OpenSQLDatabase(ctx context.Context, dialect SQLDialect, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(string(dialect), dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	return db, nil
+}
+
+// rebind translates the PostgreSQL placeholders of the query into the ones of the dialect.
+// Queries are written with PostgreSQL placeholders, which SQLite numbers as ?1, ?2, etc.
+func (d SQLDialect) rebind(query string) string {
+	if d == SQLDialectSQLite {
+		return placeholder.ReplaceAllString(query, "?$1")
+	}
+	return query
+}
+
+// noLimit is the LIMIT clause value that doesn't limit the number of rows.
+func (d SQLDialect) noLimit() string {
+	if d == SQLDialectSQLite {
+		return "-1"
+	}
+	return "ALL"
+}
+
+// forUpdate is the clause locking the selected rows until the end of the transaction.
+// SQLite locks the whole database on write, so it doesn't need one.
+func (d SQLDialect) forUpdate() string {
+	if d == SQLDialectPostgres {
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+// isUniqueViolation reports whether the error is caused by a primary key or unique constraint.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name() == "unique_violation"
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE ||
+			sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
+// escapeLike escapes the LIKE wildcards of s, to be used with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// migrate applies the embedded schema migrations that haven't been applied yet, in version order.
+// Each migration runs in its own transaction and is recorded in the schema_migrations table.
+//
+// Migrations are named "<version>.sql", or "<version>.<dialect>.sql" when they're written for
+// a single dialect, in which case every dialect must have its own version of the migration.
+func migrate(ctx context.Context, db *sql.DB, dialect SQLDialect) error {
+	_, err := db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    TEXT PRIMARY KEY,
+	applied_at TIMESTAMP NOT NULL
+)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations table: %w", err)
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("list migrations: %w", err)
+	}
+	for _, file := range files {
+		version, fileDialect, _ := strings.Cut(strings.TrimSuffix(path.Base(file), ".sql"), ".")
+		if fileDialect != "" && SQLDialect(fileDialect) != dialect {
+			continue
+		}
+		if err = applyMigration(ctx, db, dialect, version, file); err != nil {
+			return fmt.Errorf("apply migration %s: %w", path.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// applyMigration applies the migration file unless its version has already been applied.
+func applyMigration(ctx context.Context, db *sql.DB, dialect SQLDialect, version, file string) error {
+	script, err := migrations.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if dialect == SQLDialectPostgres {
+		if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", migrationsLockID); err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+	}
+
+	var applied int
+	err = tx.QueryRowContext(ctx,
+		dialect.rebind("SELECT COUNT(*) FROM schema_migrations WHERE version = $1"), version).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("check migration: %w", err)
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if _, err = tx.ExecContext(ctx, string(script)); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		dialect.rebind("INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)"),
+		version, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("record migration: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
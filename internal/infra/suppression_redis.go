@@ -0,0 +1,129 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"sort"
+	"time"
+)
+
+// suppressionsKey is the hash holding the suppression list of the default tenant, keyed by normalized
+// email address. The suppression lists of the other tenants are held by the hash of the same name
+// suffixed with ":<tenant ID>".
+const suppressionsKey = "suppressions"
+
+// NewRedisSuppressionRepository creates a new RedisSuppressionRepository instance.
+func NewRedisSuppressionRepository(client *redis.Client) *RedisSuppressionRepository {
+	return &RedisSuppressionRepository{client}
+}
+
+// RedisSuppressionRepository is the Redis implementation of the suppression list repository.
+//
+// The suppressions are read from Redis on every lookup, so an address suppressed by a bounce
+// received by one replica is skipped by every replica sharing the same Redis instance.
+// Each tenant has its own suppressions hash.
+type RedisSuppressionRepository struct {
+	client *redis.Client
+}
+
+// redisSuppression is the JSON representation of a suppression in the suppressions hash.
+type redisSuppression struct {
+	Reason    string    `json:"reason"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Get retrieves the suppression of the given email address.
+// It returns repository.ErrSuppressionNotFound if the address isn't suppressed.
+func (r RedisSuppressionRepository) Get(ctx context.Context, email string) (domain.Suppression, error) {
+	email = domain.NormalizeEmail(email)
+	encoded, err := r.client.HGet(ctx, tenantSuppressionsKey(ctx), email).Result()
+	if errors.Is(err, redis.Nil) {
+		return domain.Suppression{}, repository.ErrSuppressionNotFound
+	}
+	if err != nil {
+		return domain.Suppression{}, fmt.Errorf("redis get suppression: %w", err)
+	}
+	return decodeRedisSuppression(email, encoded)
+}
+
+// Save stores a suppression, replacing any existing one for the same email address.
+func (r RedisSuppressionRepository) Save(ctx context.Context, suppression domain.Suppression) error {
+	encoded, err := json.Marshal(redisSuppression{
+		Reason:    suppression.Reason.String(),
+		Detail:    suppression.Detail,
+		CreatedAt: suppression.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("encode suppression: %w", err)
+	}
+	email := domain.NormalizeEmail(suppression.Email)
+	if err := r.client.HSet(ctx, tenantSuppressionsKey(ctx), email, string(encoded)).Err(); err != nil {
+		return fmt.Errorf("redis save suppression: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the given email address from the suppression list.
+// It returns repository.ErrSuppressionNotFound if the address isn't suppressed.
+func (r RedisSuppressionRepository) Delete(ctx context.Context, email string) error {
+	deleted, err := r.client.HDel(ctx, tenantSuppressionsKey(ctx), domain.NormalizeEmail(email)).Result()
+	if err != nil {
+		return fmt.Errorf("redis delete suppression: %w", err)
+	}
+	if deleted == 0 {
+		return repository.ErrSuppressionNotFound
+	}
+	return nil
+}
+
+// List retrieves every suppression sorted by email address.
+func (r RedisSuppressionRepository) List(ctx context.Context) ([]domain.Suppression, error) {
+	fields, err := r.client.HGetAll(ctx, tenantSuppressionsKey(ctx)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis list suppressions: %w", err)
+	}
+
+	suppressions := make([]domain.Suppression, 0, len(fields))
+	for email, encoded := range fields {
+		suppression, err := decodeRedisSuppression(email, encoded)
+		if err != nil {
+			return nil, err
+		}
+		suppressions = append(suppressions, suppression)
+	}
+	sort.Slice(suppressions, func(i, j int) bool {
+		return suppressions[i].Email < suppressions[j].Email
+	})
+	return suppressions, nil
+}
+
+// tenantSuppressionsKey returns the hash holding the suppression list of the tenant of ctx.
+func tenantSuppressionsKey(ctx context.Context) string {
+	if tenantID := repository.TenantFromContext(ctx); tenantID != domain.DefaultTenantID {
+		return suppressionsKey + ":" + tenantID
+	}
+	return suppressionsKey
+}
+
+func decodeRedisSuppression(email, encoded string) (domain.Suppression, error) {
+	var s redisSuppression
+	if err := json.Unmarshal([]byte(encoded), &s); err != nil {
+		return domain.Suppression{}, fmt.Errorf("decode suppression: %w", err)
+	}
+	reason, err := domain.ToSuppressionReason(s.Reason)
+	if err != nil {
+		return domain.Suppression{}, fmt.Errorf("decode suppression: %w", err)
+	}
+	return domain.Suppression{
+		Email:     email,
+		Reason:    reason,
+		Detail:    s.Detail,
+		CreatedAt: s.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,119 @@
+package infra_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestRedisSuppressionRepository_Get(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("suppression is decoded", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHGet("suppressions", "jane@example.com").
+			SetVal(`{"reason":"bounce","detail":"550 mailbox unavailable","createdAt":"2024-05-01T12:00:00Z"}`)
+
+		repo := infra.NewRedisSuppressionRepository(db)
+		suppression, err := repo.Get(context.Background(), " Jane@Example.com")
+		require.NoError(t, err)
+		assert.Equal(t, domain.Suppression{
+			Email:     "jane@example.com",
+			Reason:    domain.SuppressedByBounce,
+			Detail:    "550 mailbox unavailable",
+			CreatedAt: createdAt,
+		}, suppression)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing suppression", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHGet("suppressions:billing", "jane@example.com").RedisNil()
+
+		repo := infra.NewRedisSuppressionRepository(db)
+		_, err := repo.Get(repository.WithTenant(context.Background(), "billing"), "jane@example.com")
+		assert.ErrorIs(t, err, repository.ErrSuppressionNotFound)
+	})
+
+	t.Run("redis failure", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHGet("suppressions", "jane@example.com").SetErr(errors.New("connection refused"))
+
+		repo := infra.NewRedisSuppressionRepository(db)
+		_, err := repo.Get(context.Background(), "jane@example.com")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, repository.ErrSuppressionNotFound)
+	})
+}
+
+func TestRedisSuppressionRepository_List(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	defer db.Close()
+	mock.ExpectHGetAll("suppressions").SetVal(map[string]string{
+		"john@example.com": `{"reason":"manual","createdAt":"2024-05-02T08:00:00Z"}`,
+		"jane@example.com": `{"reason":"complaint","createdAt":"2024-05-01T12:00:00Z"}`,
+	})
+
+	repo := infra.NewRedisSuppressionRepository(db)
+	suppressions, err := repo.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Suppression{
+		{
+			Email:     "jane@example.com",
+			Reason:    domain.SuppressedByComplaint,
+			CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			Email:     "john@example.com",
+			Reason:    domain.SuppressedManually,
+			CreatedAt: time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC),
+		},
+	}, suppressions)
+}
+
+func TestRedisSuppressionRepository_Save(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	defer db.Close()
+	mock.ExpectHSet("suppressions:billing", "jane@example.com",
+		`{"reason":"bounce","detail":"550 mailbox unavailable","createdAt":"2024-05-01T12:00:00Z"}`).SetVal(1)
+
+	repo := infra.NewRedisSuppressionRepository(db)
+	err := repo.Save(repository.WithTenant(context.Background(), "billing"), domain.Suppression{
+		Email:     "Jane@Example.com",
+		Reason:    domain.SuppressedByBounce,
+		Detail:    "550 mailbox unavailable",
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedisSuppressionRepository_Delete(t *testing.T) {
+	t.Run("suppression is deleted", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHDel("suppressions", "jane@example.com").SetVal(1)
+
+		repo := infra.NewRedisSuppressionRepository(db)
+		assert.NoError(t, repo.Delete(context.Background(), "Jane@Example.com"))
+	})
+
+	t.Run("missing suppression", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectHDel("suppressions", "jane@example.com").SetVal(0)
+
+		repo := infra.NewRedisSuppressionRepository(db)
+		assert.ErrorIs(t, repo.Delete(context.Background(), "jane@example.com"), repository.ErrSuppressionNotFound)
+	})
+}
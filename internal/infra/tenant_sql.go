@@ -0,0 +1,171 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"time"
+)
+
+// tenantColumns are the columns scanned by scanTenant.
+const tenantColumns = "id, name, mail_from, subjects, quota_max_count, quota_expiration, created_at"
+
+// NewSQLTenantRepository creates a new SQLTenantRepository instance.
+func NewSQLTenantRepository(db *sql.DB, dialect SQLDialect) *SQLTenantRepository {
+	return &SQLTenantRepository{db: db, dialect: dialect}
+}
+
+// SQLTenantRepository is the SQL implementation of the tenant repository, running on PostgreSQL or SQLite.
+// The subjects of each tenant are stored as a JSON object keyed by notification type.
+type SQLTenantRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// Migrate applies the pending schema migrations.
+func (r SQLTenantRepository) Migrate(ctx context.Context) error {
+	return migrate(ctx, r.db, r.dialect)
+}
+
+// Get retrieves a tenant by its ID.
+func (r SQLTenantRepository) Get(ctx context.Context, id string) (domain.Tenant, error) {
+	row := r.db.QueryRowContext(ctx,
+		r.dialect.rebind("SELECT "+tenantColumns+" FROM tenants WHERE id = $1"), id)
+	tenant, err := scanTenant(ctx, row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Tenant{}, repository.ErrTenantNotFound
+	}
+	if err != nil {
+		return domain.Tenant{}, fmt.Errorf("query tenant: %w", err)
+	}
+	return tenant, nil
+}
+
+// Save stores a given tenant in the repository.
+func (r SQLTenantRepository) Save(ctx context.Context, tenant domain.Tenant) error {
+	subjects, err := encodeSubjects(tenant.Subjects)
+	if err != nil {
+		return err
+	}
+	quotaMaxCount, quotaExpiration, _ := nullRule(tenant.Quota)
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(`
+INSERT INTO tenants (id, name, mail_from, subjects, quota_max_count, quota_expiration, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`),
+		tenant.ID, tenant.Name, tenant.MailFrom, subjects, quotaMaxCount, quotaExpiration, tenant.CreatedAt.UTC())
+	if isUniqueViolation(err) {
+		return repository.ErrTenantAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("insert tenant: %w", err)
+	}
+	return nil
+}
+
+// Update replaces an existing tenant, identified by its ID. The creation time is kept.
+func (r SQLTenantRepository) Update(ctx context.Context, tenant domain.Tenant) error {
+	subjects, err := encodeSubjects(tenant.Subjects)
+	if err != nil {
+		return err
+	}
+	quotaMaxCount, quotaExpiration, _ := nullRule(tenant.Quota)
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind(`
+UPDATE tenants SET name = $2, mail_from = $3, subjects = $4, quota_max_count = $5, quota_expiration = $6
+WHERE id = $1`),
+		tenant.ID, tenant.Name, tenant.MailFrom, subjects, quotaMaxCount, quotaExpiration)
+	if err != nil {
+		return fmt.Errorf("update tenant: %w", err)
+	}
+	return requireAffected(result, repository.ErrTenantNotFound)
+}
+
+// Delete removes a tenant by its ID.
+func (r SQLTenantRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM tenants WHERE id = $1"), id)
+	if err != nil {
+		return fmt.Errorf("delete tenant: %w", err)
+	}
+	return requireAffected(result, repository.ErrTenantNotFound)
+}
+
+// List retrieves every tenant, sorted by ID.
+func (r SQLTenantRepository) List(ctx context.Context) ([]domain.Tenant, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+tenantColumns+" FROM tenants ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("query tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := make([]domain.Tenant, 0)
+	for rows.Next() {
+		tenant, err := scanTenant(ctx, rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan tenant: %w", err)
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("read tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+// scanTenant scans the tenantColumns of the row.
+func scanTenant(ctx context.Context, row interface{ Scan(...any) error }) (domain.Tenant, error) {
+	var (
+		tenant                         domain.Tenant
+		subjects                       string
+		quotaMaxCount, quotaExpiration sql.NullInt64
+		createdAt                      time.Time
+	)
+	err := row.Scan(&tenant.ID, &tenant.Name, &tenant.MailFrom, &subjects,
+		&quotaMaxCount, &quotaExpiration, &createdAt)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	if tenant.Subjects, err = decodeSubjects(ctx, subjects); err != nil {
+		return domain.Tenant{}, err
+	}
+	tenant.Quota = nullableRule(quotaMaxCount, quotaExpiration, sql.NullBool{})
+	tenant.CreatedAt = createdAt.UTC()
+	return tenant, nil
+}
+
+// encodeSubjects encodes the subjects as a JSON object keyed by notification type.
+func encodeSubjects(subjects map[domain.NotificationType]string) (string, error) {
+	encoded := make(map[string]string, len(subjects))
+	for notificationType, subject := range subjects {
+		encoded[notificationType.String()] = subject
+	}
+	b, err := json.Marshal(encoded)
+	if err != nil {
+		return "", fmt.Errorf("encode subjects: %w", err)
+	}
+	return string(b), nil
+}
+
+// decodeSubjects decodes the subjects encoded by encodeSubjects, skipping the unknown notification types.
+// It returns nil if there are no subjects.
+func decodeSubjects(ctx context.Context, encoded string) (map[domain.NotificationType]string, error) {
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+		return nil, fmt.Errorf("decode subjects: %w", err)
+	}
+	if len(decoded) == 0 {
+		return nil, nil
+	}
+	subjects := make(map[domain.NotificationType]string, len(decoded))
+	for field, subject := range decoded {
+		notificationType, err := domain.ToNotificationType(field)
+		if err != nil {
+			logging.FromContext(ctx).Warn().Str("type", field).Msg("skipping subject of unknown notification type")
+			continue
+		}
+		subjects[notificationType] = subject
+	}
+	return subjects, nil
+}
@@ -0,0 +1,83 @@
+package infra_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/internal/repository"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newSQLiteTenantRepository creates a SQLTenantRepository on a fresh SQLite database.
+func newSQLiteTenantRepository(t *testing.T) *infra.SQLTenantRepository {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notification.db")
+	db, err := infra.OpenSQLDatabase(context.Background(), infra.SQLDialectSQLite, path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	repo := infra.NewSQLTenantRepository(db, infra.SQLDialectSQLite)
+	require.NoError(t, repo.Migrate(context.Background()))
+	return repo
+}
+
+func TestSQLTenantRepository(t *testing.T) {
+	ctx := context.Background()
+	tenant := domain.Tenant{
+		ID:        "billing",
+		Name:      "Billing",
+		MailFrom:  "billing@example.com",
+		Subjects:  map[domain.NotificationType]string{domain.Status: "Your invoice"},
+		Quota:     &domain.RateLimitRule{MaxCount: 100, Expiration: time.Hour},
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("tenant is saved", func(t *testing.T) {
+		repo := newSQLiteTenantRepository(t)
+		require.NoError(t, repo.Save(ctx, tenant))
+
+		got, err := repo.Get(ctx, "billing")
+		require.NoError(t, err)
+		assert.Equal(t, tenant, got)
+		assert.ErrorIs(t, repo.Save(ctx, tenant), repository.ErrTenantAlreadyExists)
+	})
+
+	t.Run("tenant is updated", func(t *testing.T) {
+		repo := newSQLiteTenantRepository(t)
+		require.NoError(t, repo.Save(ctx, tenant))
+
+		updated := domain.Tenant{ID: "billing", Name: "Invoicing", CreatedAt: time.Now()}
+		require.NoError(t, repo.Update(ctx, updated))
+
+		got, err := repo.Get(ctx, "billing")
+		require.NoError(t, err)
+		updated.CreatedAt = tenant.CreatedAt
+		assert.Equal(t, updated, got)
+		assert.ErrorIs(t, repo.Update(ctx, domain.Tenant{ID: "shipping"}), repository.ErrTenantNotFound)
+	})
+
+	t.Run("tenants are listed by ID", func(t *testing.T) {
+		repo := newSQLiteTenantRepository(t)
+		other := domain.Tenant{ID: "accounts", CreatedAt: tenant.CreatedAt}
+		require.NoError(t, repo.Save(ctx, tenant))
+		require.NoError(t, repo.Save(ctx, other))
+
+		tenants, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Tenant{other, tenant}, tenants)
+	})
+
+	t.Run("tenant is deleted", func(t *testing.T) {
+		repo := newSQLiteTenantRepository(t)
+		require.NoError(t, repo.Save(ctx, tenant))
+
+		require.NoError(t, repo.Delete(ctx, "billing"))
+		_, err := repo.Get(ctx, "billing")
+		assert.ErrorIs(t, err, repository.ErrTenantNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, "billing"), repository.ErrTenantNotFound)
+	})
+}
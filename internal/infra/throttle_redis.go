@@ -0,0 +1,162 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"notification/internal/service"
+	"notification/internal/tracing"
+	"time"
+)
+
+// reserveScript reserves the next send slot of a throughput limit, using the Generic Cell Rate
+// Algorithm: the key holds the theoretical arrival time of the next email, in microseconds of the
+// Redis clock, so that the replicas share the same clock.
+//
+// It returns the wait until the slot in microseconds, or -1 without reserving anything if the
+// wait exceeds the max wait.
+//
+// KEYS[1] is the key of the limit, ARGV[1] the emission interval of the limit and ARGV[2] the
+// max wait, both in microseconds.
+var reserveScript = redis.NewScript(`
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local interval = tonumber(ARGV[1])
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local wait = tat - now
+if wait > tonumber(ARGV[2]) then
+	return -1
+end
+-- the timestamps exceed the precision of the default number formatting.
+redis.call("SET", KEYS[1], string.format("%.0f", tat + interval),
+	"PX", string.format("%.0f", math.ceil((wait + interval) / 1000) + 1000))
+return wait
+`)
+
+// releaseScript gives back a slot reserved by reserveScript, moving the theoretical arrival time of
+// the next email back by the emission interval, but never before the current time.
+//
+// KEYS[1] is the key of the limit and ARGV[1] the emission interval of the limit in microseconds.
+var releaseScript = redis.NewScript(`
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat then
+	return 0
+end
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+tat = tat - tonumber(ARGV[1])
+if tat <= now then
+	redis.call("DEL", KEYS[1])
+	return 0
+end
+redis.call("SET", KEYS[1], string.format("%.0f", tat),
+	"PX", string.format("%.0f", math.ceil((tat - now) / 1000) + 1000))
+return 0
+`)
+
+// RedisThrottleOption defines the optional params for RedisThrottle.
+type RedisThrottleOption func(*RedisThrottle)
+
+// WithMaxWait sets how long an email may wait for its slot before it's rejected with
+// service.ErrMailThrottled. Defaults to 30 seconds.
+func WithMaxWait(maxWait time.Duration) RedisThrottleOption {
+	return func(t *RedisThrottle) {
+		t.maxWait = maxWait
+	}
+}
+
+// NewRedisThrottle creates a new RedisThrottle instance.
+func NewRedisThrottle(client *redis.Client, opts ...RedisThrottleOption) *RedisThrottle {
+	t := &RedisThrottle{
+		client:  client,
+		maxWait: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// RedisThrottle is the Redis implementation of the throughput limiter shared by the replicas.
+//
+// Each email reserves the next slot of every limit applying to it, the slots being spread
+// evenly over each second, and waits for it. The reservations are atomic, so the replicas
+// queue their emails in the order they reserve their slots.
+type RedisThrottle struct {
+	client  *redis.Client
+	maxWait time.Duration
+}
+
+// Wait blocks until an email can be sent within the limit, reserving its slot. It returns
+// service.ErrMailThrottled, without reserving anything, if the slot is further away than the
+// max wait, or the error of ctx if it's done in the meantime, after releasing the slot.
+func (t RedisThrottle) Wait(ctx context.Context, limit service.ThroughputLimit) error {
+	wait, err := t.reserve(ctx, limit)
+	if err != nil {
+		return err
+	}
+	if err = sleepContext(ctx, wait); err != nil {
+		// the slot is released even though ctx is done, since it would be lost otherwise.
+		if releaseErr := t.Release(context.WithoutCancel(ctx), limit); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Release gives back the slot reserved by Wait for an email that isn't sent after all.
+func (t RedisThrottle) Release(ctx context.Context, limit service.ThroughputLimit) (err error) {
+	ctx, span := startRedisSpan(ctx, "EVALSHA")
+	defer func() { tracing.End(span, err) }()
+
+	interval := time.Second / time.Duration(limit.Rate)
+	if err := releaseScript.Run(ctx, t.client, []string{throttleKey(limit)}, interval.Microseconds()).Err(); err != nil {
+		return fmt.Errorf("redis release slot: %w", err)
+	}
+	return nil
+}
+
+// reserve reserves the next slot of the limit and returns the wait until the slot.
+func (t RedisThrottle) reserve(ctx context.Context, limit service.ThroughputLimit) (wait time.Duration, err error) {
+	ctx, span := startRedisSpan(ctx, "EVALSHA")
+	defer func() { tracing.End(span, err) }()
+
+	interval := time.Second / time.Duration(limit.Rate)
+	result, err := reserveScript.Run(ctx, t.client, []string{throttleKey(limit)},
+		interval.Microseconds(), t.maxWait.Microseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("redis reserve slot: %w", err)
+	}
+	if result < 0 {
+		return 0, service.ErrMailThrottled
+	}
+	return time.Duration(result) * time.Microsecond, nil
+}
+
+// throttleKey returns the key holding the next slot of the limit.
+func throttleKey(limit service.ThroughputLimit) string {
+	if limit.Key == "" {
+		return "throttle:" + limit.Scope
+	}
+	return "throttle:" + limit.Scope + ":" + limit.Key
+}
+
+// sleepContext waits for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
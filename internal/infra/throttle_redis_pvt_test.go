@@ -0,0 +1,121 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"notification/internal/service"
+	"testing"
+	"time"
+)
+
+func TestRedisThrottle_Wait(t *testing.T) {
+	global := service.ThroughputLimit{Scope: service.ThrottleGlobal, Rate: 10}
+
+	t.Run("slot is available", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectEvalSha(reserveScript.Hash(), []string{"throttle:global"}, int64(100000), int64(30000000)).
+			SetVal(int64(0))
+
+		err := NewRedisThrottle(db).Wait(context.Background(), global)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("waits for the slot", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectEvalSha(reserveScript.Hash(), []string{"throttle:global"}, int64(100000), int64(30000000)).
+			SetVal(int64(20000))
+
+		start := time.Now()
+		err := NewRedisThrottle(db).Wait(context.Background(), global)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("slot beyond the max wait", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectEvalSha(reserveScript.Hash(), []string{"throttle:tenant:billing"}, int64(500000), int64(2000000)).
+			SetVal(int64(-1))
+
+		limit := service.ThroughputLimit{Scope: service.ThrottleTenant, Key: "billing", Rate: 2}
+		err := NewRedisThrottle(db, WithMaxWait(2*time.Second)).Wait(context.Background(), limit)
+		assert.ErrorIs(t, err, service.ErrMailThrottled)
+	})
+
+	t.Run("context is done while waiting", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectEvalSha(reserveScript.Hash(), []string{"throttle:global"}, int64(100000), int64(30000000)).
+			SetVal(int64(10000000))
+		mock.ExpectEvalSha(releaseScript.Hash(), []string{"throttle:global"}, int64(100000)).
+			SetVal(int64(0))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := NewRedisThrottle(db).Wait(ctx, global)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NoError(t, mock.ExpectationsWereMet(), "the slot is released")
+	})
+
+	t.Run("context is cancelled while waiting", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectEvalSha(reserveScript.Hash(), []string{"throttle:domain:example.com"}, int64(200000), int64(30000000)).
+			SetVal(int64(10000000))
+		mock.ExpectEvalSha(releaseScript.Hash(), []string{"throttle:domain:example.com"}, int64(200000)).
+			SetErr(errors.New("connection refused"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		limit := service.ThroughputLimit{Scope: service.ThrottleDomain, Key: "example.com", Rate: 5}
+		err := NewRedisThrottle(db).Wait(ctx, limit)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorContains(t, err, "redis release slot")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("redis failure", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectEvalSha(reserveScript.Hash(), []string{"throttle:global"}, int64(100000), int64(30000000)).
+			SetErr(errors.New("connection refused"))
+
+		err := NewRedisThrottle(db).Wait(context.Background(), global)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, service.ErrMailThrottled)
+	})
+}
+
+func TestRedisThrottle_Release(t *testing.T) {
+	tenant := service.ThroughputLimit{Scope: service.ThrottleTenant, Key: "billing", Rate: 10}
+
+	t.Run("slot is released", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectEvalSha(releaseScript.Hash(), []string{"throttle:tenant:billing"}, int64(100000)).
+			SetVal(int64(0))
+
+		assert.NoError(t, NewRedisThrottle(db).Release(context.Background(), tenant))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("redis failure", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		defer db.Close()
+		mock.ExpectEvalSha(releaseScript.Hash(), []string{"throttle:tenant:billing"}, int64(100000)).
+			SetErr(errors.New("connection refused"))
+
+		assert.Error(t, NewRedisThrottle(db).Release(context.Background(), tenant))
+	})
+}
+
+func TestThrottleKey(t *testing.T) {
+	assert.Equal(t, "throttle:global", throttleKey(service.ThroughputLimit{Scope: service.ThrottleGlobal}))
+	assert.Equal(t, "throttle:domain:example.com",
+		throttleKey(service.ThroughputLimit{Scope: service.ThrottleDomain, Key: "example.com"}))
+}
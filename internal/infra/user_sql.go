@@ -0,0 +1,160 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"strings"
+)
+
+// NewSQLUserRepository creates a new SQLUserRepository instance.
+func NewSQLUserRepository(db *sql.DB, dialect SQLDialect) *SQLUserRepository {
+	return &SQLUserRepository{db: db, dialect: dialect}
+}
+
+// SQLUserRepository is the SQL implementation of the user repository, running on PostgreSQL or SQLite.
+// The uniqueness of the user IDs and emails within a tenant is enforced by the database constraints.
+type SQLUserRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// Migrate applies the pending schema migrations.
+func (r SQLUserRepository) Migrate(ctx context.Context) error {
+	return migrate(ctx, r.db, r.dialect)
+}
+
+// Get retrieves a user by its ID.
+func (r SQLUserRepository) Get(ctx context.Context, id string) (domain.User, error) {
+	var (
+		user     domain.User
+		segments string
+	)
+	err := r.db.QueryRowContext(ctx,
+		r.dialect.rebind("SELECT id, name, last_name, email, segments FROM users WHERE tenant_id = $1 AND id = $2"),
+		repository.TenantFromContext(ctx), id).
+		Scan(&user.ID, &user.Name, &user.LastName, &user.Email, &segments)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, repository.ErrInvalidUserID
+	}
+	if err != nil {
+		return domain.User{}, fmt.Errorf("query user: %w", err)
+	}
+	user.Segments = decodeSegments(segments)
+	return user, nil
+}
+
+// Save stores a given user in the repository.
+func (r SQLUserRepository) Save(ctx context.Context, user domain.User) error {
+	_, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(
+			"INSERT INTO users (tenant_id, id, name, last_name, email, segments) VALUES ($1, $2, $3, $4, $5, $6)"),
+		repository.TenantFromContext(ctx), user.ID, user.Name, user.LastName, user.Email, encodeSegments(user.Segments))
+	if isUniqueViolation(err) {
+		return repository.ErrUserAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("insert user: %w", err)
+	}
+	return nil
+}
+
+// Update replaces an existing user, identified by its ID.
+func (r SQLUserRepository) Update(ctx context.Context, user domain.User) error {
+	result, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(
+			"UPDATE users SET name = $3, last_name = $4, email = $5, segments = $6 WHERE tenant_id = $1 AND id = $2"),
+		repository.TenantFromContext(ctx), user.ID, user.Name, user.LastName, user.Email, encodeSegments(user.Segments))
+	if isUniqueViolation(err) {
+		return repository.ErrUserAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("update user: %w", err)
+	}
+	return requireAffected(result, repository.ErrInvalidUserID)
+}
+
+// Delete removes a user by its ID.
+func (r SQLUserRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM users WHERE tenant_id = $1 AND id = $2"),
+		repository.TenantFromContext(ctx), id)
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	return requireAffected(result, repository.ErrInvalidUserID)
+}
+
+// List retrieves the page of users matching the query, sorted by ID,
+// along with the total number of matching users.
+func (r SQLUserRepository) List(ctx context.Context, query repository.UserQuery) ([]domain.User, int, error) {
+	condition := `tenant_id = $1 AND LOWER(email) LIKE $2 ESCAPE '\'`
+	tenantID := repository.TenantFromContext(ctx)
+	pattern := "%" + escapeLike(strings.ToLower(query.Email)) + "%"
+
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		r.dialect.rebind("SELECT COUNT(*) FROM users WHERE "+condition), tenantID, pattern).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count users: %w", err)
+	}
+
+	limit := r.dialect.noLimit()
+	if query.Limit > 0 {
+		limit = fmt.Sprint(query.Limit)
+	}
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(`
+SELECT id, name, last_name, email, segments FROM users
+WHERE `+condition+`
+ORDER BY id
+LIMIT `+limit+` OFFSET $3`), tenantID, pattern, max(query.Offset, 0))
+	if err != nil {
+		return nil, 0, fmt.Errorf("query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]domain.User, 0)
+	for rows.Next() {
+		var (
+			user     domain.User
+			segments string
+		)
+		if err = rows.Scan(&user.ID, &user.Name, &user.LastName, &user.Email, &segments); err != nil {
+			return nil, 0, fmt.Errorf("scan user: %w", err)
+		}
+		user.Segments = decodeSegments(segments)
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("read users: %w", err)
+	}
+	return users, total, nil
+}
+
+// encodeSegments returns the segments column of the user segments, a comma separated list.
+// The segment names can't contain commas, see domain.ValidSegment.
+func encodeSegments(segments []string) string {
+	return strings.Join(segments, ",")
+}
+
+// decodeSegments returns the user segments of the segments column.
+func decodeSegments(column string) []string {
+	if column == "" {
+		return nil
+	}
+	return strings.Split(column, ",")
+}
+
+// requireAffected returns notFound if the statement didn't affect any row.
+func requireAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package infra_test
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/internal/repository"
+	"path/filepath"
+	"testing"
+)
+
+// newSQLiteUserRepository creates a SQLUserRepository on a fresh SQLite database.
+func newSQLiteUserRepository(t *testing.T) *infra.SQLUserRepository {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notification.db")
+	db, err := infra.OpenSQLDatabase(context.Background(), infra.SQLDialectSQLite, path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	repo := infra.NewSQLUserRepository(db, infra.SQLDialectSQLite)
+	require.NoError(t, repo.Migrate(context.Background()))
+	return repo
+}
+
+func TestSQLUserRepository_Migrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notification.db")
+	db, err := infra.OpenSQLDatabase(context.Background(), infra.SQLDialectSQLite, path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := infra.NewSQLUserRepository(db, infra.SQLDialectSQLite)
+	require.NoError(t, repo.Migrate(context.Background()))
+	require.NoError(t, repo.Migrate(context.Background()), "applied migrations are skipped")
+
+	var versions []string
+	rows, err := db.Query("SELECT version FROM schema_migrations ORDER BY version")
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var version string
+		require.NoError(t, rows.Scan(&version))
+		versions = append(versions, version)
+	}
+	assert.Equal(t, []string{"0001_create_users", "0002_create_rate_limit_rules",
+		"0003_add_unlimited_rate_limit_rules", "0004_create_api_keys", "0005_add_tenants",
+		"0006_add_user_segments", "0007_create_deliveries"}, versions)
+}
+
+func TestSQLUserRepository_Save(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("user is saved", func(t *testing.T) {
+		repo := newSQLiteUserRepository(t)
+		user := domain.User{ID: "123-abc", Name: "John", LastName: "Doe", Email: "john.doe@example.com",
+			Segments: []string{"vip", "beta"}}
+
+		require.NoError(t, repo.Save(ctx, user))
+
+		savedUser, err := repo.Get(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user, savedUser)
+	})
+	t.Run("duplicate id", func(t *testing.T) {
+		repo := newSQLiteUserRepository(t)
+		require.NoError(t, repo.Save(ctx, domain.User{ID: "123-abc", Email: "john.doe@example.com"}))
+
+		err := repo.Save(ctx, domain.User{ID: "123-abc", Email: "someone@example.com"})
+		assert.ErrorIs(t, err, repository.ErrUserAlreadyExists)
+	})
+	t.Run("duplicate email, case-insensitively", func(t *testing.T) {
+		repo := newSQLiteUserRepository(t)
+		require.NoError(t, repo.Save(ctx, domain.User{ID: "123-abc", Email: "john.doe@example.com"}))
+
+		err := repo.Save(ctx, domain.User{ID: "456-bbb", Email: "John.Doe@example.com"})
+		assert.ErrorIs(t, err, repository.ErrUserAlreadyExists)
+	})
+}
+
+func TestSQLUserRepository_Get(t *testing.T) {
+	repo := newSQLiteUserRepository(t)
+
+	_, err := repo.Get(context.Background(), "invalid")
+	assert.ErrorIs(t, err, repository.ErrInvalidUserID)
+}
+
+func TestSQLUserRepository_Update(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteUserRepository(t)
+	require.NoError(t, repo.Save(ctx, domain.User{ID: "123-abc", Name: "John", Email: "john.doe@example.com"}))
+	require.NoError(t, repo.Save(ctx, domain.User{ID: "456-bbb", Name: "Jane", Email: "jane.doe@example.com"}))
+
+	t.Run("user is updated", func(t *testing.T) {
+		user := domain.User{ID: "123-abc", Name: "Johnny", LastName: "Doe", Email: "johnny@example.com",
+			Segments: []string{"internal"}}
+		require.NoError(t, repo.Update(ctx, user))
+
+		savedUser, err := repo.Get(ctx, "123-abc")
+		require.NoError(t, err)
+		assert.Equal(t, user, savedUser)
+	})
+	t.Run("unknown user", func(t *testing.T) {
+		err := repo.Update(ctx, domain.User{ID: "invalid", Email: "someone@example.com"})
+		assert.ErrorIs(t, err, repository.ErrInvalidUserID)
+	})
+	t.Run("email taken by another user", func(t *testing.T) {
+		err := repo.Update(ctx, domain.User{ID: "123-abc", Email: "Jane.Doe@example.com"})
+		assert.ErrorIs(t, err, repository.ErrUserAlreadyExists)
+	})
+}
+
+func TestSQLUserRepository_Delete(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteUserRepository(t)
+	require.NoError(t, repo.Save(ctx, domain.User{ID: "123-abc", Email: "john.doe@example.com"}))
+
+	require.NoError(t, repo.Delete(ctx, "123-abc"))
+	_, err := repo.Get(ctx, "123-abc")
+	assert.ErrorIs(t, err, repository.ErrInvalidUserID)
+
+	assert.ErrorIs(t, repo.Delete(ctx, "123-abc"), repository.ErrInvalidUserID)
+}
+
+func TestSQLUserRepository_Tenants(t *testing.T) {
+	billing := repository.WithTenant(context.Background(), "billing")
+	shipping := repository.WithTenant(context.Background(), "shipping")
+	user := domain.User{ID: "123-abc", Name: "John", Email: "john.doe@example.com"}
+
+	repo := newSQLiteUserRepository(t)
+	require.NoError(t, repo.Save(billing, user))
+
+	_, err := repo.Get(shipping, user.ID)
+	assert.ErrorIs(t, err, repository.ErrInvalidUserID, "the users of other tenants can't be read")
+	assert.ErrorIs(t, repo.Update(shipping, user), repository.ErrInvalidUserID)
+	assert.ErrorIs(t, repo.Delete(shipping, user.ID), repository.ErrInvalidUserID)
+	users, total, err := repo.List(shipping, repository.UserQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	assert.Zero(t, total)
+
+	other := domain.User{ID: "123-abc", Name: "Johnny", Email: "john.doe@example.com"}
+	require.NoError(t, repo.Save(shipping, other), "IDs and emails are unique within a tenant")
+
+	got, err := repo.Get(billing, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+	got, err = repo.Get(shipping, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, other, got)
+}
+
+func TestSQLUserRepository_List(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteUserRepository(t)
+	require.NoError(t, repo.Save(ctx, domain.User{ID: "3", Email: "carl@example.org"}))
+	require.NoError(t, repo.Save(ctx, domain.User{ID: "1", Email: "alice@example.com"}))
+	require.NoError(t, repo.Save(ctx, domain.User{ID: "2", Email: "bob_smith@example.com"}))
+
+	tests := []struct {
+		name      string
+		query     repository.UserQuery
+		wantIDs   []string
+		wantTotal int
+	}{
+		{name: "all users sorted by id", wantIDs: []string{"1", "2", "3"}, wantTotal: 3},
+		{name: "email filter", query: repository.UserQuery{Email: "EXAMPLE.COM"}, wantIDs: []string{"1", "2"}, wantTotal: 2},
+		{name: "wildcards are matched literally", query: repository.UserQuery{Email: "_"}, wantIDs: []string{"2"}, wantTotal: 1},
+		{name: "pagination", query: repository.UserQuery{Offset: 1, Limit: 1}, wantIDs: []string{"2"}, wantTotal: 3},
+		{name: "offset without limit", query: repository.UserQuery{Offset: 2}, wantIDs: []string{"3"}, wantTotal: 3},
+		{name: "offset out of range", query: repository.UserQuery{Offset: 10}, wantIDs: []string{}, wantTotal: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := repo.List(ctx, tt.query)
+			require.NoError(t, err)
+
+			ids := make([]string, 0, len(users))
+			for _, u := range users {
+				ids = append(ids, u.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, tt.wantTotal, total)
+		})
+	}
+}
+
+func TestSQLUserRepository_Postgres(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unique violation", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(
+			`INSERT INTO users \(tenant_id, id, name, last_name, email, segments\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)`).
+			WithArgs("default", "123-abc", "John", "Doe", "john@example.com", "").
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		repo := infra.NewSQLUserRepository(db, infra.SQLDialectPostgres)
+		err = repo.Save(ctx, domain.User{ID: "123-abc", Name: "John", LastName: "Doe", Email: "john@example.com"})
+		assert.ErrorIs(t, err, repository.ErrUserAlreadyExists)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("list without limit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).
+			WithArgs("default", "%%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(`LIMIT ALL OFFSET \$3`).
+			WithArgs("default", "%%", 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "last_name", "email", "segments"}).
+				AddRow("123-abc", "John", "Doe", "john@example.com", ""))
+
+		repo := infra.NewSQLUserRepository(db, infra.SQLDialectPostgres)
+		users, total, err := repo.List(ctx, repository.UserQuery{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []domain.User{{ID: "123-abc", Name: "John", LastName: "Doe", Email: "john@example.com"}}, users)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("connection errors are returned", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT id, name, last_name, email, segments FROM users").
+			WillReturnError(sql.ErrConnDone)
+
+		repo := infra.NewSQLUserRepository(db, infra.SQLDialectPostgres)
+		_, err = repo.Get(ctx, "123-abc")
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
@@ -0,0 +1,305 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/netip"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrWebhookQueueFull is the error when the delivery event can't be queued
+	// because the WebhookDispatcher is lagging behind.
+	ErrWebhookQueueFull = errors.New("webhook queue is full")
+	// ErrWebhookAddressForbidden is the error when the host of a callback URL resolves to an
+	// address that isn't public.
+	ErrWebhookAddressForbidden = errors.New("callback address is not public")
+)
+
+// WebhookConfig defines the WebhookDispatcher settings.
+type WebhookConfig struct {
+	// Secret is the key used to sign the payloads. Payloads aren't signed if it's empty.
+	Secret string
+	// MaxAttempts is the number of times an event is posted before giving up. Defaults to 5.
+	MaxAttempts int
+	// InitialBackoff is the wait time before the first retry, doubled on each retry. Defaults to 1s.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait time between retries. Defaults to 1m.
+	MaxBackoff time.Duration
+	// Timeout is the timeout of each callback request. Defaults to 10s.
+	Timeout time.Duration
+	// Workers is the number of events posted concurrently. Defaults to 4.
+	Workers int
+	// QueueSize is the number of events waiting to be posted before Publish fails. Defaults to 1000.
+	QueueSize int
+	// AllowPrivateNetworks allows the callback URLs to reach the addresses that aren't public,
+	// see domain.IsPublicAddress, and the callback requests to go through the proxy set in the
+	// environment. Defaults to false.
+	AllowPrivateNetworks bool
+	// Logger is the logger of the WebhookDispatcher. Defaults to logging.Default().
+	Logger *zerolog.Logger
+}
+
+// webhookPayload is the JSON body posted to the callback URLs.
+type webhookPayload struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	CorrelationID string    `json:"correlationId"`
+	UserID        string    `json:"userId"`
+	Detail        string    `json:"detail,omitempty"`
+	OccurredAt    time.Time `json:"occurredAt"`
+}
+
+// NewWebhookDispatcher creates a new WebhookDispatcher instance logging every
+// callback attempt into callbackLog. Start must be called for the events to be posted.
+func NewWebhookDispatcher(cfg WebhookConfig, callbackLog repository.CallbackLogRepository) *WebhookDispatcher {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = time.Second
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = max(time.Minute, cfg.InitialBackoff)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.Logger == nil {
+		logger := logging.Default()
+		cfg.Logger = &logger
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivateNetworks {
+		// the addresses are checked once resolved, so that a host can't resolve to a public address
+		// when the URL is accepted and to an internal one when the event is posted.
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialPublicOnly}
+		transport.DialContext = dialer.DialContext
+		transport.Proxy = nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookDispatcher{
+		cfg:         cfg,
+		callbackLog: callbackLog,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			// the redirects aren't followed, since they could lead to an internal address.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		queue:  make(chan queuedEvent, cfg.QueueSize),
+		now:    time.Now,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// WebhookDispatcher posts the delivery events to the producers' callback URLs.
+//
+// Events are queued and posted by a pool of workers. Network failures, throttling (429),
+// request timeouts (408) and server errors (5xx) are retried with an exponential backoff; other responses,
+// redirects included, are final. Unless AllowPrivateNetworks is set, the callback URLs resolving to
+// addresses that aren't public are refused. When a Secret is set, each request carries an
+// "X-Notification-Signature: t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">" header.
+type WebhookDispatcher struct {
+	cfg         WebhookConfig
+	callbackLog repository.CallbackLogRepository
+	client      *http.Client
+	queue       chan queuedEvent
+	now         func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// queuedEvent is a delivery event waiting to be posted, along with the tenant it belongs to.
+type queuedEvent struct {
+	event    domain.DeliveryEvent
+	tenantID string
+}
+
+// Publish queues the delivery event to be posted to its callback URL. Its callback attempts are
+// logged for the tenant carried by ctx, see repository.WithTenant.
+func (d *WebhookDispatcher) Publish(ctx context.Context, event domain.DeliveryEvent) error {
+	select {
+	case d.queue <- queuedEvent{event: event, tenantID: repository.TenantFromContext(ctx)}:
+		return nil
+	default:
+		return ErrWebhookQueueFull
+	}
+}
+
+// Start launches the workers posting the queued events.
+func (d *WebhookDispatcher) Start() {
+	for range d.cfg.Workers {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for {
+				select {
+				case <-d.ctx.Done():
+					return
+				case queued := <-d.queue:
+					d.deliver(repository.WithTenant(d.ctx, queued.tenantID), queued.event)
+				}
+			}
+		}()
+	}
+}
+
+// Close stops the workers, abandoning the retries in progress and the queued events.
+func (d *WebhookDispatcher) Close() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// deliver posts the event until it succeeds, it's rejected or it runs out of attempts.
+// The attempts are logged for the tenant carried by ctx.
+func (d *WebhookDispatcher) deliver(ctx context.Context, event domain.DeliveryEvent) {
+	body, err := json.Marshal(webhookPayload{
+		ID:            event.ID,
+		Type:          event.Type.String(),
+		CorrelationID: event.CorrelationID,
+		UserID:        event.UserID,
+		Detail:        event.Detail,
+		OccurredAt:    event.OccurredAt,
+	})
+	if err != nil {
+		d.cfg.Logger.Error().Err(err).Str("eventId", event.ID).Msg("failed to encode delivery event")
+		return
+	}
+
+	for attempt := 1; attempt <= d.cfg.MaxAttempts; attempt++ {
+		statusCode, err := d.post(event, body)
+		d.logAttempt(ctx, event, attempt, statusCode, err)
+		if err == nil || !isRetryableCallback(statusCode) || errors.Is(err, ErrWebhookAddressForbidden) {
+			return
+		}
+		if attempt == d.cfg.MaxAttempts {
+			// the callback URL isn't logged, since it may hold credentials.
+			d.cfg.Logger.Warn().Err(err).
+				Str("eventId", event.ID).
+				Str(logging.CorrelationIDKey, event.CorrelationID).
+				Int("attempts", attempt).
+				Msg("giving up on delivery event")
+			return
+		}
+
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-time.After(d.backoff(attempt)):
+		}
+	}
+}
+
+// post sends a single callback request, returning the response status code if any.
+func (d *WebhookDispatcher) post(event domain.DeliveryEvent, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, event.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Notification-Event", event.Type.String())
+	req.Header.Set("X-Notification-Event-Id", event.ID)
+	if d.cfg.Secret != "" {
+		req.Header.Set("X-Notification-Signature", SignWebhookPayload(d.cfg.Secret, d.now(), body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodySize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("callback responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *WebhookDispatcher) logAttempt(ctx context.Context, event domain.DeliveryEvent,
+	attempt, statusCode int, err error) {
+	record := domain.CallbackAttempt{
+		EventID:       event.ID,
+		EventType:     event.Type,
+		CorrelationID: event.CorrelationID,
+		URL:           event.CallbackURL,
+		Attempt:       attempt,
+		StatusCode:    statusCode,
+		At:            d.now(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if err := d.callbackLog.Add(ctx, record); err != nil {
+		d.cfg.Logger.Error().Err(err).Str("eventId", event.ID).Msg("failed to log callback attempt")
+	}
+}
+
+// backoff returns the wait time after the given attempt: the exponential backoff
+// capped at MaxBackoff, with a random jitter of up to half of it.
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	wait := d.cfg.MaxBackoff
+	if shift := attempt - 1; shift < 32 && d.cfg.InitialBackoff<<shift < d.cfg.MaxBackoff {
+		wait = d.cfg.InitialBackoff << shift
+	}
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// dialPublicOnly refuses the connections to the addresses that aren't public.
+func dialPublicOnly(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !domain.IsPublicAddress(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressForbidden, addrPort.Addr())
+	}
+	return nil
+}
+
+// isRetryableCallback reports whether a failed callback must be retried: on network
+// failures (no status code), throttling, request timeouts and server errors.
+func isRetryableCallback(statusCode int) bool {
+	return statusCode == 0 || isRetryableStatus(statusCode)
+}
+
+// SignWebhookPayload returns the X-Notification-Signature header value of the payload body
+// sent at t, so the receivers can check the events come from this service.
+func SignWebhookPayload(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
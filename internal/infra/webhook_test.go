@@ -0,0 +1,222 @@
+package infra_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/domain"
+	"notification/internal/infra"
+	"notification/internal/repository"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookDispatcher_Publish(t *testing.T) {
+	event := domain.DeliveryEvent{
+		ID:            "e1",
+		Type:          domain.EventSent,
+		CorrelationID: "c1",
+		UserID:        "u1",
+		OccurredAt:    time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+	}
+	cfg := infra.WebhookConfig{
+		Secret:         "secret",
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+		// the test servers listen on the loopback interface.
+		AllowPrivateNetworks: true,
+	}
+
+	t.Run("signed event is posted", func(t *testing.T) {
+		received := make(chan map[string]any, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.Equal(t, "sent", r.Header.Get("X-Notification-Event"))
+			assert.Equal(t, "e1", r.Header.Get("X-Notification-Event-Id"))
+
+			signature := r.Header.Get("X-Notification-Signature")
+			timestamp, _, _ := strings.Cut(strings.TrimPrefix(signature, "t="), ",")
+			unix, err := strconv.ParseInt(timestamp, 10, 64)
+			require.NoError(t, err)
+			assert.Equal(t, infra.SignWebhookPayload("secret", time.Unix(unix, 0), body), signature)
+
+			var payload map[string]any
+			require.NoError(t, json.Unmarshal(body, &payload))
+			received <- payload
+		}))
+		defer server.Close()
+
+		callbackLog := repository.NewInMemoryCallbackLogRepository(10)
+		dispatcher := infra.NewWebhookDispatcher(cfg, callbackLog)
+		dispatcher.Start()
+		defer dispatcher.Close()
+
+		event := event
+		event.CallbackURL = server.URL
+		require.NoError(t, dispatcher.Publish(context.Background(), event))
+
+		select {
+		case payload := <-received:
+			assert.Equal(t, map[string]any{
+				"id":            "e1",
+				"type":          "sent",
+				"correlationId": "c1",
+				"userId":        "u1",
+				"occurredAt":    "2026-10-19T10:00:00Z",
+			}, payload)
+		case <-time.After(5 * time.Second):
+			t.Fatal("event not posted")
+		}
+		assertAttempts(t, callbackLog, 1)
+	})
+
+	t.Run("server errors are retried", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		callbackLog := repository.NewInMemoryCallbackLogRepository(10)
+		dispatcher := infra.NewWebhookDispatcher(cfg, callbackLog)
+		dispatcher.Start()
+		defer dispatcher.Close()
+
+		event := event
+		event.CallbackURL = server.URL
+		require.NoError(t, dispatcher.Publish(context.Background(), event))
+
+		attempts := assertAttempts(t, callbackLog, 3)
+		assert.Equal(t, http.StatusServiceUnavailable, attempts[0].StatusCode)
+		assert.NotEmpty(t, attempts[0].Error)
+		assert.Equal(t, http.StatusOK, attempts[2].StatusCode)
+		assert.Empty(t, attempts[2].Error)
+	})
+
+	t.Run("client errors aren't retried", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusGone)
+		}))
+		defer server.Close()
+
+		callbackLog := repository.NewInMemoryCallbackLogRepository(10)
+		dispatcher := infra.NewWebhookDispatcher(cfg, callbackLog)
+		dispatcher.Start()
+
+		event := event
+		event.CallbackURL = server.URL
+		require.NoError(t, dispatcher.Publish(context.Background(), event))
+
+		assertAttempts(t, callbackLog, 1)
+		// give a wrongly scheduled retry the chance to happen.
+		time.Sleep(20 * time.Millisecond)
+		dispatcher.Close()
+		assertAttempts(t, callbackLog, 1)
+	})
+
+	t.Run("redirects aren't followed", func(t *testing.T) {
+		var redirected atomic.Bool
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			redirected.Store(true)
+		}))
+		defer target.Close()
+		server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+		defer server.Close()
+
+		callbackLog := repository.NewInMemoryCallbackLogRepository(10)
+		dispatcher := infra.NewWebhookDispatcher(cfg, callbackLog)
+		dispatcher.Start()
+		defer dispatcher.Close()
+
+		event := event
+		event.CallbackURL = server.URL
+		require.NoError(t, dispatcher.Publish(context.Background(), event))
+
+		attempts := assertAttempts(t, callbackLog, 1)
+		assert.Equal(t, http.StatusFound, attempts[0].StatusCode)
+		assert.False(t, redirected.Load())
+	})
+
+	t.Run("internal addresses are refused", func(t *testing.T) {
+		var called atomic.Bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called.Store(true)
+		}))
+		defer server.Close()
+
+		cfg := cfg
+		cfg.AllowPrivateNetworks = false
+		callbackLog := repository.NewInMemoryCallbackLogRepository(10)
+		dispatcher := infra.NewWebhookDispatcher(cfg, callbackLog)
+		dispatcher.Start()
+
+		event := event
+		event.CallbackURL = server.URL
+		require.NoError(t, dispatcher.Publish(context.Background(), event))
+
+		attempts := assertAttempts(t, callbackLog, 1)
+		assert.Contains(t, attempts[0].Error, infra.ErrWebhookAddressForbidden.Error())
+		// give a wrongly scheduled retry the chance to happen.
+		time.Sleep(20 * time.Millisecond)
+		dispatcher.Close()
+		assertAttempts(t, callbackLog, 1)
+		assert.False(t, called.Load())
+	})
+
+	t.Run("attempts are logged for the tenant of the event", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		callbackLog := repository.NewInMemoryCallbackLogRepository(10)
+		dispatcher := infra.NewWebhookDispatcher(cfg, callbackLog)
+		dispatcher.Start()
+		defer dispatcher.Close()
+
+		event := event
+		event.CallbackURL = server.URL
+		billing := repository.WithTenant(context.Background(), "billing")
+		require.NoError(t, dispatcher.Publish(billing, event))
+
+		require.Eventually(t, func() bool {
+			attempts, err := callbackLog.ListByCorrelationID(billing, "c1")
+			require.NoError(t, err)
+			return len(attempts) == 1
+		}, 5*time.Second, 5*time.Millisecond)
+		attempts, err := callbackLog.ListByCorrelationID(context.Background(), "c1")
+		require.NoError(t, err)
+		assert.Empty(t, attempts)
+	})
+
+	t.Run("full queue", func(t *testing.T) {
+		cfg := cfg
+		cfg.QueueSize = 1
+		dispatcher := infra.NewWebhookDispatcher(cfg, repository.NewInMemoryCallbackLogRepository(10))
+
+		require.NoError(t, dispatcher.Publish(context.Background(), event))
+		assert.ErrorIs(t, dispatcher.Publish(context.Background(), event), infra.ErrWebhookQueueFull)
+	})
+}
+
+// assertAttempts waits for the callback log to hold n attempts of the "c1" notification.
+func assertAttempts(t *testing.T, callbackLog repository.CallbackLogRepository, n int) []domain.CallbackAttempt {
+	var attempts []domain.CallbackAttempt
+	require.Eventually(t, func() bool {
+		var err error
+		attempts, err = callbackLog.ListByCorrelationID(context.Background(), "c1")
+		require.NoError(t, err)
+		return len(attempts) == n
+	}, 5*time.Second, 5*time.Millisecond)
+	return attempts
+}
@@ -0,0 +1,118 @@
+// Package logging provides the structured logger of the application, built on zerolog,
+// and carries the request-scoped loggers through context.Context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// FormatJSON writes one JSON object per line, meant for log aggregators.
+	FormatJSON = "json"
+	// FormatConsole writes human-friendly colored lines, meant for development.
+	FormatConsole = "console"
+)
+
+// Field names shared by every log line of the same request or notification.
+const (
+	// RequestIDKey is the field holding the request ID.
+	RequestIDKey = "requestId"
+	// CorrelationIDKey is the field holding the notification correlation ID.
+	CorrelationIDKey = "correlationId"
+	// UserIDKey is the field holding the ID of the notified user.
+	UserIDKey = "userId"
+	// NotificationTypeKey is the field holding the notification type.
+	NotificationTypeKey = "notificationType"
+	// TraceIDKey is the field holding the ID of the trace of the request.
+	TraceIDKey = "traceId"
+	// ComponentKey is the field identifying the component logging, e.g. "webhook".
+	ComponentKey = "component"
+	// ClientIDKey is the field holding the ID of the authenticated API client, e.g. the API key ID.
+	ClientIDKey = "clientId"
+	// ClientNameKey is the field holding the name of the authenticated API client.
+	ClientNameKey = "clientName"
+	// TenantIDKey is the field holding the ID of the tenant the request acts on behalf of.
+	TenantIDKey = "tenantId"
+)
+
+var defaultLogger atomic.Pointer[zerolog.Logger]
+
+func init() {
+	logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+	defaultLogger.Store(&logger)
+}
+
+// New creates a logger writing to w the entries of the given level or above,
+// e.g. "debug", "info", "warn" or "error", in the given format, FormatJSON or FormatConsole.
+func New(w io.Writer, level, format string) (zerolog.Logger, error) {
+	lvl, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil {
+		return zerolog.Nop(), fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	switch format {
+	case FormatJSON:
+	case FormatConsole:
+		w = zerolog.ConsoleWriter{Out: w, TimeFormat: time.RFC3339}
+	default:
+		return zerolog.Nop(), fmt.Errorf("invalid log format %q", format)
+	}
+	return zerolog.New(w).Level(lvl).With().Timestamp().Logger(), nil
+}
+
+// Default returns the default logger, writing JSON to the standard error until SetDefault is called.
+func Default() zerolog.Logger {
+	return *defaultLogger.Load()
+}
+
+// SetDefault replaces the default logger, used by the components without a logger of their own
+// and when a context carries no logger.
+func SetDefault(logger zerolog.Logger) {
+	defaultLogger.Store(&logger)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying a copy of logger.
+func NewContext(ctx context.Context, logger zerolog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger if there's none.
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*zerolog.Logger); ok {
+		return logger
+	}
+	logger := Default()
+	return &logger
+}
+
+// AddFields adds the fields to the logger carried by ctx, so that they're also logged by the
+// code that created ctx, e.g. the request log line of middleware.Logger. It's a no-op if ctx
+// carries no logger.
+//
+// The logger is modified in place, so ctx must carry a logger of its own, as the request-scoped
+// ones do, and AddFields mustn't be called concurrently with any other use of it.
+func AddFields(ctx context.Context, fields map[string]any) {
+	if logger, ok := ctx.Value(contextKey{}).(*zerolog.Logger); ok {
+		logger.UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Fields(fields)
+		})
+	}
+}
+
+// RedactEmail masks the local part of an email address but its first character,
+// e.g. "j***@example.com", so that it can be logged without exposing the address.
+func RedactEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	return local[:1] + "***@" + domain
+}
@@ -0,0 +1,80 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/logging"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("JSON entries of the level or above", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := logging.New(&buf, "WARN", logging.FormatJSON)
+		require.NoError(t, err)
+
+		logger.Info().Msg("ignored")
+		logger.Warn().Str("key", "value").Msg("logged")
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "warn", entry["level"])
+		assert.Equal(t, "value", entry["key"])
+		assert.Equal(t, "logged", entry["message"])
+		assert.Contains(t, entry, "time")
+	})
+
+	t.Run("console format", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := logging.New(&buf, "debug", logging.FormatConsole)
+		require.NoError(t, err)
+
+		logger.Debug().Msg("logged")
+		assert.Contains(t, buf.String(), "logged")
+		assert.False(t, json.Valid(buf.Bytes()))
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		_, err := logging.New(&bytes.Buffer{}, "verbose", logging.FormatJSON)
+		assert.Error(t, err)
+
+		_, err = logging.New(&bytes.Buffer{}, "info", "xml")
+		assert.Error(t, err)
+	})
+}
+
+func TestContext(t *testing.T) {
+	defaultLogger := logging.Default()
+	t.Cleanup(func() { logging.SetDefault(defaultLogger) })
+
+	var defaultBuf, requestBuf bytes.Buffer
+	logging.SetDefault(logging.Default().Output(&defaultBuf))
+
+	t.Run("default logger without logger in context", func(t *testing.T) {
+		logging.AddFields(context.Background(), map[string]any{"ignored": true})
+		logging.FromContext(context.Background()).Info().Msg("default")
+
+		assert.Contains(t, defaultBuf.String(), `"message":"default"`)
+		assert.NotContains(t, defaultBuf.String(), "ignored")
+	})
+
+	t.Run("fields are shared by the context logger", func(t *testing.T) {
+		ctx := logging.NewContext(context.Background(), logging.Default().Output(&requestBuf))
+		logging.AddFields(ctx, map[string]any{logging.CorrelationIDKey: "abc"})
+
+		logging.FromContext(ctx).Info().Msg("request")
+		assert.Contains(t, requestBuf.String(), `"correlationId":"abc"`)
+
+		logging.FromContext(context.Background()).Info().Msg("other")
+		assert.NotContains(t, defaultBuf.String(), "correlationId", "the default logger is left untouched")
+	})
+}
+
+func TestRedactEmail(t *testing.T) {
+	assert.Equal(t, "j***@example.com", logging.RedactEmail("john.doe@example.com"))
+	assert.Equal(t, "***", logging.RedactEmail("not-an-email"))
+	assert.Equal(t, "***", logging.RedactEmail("@example.com"))
+}
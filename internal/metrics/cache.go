@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"notification/internal/service"
+	"time"
+)
+
+// NewCache decorates cache, recording the duration of the calls and their failures.
+func NewCache(cache service.Cache, m *Metrics) *Cache {
+	return &Cache{cache: cache, metrics: m}
+}
+
+// Cache is the service.Cache decorator recording the Redis call metrics.
+type Cache struct {
+	cache   service.Cache
+	metrics *Metrics
+}
+
+// Incr increments the integer in key by 1 with a TTL defined by expiration.
+func (c *Cache) Incr(ctx context.Context, key string, expiration time.Duration) error {
+	defer c.observe("incr", time.Now())
+	return c.countError("incr", c.cache.Incr(ctx, key, expiration))
+}
+
+// Get retrieves the value for the given cache key.
+func (c *Cache) Get(ctx context.Context, key string) string {
+	defer c.observe("get", time.Now())
+	return c.cache.Get(ctx, key)
+}
+
+// Set sets a new key/value pair to the cache.
+func (c *Cache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	defer c.observe("set", time.Now())
+	return c.countError("set", c.cache.Set(ctx, key, value, expiration))
+}
+
+// Decr decrements the integer in key by 1.
+func (c *Cache) Decr(ctx context.Context, key string) error {
+	defer c.observe("decr", time.Now())
+	return c.countError("decr", c.cache.Decr(ctx, key))
+}
+
+// TTL retrieves the time to live left of the given cache key.
+func (c *Cache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	defer c.observe("ttl", time.Now())
+	ttl, err := c.cache.TTL(ctx, key)
+	return ttl, c.countError("ttl", err)
+}
+
+// observe records the duration of the operation started at start.
+func (c *Cache) observe(operation string, start time.Time) {
+	c.metrics.cacheDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// countError counts err, if any, as a failure of the operation, and returns it.
+func (c *Cache) countError(operation string, err error) error {
+	if err != nil {
+		c.metrics.cacheErrors.WithLabelValues(operation).Inc()
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"context"
+	"notification/internal/service"
+	"time"
+)
+
+// NewMailer decorates mailer, recording the duration and outcome of the email deliveries.
+// The decorator is a service.ContextMailer: the provider message ID is reported whenever
+// mailer is able to report it.
+func NewMailer(mailer service.Mailer, m *Metrics) *Mailer {
+	return &Mailer{mailer: mailer, metrics: m}
+}
+
+// Mailer is the service.Mailer decorator recording the email delivery metrics.
+type Mailer struct {
+	mailer  service.Mailer
+	metrics *Metrics
+}
+
+// SendEmail sends the email message through the decorated mailer.
+func (m *Mailer) SendEmail(to string, subject string, msg string) error {
+	_, err := m.SendEmailWithID(to, subject, msg)
+	return err
+}
+
+// SendEmailWithID sends the email message through the decorated mailer and returns the
+// provider message ID, which is empty if the decorated mailer isn't a service.MessageIDMailer.
+func (m *Mailer) SendEmailWithID(to string, subject string, msg string) (string, error) {
+	return m.SendEmailContext(context.Background(), to, subject, msg)
+}
+
+// SendEmailContext is SendEmailWithID passing ctx along to the decorated mailer.
+func (m *Mailer) SendEmailContext(ctx context.Context,
+	to string, subject string, msg string) (messageID string, err error) {
+	start := time.Now()
+	defer func() {
+		m.metrics.mailDuration.WithLabelValues(outcome(err)).Observe(time.Since(start).Seconds())
+	}()
+
+	return service.SendWithMessageID(ctx, m.mailer, to, subject, msg)
+}
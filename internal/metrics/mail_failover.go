@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"notification/internal/service"
+)
+
+// circuitStates are the circuit breaker states reported by the provider circuit state gauge.
+var circuitStates = []service.CircuitState{service.CircuitClosed, service.CircuitHalfOpen, service.CircuitOpen}
+
+// ProviderStateReporter reports the circuit breaker state of the mail providers,
+// e.g. service.FailoverMailer.
+type ProviderStateReporter interface {
+	ProviderStates() []service.ProviderState
+}
+
+// RegisterProviderStates registers the gauge of the circuit breaker state of the mail providers,
+// read from reporter on every scrape. The gauge of a provider is 1 for its current state,
+// and 0 for the other states.
+func (m *Metrics) RegisterProviderStates(reporter ProviderStateReporter) {
+	m.registry.MustRegister(&providerStateCollector{
+		reporter: reporter,
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mail_provider_circuit_state"),
+			"Circuit breaker state of the mail providers: closed, half-open or open.",
+			[]string{"provider", "state"}, nil),
+	})
+}
+
+// providerStateCollector collects the circuit breaker state of the mail providers.
+type providerStateCollector struct {
+	reporter ProviderStateReporter
+	desc     *prometheus.Desc
+}
+
+// Describe sends the descriptor of the provider circuit state gauge.
+func (c *providerStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect sends the provider circuit state gauges.
+func (c *providerStateCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, provider := range c.reporter.ProviderStates() {
+		for _, state := range circuitStates {
+			value := 0.0
+			if provider.State == state.String() {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, value, provider.Name, state.String())
+		}
+	}
+}
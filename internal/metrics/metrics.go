@@ -0,0 +1,134 @@
+// Package metrics instruments the application with Prometheus metrics. The services are
+// instrumented through decorators, so that they don't depend on the metrics themselves.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// namespace prefixes the name of the application metrics.
+const namespace = "notification"
+
+// The outcomes of the notifications.
+const (
+	OutcomeSent        = "sent"
+	OutcomeRateLimited = "rate_limited"
+	OutcomeDuplicate   = "duplicate"
+	OutcomeFailed      = "failed"
+)
+
+// The reasons of the rate limit rejections.
+const (
+	ReasonLimitExceeded = "limit_exceeded"
+	ReasonTenantQuota   = "tenant_quota"
+	ReasonNoRule        = "no_rule"
+)
+
+// New creates the application metrics and registers them, along with the Go runtime and
+// process metrics, in registry.
+func New(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		registry: registry,
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notifications_total",
+			Help:      "Number of notifications processed, by notification type and outcome.",
+		}, []string{"type", "outcome"}),
+		rateLimitRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_rejections_total",
+			Help:      "Number of notifications rejected by the rate limiter, by notification type and reason.",
+		}, []string{"type", "reason"}),
+		mailDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "mail_send_duration_seconds",
+			Help:      "Duration of the email deliveries to the mail provider, by outcome.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"outcome"}),
+		throttleWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "mail_throttle_wait_seconds",
+			Help:      "Duration the emails waited for their slot within the throughput limits, by scope.",
+			Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"scope"}),
+		mailThrottled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mail_throttled_total",
+			Help:      "Number of emails rejected for exceeding the max wait of a throughput limit, by scope.",
+		}, []string{"scope"}),
+		cacheDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "redis_duration_seconds",
+			Help:      "Duration of the Redis calls, by operation.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"operation"}),
+		cacheErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redis_errors_total",
+			Help:      "Number of failed Redis calls, by operation.",
+		}, []string{"operation"}),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests handled, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of the HTTP requests, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.notifications,
+		m.rateLimitRejections,
+		m.mailDuration,
+		m.throttleWait,
+		m.mailThrottled,
+		m.cacheDuration,
+		m.cacheErrors,
+		m.httpRequests,
+		m.httpDuration,
+	)
+	return m
+}
+
+// Metrics holds the application metrics.
+type Metrics struct {
+	registry            *prometheus.Registry
+	notifications       *prometheus.CounterVec
+	rateLimitRejections *prometheus.CounterVec
+	mailDuration        *prometheus.HistogramVec
+	throttleWait        *prometheus.HistogramVec
+	mailThrottled       *prometheus.CounterVec
+	cacheDuration       *prometheus.HistogramVec
+	cacheErrors         *prometheus.CounterVec
+	httpRequests        *prometheus.CounterVec
+	httpDuration        *prometheus.HistogramVec
+}
+
+// Handler returns the HTTP handler exposing the metrics in the Prometheus format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTPRequest records an HTTP request handled, see middleware.Metrics.
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// outcome returns the label value of the outcome of an operation failing with err.
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
@@ -0,0 +1,235 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"notification/internal/domain"
+	"notification/internal/metrics"
+	"notification/internal/service"
+	"notification/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotificationSender_Send(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := metrics.New(registry)
+	senderMock := mocks.NewNotificationSender(t)
+	sender := metrics.NewNotificationSender(senderMock, m)
+	ctx := context.Background()
+
+	send := func(notificationType domain.NotificationType, correlationID string,
+		retryAfter time.Duration, err error) {
+		notification := domain.Notification{CorrelationID: correlationID, Type: notificationType}
+		result := service.SendResult{RetryAfter: retryAfter}
+		senderMock.On("Send", ctx, "user", notification).Return(result, err).Once()
+
+		gotResult, gotErr := sender.Send(ctx, "user", notification)
+		assert.Equal(t, result, gotResult)
+		assert.Equal(t, err, gotErr)
+	}
+	send(domain.Status, "1", 0, nil)
+	send(domain.Status, "2", 0, nil)
+	send(domain.Status, "3", time.Minute, errors.Join(service.ErrRateLimitExceeded, errors.New("limit")))
+	send(domain.News, "4", 0, errors.Join(service.ErrIdempotencyViolation, errors.New("duplicate")))
+	send(domain.Marketing, "5", 0, errors.Join(service.ErrNoRateLimitRule, errors.New("no rule")))
+	send(domain.News, "6", 0, errors.New("smtp failure"))
+	send(domain.Status, "7", time.Hour, errors.Join(service.ErrRateLimitExceeded, service.ErrTenantQuotaExceeded))
+
+	expected := `
+# HELP notification_notifications_total Number of notifications processed, by notification type and outcome.
+# TYPE notification_notifications_total counter
+notification_notifications_total{outcome="duplicate",type="news"} 1
+notification_notifications_total{outcome="failed",type="marketing"} 1
+notification_notifications_total{outcome="failed",type="news"} 1
+notification_notifications_total{outcome="rate_limited",type="status"} 2
+notification_notifications_total{outcome="sent",type="status"} 2
+# HELP notification_rate_limit_rejections_total Number of notifications rejected by the rate limiter, by notification type and reason.
+# TYPE notification_rate_limit_rejections_total counter
+notification_rate_limit_rejections_total{reason="limit_exceeded",type="status"} 1
+notification_rate_limit_rejections_total{reason="no_rule",type="marketing"} 1
+notification_rate_limit_rejections_total{reason="tenant_quota",type="status"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"notification_notifications_total", "notification_rate_limit_rejections_total"))
+}
+
+func TestMailer(t *testing.T) {
+	t.Run("message ID is reported", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		mailerMock := mocks.NewMessageIDMailer(t)
+		mailerMock.On("SendEmailWithID", "john@example.com", "subject", "msg").Return("id-1", nil).Once()
+		mailerMock.On("SendEmailWithID", "john@example.com", "subject", "msg").Return("", assert.AnError).Once()
+		mailer := metrics.NewMailer(mailerMock, metrics.New(registry))
+
+		messageID, err := mailer.SendEmailWithID("john@example.com", "subject", "msg")
+		assert.NoError(t, err)
+		assert.Equal(t, "id-1", messageID)
+		assert.ErrorIs(t, mailer.SendEmail("john@example.com", "subject", "msg"), assert.AnError)
+
+		assert.Equal(t, uint64(1), sampleCount(t, registry, "notification_mail_send_duration_seconds",
+			map[string]string{"outcome": "success"}))
+		assert.Equal(t, uint64(1), sampleCount(t, registry, "notification_mail_send_duration_seconds",
+			map[string]string{"outcome": "error"}))
+	})
+
+	t.Run("plain mailer", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		mailerMock := mocks.NewMailer(t)
+		mailerMock.On("SendEmail", "john@example.com", "subject", "msg").Return(nil).Once()
+		mailer := metrics.NewMailer(mailerMock, metrics.New(registry))
+
+		var _ service.MessageIDMailer = mailer
+		messageID, err := mailer.SendEmailWithID("john@example.com", "subject", "msg")
+		assert.NoError(t, err)
+		assert.Empty(t, messageID)
+
+		assert.Equal(t, uint64(1), sampleCount(t, registry, "notification_mail_send_duration_seconds",
+			map[string]string{"outcome": "success"}))
+	})
+}
+
+func TestCache(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	cacheMock := mocks.NewCache(t)
+	cache := metrics.NewCache(cacheMock, metrics.New(registry))
+	ctx := context.Background()
+
+	cacheMock.On("Incr", ctx, "key", time.Hour).Return(nil).Once()
+	cacheMock.On("Incr", ctx, "key", time.Hour).Return(assert.AnError).Once()
+	cacheMock.On("Get", ctx, "key").Return("1").Once()
+	cacheMock.On("Set", ctx, "key", "value", time.Hour).Return(nil).Once()
+	cacheMock.On("Decr", ctx, "key").Return(assert.AnError).Once()
+	cacheMock.On("TTL", ctx, "key").Return(time.Minute, nil).Once()
+
+	assert.NoError(t, cache.Incr(ctx, "key", time.Hour))
+	assert.ErrorIs(t, cache.Incr(ctx, "key", time.Hour), assert.AnError)
+	assert.Equal(t, "1", cache.Get(ctx, "key"))
+	assert.NoError(t, cache.Set(ctx, "key", "value", time.Hour))
+	assert.ErrorIs(t, cache.Decr(ctx, "key"), assert.AnError)
+	ttl, err := cache.TTL(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	for operation, count := range map[string]uint64{"incr": 2, "get": 1, "set": 1, "decr": 1, "ttl": 1} {
+		assert.Equal(t, count, sampleCount(t, registry, "notification_redis_duration_seconds",
+			map[string]string{"operation": operation}), operation)
+	}
+	expected := `
+# HELP notification_redis_errors_total Number of failed Redis calls, by operation.
+# TYPE notification_redis_errors_total counter
+notification_redis_errors_total{operation="decr"} 1
+notification_redis_errors_total{operation="incr"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "notification_redis_errors_total"))
+}
+
+func TestThrottle(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	throttleMock := mocks.NewThrottle(t)
+	throttle := metrics.NewThrottle(throttleMock, metrics.New(registry))
+	ctx := context.Background()
+	tenant := service.ThroughputLimit{Scope: service.ThrottleTenant, Key: "billing", Rate: 5}
+	global := service.ThroughputLimit{Scope: service.ThrottleGlobal, Rate: 50}
+
+	throttleMock.On("Wait", ctx, tenant).Return(nil).Once()
+	throttleMock.On("Wait", ctx, global).Return(nil).Once()
+	throttleMock.On("Wait", ctx, tenant).Return(service.ErrMailThrottled).Once()
+
+	assert.NoError(t, throttle.Wait(ctx, tenant))
+	assert.NoError(t, throttle.Wait(ctx, global))
+	assert.ErrorIs(t, throttle.Wait(ctx, tenant), service.ErrMailThrottled)
+
+	for scope, count := range map[string]uint64{service.ThrottleTenant: 1, service.ThrottleGlobal: 1} {
+		assert.Equal(t, count, sampleCount(t, registry, "notification_mail_throttle_wait_seconds",
+			map[string]string{"scope": scope}), scope)
+	}
+	expected := `
+# HELP notification_mail_throttled_total Number of emails rejected for exceeding the max wait of a throughput limit, by scope.
+# TYPE notification_mail_throttled_total counter
+notification_mail_throttled_total{scope="tenant"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "notification_mail_throttled_total"))
+}
+
+// providerStates is a metrics.ProviderStateReporter reporting fixed states.
+type providerStates []service.ProviderState
+
+func (p providerStates) ProviderStates() []service.ProviderState {
+	return p
+}
+
+func TestMetrics_RegisterProviderStates(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := metrics.New(registry)
+	m.RegisterProviderStates(providerStates{
+		{Name: "sendgrid", Priority: 1, State: service.CircuitOpen.String(), ConsecutiveFailures: 5},
+		{Name: "smtp", Priority: 2, State: service.CircuitClosed.String()},
+	})
+
+	expected := `
+# HELP notification_mail_provider_circuit_state Circuit breaker state of the mail providers: closed, half-open or open.
+# TYPE notification_mail_provider_circuit_state gauge
+notification_mail_provider_circuit_state{provider="sendgrid",state="closed"} 0
+notification_mail_provider_circuit_state{provider="sendgrid",state="half-open"} 0
+notification_mail_provider_circuit_state{provider="sendgrid",state="open"} 1
+notification_mail_provider_circuit_state{provider="smtp",state="closed"} 1
+notification_mail_provider_circuit_state{provider="smtp",state="half-open"} 0
+notification_mail_provider_circuit_state{provider="smtp",state="open"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"notification_mail_provider_circuit_state"))
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := metrics.New(prometheus.NewRegistry())
+	m.ObserveHTTPRequest(http.MethodPost, "/send", http.StatusAccepted, 10*time.Millisecond)
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	body, err := io.ReadAll(rr.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `notification_http_requests_total{method="POST",route="/send",status="202"} 1`)
+	assert.Contains(t, string(body), `notification_http_request_duration_seconds_count{method="POST",route="/send"} 1`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
+
+// sampleCount returns the number of observations of the histogram name having the given labels.
+func sampleCount(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) uint64 {
+	t.Helper()
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if hasLabels(metric, labels) {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+// hasLabels reports whether metric has the given labels.
+func hasLabels(metric *dto.Metric, labels map[string]string) bool {
+	matched := 0
+	for _, label := range metric.GetLabel() {
+		if value, ok := labels[label.GetName()]; ok && value == label.GetValue() {
+			matched++
+		}
+	}
+	return matched == len(labels)
+}
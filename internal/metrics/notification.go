@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"notification/internal/domain"
+	"notification/internal/service"
+)
+
+// NewNotificationSender decorates sender, counting the notifications by type and outcome,
+// and the rate limit rejections by type.
+func NewNotificationSender(sender service.NotificationSender, m *Metrics) *NotificationSender {
+	return &NotificationSender{sender: sender, metrics: m}
+}
+
+// NotificationSender is the service.NotificationSender decorator recording the notification metrics.
+type NotificationSender struct {
+	sender  service.NotificationSender
+	metrics *Metrics
+}
+
+// Send sends the notification through the decorated sender.
+func (n *NotificationSender) Send(ctx context.Context,
+	userID string, notification domain.Notification) (service.SendResult, error) {
+	result, err := n.sender.Send(ctx, userID, notification)
+
+	notificationType := notification.Type.String()
+	var notificationOutcome string
+	switch {
+	case err == nil:
+		notificationOutcome = OutcomeSent
+	case errors.Is(err, service.ErrTenantQuotaExceeded):
+		notificationOutcome = OutcomeRateLimited
+		n.metrics.rateLimitRejections.WithLabelValues(notificationType, ReasonTenantQuota).Inc()
+	case errors.Is(err, service.ErrRateLimitExceeded):
+		notificationOutcome = OutcomeRateLimited
+		n.metrics.rateLimitRejections.WithLabelValues(notificationType, ReasonLimitExceeded).Inc()
+	case errors.Is(err, service.ErrNoRateLimitRule):
+		notificationOutcome = OutcomeFailed
+		n.metrics.rateLimitRejections.WithLabelValues(notificationType, ReasonNoRule).Inc()
+	case errors.Is(err, service.ErrIdempotencyViolation):
+		notificationOutcome = OutcomeDuplicate
+	default:
+		notificationOutcome = OutcomeFailed
+	}
+	n.metrics.notifications.WithLabelValues(notificationType, notificationOutcome).Inc()
+	return result, err
+}
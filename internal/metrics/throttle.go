@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"notification/internal/service"
+	"time"
+)
+
+// NewThrottle decorates throttle, recording how long the emails wait for their slot and how
+// many are rejected for exceeding the max wait.
+func NewThrottle(throttle service.Throttle, m *Metrics) *Throttle {
+	return &Throttle{throttle: throttle, metrics: m}
+}
+
+// Throttle is the service.Throttle decorator recording the throughput limit metrics.
+type Throttle struct {
+	throttle service.Throttle
+	metrics  *Metrics
+}
+
+// Wait blocks until an email can be sent within the limit, reserving its slot.
+func (t *Throttle) Wait(ctx context.Context, limit service.ThroughputLimit) error {
+	start := time.Now()
+	err := t.throttle.Wait(ctx, limit)
+	if errors.Is(err, service.ErrMailThrottled) {
+		t.metrics.mailThrottled.WithLabelValues(limit.Scope).Inc()
+		return err
+	}
+	t.metrics.throttleWait.WithLabelValues(limit.Scope).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// Release gives back the slot reserved by Wait for an email that isn't sent after all.
+func (t *Throttle) Release(ctx context.Context, limit service.ThroughputLimit) error {
+	return t.throttle.Release(ctx, limit)
+}
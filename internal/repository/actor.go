@@ -0,0 +1,22 @@
+package repository
+
+import "context"
+
+// UnknownActor is the actor of the changes made without an actor in the context.
+const UnknownActor = "unknown"
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor making the changes,
+// which is recorded in the audit history of the repositories keeping one.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by ctx, or UnknownActor if there's none.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return UnknownActor
+}
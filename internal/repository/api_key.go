@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"notification/internal/domain"
+	"slices"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrAPIKeyAlreadyExists is the error when an API key with the same ID or hash is already stored.
+	ErrAPIKeyAlreadyExists = errors.New("api key already exists")
+	// ErrAPIKeyNotFound is the error when the API key requested isn't stored.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+// APIKeyRepository is the abstract representation of the API key repository.
+// API key IDs and hashes are unique across the repository.
+type APIKeyRepository interface {
+	// Get retrieves an API key by its ID.
+	Get(ctx context.Context, id string) (domain.APIKey, error)
+	// GetByHash retrieves an API key by the hash of the key.
+	GetByHash(ctx context.Context, hash string) (domain.APIKey, error)
+	// Save stores a given API key in the repository.
+	Save(ctx context.Context, key domain.APIKey) error
+	// Delete removes an API key by its ID, revoking it.
+	Delete(ctx context.Context, id string) error
+	// List retrieves every API key, sorted by ID.
+	List(ctx context.Context) ([]domain.APIKey, error)
+}
+
+// NewInMemoryAPIKeyRepository creates a new InMemoryAPIKeyRepository instance.
+func NewInMemoryAPIKeyRepository() *InMemoryAPIKeyRepository {
+	return &InMemoryAPIKeyRepository{
+		keys: make(map[string]domain.APIKey),
+	}
+}
+
+// InMemoryAPIKeyRepository is the in-memory representation of the API key repository.
+// It's safe for concurrent use.
+type InMemoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string]domain.APIKey
+}
+
+// Get retrieves an API key by its ID.
+func (r *InMemoryAPIKeyRepository) Get(_ context.Context, id string) (domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return domain.APIKey{}, ErrAPIKeyNotFound
+	}
+	return cloneAPIKey(key), nil
+}
+
+// GetByHash retrieves an API key by the hash of the key.
+func (r *InMemoryAPIKeyRepository) GetByHash(_ context.Context, hash string) (domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			return cloneAPIKey(key), nil
+		}
+	}
+	return domain.APIKey{}, ErrAPIKeyNotFound
+}
+
+// Save stores a given API key in the repository.
+func (r *InMemoryAPIKeyRepository) Save(_ context.Context, key domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[key.ID]; ok {
+		return ErrAPIKeyAlreadyExists
+	}
+	for _, stored := range r.keys {
+		if stored.Hash == key.Hash {
+			return ErrAPIKeyAlreadyExists
+		}
+	}
+
+	r.keys[key.ID] = cloneAPIKey(key)
+	return nil
+}
+
+// Delete removes an API key by its ID, revoking it.
+func (r *InMemoryAPIKeyRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[id]; !ok {
+		return ErrAPIKeyNotFound
+	}
+	delete(r.keys, id)
+	return nil
+}
+
+// List retrieves every API key, sorted by ID.
+func (r *InMemoryAPIKeyRepository) List(_ context.Context) ([]domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]domain.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, cloneAPIKey(key))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+// cloneAPIKey copies the scopes of key, so that the stored keys can't be changed by the callers.
+func cloneAPIKey(key domain.APIKey) domain.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	return key
+}
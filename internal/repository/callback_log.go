@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"notification/internal/domain"
+	"sync"
+)
+
+// CallbackLogRepository is the abstract representation of the log of delivery event callbacks.
+// Every operation is scoped to the tenant carried by the context, see WithTenant.
+type CallbackLogRepository interface {
+	// Add records a callback attempt.
+	Add(ctx context.Context, attempt domain.CallbackAttempt) error
+	// ListByCorrelationID retrieves the callback attempts of a notification, oldest first.
+	ListByCorrelationID(ctx context.Context, correlationID string) ([]domain.CallbackAttempt, error)
+}
+
+// NewInMemoryCallbackLogRepository creates a new InMemoryCallbackLogRepository instance
+// keeping up to capacity attempts. When full, the oldest attempts are dropped.
+func NewInMemoryCallbackLogRepository(capacity int) *InMemoryCallbackLogRepository {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &InMemoryCallbackLogRepository{
+		attempts: make([]tenantAttempt, 0, capacity),
+		capacity: capacity,
+	}
+}
+
+// InMemoryCallbackLogRepository is the in-memory, bounded representation of the callback log.
+// It's safe for concurrent use.
+type InMemoryCallbackLogRepository struct {
+	mu       sync.RWMutex
+	attempts []tenantAttempt
+	capacity int
+}
+
+// tenantAttempt is a callback attempt logged for a tenant. The capacity is shared by the tenants.
+type tenantAttempt struct {
+	tenantID string
+	attempt  domain.CallbackAttempt
+}
+
+// Add records a callback attempt, dropping the oldest one if the log is full.
+func (r *InMemoryCallbackLogRepository) Add(ctx context.Context, attempt domain.CallbackAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.attempts) == r.capacity {
+		copy(r.attempts, r.attempts[1:])
+		r.attempts = r.attempts[:len(r.attempts)-1]
+	}
+	r.attempts = append(r.attempts, tenantAttempt{tenantID: TenantFromContext(ctx), attempt: attempt})
+	return nil
+}
+
+// ListByCorrelationID retrieves the callback attempts of a notification, oldest first.
+func (r *InMemoryCallbackLogRepository) ListByCorrelationID(ctx context.Context,
+	correlationID string) ([]domain.CallbackAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := TenantFromContext(ctx)
+	attempts := make([]domain.CallbackAttempt, 0)
+	for _, a := range r.attempts {
+		if a.tenantID == tenantID && a.attempt.CorrelationID == correlationID {
+			attempts = append(attempts, a.attempt)
+		}
+	}
+	return attempts, nil
+}
@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"testing"
+)
+
+func TestInMemoryCallbackLogRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("attempts are listed by correlation ID", func(t *testing.T) {
+		repo := repository.NewInMemoryCallbackLogRepository(10)
+		require.NoError(t, repo.Add(ctx, domain.CallbackAttempt{CorrelationID: "c1", Attempt: 1}))
+		require.NoError(t, repo.Add(ctx, domain.CallbackAttempt{CorrelationID: "c2", Attempt: 1}))
+		require.NoError(t, repo.Add(ctx, domain.CallbackAttempt{CorrelationID: "c1", Attempt: 2}))
+
+		got, err := repo.ListByCorrelationID(ctx, "c1")
+		require.NoError(t, err)
+		assert.Equal(t, []domain.CallbackAttempt{
+			{CorrelationID: "c1", Attempt: 1},
+			{CorrelationID: "c1", Attempt: 2},
+		}, got)
+	})
+
+	t.Run("oldest attempts are dropped when full", func(t *testing.T) {
+		repo := repository.NewInMemoryCallbackLogRepository(2)
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, repo.Add(ctx, domain.CallbackAttempt{CorrelationID: "c1", Attempt: i}))
+		}
+
+		got, err := repo.ListByCorrelationID(ctx, "c1")
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, 2, got[0].Attempt)
+		assert.Equal(t, 3, got[1].Attempt)
+	})
+
+	t.Run("unknown notification", func(t *testing.T) {
+		repo := repository.NewInMemoryCallbackLogRepository(2)
+
+		got, err := repo.ListByCorrelationID(ctx, "c1")
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+	t.Run("attempts are isolated per tenant", func(t *testing.T) {
+		repo := repository.NewInMemoryCallbackLogRepository(10)
+		billing := repository.WithTenant(ctx, "billing")
+		require.NoError(t, repo.Add(billing, domain.CallbackAttempt{CorrelationID: "c1", Attempt: 1}))
+
+		got, err := repo.ListByCorrelationID(ctx, "c1")
+		require.NoError(t, err)
+		assert.Empty(t, got)
+
+		got, err = repo.ListByCorrelationID(billing, "c1")
+		require.NoError(t, err)
+		assert.Len(t, got, 1)
+	})
+}
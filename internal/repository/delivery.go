@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"notification/internal/domain"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrDeliveryNotFound is the error when there's no delivery record for the correlation ID.
+	ErrDeliveryNotFound = errors.New("delivery record not found")
+)
+
+// DeliveryRepository is the abstract representation of the notification delivery tracking store.
+// Every operation is scoped to the tenant carried by the context, see WithTenant, except the lookup by
+// provider message ID: the provider feedback, such as bounces, doesn't tell the tenant.
+type DeliveryRepository interface {
+	// AddTransition appends a transition to the delivery record of a notification, creating
+	// the record if it doesn't exist yet. Only the CorrelationID, UserID, Type, ProviderMessageID,
+	// CallbackURL and Caller fields of record are used, the last three only if they're not empty.
+	AddTransition(ctx context.Context, record domain.DeliveryRecord, transition domain.StatusTransition) error
+	// Get retrieves the delivery record of a notification by its correlation ID.
+	Get(ctx context.Context, correlationID string) (domain.DeliveryRecord, error)
+	// GetByProviderMessageID retrieves the delivery record of a notification by the message ID
+	// reported by the mail provider, whatever its tenant.
+	GetByProviderMessageID(ctx context.Context, messageID string) (domain.DeliveryRecord, error)
+	// ListByUser retrieves up to limit delivery records of a user, the most recent first.
+	ListByUser(ctx context.Context, userID string, limit int) ([]domain.DeliveryRecord, error)
+}
+
+// NewInMemoryDeliveryRepository creates a new InMemoryDeliveryRepository instance.
+func NewInMemoryDeliveryRepository() *InMemoryDeliveryRepository {
+	return &InMemoryDeliveryRepository{
+		records:    make(map[deliveryKey]*domain.DeliveryRecord),
+		messageIDs: make(map[string]deliveryKey),
+	}
+}
+
+// deliveryKey identifies a delivery record, the correlation IDs being unique within a tenant only.
+type deliveryKey struct {
+	tenantID      string
+	correlationID string
+}
+
+// InMemoryDeliveryRepository is the in-memory representation of the delivery tracking store.
+// It's safe for concurrent use.
+type InMemoryDeliveryRepository struct {
+	mu         sync.RWMutex
+	records    map[deliveryKey]*domain.DeliveryRecord
+	messageIDs map[string]deliveryKey
+}
+
+// AddTransition appends a transition to the delivery record of a notification,
+// creating the record if it doesn't exist yet.
+func (r *InMemoryDeliveryRepository) AddTransition(ctx context.Context, record domain.DeliveryRecord,
+	transition domain.StatusTransition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := deliveryKey{tenantID: TenantFromContext(ctx), correlationID: record.CorrelationID}
+	stored, ok := r.records[key]
+	if !ok {
+		stored = &domain.DeliveryRecord{
+			CorrelationID: record.CorrelationID,
+			TenantID:      key.tenantID,
+			UserID:        record.UserID,
+			Type:          record.Type,
+			CreatedAt:     transition.At,
+		}
+		r.records[key] = stored
+	}
+	if record.ProviderMessageID != "" {
+		stored.ProviderMessageID = record.ProviderMessageID
+		r.messageIDs[record.ProviderMessageID] = key
+	}
+	if record.CallbackURL != "" {
+		stored.CallbackURL = record.CallbackURL
+	}
+	if record.Caller != "" {
+		stored.Caller = record.Caller
+	}
+	stored.Status = transition.Status
+	stored.UpdatedAt = transition.At
+	stored.Transitions = append(stored.Transitions, transition)
+	return nil
+}
+
+// Get retrieves the delivery record of a notification by its correlation ID.
+func (r *InMemoryDeliveryRepository) Get(ctx context.Context, correlationID string) (domain.DeliveryRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, ok := r.records[deliveryKey{tenantID: TenantFromContext(ctx), correlationID: correlationID}]
+	if !ok {
+		return domain.DeliveryRecord{}, ErrDeliveryNotFound
+	}
+	return copyRecord(record), nil
+}
+
+// GetByProviderMessageID retrieves the delivery record of a notification by the message ID
+// reported by the mail provider, whatever its tenant.
+func (r *InMemoryDeliveryRepository) GetByProviderMessageID(_ context.Context,
+	messageID string) (domain.DeliveryRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, ok := r.records[r.messageIDs[messageID]]
+	if !ok {
+		return domain.DeliveryRecord{}, ErrDeliveryNotFound
+	}
+	return copyRecord(record), nil
+}
+
+// ListByUser retrieves up to limit delivery records of a user, the most recent first.
+func (r *InMemoryDeliveryRepository) ListByUser(ctx context.Context, userID string,
+	limit int) ([]domain.DeliveryRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := TenantFromContext(ctx)
+	var records []domain.DeliveryRecord
+	for key, record := range r.records {
+		if key.tenantID == tenantID && record.UserID == userID {
+			records = append(records, copyRecord(record))
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// copyRecord returns a copy of the record which doesn't share the transitions with the stored one.
+func copyRecord(record *domain.DeliveryRecord) domain.DeliveryRecord {
+	c := *record
+	c.Transitions = append([]domain.StatusTransition(nil), record.Transitions...)
+	return c
+}
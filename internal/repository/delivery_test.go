@@ -0,0 +1,94 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestInMemoryDeliveryRepository(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	t.Run("transitions are tracked", func(t *testing.T) {
+		repo := repository.NewInMemoryDeliveryRepository()
+		record := domain.DeliveryRecord{
+			CorrelationID: "c1",
+			UserID:        "u1",
+			Type:          domain.News,
+			CallbackURL:   "https://example.com/callback",
+			Caller:        "k1",
+		}
+
+		require.NoError(t, repo.AddTransition(ctx, record,
+			domain.StatusTransition{Status: domain.DeliveryAccepted, At: start}))
+		record.ProviderMessageID = "m1"
+		require.NoError(t, repo.AddTransition(ctx, record,
+			domain.StatusTransition{Status: domain.DeliverySent, At: start.Add(time.Second)}))
+
+		got, err := repo.Get(ctx, "c1")
+		require.NoError(t, err)
+		assert.Equal(t, domain.DeliverySent, got.Status)
+		assert.Equal(t, "m1", got.ProviderMessageID)
+		assert.Equal(t, start, got.CreatedAt)
+		assert.Equal(t, start.Add(time.Second), got.UpdatedAt)
+		assert.Equal(t, "https://example.com/callback", got.CallbackURL)
+		assert.Equal(t, "k1", got.Caller)
+		assert.Len(t, got.Transitions, 2)
+
+		got, err = repo.GetByProviderMessageID(ctx, "m1")
+		require.NoError(t, err)
+		assert.Equal(t, "c1", got.CorrelationID)
+	})
+
+	t.Run("missing record", func(t *testing.T) {
+		repo := repository.NewInMemoryDeliveryRepository()
+		_, err := repo.Get(ctx, "c1")
+		assert.ErrorIs(t, err, repository.ErrDeliveryNotFound)
+		_, err = repo.GetByProviderMessageID(ctx, "m1")
+		assert.ErrorIs(t, err, repository.ErrDeliveryNotFound)
+	})
+
+	t.Run("user history is sorted by most recent first", func(t *testing.T) {
+		repo := repository.NewInMemoryDeliveryRepository()
+		for i, id := range []string{"c1", "c2", "c3"} {
+			require.NoError(t, repo.AddTransition(ctx,
+				domain.DeliveryRecord{CorrelationID: id, UserID: "u1"},
+				domain.StatusTransition{Status: domain.DeliveryAccepted, At: start.Add(time.Duration(i) * time.Minute)}))
+		}
+		require.NoError(t, repo.AddTransition(ctx,
+			domain.DeliveryRecord{CorrelationID: "c4", UserID: "u2"},
+			domain.StatusTransition{Status: domain.DeliveryAccepted, At: start}))
+
+		got, err := repo.ListByUser(ctx, "u1", 2)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, "c3", got[0].CorrelationID)
+		assert.Equal(t, "c2", got[1].CorrelationID)
+	})
+	t.Run("records are isolated per tenant", func(t *testing.T) {
+		repo := repository.NewInMemoryDeliveryRepository()
+		billing := repository.WithTenant(ctx, "billing")
+		require.NoError(t, repo.AddTransition(billing,
+			domain.DeliveryRecord{CorrelationID: "c1", UserID: "u1", ProviderMessageID: "m1"},
+			domain.StatusTransition{Status: domain.DeliverySent, At: start}))
+
+		_, err := repo.Get(ctx, "c1")
+		assert.ErrorIs(t, err, repository.ErrDeliveryNotFound)
+		got, err := repo.ListByUser(ctx, "u1", 10)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+
+		record, err := repo.Get(billing, "c1")
+		require.NoError(t, err)
+		assert.Equal(t, "billing", record.TenantID)
+		// the provider feedback doesn't tell the tenant.
+		record, err = repo.GetByProviderMessageID(ctx, "m1")
+		require.NoError(t, err)
+		assert.Equal(t, "billing", record.TenantID)
+	})
+}
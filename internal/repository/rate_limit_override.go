@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"notification/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrOverrideNotFound is the error when the subject has no rate limit override.
+	ErrOverrideNotFound = errors.New("rate limit override not found")
+	// ErrExemptionNotFound is the error when the notification type has no active rate limit exemption.
+	ErrExemptionNotFound = errors.New("rate limit exemption not found")
+)
+
+// RateLimitOverrideRepository is the abstract representation of the rate limit override repository.
+// Every operation is scoped to the tenant carried by the context, see WithTenant.
+type RateLimitOverrideRepository interface {
+	// Get retrieves the override of a user or segment.
+	// It returns ErrOverrideNotFound if the subject has no override.
+	Get(ctx context.Context, scope domain.OverrideScope, subject string) (domain.RateLimitOverride, error)
+	// List retrieves every override, sorted as SortOverrides does.
+	List(ctx context.Context) ([]domain.RateLimitOverride, error)
+	// Save stores an override, replacing the one of the same subject if any.
+	Save(ctx context.Context, override domain.RateLimitOverride) error
+	// Delete removes the override of a user or segment.
+	// It returns ErrOverrideNotFound if the subject has no override.
+	Delete(ctx context.Context, scope domain.OverrideScope, subject string) error
+}
+
+// RateLimitExemptionRepository is the abstract representation of the rate limit exemption repository.
+// Every operation is scoped to the tenant carried by the context, see WithTenant. The expired
+// exemptions are never returned.
+type RateLimitExemptionRepository interface {
+	// Get retrieves the active exemption of the notification type.
+	// It returns ErrExemptionNotFound if the notification type has none.
+	Get(ctx context.Context, notificationType domain.NotificationType) (domain.RateLimitExemption, error)
+	// List retrieves every active exemption, sorted by notification type.
+	List(ctx context.Context) ([]domain.RateLimitExemption, error)
+	// Save stores an exemption, replacing the one of the same notification type if any.
+	Save(ctx context.Context, exemption domain.RateLimitExemption) error
+	// Delete ends the exemption of the notification type.
+	// It returns ErrExemptionNotFound if the notification type has no active exemption.
+	Delete(ctx context.Context, notificationType domain.NotificationType) error
+}
+
+// NewInMemoryRateLimitOverrideRepository creates a new InMemoryRateLimitOverrideRepository instance.
+func NewInMemoryRateLimitOverrideRepository() *InMemoryRateLimitOverrideRepository {
+	return &InMemoryRateLimitOverrideRepository{
+		overrides: make(map[string]map[overrideKey]domain.RateLimitOverride),
+	}
+}
+
+// InMemoryRateLimitOverrideRepository is the in-memory representation of the rate limit override
+// repository. It's safe for concurrent use.
+type InMemoryRateLimitOverrideRepository struct {
+	mu sync.RWMutex
+	// overrides are keyed by tenant ID, then by subject.
+	overrides map[string]map[overrideKey]domain.RateLimitOverride
+}
+
+// overrideKey identifies the subject of an override.
+type overrideKey struct {
+	scope   domain.OverrideScope
+	subject string
+}
+
+// Get retrieves the override of a user or segment.
+// It returns ErrOverrideNotFound if the subject has no override.
+func (r *InMemoryRateLimitOverrideRepository) Get(ctx context.Context,
+	scope domain.OverrideScope, subject string) (domain.RateLimitOverride, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	override, ok := r.overrides[TenantFromContext(ctx)][overrideKey{scope, subject}]
+	if !ok {
+		return domain.RateLimitOverride{}, ErrOverrideNotFound
+	}
+	return cloneOverride(override), nil
+}
+
+// List retrieves every override, sorted as SortOverrides does.
+func (r *InMemoryRateLimitOverrideRepository) List(ctx context.Context) ([]domain.RateLimitOverride, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	overrides := make([]domain.RateLimitOverride, 0, len(r.overrides[TenantFromContext(ctx)]))
+	for _, override := range r.overrides[TenantFromContext(ctx)] {
+		overrides = append(overrides, cloneOverride(override))
+	}
+	SortOverrides(overrides)
+	return overrides, nil
+}
+
+// Save stores an override, replacing the one of the same subject if any.
+func (r *InMemoryRateLimitOverrideRepository) Save(ctx context.Context, override domain.RateLimitOverride) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := TenantFromContext(ctx)
+	overrides, ok := r.overrides[tenantID]
+	if !ok {
+		overrides = make(map[overrideKey]domain.RateLimitOverride)
+		r.overrides[tenantID] = overrides
+	}
+	overrides[overrideKey{override.Scope, override.Subject}] = cloneOverride(override)
+	return nil
+}
+
+// Delete removes the override of a user or segment.
+// It returns ErrOverrideNotFound if the subject has no override.
+func (r *InMemoryRateLimitOverrideRepository) Delete(ctx context.Context,
+	scope domain.OverrideScope, subject string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	overrides := r.overrides[TenantFromContext(ctx)]
+	if _, ok := overrides[overrideKey{scope, subject}]; !ok {
+		return ErrOverrideNotFound
+	}
+	delete(overrides, overrideKey{scope, subject})
+	return nil
+}
+
+// SortOverrides sorts the overrides in the order of the override listings: the user overrides
+// first, then the segment ones, each sorted by subject.
+func SortOverrides(overrides []domain.RateLimitOverride) {
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].Scope != overrides[j].Scope {
+			return overrides[i].Scope > overrides[j].Scope
+		}
+		return overrides[i].Subject < overrides[j].Subject
+	})
+}
+
+// cloneOverride returns a copy of the override which doesn't share its rules.
+func cloneOverride(override domain.RateLimitOverride) domain.RateLimitOverride {
+	override.Rules = maps.Clone(override.Rules)
+	if override.Default != nil {
+		rule := *override.Default
+		override.Default = &rule
+	}
+	return override
+}
+
+// NewInMemoryRateLimitExemptionRepository creates a new InMemoryRateLimitExemptionRepository instance.
+func NewInMemoryRateLimitExemptionRepository() *InMemoryRateLimitExemptionRepository {
+	return &InMemoryRateLimitExemptionRepository{
+		exemptions: make(map[string]map[domain.NotificationType]domain.RateLimitExemption),
+	}
+}
+
+// InMemoryRateLimitExemptionRepository is the in-memory representation of the rate limit exemption
+// repository. It's safe for concurrent use.
+type InMemoryRateLimitExemptionRepository struct {
+	mu sync.RWMutex
+	// exemptions are keyed by tenant ID, then by notification type.
+	exemptions map[string]map[domain.NotificationType]domain.RateLimitExemption
+}
+
+// Get retrieves the active exemption of the notification type.
+// It returns ErrExemptionNotFound if the notification type has none.
+func (r *InMemoryRateLimitExemptionRepository) Get(ctx context.Context,
+	notificationType domain.NotificationType) (domain.RateLimitExemption, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	exemption, ok := r.exemptions[TenantFromContext(ctx)][notificationType]
+	if !ok || !exemption.Active(time.Now()) {
+		return domain.RateLimitExemption{}, ErrExemptionNotFound
+	}
+	return exemption, nil
+}
+
+// List retrieves every active exemption, sorted by notification type.
+func (r *InMemoryRateLimitExemptionRepository) List(ctx context.Context) ([]domain.RateLimitExemption, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	exemptions := make([]domain.RateLimitExemption, 0)
+	for _, exemption := range r.exemptions[TenantFromContext(ctx)] {
+		if exemption.Active(now) {
+			exemptions = append(exemptions, exemption)
+		}
+	}
+	sort.Slice(exemptions, func(i, j int) bool {
+		return exemptions[i].Type < exemptions[j].Type
+	})
+	return exemptions, nil
+}
+
+// Save stores an exemption, replacing the one of the same notification type if any.
+func (r *InMemoryRateLimitExemptionRepository) Save(ctx context.Context, exemption domain.RateLimitExemption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := TenantFromContext(ctx)
+	exemptions, ok := r.exemptions[tenantID]
+	if !ok {
+		exemptions = make(map[domain.NotificationType]domain.RateLimitExemption)
+		r.exemptions[tenantID] = exemptions
+	}
+	exemptions[exemption.Type] = exemption
+	return nil
+}
+
+// Delete ends the exemption of the notification type.
+// It returns ErrExemptionNotFound if the notification type has no active exemption.
+func (r *InMemoryRateLimitExemptionRepository) Delete(ctx context.Context,
+	notificationType domain.NotificationType) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exemptions := r.exemptions[TenantFromContext(ctx)]
+	exemption, ok := exemptions[notificationType]
+	delete(exemptions, notificationType)
+	if !ok || !exemption.Active(time.Now()) {
+		return ErrExemptionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestInMemoryRateLimitOverrideRepository(t *testing.T) {
+	ctx := context.Background()
+	vip := domain.RateLimitOverride{
+		Scope:   domain.OverrideSegment,
+		Subject: "vip",
+		Default: &domain.RateLimitRule{MaxCount: 10, Expiration: time.Hour},
+	}
+	user := domain.RateLimitOverride{
+		Scope:   domain.OverrideUser,
+		Subject: "abc-123",
+		Rules:   domain.RateLimitRules{domain.News: {Unlimited: true}},
+	}
+
+	t.Run("override is saved", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitOverrideRepository()
+		require.NoError(t, repo.Save(ctx, vip))
+
+		got, err := repo.Get(ctx, domain.OverrideSegment, "vip")
+		require.NoError(t, err)
+		assert.Equal(t, vip, got)
+
+		_, err = repo.Get(ctx, domain.OverrideUser, "vip")
+		assert.ErrorIs(t, err, repository.ErrOverrideNotFound)
+	})
+
+	t.Run("override is replaced", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitOverrideRepository()
+		require.NoError(t, repo.Save(ctx, vip))
+
+		replaced := domain.RateLimitOverride{Scope: domain.OverrideSegment, Subject: "vip",
+			Rules: domain.RateLimitRules{domain.Status: {Unlimited: true}}}
+		require.NoError(t, repo.Save(ctx, replaced))
+
+		got, err := repo.Get(ctx, domain.OverrideSegment, "vip")
+		require.NoError(t, err)
+		assert.Equal(t, replaced, got)
+	})
+
+	t.Run("stored override isn't shared", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitOverrideRepository()
+		require.NoError(t, repo.Save(ctx, user))
+
+		got, err := repo.Get(ctx, domain.OverrideUser, "abc-123")
+		require.NoError(t, err)
+		got.Rules[domain.News] = domain.RateLimitRule{MaxCount: 1, Expiration: time.Hour}
+
+		got, err = repo.Get(ctx, domain.OverrideUser, "abc-123")
+		require.NoError(t, err)
+		assert.Equal(t, user, got)
+	})
+
+	t.Run("overrides are listed users first", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitOverrideRepository()
+		require.NoError(t, repo.Save(ctx, vip))
+		require.NoError(t, repo.Save(ctx, user))
+
+		overrides, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.RateLimitOverride{user, vip}, overrides)
+	})
+
+	t.Run("override is deleted", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitOverrideRepository()
+		require.NoError(t, repo.Save(ctx, user))
+
+		require.NoError(t, repo.Delete(ctx, domain.OverrideUser, "abc-123"))
+		assert.ErrorIs(t, repo.Delete(ctx, domain.OverrideUser, "abc-123"), repository.ErrOverrideNotFound)
+	})
+
+	t.Run("overrides are isolated per tenant", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitOverrideRepository()
+		require.NoError(t, repo.Save(repository.WithTenant(ctx, "billing"), user))
+
+		_, err := repo.Get(ctx, domain.OverrideUser, "abc-123")
+		assert.ErrorIs(t, err, repository.ErrOverrideNotFound)
+		overrides, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, overrides)
+	})
+}
+
+func TestInMemoryRateLimitExemptionRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	exemption := domain.RateLimitExemption{
+		Type:      domain.Status,
+		Reason:    "INC-42",
+		Actor:     "ops",
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+
+	t.Run("exemption is saved", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitExemptionRepository()
+		require.NoError(t, repo.Save(ctx, exemption))
+
+		got, err := repo.Get(ctx, domain.Status)
+		require.NoError(t, err)
+		assert.Equal(t, exemption, got)
+
+		exemptions, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.RateLimitExemption{exemption}, exemptions)
+	})
+
+	t.Run("expired exemption isn't returned", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitExemptionRepository()
+		expired := exemption
+		expired.ExpiresAt = now.Add(-time.Second)
+		require.NoError(t, repo.Save(ctx, expired))
+
+		_, err := repo.Get(ctx, domain.Status)
+		assert.ErrorIs(t, err, repository.ErrExemptionNotFound)
+		exemptions, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, exemptions)
+		assert.ErrorIs(t, repo.Delete(ctx, domain.Status), repository.ErrExemptionNotFound)
+	})
+
+	t.Run("exemption is ended", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitExemptionRepository()
+		require.NoError(t, repo.Save(ctx, exemption))
+
+		require.NoError(t, repo.Delete(ctx, domain.Status))
+		_, err := repo.Get(ctx, domain.Status)
+		assert.ErrorIs(t, err, repository.ErrExemptionNotFound)
+	})
+
+	t.Run("exemptions are isolated per tenant", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitExemptionRepository()
+		require.NoError(t, repo.Save(repository.WithTenant(ctx, "billing"), exemption))
+
+		_, err := repo.Get(ctx, domain.Status)
+		assert.ErrorIs(t, err, repository.ErrExemptionNotFound)
+	})
+}
@@ -1,49 +1,191 @@
 package repository
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"notification/internal/domain"
+	"sync"
+	"time"
 )
 
 var (
 	// ErrRuleAlreadyExists is the error when the rate limit rule already exists in the data store.
 	ErrRuleAlreadyExists = fmt.Errorf("rule already exists")
+	// ErrRuleNotFound is the error when there's no rate limit rule for the notification type.
+	ErrRuleNotFound = fmt.Errorf("rule not found")
 )
 
 // RateLimitRuleRepository is the abstract representation of the rate limit rule repository.
+// Every operation is scoped to the tenant carried by the context, see WithTenant.
 type RateLimitRuleRepository interface {
 	// GetByNotificationType retrieves a rate limit rule by notification type.
-	GetByNotificationType(notificationType domain.NotificationType) (domain.RateLimitRule, error)
+	// It returns ErrRuleNotFound if the notification type has no rule.
+	GetByNotificationType(ctx context.Context, notificationType domain.NotificationType) (domain.RateLimitRule, error)
+	// List retrieves every rate limit rule.
+	List(ctx context.Context) (domain.RateLimitRules, error)
 	// Save stores a rate limit rule in the repository.
-	Save(notificationType domain.NotificationType, rule domain.RateLimitRule) error
+	// It returns ErrRuleAlreadyExists if the notification type already has a rule.
+	Save(ctx context.Context, notificationType domain.NotificationType, rule domain.RateLimitRule) error
+	// Update stores a rate limit rule in the repository, replacing the existing one if any.
+	Update(ctx context.Context, notificationType domain.NotificationType, rule domain.RateLimitRule) error
+	// Delete removes the rate limit rule of the notification type.
+	// It returns ErrRuleNotFound if the notification type has no rule.
+	Delete(ctx context.Context, notificationType domain.NotificationType) error
+}
+
+// RateLimitRuleHistory is the audit history of the rate limit rule changes.
+// The actor of each change is taken from the context, see WithActor.
+type RateLimitRuleHistory interface {
+	// History retrieves up to limit changes of the rule of the notification type,
+	// the most recent first. Zero means no limit.
+	History(ctx context.Context, notificationType domain.NotificationType,
+		limit int) ([]domain.RateLimitRuleChange, error)
 }
 
 // NewInMemoryRateLimitRuleRepository creates a new InMemoryRateLimitRuleRepository instance.
 func NewInMemoryRateLimitRuleRepository() *InMemoryRateLimitRuleRepository {
 	return &InMemoryRateLimitRuleRepository{
-		rules: make(domain.RateLimitRules),
+		rules: make(map[string]domain.RateLimitRules),
 	}
 }
 
-// InMemoryRateLimitRuleRepository is the in-memory representation of the rate limit rule repository.
+// InMemoryRateLimitRuleRepository is the in-memory representation of the rate limit rule repository,
+// keeping the history of the rule changes. It's safe for concurrent use.
 type InMemoryRateLimitRuleRepository struct {
-	rules domain.RateLimitRules
+	mu sync.RWMutex
+	// rules are keyed by tenant ID.
+	rules   map[string]domain.RateLimitRules
+	history []tenantRuleChange
+}
+
+// tenantRuleChange is a change of the rules of a tenant.
+type tenantRuleChange struct {
+	tenantID string
+	change   domain.RateLimitRuleChange
 }
 
 // GetByNotificationType retrieves a rate limit rule by notification type.
-func (i InMemoryRateLimitRuleRepository) GetByNotificationType(
+// It returns ErrRuleNotFound if the notification type has no rule.
+func (i *InMemoryRateLimitRuleRepository) GetByNotificationType(ctx context.Context,
 	notificationType domain.NotificationType) (domain.RateLimitRule, error) {
-	return i.rules[notificationType], nil
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	rule, ok := i.rules[TenantFromContext(ctx)][notificationType]
+	if !ok {
+		return domain.RateLimitRule{}, ErrRuleNotFound
+	}
+	return rule, nil
+}
+
+// List retrieves every rate limit rule.
+func (i *InMemoryRateLimitRuleRepository) List(ctx context.Context) (domain.RateLimitRules, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	tenantRules := i.rules[TenantFromContext(ctx)]
+	rules := make(domain.RateLimitRules, len(tenantRules))
+	maps.Copy(rules, tenantRules)
+	return rules, nil
 }
 
 // Save stores a rate limit rule in the repository.
-func (i InMemoryRateLimitRuleRepository) Save(notificationType domain.NotificationType,
+func (i *InMemoryRateLimitRuleRepository) Save(ctx context.Context, notificationType domain.NotificationType,
 	rule domain.RateLimitRule) error {
-	if _, ok := i.rules[notificationType]; ok {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rules := i.tenantRules(ctx)
+	if _, ok := rules[notificationType]; ok {
 		return ErrRuleAlreadyExists
 	}
 
-	i.rules[notificationType] = rule
+	rules[notificationType] = rule
+	i.record(ctx, notificationType, nil, &rule)
+
+	return nil
+}
+
+// Update stores a rate limit rule in the repository, replacing the existing one if any.
+// Updates that don't change the rule aren't recorded in the history.
+func (i *InMemoryRateLimitRuleRepository) Update(ctx context.Context, notificationType domain.NotificationType,
+	rule domain.RateLimitRule) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rules := i.tenantRules(ctx)
+	old, ok := rules[notificationType]
+	if ok && old == rule {
+		return nil
+	}
+
+	rules[notificationType] = rule
+	if ok {
+		i.record(ctx, notificationType, &old, &rule)
+	} else {
+		i.record(ctx, notificationType, nil, &rule)
+	}
 
 	return nil
 }
+
+// Delete removes the rate limit rule of the notification type.
+func (i *InMemoryRateLimitRuleRepository) Delete(ctx context.Context, notificationType domain.NotificationType) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rules := i.rules[TenantFromContext(ctx)]
+	old, ok := rules[notificationType]
+	if !ok {
+		return ErrRuleNotFound
+	}
+	delete(rules, notificationType)
+	i.record(ctx, notificationType, &old, nil)
+
+	return nil
+}
+
+// History retrieves up to limit changes of the rule of the notification type,
+// the most recent first. Zero means no limit.
+func (i *InMemoryRateLimitRuleRepository) History(ctx context.Context, notificationType domain.NotificationType,
+	limit int) ([]domain.RateLimitRuleChange, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	tenantID := TenantFromContext(ctx)
+	changes := make([]domain.RateLimitRuleChange, 0)
+	for j := len(i.history) - 1; j >= 0 && (limit <= 0 || len(changes) < limit); j-- {
+		if i.history[j].tenantID == tenantID && i.history[j].change.Type == notificationType {
+			changes = append(changes, i.history[j].change)
+		}
+	}
+	return changes, nil
+}
+
+// tenantRules returns the rules of the tenant of ctx, creating them if needed.
+// It must be called with the lock held.
+func (i *InMemoryRateLimitRuleRepository) tenantRules(ctx context.Context) domain.RateLimitRules {
+	tenantID := TenantFromContext(ctx)
+	rules, ok := i.rules[tenantID]
+	if !ok {
+		rules = make(domain.RateLimitRules)
+		i.rules[tenantID] = rules
+	}
+	return rules
+}
+
+// record appends a change to the history. It must be called with the lock held.
+func (i *InMemoryRateLimitRuleRepository) record(ctx context.Context, notificationType domain.NotificationType,
+	before, after *domain.RateLimitRule) {
+	i.history = append(i.history, tenantRuleChange{
+		tenantID: TenantFromContext(ctx),
+		change: domain.RateLimitRuleChange{
+			Type:  notificationType,
+			Actor: ActorFromContext(ctx),
+			At:    time.Now().UTC(),
+			Old:   before,
+			New:   after,
+		},
+	})
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"notification/internal/domain"
+	"sync"
+	"time"
+)
+
+// NewCachedRateLimitRuleRepository creates a new CachedRateLimitRuleRepository instance,
+// keeping the rules read from next for ttl.
+func NewCachedRateLimitRuleRepository(next RateLimitRuleRepository, ttl time.Duration) *CachedRateLimitRuleRepository {
+	return &CachedRateLimitRuleRepository{
+		next:  next,
+		ttl:   ttl,
+		rules: make(map[cachedRuleKey]cachedRule),
+	}
+}
+
+// CachedRateLimitRuleRepository is a read-through in-process cache of a rate limit rule repository,
+// so that the rules aren't read from the data store on every notification.
+//
+// The changes made through the CachedRateLimitRuleRepository apply straight away, while the ones
+// made by other replicas apply once the cached rule expires. It's safe for concurrent use.
+type CachedRateLimitRuleRepository struct {
+	next RateLimitRuleRepository
+	ttl  time.Duration
+
+	mu    sync.RWMutex
+	rules map[cachedRuleKey]cachedRule
+}
+
+// cachedRuleKey identifies the rule of a notification type of a tenant.
+type cachedRuleKey struct {
+	tenantID         string
+	notificationType domain.NotificationType
+}
+
+// cachedRule is a rate limit rule, or ErrRuleNotFound if there's none, along with its cache expiration time.
+type cachedRule struct {
+	rule      domain.RateLimitRule
+	err       error
+	expiresAt time.Time
+}
+
+// GetByNotificationType retrieves a rate limit rule by notification type,
+// reading it from the underlying repository if it's not cached or expired.
+// Missing rules are cached as well, other errors aren't.
+func (c *CachedRateLimitRuleRepository) GetByNotificationType(ctx context.Context,
+	notificationType domain.NotificationType) (domain.RateLimitRule, error) {
+	key := cachedRuleKey{tenantID: TenantFromContext(ctx), notificationType: notificationType}
+	c.mu.RLock()
+	cached, ok := c.rules[key]
+	c.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.rule, cached.err
+	}
+
+	rule, err := c.next.GetByNotificationType(ctx, notificationType)
+	if err != nil && !errors.Is(err, ErrRuleNotFound) {
+		return rule, err
+	}
+
+	c.mu.Lock()
+	c.rules[key] = cachedRule{rule: rule, err: err, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return rule, err
+}
+
+// List retrieves every rate limit rule from the underlying repository.
+func (c *CachedRateLimitRuleRepository) List(ctx context.Context) (domain.RateLimitRules, error) {
+	return c.next.List(ctx)
+}
+
+// Save stores a rate limit rule in the underlying repository.
+func (c *CachedRateLimitRuleRepository) Save(ctx context.Context, notificationType domain.NotificationType,
+	rule domain.RateLimitRule) error {
+	defer c.invalidate(ctx, notificationType)
+	return c.next.Save(ctx, notificationType, rule)
+}
+
+// Update stores a rate limit rule in the underlying repository, replacing the existing one if any.
+func (c *CachedRateLimitRuleRepository) Update(ctx context.Context, notificationType domain.NotificationType,
+	rule domain.RateLimitRule) error {
+	defer c.invalidate(ctx, notificationType)
+	return c.next.Update(ctx, notificationType, rule)
+}
+
+// Delete removes the rate limit rule of the notification type from the underlying repository.
+func (c *CachedRateLimitRuleRepository) Delete(ctx context.Context, notificationType domain.NotificationType) error {
+	defer c.invalidate(ctx, notificationType)
+	return c.next.Delete(ctx, notificationType)
+}
+
+// invalidate drops the cached rule of the notification type of the tenant of ctx.
+func (c *CachedRateLimitRuleRepository) invalidate(ctx context.Context, notificationType domain.NotificationType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.rules, cachedRuleKey{tenantID: TenantFromContext(ctx), notificationType: notificationType})
+}
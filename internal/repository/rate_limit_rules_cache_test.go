@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"notification/mocks"
+	"testing"
+	"time"
+)
+
+func TestCachedRateLimitRuleRepository_GetByNotificationType(t *testing.T) {
+	ctx := context.Background()
+	rule := domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute}
+
+	t.Run("rules are read once until they expire", func(t *testing.T) {
+		next := mocks.NewRateLimitRuleRepository(t)
+		next.On("GetByNotificationType", mock.Anything, domain.Status).Return(rule, nil).Twice()
+
+		repo := repository.NewCachedRateLimitRuleRepository(next, 50*time.Millisecond)
+		for range 3 {
+			got, err := repo.GetByNotificationType(ctx, domain.Status)
+			require.NoError(t, err)
+			assert.Equal(t, rule, got)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		_, err := repo.GetByNotificationType(ctx, domain.Status)
+		require.NoError(t, err)
+	})
+	t.Run("errors aren't cached", func(t *testing.T) {
+		next := mocks.NewRateLimitRuleRepository(t)
+		next.On("GetByNotificationType", mock.Anything, domain.Status).
+			Return(domain.RateLimitRule{}, errors.New("connection refused")).Once()
+		next.On("GetByNotificationType", mock.Anything, domain.Status).Return(rule, nil).Once()
+
+		repo := repository.NewCachedRateLimitRuleRepository(next, time.Minute)
+		_, err := repo.GetByNotificationType(ctx, domain.Status)
+		require.Error(t, err)
+
+		got, err := repo.GetByNotificationType(ctx, domain.Status)
+		require.NoError(t, err)
+		assert.Equal(t, rule, got)
+	})
+}
+
+func TestCachedRateLimitRuleRepository_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	rule := domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute}
+	updated := domain.RateLimitRule{MaxCount: 5, Expiration: time.Minute}
+
+	next := repository.NewInMemoryRateLimitRuleRepository()
+	repo := repository.NewCachedRateLimitRuleRepository(next, time.Hour)
+
+	require.NoError(t, repo.Save(ctx, domain.News, rule))
+	got, err := repo.GetByNotificationType(ctx, domain.News)
+	require.NoError(t, err)
+	assert.Equal(t, rule, got)
+
+	require.NoError(t, repo.Update(ctx, domain.News, updated))
+	got, err = repo.GetByNotificationType(ctx, domain.News)
+	require.NoError(t, err)
+	assert.Equal(t, updated, got, "updates apply straight away")
+
+	require.NoError(t, repo.Delete(ctx, domain.News))
+	_, err = repo.GetByNotificationType(ctx, domain.News)
+	assert.ErrorIs(t, err, repository.ErrRuleNotFound, "deletions apply straight away")
+
+	// changes made elsewhere apply once the cached rule expires, missing rules included.
+	require.NoError(t, next.Update(ctx, domain.News, updated))
+	_, err = repo.GetByNotificationType(ctx, domain.News)
+	assert.ErrorIs(t, err, repository.ErrRuleNotFound)
+}
+
+func TestCachedRateLimitRuleRepository_Tenants(t *testing.T) {
+	billing := repository.WithTenant(context.Background(), "billing")
+	rule := domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute}
+
+	next := repository.NewInMemoryRateLimitRuleRepository()
+	require.NoError(t, next.Save(billing, domain.News, rule))
+	repo := repository.NewCachedRateLimitRuleRepository(next, time.Hour)
+
+	got, err := repo.GetByNotificationType(billing, domain.News)
+	require.NoError(t, err)
+	assert.Equal(t, rule, got)
+	_, err = repo.GetByNotificationType(context.Background(), domain.News)
+	assert.ErrorIs(t, err, repository.ErrRuleNotFound, "the rules are cached per tenant")
+
+	require.NoError(t, repo.Save(context.Background(), domain.News, rule))
+	got, err = repo.GetByNotificationType(context.Background(), domain.News)
+	require.NoError(t, err)
+	assert.Equal(t, rule, got)
+}
@@ -1,28 +1,32 @@
 package repository_test
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"notification/internal/domain"
 	"notification/internal/repository"
+	"sync"
 	"testing"
 	"time"
 )
 
 func TestInMemoryRateLimitRuleRepository_GetByNotificationType(t *testing.T) {
+	ctx := context.Background()
 	rule := domain.RateLimitRule{
 		MaxCount:   5,
 		Expiration: time.Minute,
 	}
 
 	repo := repository.NewInMemoryRateLimitRuleRepository()
-	require.NoError(t, repo.Save(domain.Marketing, rule))
-	got, err := repo.GetByNotificationType(domain.Marketing)
+	require.NoError(t, repo.Save(ctx, domain.Marketing, rule))
+	got, err := repo.GetByNotificationType(ctx, domain.Marketing)
 	require.NoError(t, err)
 	require.Equal(t, rule, got)
 }
 
 func TestInMemoryRateLimitRuleRepository_Save(t *testing.T) {
+	ctx := context.Background()
 	t.Run("saves successfully", func(t *testing.T) {
 		rule := domain.RateLimitRule{
 			MaxCount:   5,
@@ -30,8 +34,8 @@ func TestInMemoryRateLimitRuleRepository_Save(t *testing.T) {
 		}
 
 		repo := repository.NewInMemoryRateLimitRuleRepository()
-		require.NoError(t, repo.Save(domain.Marketing, rule))
-		got, err := repo.GetByNotificationType(domain.Marketing)
+		require.NoError(t, repo.Save(ctx, domain.Marketing, rule))
+		got, err := repo.GetByNotificationType(ctx, domain.Marketing)
 		require.NoError(t, err)
 		require.Equal(t, rule, got)
 	})
@@ -43,15 +47,141 @@ func TestInMemoryRateLimitRuleRepository_Save(t *testing.T) {
 		}
 
 		repo := repository.NewInMemoryRateLimitRuleRepository()
-		require.NoError(t, repo.Save(domain.Marketing, rule1))
+		require.NoError(t, repo.Save(ctx, domain.Marketing, rule1))
 
 		// tries saving a new rule for the same notification type
-		err := repo.Save(domain.Marketing, domain.RateLimitRule{})
+		err := repo.Save(ctx, domain.Marketing, domain.RateLimitRule{})
 		assert.ErrorIs(t, repository.ErrRuleAlreadyExists, err)
 
 		// original rule configuration is not affected by the last saving attempt.
-		got, err := repo.GetByNotificationType(domain.Marketing)
+		got, err := repo.GetByNotificationType(ctx, domain.Marketing)
 		require.NoError(t, err)
 		require.Equal(t, rule1, got)
 	})
 }
+
+func TestInMemoryRateLimitRuleRepository_Update(t *testing.T) {
+	ctx := context.Background()
+	rule := domain.RateLimitRule{
+		MaxCount:   5,
+		Expiration: time.Minute,
+	}
+
+	repo := repository.NewInMemoryRateLimitRuleRepository()
+	require.NoError(t, repo.Update(ctx, domain.Marketing, domain.RateLimitRule{MaxCount: 1, Expiration: time.Hour}))
+	require.NoError(t, repo.Update(ctx, domain.Marketing, rule))
+
+	got, err := repo.GetByNotificationType(ctx, domain.Marketing)
+	require.NoError(t, err)
+	assert.Equal(t, rule, got)
+}
+
+func TestInMemoryRateLimitRuleRepository_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rule is deleted", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitRuleRepository()
+		require.NoError(t, repo.Save(ctx, domain.Marketing, domain.RateLimitRule{MaxCount: 1, Expiration: time.Hour}))
+		require.NoError(t, repo.Save(ctx, domain.News, domain.RateLimitRule{MaxCount: 2, Expiration: time.Hour}))
+
+		require.NoError(t, repo.Delete(ctx, domain.Marketing))
+
+		rules, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RateLimitRules{
+			domain.News: domain.RateLimitRule{MaxCount: 2, Expiration: time.Hour},
+		}, rules)
+	})
+
+	t.Run("rule is not found", func(t *testing.T) {
+		repo := repository.NewInMemoryRateLimitRuleRepository()
+		assert.ErrorIs(t, repo.Delete(ctx, domain.Marketing), repository.ErrRuleNotFound)
+	})
+}
+
+func TestInMemoryRateLimitRuleRepository_History(t *testing.T) {
+	ctx := repository.WithActor(context.Background(), "jane")
+	first := domain.RateLimitRule{MaxCount: 1, Expiration: time.Minute}
+	second := domain.RateLimitRule{MaxCount: 2, Expiration: time.Minute}
+
+	repo := repository.NewInMemoryRateLimitRuleRepository()
+	require.NoError(t, repo.Save(ctx, domain.News, first))
+	require.NoError(t, repo.Update(ctx, domain.News, first), "unchanged rules aren't recorded")
+	require.NoError(t, repo.Update(context.Background(), domain.News, second))
+	require.NoError(t, repo.Save(ctx, domain.Status, first))
+	require.NoError(t, repo.Delete(ctx, domain.News))
+
+	changes, err := repo.History(ctx, domain.News, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+
+	assert.Equal(t, "jane", changes[0].Actor)
+	assert.Equal(t, &second, changes[0].Old)
+	assert.Nil(t, changes[0].New)
+
+	assert.Equal(t, repository.UnknownActor, changes[1].Actor)
+	assert.Equal(t, &first, changes[1].Old)
+	assert.Equal(t, &second, changes[1].New)
+
+	assert.Equal(t, domain.News, changes[2].Type)
+	assert.Nil(t, changes[2].Old)
+	assert.Equal(t, &first, changes[2].New)
+	assert.False(t, changes[2].At.IsZero())
+
+	changes, err = repo.History(ctx, domain.News, 1)
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+}
+
+func TestInMemoryRateLimitRuleRepository_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryRateLimitRuleRepository()
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rule := domain.RateLimitRule{MaxCount: i, Expiration: time.Minute}
+			_ = repo.Update(ctx, domain.Status, rule)
+			_, _ = repo.GetByNotificationType(ctx, domain.Status)
+			_, _ = repo.List(ctx)
+			_, _ = repo.History(ctx, domain.Status, 0)
+		}()
+	}
+	wg.Wait()
+
+	changes, err := repo.History(ctx, domain.Status, 0)
+	require.NoError(t, err)
+	assert.Len(t, changes, 10)
+}
+
+func TestInMemoryRateLimitRuleRepository_Tenants(t *testing.T) {
+	billing := repository.WithActor(repository.WithTenant(context.Background(), "billing"), "jane")
+	shipping := repository.WithTenant(context.Background(), "shipping")
+	rule := domain.RateLimitRule{MaxCount: 5, Expiration: time.Minute}
+
+	repo := repository.NewInMemoryRateLimitRuleRepository()
+	require.NoError(t, repo.Save(billing, domain.Marketing, rule))
+
+	_, err := repo.GetByNotificationType(shipping, domain.Marketing)
+	assert.ErrorIs(t, err, repository.ErrRuleNotFound)
+	_, err = repo.GetByNotificationType(context.Background(), domain.Marketing)
+	assert.ErrorIs(t, err, repository.ErrRuleNotFound)
+	rules, err := repo.List(shipping)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+	assert.ErrorIs(t, repo.Delete(shipping, domain.Marketing), repository.ErrRuleNotFound)
+	changes, err := repo.History(shipping, domain.Marketing, 0)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	require.NoError(t, repo.Save(shipping, domain.Marketing, domain.RateLimitRule{Unlimited: true}))
+	got, err := repo.GetByNotificationType(billing, domain.Marketing)
+	require.NoError(t, err)
+	assert.Equal(t, rule, got)
+	changes, err = repo.History(billing, domain.Marketing, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "jane", changes[0].Actor)
+}
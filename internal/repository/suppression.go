@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"notification/internal/domain"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrSuppressionNotFound is the error when the email address isn't in the suppression list.
+	ErrSuppressionNotFound = errors.New("suppression not found")
+)
+
+// SuppressionRepository is the abstract representation of the suppression list repository.
+// Every operation is scoped to the tenant carried by the context, see WithTenant: each tenant
+// has its own suppression list.
+type SuppressionRepository interface {
+	// Get retrieves the suppression of the given email address.
+	Get(ctx context.Context, email string) (domain.Suppression, error)
+	// Save stores a suppression, replacing any existing one for the same email address.
+	Save(ctx context.Context, suppression domain.Suppression) error
+	// Delete removes the given email address from the suppression list.
+	Delete(ctx context.Context, email string) error
+	// List retrieves every suppression sorted by email address.
+	List(ctx context.Context) ([]domain.Suppression, error)
+}
+
+// NewInMemorySuppressionRepository creates a new InMemorySuppressionRepository instance.
+func NewInMemorySuppressionRepository() *InMemorySuppressionRepository {
+	return &InMemorySuppressionRepository{
+		suppressions: make(map[string]map[string]domain.Suppression),
+	}
+}
+
+// InMemorySuppressionRepository is the in-memory representation of the suppression list repository.
+// It's safe for concurrent use.
+type InMemorySuppressionRepository struct {
+	mu sync.RWMutex
+	// suppressions are keyed by tenant ID, then by normalized email address.
+	suppressions map[string]map[string]domain.Suppression
+}
+
+// Get retrieves the suppression of the given email address.
+func (r *InMemorySuppressionRepository) Get(ctx context.Context, email string) (domain.Suppression, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	suppression, ok := r.suppressions[TenantFromContext(ctx)][domain.NormalizeEmail(email)]
+	if !ok {
+		return domain.Suppression{}, ErrSuppressionNotFound
+	}
+	return suppression, nil
+}
+
+// Save stores a suppression, replacing any existing one for the same email address.
+func (r *InMemorySuppressionRepository) Save(ctx context.Context, suppression domain.Suppression) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := TenantFromContext(ctx)
+	if r.suppressions[tenantID] == nil {
+		r.suppressions[tenantID] = make(map[string]domain.Suppression)
+	}
+	suppression.Email = domain.NormalizeEmail(suppression.Email)
+	r.suppressions[tenantID][suppression.Email] = suppression
+	return nil
+}
+
+// Delete removes the given email address from the suppression list.
+func (r *InMemorySuppressionRepository) Delete(ctx context.Context, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	suppressions := r.suppressions[TenantFromContext(ctx)]
+	email = domain.NormalizeEmail(email)
+	if _, ok := suppressions[email]; !ok {
+		return ErrSuppressionNotFound
+	}
+	delete(suppressions, email)
+	return nil
+}
+
+// List retrieves every suppression sorted by email address.
+func (r *InMemorySuppressionRepository) List(ctx context.Context) ([]domain.Suppression, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantSuppressions := r.suppressions[TenantFromContext(ctx)]
+	suppressions := make([]domain.Suppression, 0, len(tenantSuppressions))
+	for _, s := range tenantSuppressions {
+		suppressions = append(suppressions, s)
+	}
+	sort.Slice(suppressions, func(i, j int) bool {
+		return suppressions[i].Email < suppressions[j].Email
+	})
+	return suppressions, nil
+}
@@ -0,0 +1,76 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"testing"
+)
+
+func TestInMemorySuppressionRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("email addresses are normalized", func(t *testing.T) {
+		repo := repository.NewInMemorySuppressionRepository()
+		require.NoError(t, repo.Save(ctx, domain.Suppression{
+			Email:  " John@Example.com",
+			Reason: domain.SuppressedByBounce,
+		}))
+
+		got, err := repo.Get(ctx, "john@example.COM")
+		require.NoError(t, err)
+		assert.Equal(t, "john@example.com", got.Email)
+		assert.Equal(t, domain.SuppressedByBounce, got.Reason)
+	})
+
+	t.Run("save replaces the existing suppression", func(t *testing.T) {
+		repo := repository.NewInMemorySuppressionRepository()
+		require.NoError(t, repo.Save(ctx, domain.Suppression{Email: "john@example.com", Reason: domain.SuppressedByBounce}))
+		require.NoError(t, repo.Save(ctx, domain.Suppression{Email: "john@example.com", Reason: domain.SuppressedByComplaint}))
+
+		got, err := repo.Get(ctx, "john@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, domain.SuppressedByComplaint, got.Reason)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo := repository.NewInMemorySuppressionRepository()
+
+		_, err := repo.Get(ctx, "john@example.com")
+		assert.ErrorIs(t, err, repository.ErrSuppressionNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, "john@example.com"), repository.ErrSuppressionNotFound)
+	})
+
+	t.Run("list and delete", func(t *testing.T) {
+		repo := repository.NewInMemorySuppressionRepository()
+		require.NoError(t, repo.Save(ctx, domain.Suppression{Email: "jane@example.com"}))
+		require.NoError(t, repo.Save(ctx, domain.Suppression{Email: "amy@example.com"}))
+
+		list, err := repo.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, "amy@example.com", list[0].Email)
+
+		require.NoError(t, repo.Delete(ctx, "Amy@example.com"))
+		list, err = repo.List(ctx)
+		require.NoError(t, err)
+		assert.Len(t, list, 1)
+	})
+	t.Run("tenants have their own list", func(t *testing.T) {
+		repo := repository.NewInMemorySuppressionRepository()
+		acme := repository.WithTenant(ctx, "acme")
+		require.NoError(t, repo.Save(acme, domain.Suppression{Email: "john@example.com"}))
+
+		_, err := repo.Get(ctx, "john@example.com")
+		assert.ErrorIs(t, err, repository.ErrSuppressionNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, "john@example.com"), repository.ErrSuppressionNotFound)
+		list, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, list)
+
+		_, err = repo.Get(acme, "john@example.com")
+		assert.NoError(t, err)
+	})
+}
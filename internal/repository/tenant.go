@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"notification/internal/domain"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrTenantAlreadyExists is the error when a tenant with the same ID is already stored.
+	ErrTenantAlreadyExists = errors.New("tenant already exists")
+	// ErrTenantNotFound is the error when the tenant requested isn't stored.
+	ErrTenantNotFound = errors.New("tenant not found")
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the ID of the tenant the request acts on behalf of.
+// The tenant scoped repositories, such as the user and rate limit rule ones, only ever read and
+// change the data of the tenant carried by the context.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ID carried by ctx, or domain.DefaultTenantID if there's none.
+func TenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantKey{}).(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return domain.DefaultTenantID
+}
+
+// TenantRepository is the abstract representation of the tenant repository.
+type TenantRepository interface {
+	// Get retrieves a tenant by its ID.
+	Get(ctx context.Context, id string) (domain.Tenant, error)
+	// Save stores a given tenant in the repository.
+	Save(ctx context.Context, tenant domain.Tenant) error
+	// Update replaces an existing tenant, identified by its ID.
+	Update(ctx context.Context, tenant domain.Tenant) error
+	// Delete removes a tenant by its ID.
+	Delete(ctx context.Context, id string) error
+	// List retrieves every tenant, sorted by ID.
+	List(ctx context.Context) ([]domain.Tenant, error)
+}
+
+// NewInMemoryTenantRepository creates a new InMemoryTenantRepository instance.
+func NewInMemoryTenantRepository() *InMemoryTenantRepository {
+	return &InMemoryTenantRepository{
+		tenants: make(map[string]domain.Tenant),
+	}
+}
+
+// InMemoryTenantRepository is the in-memory representation of the tenant repository.
+// It's safe for concurrent use.
+type InMemoryTenantRepository struct {
+	mu      sync.RWMutex
+	tenants map[string]domain.Tenant
+}
+
+// Get retrieves a tenant by its ID.
+func (r *InMemoryTenantRepository) Get(_ context.Context, id string) (domain.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant, ok := r.tenants[id]
+	if !ok {
+		return domain.Tenant{}, ErrTenantNotFound
+	}
+	return cloneTenant(tenant), nil
+}
+
+// Save stores a given tenant in the repository.
+func (r *InMemoryTenantRepository) Save(_ context.Context, tenant domain.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tenants[tenant.ID]; ok {
+		return ErrTenantAlreadyExists
+	}
+	r.tenants[tenant.ID] = cloneTenant(tenant)
+	return nil
+}
+
+// Update replaces an existing tenant, identified by its ID. The creation time is kept.
+func (r *InMemoryTenantRepository) Update(_ context.Context, tenant domain.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tenants[tenant.ID]
+	if !ok {
+		return ErrTenantNotFound
+	}
+	tenant.CreatedAt = stored.CreatedAt
+	r.tenants[tenant.ID] = cloneTenant(tenant)
+	return nil
+}
+
+// Delete removes a tenant by its ID.
+func (r *InMemoryTenantRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tenants[id]; !ok {
+		return ErrTenantNotFound
+	}
+	delete(r.tenants, id)
+	return nil
+}
+
+// List retrieves every tenant, sorted by ID.
+func (r *InMemoryTenantRepository) List(_ context.Context) ([]domain.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants := make([]domain.Tenant, 0, len(r.tenants))
+	for _, tenant := range r.tenants {
+		tenants = append(tenants, cloneTenant(tenant))
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].ID < tenants[j].ID
+	})
+	return tenants, nil
+}
+
+// cloneTenant copies the subjects and the quota of tenant, so that the stored tenants can't be changed by the callers.
+func cloneTenant(tenant domain.Tenant) domain.Tenant {
+	tenant.Subjects = maps.Clone(tenant.Subjects)
+	if tenant.Quota != nil {
+		quota := *tenant.Quota
+		tenant.Quota = &quota
+	}
+	return tenant
+}
@@ -0,0 +1,91 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestTenantFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, domain.DefaultTenantID, repository.TenantFromContext(ctx))
+	assert.Equal(t, domain.DefaultTenantID, repository.TenantFromContext(repository.WithTenant(ctx, "")))
+	assert.Equal(t, "billing", repository.TenantFromContext(repository.WithTenant(ctx, "billing")))
+}
+
+func TestInMemoryTenantRepository(t *testing.T) {
+	ctx := context.Background()
+	tenant := domain.Tenant{
+		ID:        "billing",
+		Name:      "Billing",
+		MailFrom:  "billing@example.com",
+		Subjects:  map[domain.NotificationType]string{domain.Status: "Your invoice"},
+		Quota:     &domain.RateLimitRule{MaxCount: 100, Expiration: time.Hour},
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("tenant is saved", func(t *testing.T) {
+		repo := repository.NewInMemoryTenantRepository()
+		require.NoError(t, repo.Save(ctx, tenant))
+
+		got, err := repo.Get(ctx, "billing")
+		require.NoError(t, err)
+		assert.Equal(t, tenant, got)
+		assert.ErrorIs(t, repo.Save(ctx, tenant), repository.ErrTenantAlreadyExists)
+	})
+
+	t.Run("tenant is updated", func(t *testing.T) {
+		repo := repository.NewInMemoryTenantRepository()
+		require.NoError(t, repo.Save(ctx, tenant))
+
+		updated := domain.Tenant{ID: "billing", Name: "Invoicing"}
+		require.NoError(t, repo.Update(ctx, updated))
+
+		got, err := repo.Get(ctx, "billing")
+		require.NoError(t, err)
+		updated.CreatedAt = tenant.CreatedAt
+		assert.Equal(t, updated, got)
+		assert.ErrorIs(t, repo.Update(ctx, domain.Tenant{ID: "shipping"}), repository.ErrTenantNotFound)
+	})
+
+	t.Run("tenant is deleted", func(t *testing.T) {
+		repo := repository.NewInMemoryTenantRepository()
+		require.NoError(t, repo.Save(ctx, tenant))
+
+		require.NoError(t, repo.Delete(ctx, "billing"))
+		_, err := repo.Get(ctx, "billing")
+		assert.ErrorIs(t, err, repository.ErrTenantNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, "billing"), repository.ErrTenantNotFound)
+	})
+
+	t.Run("tenants are listed by ID", func(t *testing.T) {
+		repo := repository.NewInMemoryTenantRepository()
+		require.NoError(t, repo.Save(ctx, domain.Tenant{ID: "shipping"}))
+		require.NoError(t, repo.Save(ctx, tenant))
+
+		tenants, err := repo.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, tenants, 2)
+		assert.Equal(t, "billing", tenants[0].ID)
+		assert.Equal(t, "shipping", tenants[1].ID)
+	})
+
+	t.Run("stored tenants can't be changed by the callers", func(t *testing.T) {
+		repo := repository.NewInMemoryTenantRepository()
+		saved := tenant
+		saved.Subjects = map[domain.NotificationType]string{domain.Status: "Your invoice"}
+		require.NoError(t, repo.Save(ctx, saved))
+		saved.Subjects[domain.Status] = "changed"
+
+		got, err := repo.Get(ctx, "billing")
+		require.NoError(t, err)
+		got.Quota.MaxCount = 1
+		got, err = repo.Get(ctx, "billing")
+		require.NoError(t, err)
+		assert.Equal(t, tenant, got)
+	})
+}
@@ -1,8 +1,13 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"notification/internal/domain"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 )
 
 var (
@@ -12,44 +17,151 @@ var (
 	ErrInvalidUserID = errors.New("invalid user id")
 )
 
+// UserQuery defines the filters and pagination of a user listing.
+type UserQuery struct {
+	// Email filters the users whose email contains the given text, case-insensitively.
+	Email string
+	// Offset is the number of matching users to skip.
+	Offset int
+	// Limit is the maximum number of users returned. Zero means no limit.
+	Limit int
+}
+
 // UserRepository is the abstract representation of the user repository.
+// Every operation is scoped to the tenant carried by the context, see WithTenant: the users
+// of the other tenants can't be read nor changed. User IDs and emails are unique within a tenant.
 type UserRepository interface {
 	// Get retrieves a user by its ID.
-	Get(id string) (domain.User, error)
+	Get(ctx context.Context, id string) (domain.User, error)
 	// Save stores a given user in the repository.
-	Save(user domain.User) error
+	Save(ctx context.Context, user domain.User) error
+	// Update replaces an existing user, identified by its ID.
+	Update(ctx context.Context, user domain.User) error
+	// Delete removes a user by its ID.
+	Delete(ctx context.Context, id string) error
+	// List retrieves the page of users matching the query, sorted by ID,
+	// along with the total number of matching users.
+	List(ctx context.Context, query UserQuery) ([]domain.User, int, error)
 }
 
 // NewInMemoryUserRepository creates a new InMemoryUserRepository instance.
 func NewInMemoryUserRepository() *InMemoryUserRepository {
 	return &InMemoryUserRepository{
-		users: make(map[string]domain.User),
+		users: make(map[string]map[string]domain.User),
 	}
 }
 
 // InMemoryUserRepository is the in-memory representation of the user repository.
+// It's safe for concurrent use.
 type InMemoryUserRepository struct {
-	users map[string]domain.User
+	mu sync.RWMutex
+	// users are keyed by tenant ID, then by user ID.
+	users map[string]map[string]domain.User
 }
 
 // Get retrieves a user by its ID.
-func (r *InMemoryUserRepository) Get(id string) (domain.User, error) {
-	user, ok := r.users[id]
+func (r *InMemoryUserRepository) Get(ctx context.Context, id string) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[TenantFromContext(ctx)][id]
 	if !ok {
 		return domain.User{}, ErrInvalidUserID
 	}
-	return user, nil
+	return cloneUser(user), nil
 }
 
 // Save stores a given user in the repository.
-func (r *InMemoryUserRepository) Save(user domain.User) error {
-	for _, u := range r.users {
-		if u.Email == user.Email || u.ID == user.ID {
-			return ErrUserAlreadyExists
-		}
+func (r *InMemoryUserRepository) Save(ctx context.Context, user domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := TenantFromContext(ctx)
+	users, ok := r.users[tenantID]
+	if !ok {
+		users = make(map[string]domain.User)
+		r.users[tenantID] = users
+	}
+	if _, ok = users[user.ID]; ok || emailTaken(users, user.Email, "") {
+		return ErrUserAlreadyExists
 	}
 
-	r.users[user.ID] = user
+	users[user.ID] = cloneUser(user)
+
+	return nil
+}
+
+// Update replaces an existing user, identified by its ID.
+func (r *InMemoryUserRepository) Update(ctx context.Context, user domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := r.users[TenantFromContext(ctx)]
+	if _, ok := users[user.ID]; !ok {
+		return ErrInvalidUserID
+	}
+	if emailTaken(users, user.Email, user.ID) {
+		return ErrUserAlreadyExists
+	}
+
+	users[user.ID] = cloneUser(user)
+
+	return nil
+}
+
+// Delete removes a user by its ID.
+func (r *InMemoryUserRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := r.users[TenantFromContext(ctx)]
+	if _, ok := users[id]; !ok {
+		return ErrInvalidUserID
+	}
+	delete(users, id)
 
 	return nil
 }
+
+// List retrieves the page of users matching the query, sorted by ID,
+// along with the total number of matching users.
+func (r *InMemoryUserRepository) List(ctx context.Context, query UserQuery) ([]domain.User, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := r.users[TenantFromContext(ctx)]
+	email := strings.ToLower(query.Email)
+	matching := make([]domain.User, 0, len(users))
+	for _, u := range users {
+		if strings.Contains(strings.ToLower(u.Email), email) {
+			matching = append(matching, cloneUser(u))
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].ID < matching[j].ID
+	})
+
+	total := len(matching)
+	start := min(max(query.Offset, 0), total)
+	end := total
+	if query.Limit > 0 {
+		end = min(start+query.Limit, total)
+	}
+	return matching[start:end], total, nil
+}
+
+// cloneUser returns a copy of the user which doesn't share its segments.
+func cloneUser(user domain.User) domain.User {
+	user.Segments = slices.Clone(user.Segments)
+	return user
+}
+
+// emailTaken reports whether a user of users other than the one with exceptID has the given email.
+func emailTaken(users map[string]domain.User, email, exceptID string) bool {
+	for _, u := range users {
+		if u.ID != exceptID && strings.EqualFold(u.Email, email) {
+			return true
+		}
+	}
+	return false
+}
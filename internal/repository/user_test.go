@@ -1,6 +1,7 @@
 package repository_test
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"notification/internal/domain"
@@ -9,6 +10,7 @@ import (
 )
 
 func TestInMemoryUserRepository_Save(t *testing.T) {
+	ctx := context.Background()
 	t.Run("user is saved", func(t *testing.T) {
 		user := domain.User{
 			ID:       "123-abc",
@@ -18,9 +20,9 @@ func TestInMemoryUserRepository_Save(t *testing.T) {
 		}
 
 		repo := repository.NewInMemoryUserRepository()
-		require.NoError(t, repo.Save(user))
+		require.NoError(t, repo.Save(ctx, user))
 
-		savedUser, err := repo.Get(user.ID)
+		savedUser, err := repo.Get(ctx, user.ID)
 		require.NoError(t, err)
 		require.NotEmpty(t, savedUser)
 		require.Equal(t, user.ID, savedUser.ID)
@@ -35,9 +37,9 @@ func TestInMemoryUserRepository_Save(t *testing.T) {
 		}
 
 		repo := repository.NewInMemoryUserRepository()
-		require.NoError(t, repo.Save(user))
+		require.NoError(t, repo.Save(ctx, user))
 
-		err := repo.Save(domain.User{ID: "123-abc"})
+		err := repo.Save(ctx, domain.User{ID: "123-abc"})
 		assert.ErrorIs(t, err, repository.ErrUserAlreadyExists)
 	})
 
@@ -50,14 +52,15 @@ func TestInMemoryUserRepository_Save(t *testing.T) {
 		}
 
 		repo := repository.NewInMemoryUserRepository()
-		require.NoError(t, repo.Save(user))
+		require.NoError(t, repo.Save(ctx, user))
 
-		err := repo.Save(domain.User{Email: "john.doe@example.com"})
+		err := repo.Save(ctx, domain.User{Email: "john.doe@example.com"})
 		assert.ErrorIs(t, err, repository.ErrUserAlreadyExists)
 	})
 }
 
 func TestInMemoryUserRepository_Get(t *testing.T) {
+	ctx := context.Background()
 	t.Run("user is found", func(t *testing.T) {
 		user := domain.User{
 			ID:       "123-abc",
@@ -67,9 +70,9 @@ func TestInMemoryUserRepository_Get(t *testing.T) {
 		}
 
 		repo := repository.NewInMemoryUserRepository()
-		require.NoError(t, repo.Save(user))
+		require.NoError(t, repo.Save(ctx, user))
 
-		savedUser, err := repo.Get(user.ID)
+		savedUser, err := repo.Get(ctx, user.ID)
 		require.NoError(t, err)
 		require.NotEmpty(t, savedUser)
 		require.Equal(t, user.ID, savedUser.ID)
@@ -77,7 +80,140 @@ func TestInMemoryUserRepository_Get(t *testing.T) {
 
 	t.Run("user is not found", func(t *testing.T) {
 		repo := repository.NewInMemoryUserRepository()
-		_, err := repo.Get("invalid")
+		_, err := repo.Get(ctx, "invalid")
 		assert.ErrorIs(t, repository.ErrInvalidUserID, err)
 	})
 }
+
+func TestInMemoryUserRepository_Tenants(t *testing.T) {
+	billing := repository.WithTenant(context.Background(), "billing")
+	shipping := repository.WithTenant(context.Background(), "shipping")
+	user := domain.User{ID: "123-abc", Name: "John", Email: "john.doe@example.com"}
+
+	repo := repository.NewInMemoryUserRepository()
+	require.NoError(t, repo.Save(billing, user))
+
+	_, err := repo.Get(shipping, user.ID)
+	assert.ErrorIs(t, err, repository.ErrInvalidUserID, "the users of other tenants can't be read")
+	_, err = repo.Get(context.Background(), user.ID)
+	assert.ErrorIs(t, err, repository.ErrInvalidUserID)
+	assert.ErrorIs(t, repo.Update(shipping, user), repository.ErrInvalidUserID)
+	assert.ErrorIs(t, repo.Delete(shipping, user.ID), repository.ErrInvalidUserID)
+	users, total, err := repo.List(shipping, repository.UserQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	assert.Zero(t, total)
+
+	other := domain.User{ID: "123-abc", Name: "Johnny", Email: "john.doe@example.com"}
+	require.NoError(t, repo.Save(shipping, other), "IDs and emails are unique within a tenant")
+
+	got, err := repo.Get(billing, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+	got, err = repo.Get(shipping, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, other, got)
+}
+
+func TestInMemoryUserRepository_Update(t *testing.T) {
+	ctx := context.Background()
+	newRepo := func(t *testing.T) *repository.InMemoryUserRepository {
+		repo := repository.NewInMemoryUserRepository()
+		require.NoError(t, repo.Save(ctx, domain.User{ID: "123-abc", Name: "John", Email: "john.doe@example.com"}))
+		require.NoError(t, repo.Save(ctx, domain.User{ID: "456-bbb", Name: "Jane", Email: "jane.doe@example.com"}))
+		return repo
+	}
+
+	t.Run("user is updated", func(t *testing.T) {
+		repo := newRepo(t)
+		user := domain.User{ID: "123-abc", Name: "Johnny", Email: "john.doe@example.com"}
+		require.NoError(t, repo.Update(ctx, user))
+
+		savedUser, err := repo.Get(ctx, "123-abc")
+		require.NoError(t, err)
+		assert.Equal(t, user, savedUser)
+	})
+
+	t.Run("user is not updated: unknown ID", func(t *testing.T) {
+		repo := newRepo(t)
+		err := repo.Update(ctx, domain.User{ID: "invalid", Email: "someone@example.com"})
+		assert.ErrorIs(t, err, repository.ErrInvalidUserID)
+	})
+
+	t.Run("user is not updated: conflicting emails", func(t *testing.T) {
+		repo := newRepo(t)
+		err := repo.Update(ctx, domain.User{ID: "123-abc", Email: "Jane.Doe@example.com"})
+		assert.ErrorIs(t, err, repository.ErrUserAlreadyExists)
+	})
+}
+
+func TestInMemoryUserRepository_Delete(t *testing.T) {
+	ctx := context.Background()
+	t.Run("user is deleted", func(t *testing.T) {
+		repo := repository.NewInMemoryUserRepository()
+		require.NoError(t, repo.Save(ctx, domain.User{ID: "123-abc", Email: "john.doe@example.com"}))
+
+		require.NoError(t, repo.Delete(ctx, "123-abc"))
+		_, err := repo.Get(ctx, "123-abc")
+		assert.ErrorIs(t, err, repository.ErrInvalidUserID)
+	})
+
+	t.Run("user is not found", func(t *testing.T) {
+		repo := repository.NewInMemoryUserRepository()
+		assert.ErrorIs(t, repo.Delete(ctx, "invalid"), repository.ErrInvalidUserID)
+	})
+}
+
+func TestInMemoryUserRepository_List(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryUserRepository()
+	require.NoError(t, repo.Save(ctx, domain.User{ID: "3", Email: "carl@example.org"}))
+	require.NoError(t, repo.Save(ctx, domain.User{ID: "1", Email: "alice@example.com"}))
+	require.NoError(t, repo.Save(ctx, domain.User{ID: "2", Email: "bob@example.com"}))
+
+	tests := []struct {
+		name      string
+		query     repository.UserQuery
+		wantIDs   []string
+		wantTotal int
+	}{
+		{
+			name:      "all users",
+			query:     repository.UserQuery{},
+			wantIDs:   []string{"1", "2", "3"},
+			wantTotal: 3,
+		},
+		{
+			name:      "paginated",
+			query:     repository.UserQuery{Offset: 1, Limit: 1},
+			wantIDs:   []string{"2"},
+			wantTotal: 3,
+		},
+		{
+			name:      "offset out of range",
+			query:     repository.UserQuery{Offset: 5, Limit: 1},
+			wantIDs:   []string{},
+			wantTotal: 3,
+		},
+		{
+			name:      "email search",
+			query:     repository.UserQuery{Email: "EXAMPLE.COM"},
+			wantIDs:   []string{"1", "2"},
+			wantTotal: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := repo.List(ctx, tt.query)
+			require.NoError(t, err)
+
+			ids := make([]string, 0, len(users))
+			for _, u := range users {
+				ids = append(ids, u.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, tt.wantTotal, total)
+		})
+	}
+}
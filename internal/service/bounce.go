@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification/internal/domain"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"strings"
+	"time"
+)
+
+// BounceHandler is the abstract representation of the delivery feedback processing.
+type BounceHandler interface {
+	// HandleBounces processes delivery feedback events, suppressing the addresses
+	// that must not receive notifications anymore.
+	HandleBounces(ctx context.Context, events []domain.BounceEvent) error
+}
+
+// SuppressionBounceHandlerOption defines the optional params for SuppressionBounceHandler.
+type SuppressionBounceHandlerOption func(*SuppressionBounceHandler)
+
+// WithBouncedEvents posts a bounced delivery event for the hard bounces and complaints about
+// notifications having a callback URL. The notifications are looked up in deliveries by the
+// provider message ID of the bounce events, which also tells the tenant whose suppression list
+// the address is added to.
+func WithBouncedEvents(deliveries repository.DeliveryRepository,
+	publisher DeliveryEventPublisher) SuppressionBounceHandlerOption {
+	return func(h *SuppressionBounceHandler) {
+		h.deliveries = deliveries
+		h.events = publisher
+	}
+}
+
+// NewSuppressionBounceHandler creates a new SuppressionBounceHandler instance.
+func NewSuppressionBounceHandler(repo repository.SuppressionRepository,
+	opts ...SuppressionBounceHandlerOption) *SuppressionBounceHandler {
+	h := &SuppressionBounceHandler{
+		repo: repo,
+		now:  time.Now,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// SuppressionBounceHandler adds hard-bounced and complaining addresses to the suppression list.
+// Soft bounces are temporary failures, so they are ignored.
+//
+// The addresses are suppressed for the tenant of the bounced notification only, when it's found
+// in the delivery records, see WithBouncedEvents. Otherwise they're suppressed for the tenant
+// carried by the context.
+type SuppressionBounceHandler struct {
+	repo       repository.SuppressionRepository
+	deliveries repository.DeliveryRepository
+	events     DeliveryEventPublisher
+	now        func() time.Time
+}
+
+// HandleBounces adds hard-bounced and complaining addresses to the suppression list.
+func (h SuppressionBounceHandler) HandleBounces(ctx context.Context, events []domain.BounceEvent) error {
+	logger := logging.FromContext(ctx)
+	var errs []error
+	for _, event := range events {
+		var reason domain.SuppressionReason
+		switch event.Type {
+		case domain.HardBounce:
+			reason = domain.SuppressedByBounce
+		case domain.Complaint:
+			reason = domain.SuppressedByComplaint
+		default:
+			logger.Debug().Stringer("type", event.Type).Str("source", event.Source).Msg("ignoring bounce")
+			continue
+		}
+
+		record, found := h.bouncedRecord(ctx, event)
+		tenantCtx := ctx
+		if found {
+			tenantCtx = repository.WithTenant(ctx, record.TenantID)
+		}
+
+		detail := strings.TrimSpace(fmt.Sprintf("%s %s", event.Status, event.Diagnostic))
+		logger.Info().
+			Stringer("type", event.Type).
+			Str("source", event.Source).
+			Str("recipient", logging.RedactEmail(event.Email)).
+			Str("tenant", repository.TenantFromContext(tenantCtx)).
+			Msg("suppressing recipient")
+		err := h.repo.Save(tenantCtx, domain.Suppression{
+			Email:     event.Email,
+			Reason:    reason,
+			Detail:    detail,
+			CreatedAt: h.now(),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to save suppression: %w", err))
+		}
+
+		if found {
+			h.emitBounced(tenantCtx, event, record, detail)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// bouncedRecord looks up the delivery record of the bounced notification, reporting whether it's found.
+// Failures are logged without interrupting the bounce processing.
+func (h SuppressionBounceHandler) bouncedRecord(ctx context.Context,
+	event domain.BounceEvent) (domain.DeliveryRecord, bool) {
+	if h.deliveries == nil || event.MessageID == "" {
+		return domain.DeliveryRecord{}, false
+	}
+	record, err := h.deliveries.GetByProviderMessageID(ctx, event.MessageID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrDeliveryNotFound) {
+			logging.FromContext(ctx).Error().Err(err).Msg("failed to look up the bounced notification")
+		}
+		return domain.DeliveryRecord{}, false
+	}
+	return record, true
+}
+
+// emitBounced publishes a bounced delivery event if the bounced notification has a callback URL.
+// Publishing is best-effort: failures are logged without interrupting the bounce processing.
+func (h SuppressionBounceHandler) emitBounced(ctx context.Context, event domain.BounceEvent,
+	record domain.DeliveryRecord, detail string) {
+	if h.events == nil || record.CallbackURL == "" {
+		return
+	}
+
+	err := h.events.Publish(ctx, domain.DeliveryEvent{
+		ID:            newEventID(),
+		Type:          domain.EventBounced,
+		CorrelationID: record.CorrelationID,
+		UserID:        record.UserID,
+		CallbackURL:   record.CallbackURL,
+		Detail:        strings.TrimSpace(fmt.Sprintf("%s %s", event.Type, detail)),
+		OccurredAt:    h.now(),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error().Err(err).
+			Str(logging.CorrelationIDKey, record.CorrelationID).
+			Msg("failed to publish bounced delivery event")
+	}
+}
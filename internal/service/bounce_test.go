@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"notification/internal/service"
+	"notification/mocks"
+	"testing"
+)
+
+func TestSuppressionBounceHandler_HandleBounces(t *testing.T) {
+	t.Run("hard bounces and complaints are suppressed", func(t *testing.T) {
+		repo := repository.NewInMemorySuppressionRepository()
+		handler := service.NewSuppressionBounceHandler(repo)
+
+		err := handler.HandleBounces(context.Background(), []domain.BounceEvent{
+			{Email: "john@example.com", Type: domain.HardBounce, Status: "5.1.1", Diagnostic: "user unknown"},
+			{Email: "jane@example.com", Type: domain.Complaint},
+			{Email: "bob@example.com", Type: domain.SoftBounce, Status: "4.2.2"},
+		})
+		require.NoError(t, err)
+
+		suppressions, err := repo.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, suppressions, 2)
+		assert.Equal(t, "jane@example.com", suppressions[0].Email)
+		assert.Equal(t, domain.SuppressedByComplaint, suppressions[0].Reason)
+		assert.Equal(t, "john@example.com", suppressions[1].Email)
+		assert.Equal(t, domain.SuppressedByBounce, suppressions[1].Reason)
+		assert.Equal(t, "5.1.1 user unknown", suppressions[1].Detail)
+	})
+
+	t.Run("bounced event is published", func(t *testing.T) {
+		deliveries := repository.NewInMemoryDeliveryRepository()
+		require.NoError(t, deliveries.AddTransition(context.Background(), domain.DeliveryRecord{
+			CorrelationID:     "c1",
+			UserID:            "u1",
+			ProviderMessageID: "<m1@example.com>",
+			CallbackURL:       "https://example.com/callback",
+		}, domain.StatusTransition{Status: domain.DeliverySent}))
+
+		publisher := mocks.NewDeliveryEventPublisher(t)
+		publisher.
+			On("Publish", mock.Anything, mock.MatchedBy(func(event domain.DeliveryEvent) bool {
+				return event.Type == domain.EventBounced &&
+					event.CorrelationID == "c1" &&
+					event.CallbackURL == "https://example.com/callback" &&
+					event.Detail == "hard_bounce 5.1.1"
+			})).
+			Return(nil).
+			Once()
+
+		handler := service.NewSuppressionBounceHandler(repository.NewInMemorySuppressionRepository(),
+			service.WithBouncedEvents(deliveries, publisher))
+
+		err := handler.HandleBounces(context.Background(), []domain.BounceEvent{
+			{Email: "john@example.com", Type: domain.HardBounce, Status: "5.1.1", MessageID: "<m1@example.com>"},
+			// unknown notifications are skipped
+			{Email: "jane@example.com", Type: domain.HardBounce, MessageID: "<m2@example.com>"},
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("bounces are suppressed for the tenant of the notification", func(t *testing.T) {
+		deliveries := repository.NewInMemoryDeliveryRepository()
+		acme := repository.WithTenant(context.Background(), "acme")
+		require.NoError(t, deliveries.AddTransition(acme, domain.DeliveryRecord{
+			CorrelationID:     "c1",
+			UserID:            "u1",
+			ProviderMessageID: "<m1@example.com>",
+		}, domain.StatusTransition{Status: domain.DeliverySent}))
+
+		repo := repository.NewInMemorySuppressionRepository()
+		handler := service.NewSuppressionBounceHandler(repo,
+			service.WithBouncedEvents(deliveries, mocks.NewDeliveryEventPublisher(t)))
+
+		err := handler.HandleBounces(context.Background(), []domain.BounceEvent{
+			{Email: "john@example.com", Type: domain.HardBounce, MessageID: "<m1@example.com>"},
+		})
+		require.NoError(t, err)
+
+		_, err = repo.Get(acme, "john@example.com")
+		assert.NoError(t, err)
+		_, err = repo.Get(context.Background(), "john@example.com")
+		assert.ErrorIs(t, err, repository.ErrSuppressionNotFound)
+	})
+
+	t.Run("repository failure", func(t *testing.T) {
+		repo := mocks.NewSuppressionRepository(t)
+		repo.On("Save", mock.Anything, mock.Anything).Return(assert.AnError)
+		handler := service.NewSuppressionBounceHandler(repo)
+
+		err := handler.HandleBounces(context.Background(), []domain.BounceEvent{
+			{Email: "john@example.com", Type: domain.HardBounce},
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"notification/internal/domain"
+	"notification/internal/repository"
+	"strings"
 	"time"
 )
 
@@ -15,4 +18,36 @@ type Cache interface {
 	Set(ctx context.Context, key string, value string, expiration time.Duration) error
 	// Decr decrements the integer in key by 1 on Redis.
 	Decr(ctx context.Context, key string) error
+	// TTL retrieves the time to live left of the given cache key. It's zero if the key
+	// doesn't exist or doesn't expire.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
+
+// The kinds of the cache keys, each having its own namespace, see tenantCacheKey.
+const (
+	// rateLimitCacheKind is the kind of the rate limit counters of the users.
+	rateLimitCacheKind = "ratelimit"
+	// idempotencyCacheKind is the kind of the correlation IDs of the processed notifications.
+	idempotencyCacheKind = "idempotency"
+)
+
+// tenantCacheKey returns the cache key of key, of the given kind, for the tenant of ctx:
+// "tenant:<tenant ID>:<kind>:<key>". Every tenant, the default one included, and every kind of key
+// have their own namespace, which the keys chosen by the clients, e.g. the correlation IDs, can't
+// escape since neither the tenant IDs nor the kinds have colons.
+func tenantCacheKey(ctx context.Context, kind, key string) string {
+	return "tenant:" + repository.TenantFromContext(ctx) + ":" + kind + ":" + key
+}
+
+// legacyCacheKey returns the cache key of key as it was before every tenant and kind had its own
+// namespace: "tenant:<tenant ID>:<key>", or key itself for the default tenant. It's empty if the key
+// of the default tenant would be in the namespace of another tenant.
+func legacyCacheKey(ctx context.Context, key string) string {
+	if tenantID := repository.TenantFromContext(ctx); tenantID != domain.DefaultTenantID {
+		return "tenant:" + tenantID + ":" + key
+	}
+	if strings.HasPrefix(key, "tenant:") {
+		return ""
+	}
+	return key
 }
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed means calls flow normally.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen means calls are rejected until the open timeout elapses.
+	CircuitOpen
+	// CircuitHalfOpen means a single probe call is allowed to check if the dependency recovered.
+	CircuitHalfOpen
+)
+
+// String returns the string equivalent of CircuitState.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return ""
+	}
+}
+
+// NewCircuitBreaker creates a new CircuitBreaker instance which opens after failureThreshold
+// consecutive failures and allows a probe call once openTimeout has elapsed.
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		now:              time.Now,
+	}
+}
+
+// CircuitBreaker prevents calling a failing dependency over and over again.
+type CircuitBreaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	now              func() time.Time
+
+	mu                  sync.Mutex
+	state               CircuitState
+	consecutiveFailures int
+	openedAt            time.Time
+	probing             bool
+}
+
+// Allow reports whether a call may go through. When the open timeout has elapsed
+// the breaker becomes half-open and a single probe call is allowed.
+func (c *CircuitBreaker) Allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		if c.now().Sub(c.openedAt) < c.openTimeout {
+			return false
+		}
+		c.state = CircuitHalfOpen
+		c.probing = true
+		return true
+	case CircuitHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a successful call, closing the breaker.
+func (c *CircuitBreaker) Success() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = CircuitClosed
+	c.consecutiveFailures = 0
+	c.probing = false
+}
+
+// Failure records a failed call, opening the breaker once the failure
+// threshold is reached or if the probe call failed.
+func (c *CircuitBreaker) Failure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.consecutiveFailures++
+	c.probing = false
+	if c.state == CircuitHalfOpen || c.consecutiveFailures >= c.failureThreshold {
+		c.state = CircuitOpen
+		c.openedAt = c.now()
+	}
+}
+
+// State returns the current state of the breaker and its consecutive failure count.
+func (c *CircuitBreaker) State() (CircuitState, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.state
+	if state == CircuitOpen && c.now().Sub(c.openedAt) >= c.openTimeout {
+		// the next call will be allowed as a probe.
+		state = CircuitHalfOpen
+	}
+	return state, c.consecutiveFailures
+}
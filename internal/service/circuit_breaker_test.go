@@ -0,0 +1,65 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/service"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		breaker := service.NewCircuitBreaker(2, time.Hour)
+
+		assert.True(t, breaker.Allow())
+		breaker.Failure()
+		assert.True(t, breaker.Allow())
+		breaker.Failure()
+
+		assert.False(t, breaker.Allow())
+		state, failures := breaker.State()
+		assert.Equal(t, service.CircuitOpen, state)
+		assert.Equal(t, 2, failures)
+	})
+
+	t.Run("success resets the failure count", func(t *testing.T) {
+		breaker := service.NewCircuitBreaker(2, time.Hour)
+
+		breaker.Failure()
+		breaker.Success()
+		breaker.Failure()
+
+		assert.True(t, breaker.Allow())
+	})
+
+	t.Run("half-open allows a single probe", func(t *testing.T) {
+		breaker := service.NewCircuitBreaker(1, time.Millisecond)
+		breaker.Failure()
+		time.Sleep(2 * time.Millisecond)
+
+		state, _ := breaker.State()
+		assert.Equal(t, service.CircuitHalfOpen, state)
+		assert.True(t, breaker.Allow())
+		assert.False(t, breaker.Allow())
+
+		t.Run("successful probe closes the circuit", func(t *testing.T) {
+			breaker.Success()
+			state, _ := breaker.State()
+			assert.Equal(t, service.CircuitClosed, state)
+			assert.True(t, breaker.Allow())
+		})
+	})
+
+	t.Run("failed probe opens the circuit again", func(t *testing.T) {
+		breaker := service.NewCircuitBreaker(3, 50*time.Millisecond)
+		breaker.Failure()
+		breaker.Failure()
+		breaker.Failure()
+		time.Sleep(60 * time.Millisecond)
+
+		assert.True(t, breaker.Allow())
+		breaker.Failure()
+
+		assert.False(t, breaker.Allow())
+	})
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"notification/internal/domain"
+)
+
+// DeliveryEventPublisher is the abstract representation of the delivery events
+// reporting to the notification producers.
+type DeliveryEventPublisher interface {
+	// Publish posts the delivery event to its callback URL. Implementations are expected
+	// to deliver the event asynchronously, retrying on failures.
+	Publish(ctx context.Context, event domain.DeliveryEvent) error
+}
+
+// newEventID generates a random delivery event ID.
+func newEventID() string {
+	id := make([]byte, 16)
+	// crypto/rand.Read never fails on the supported platforms.
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
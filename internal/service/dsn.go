@@ -0,0 +1,149 @@
+package service
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"notification/internal/domain"
+	"strings"
+)
+
+var (
+	// ErrNotDSN is the error when the message isn't a Delivery Status Notification.
+	ErrNotDSN = errors.New("message is not a delivery status notification")
+)
+
+// ParseDSN parses a Delivery Status Notification message (RFC 3464) and returns
+// a BounceEvent for each failed or delayed recipient.
+//
+// It returns ErrNotDSN if the message isn't a "multipart/report" message
+// with a "message/delivery-status" part.
+func ParseDSN(r io.Reader) ([]domain.BounceEvent, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("read message: %w", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/report" || params["boundary"] == "" {
+		return nil, ErrNotDSN
+	}
+
+	var (
+		deliveryStatus string
+		found          bool
+		messageID      string
+	)
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read message part: %w", err)
+		}
+
+		var content io.Reader = part
+		if strings.EqualFold(part.Header.Get("Content-Transfer-Encoding"), "base64") {
+			content = base64.NewDecoder(base64.StdEncoding, part)
+		}
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		switch partType {
+		case "message/delivery-status", "message/global-delivery-status":
+			body, err := io.ReadAll(content)
+			if err != nil {
+				return nil, fmt.Errorf("read delivery status: %w", err)
+			}
+			deliveryStatus = string(body)
+			found = true
+		case "text/rfc822-headers", "message/rfc822", "message/global-headers", "message/global":
+			// the returned message (or its headers) identifies the bounced notification.
+			// a trailing blank line is appended, because headers-only parts may lack it.
+			original, err := mail.ReadMessage(io.MultiReader(content, strings.NewReader("\r\n\r\n")))
+			if err == nil {
+				messageID = strings.TrimSpace(original.Header.Get("Message-ID"))
+			}
+		}
+	}
+	if !found {
+		return nil, ErrNotDSN
+	}
+
+	events, err := parseDeliveryStatus(deliveryStatus)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		events[i].MessageID = messageID
+	}
+	return events, nil
+}
+
+// parseDeliveryStatus parses the "message/delivery-status" content: a per-message
+// fields block followed by one block of fields per recipient.
+func parseDeliveryStatus(content string) ([]domain.BounceEvent, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	blocks := strings.Split(strings.TrimSpace(content), "\n\n")
+
+	var events []domain.BounceEvent
+	// the first block holds the per-message fields, e.g. Reporting-MTA.
+	for _, block := range blocks[1:] {
+		if strings.TrimSpace(block) == "" {
+			continue
+		}
+		fields, err := textproto.NewReader(bufio.NewReader(strings.NewReader(block + "\n\n"))).ReadMIMEHeader()
+		if err != nil {
+			return nil, fmt.Errorf("parse recipient fields: %w", err)
+		}
+
+		recipient := addressField(fields.Get("Final-Recipient"))
+		if recipient == "" {
+			recipient = addressField(fields.Get("Original-Recipient"))
+		}
+		status := strings.TrimSpace(fields.Get("Status"))
+		bounceType := classifyDSN(strings.ToLower(strings.TrimSpace(fields.Get("Action"))), status)
+		if recipient == "" || bounceType == 0 {
+			continue
+		}
+		events = append(events, domain.BounceEvent{
+			Email:      recipient,
+			Type:       bounceType,
+			Status:     status,
+			Diagnostic: strings.TrimSpace(fields.Get("Diagnostic-Code")),
+			Source:     "dsn",
+		})
+	}
+	return events, nil
+}
+
+// addressField returns the address of a "address-type; address" field, e.g. "rfc822; john@example.com".
+func addressField(value string) string {
+	if _, address, ok := strings.Cut(value, ";"); ok {
+		return domain.NormalizeEmail(address)
+	}
+	return domain.NormalizeEmail(value)
+}
+
+// classifyDSN maps the DSN action and status of a recipient into a BounceType.
+// It returns 0 for successful deliveries.
+func classifyDSN(action, status string) domain.BounceType {
+	switch action {
+	case "failed":
+		if strings.HasPrefix(status, "4") {
+			return domain.SoftBounce
+		}
+		return domain.HardBounce
+	case "delayed":
+		return domain.SoftBounce
+	default:
+		// delivered, relayed and expanded
+		return 0
+	}
+}
@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"notification/internal/domain"
+	"notification/internal/service"
+	"strings"
+	"testing"
+)
+
+const testDSN = "From: MAILER-DAEMON@mx.example.com\r\n" +
+	"To: no-reply@example.com\r\n" +
+	"Subject: Undelivered Mail Returned to Sender\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/report; report-type=delivery-status; boundary=\"BOUNDARY\"\r\n" +
+	"\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"Your message could not be delivered.\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: message/delivery-status\r\n" +
+	"\r\n" +
+	"Reporting-MTA: dns; mx.example.com\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; John@Example.com\r\n" +
+	"Action: failed\r\n" +
+	"Status: 5.1.1\r\n" +
+	"Diagnostic-Code: smtp; 550 5.1.1 user unknown\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; jane@example.com\r\n" +
+	"Action: delayed\r\n" +
+	"Status: 4.2.2\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; bob@example.com\r\n" +
+	"Action: delivered\r\n" +
+	"Status: 2.0.0\r\n" +
+	"\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: text/rfc822-headers\r\n" +
+	"\r\n" +
+	"From: no-reply@example.com\r\n" +
+	"Message-ID: <abc123@example.com>\r\n" +
+	"--BOUNDARY--\r\n"
+
+func TestParseDSN(t *testing.T) {
+	t.Run("failed and delayed recipients are reported", func(t *testing.T) {
+		events, err := service.ParseDSN(strings.NewReader(testDSN))
+		require.NoError(t, err)
+
+		assert.Equal(t, []domain.BounceEvent{
+			{
+				Email:      "john@example.com",
+				Type:       domain.HardBounce,
+				Status:     "5.1.1",
+				Diagnostic: "smtp; 550 5.1.1 user unknown",
+				Source:     "dsn",
+				MessageID:  "<abc123@example.com>",
+			},
+			{
+				Email:     "jane@example.com",
+				Type:      domain.SoftBounce,
+				Status:    "4.2.2",
+				Source:    "dsn",
+				MessageID: "<abc123@example.com>",
+			},
+		}, events)
+	})
+
+	t.Run("regular message", func(t *testing.T) {
+		_, err := service.ParseDSN(strings.NewReader("From: john@example.com\r\n" +
+			"Content-Type: text/plain\r\n" +
+			"\r\n" +
+			"Hey there!\r\n"))
+		assert.ErrorIs(t, err, service.ErrNotDSN)
+	})
+}
@@ -1,7 +1,66 @@
 package service
 
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrMailRetryable is the error when the email could not be sent because of a temporary
+	// failure of the external service (e.g. throttling, outages), so it's safe to retry later.
+	ErrMailRetryable = errors.New("retryable mail failure")
+	// ErrMailPermanent is the error when the external service rejected the email itself
+	// and retrying it won't succeed (e.g. invalid recipient). The failures of the service,
+	// bad credentials included, are ErrMailRetryable.
+	ErrMailPermanent = errors.New("permanent mail failure")
+)
+
 // Mailer is the abstraction layer of the external email service integration itself.
 type Mailer interface {
 	// SendEmail sends the email message through the appropriate external service integration.
 	SendEmail(to string, subject string, msg string) error
 }
+
+// MessageIDMailer is a Mailer able to report the message ID assigned by the external service,
+// which allows correlating the notification with the provider's delivery events.
+type MessageIDMailer interface {
+	Mailer
+	// SendEmailWithID sends the email message and returns the provider message ID.
+	SendEmailWithID(to string, subject string, msg string) (messageID string, err error)
+}
+
+// ContextMailer is a MessageIDMailer taking the context of the notification being sent,
+// which allows tracing the exchange with the external service.
+type ContextMailer interface {
+	MessageIDMailer
+	// SendEmailContext sends the email message and returns the provider message ID.
+	SendEmailContext(ctx context.Context, to string, subject string, msg string) (messageID string, err error)
+}
+
+type senderKey struct{}
+
+// WithSender returns a copy of ctx carrying the sender address of the email being sent,
+// which the mailers taking a context use instead of the one they're configured with.
+func WithSender(ctx context.Context, from string) context.Context {
+	return context.WithValue(ctx, senderKey{}, from)
+}
+
+// SenderFromContext returns the sender address carried by ctx, or fallback if there's none.
+func SenderFromContext(ctx context.Context, fallback string) string {
+	if from, ok := ctx.Value(senderKey{}).(string); ok && from != "" {
+		return from
+	}
+	return fallback
+}
+
+// SendWithMessageID sends the email through the mailer, passing ctx along and returning
+// the provider message ID whenever the mailer is able to.
+func SendWithMessageID(ctx context.Context, mailer Mailer, to, subject, msg string) (string, error) {
+	if m, ok := mailer.(ContextMailer); ok {
+		return m.SendEmailContext(ctx, to, subject, msg)
+	}
+	if m, ok := mailer.(MessageIDMailer); ok {
+		return m.SendEmailWithID(to, subject, msg)
+	}
+	return "", mailer.SendEmail(to, subject, msg)
+}
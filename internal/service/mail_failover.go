@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"math/rand/v2"
+	"notification/internal/logging"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrNoMailProviderAvailable is the error when every mail provider either failed
+	// or has its circuit breaker open.
+	ErrNoMailProviderAvailable = errors.New("no mail provider available")
+)
+
+// MailProvider is a Mailer taking part in a FailoverMailer.
+type MailProvider struct {
+	// Name identifies the provider in logs and status reports.
+	Name string
+	// Mailer is the provider integration itself.
+	Mailer Mailer
+	// Priority defines the failover order: providers with a lower value are tried first.
+	Priority int
+	// Weight defines the share of traffic among providers of the same priority.
+	// Defaults to 1.
+	Weight int
+}
+
+// CircuitBreakerConfig defines the circuit breaker settings applied to each provider.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit.
+	FailureThreshold int
+	// OpenTimeout is the time the circuit stays open before a probe is allowed.
+	OpenTimeout time.Duration
+}
+
+// ProviderState is the status report of a mail provider.
+type ProviderState struct {
+	// Name is the provider name.
+	Name string `json:"name"`
+	// Priority is the provider priority.
+	Priority int `json:"priority"`
+	// State is the circuit breaker state: "closed", "open" or "half-open".
+	State string `json:"state"`
+	// ConsecutiveFailures is the number of failures since the last success.
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+}
+
+// FailoverMailerOption defines the optional params for FailoverMailer.
+type FailoverMailerOption func(*FailoverMailer)
+
+// WithFailoverLogger sets the logger of the FailoverMailer. Defaults to logging.Default().
+func WithFailoverLogger(logger zerolog.Logger) FailoverMailerOption {
+	return func(f *FailoverMailer) {
+		f.logger = logger
+	}
+}
+
+// NewFailoverMailer creates a new FailoverMailer instance.
+func NewFailoverMailer(providers []MailProvider, breakerConfig CircuitBreakerConfig,
+	opts ...FailoverMailerOption) *FailoverMailer {
+	mailer := &FailoverMailer{
+		randIntN: rand.IntN,
+		logger:   logging.Default(),
+	}
+	for _, opt := range opts {
+		opt(mailer)
+	}
+	for _, p := range providers {
+		if p.Weight <= 0 {
+			p.Weight = 1
+		}
+		mailer.providers = append(mailer.providers, &failoverProvider{
+			MailProvider: p,
+			breaker:      NewCircuitBreaker(breakerConfig.FailureThreshold, breakerConfig.OpenTimeout),
+		})
+	}
+	sort.SliceStable(mailer.providers, func(i, j int) bool {
+		return mailer.providers[i].Priority < mailer.providers[j].Priority
+	})
+	return mailer
+}
+
+// FailoverMailer is a Mailer composed of multiple providers. Providers are tried
+// in priority order, spreading traffic by weight among providers of the same priority,
+// and skipping the ones whose circuit breaker is open.
+//
+// Permanent failures (ErrMailPermanent) are returned straight away, because
+// any other provider would reject the same message too. Any other failure, e.g.
+// revoked credentials, counts against the circuit breaker of the provider and
+// the next provider is tried.
+type FailoverMailer struct {
+	providers []*failoverProvider
+	randIntN  func(n int) int
+	logger    zerolog.Logger
+}
+
+type failoverProvider struct {
+	MailProvider
+	breaker *CircuitBreaker
+}
+
+// SendEmail sends the email message through the first available provider.
+func (f FailoverMailer) SendEmail(to string, subject string, msg string) error {
+	_, err := f.SendEmailWithID(to, subject, msg)
+	return err
+}
+
+// SendEmailWithID sends the email message through the first available provider,
+// returning the provider message ID when the provider is able to report it.
+func (f FailoverMailer) SendEmailWithID(to string, subject string, msg string) (string, error) {
+	return f.SendEmailContext(context.Background(), to, subject, msg)
+}
+
+// SendEmailContext is SendEmailWithID passing ctx along to the providers.
+func (f FailoverMailer) SendEmailContext(ctx context.Context, to string, subject string, msg string) (string, error) {
+	var errs []error
+	for _, p := range f.candidates() {
+		if !p.breaker.Allow() {
+			continue
+		}
+
+		messageID, err := SendWithMessageID(ctx, p.Mailer, to, subject, msg)
+		if err == nil || errors.Is(err, ErrMailPermanent) {
+			// the provider is healthy even if it rejected the message.
+			p.breaker.Success()
+			return messageID, err
+		}
+
+		f.logger.Warn().Err(err).Str("provider", p.Name).Msg("mail provider failed, trying the next one")
+		p.breaker.Failure()
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+	}
+	return "", errors.Join(append([]error{ErrNoMailProviderAvailable, ErrMailRetryable}, errs...)...)
+}
+
+// ProviderStates returns the status report of every provider.
+func (f FailoverMailer) ProviderStates() []ProviderState {
+	states := make([]ProviderState, 0, len(f.providers))
+	for _, p := range f.providers {
+		state, failures := p.breaker.State()
+		states = append(states, ProviderState{
+			Name:                p.Name,
+			Priority:            p.Priority,
+			State:               state.String(),
+			ConsecutiveFailures: failures,
+		})
+	}
+	return states
+}
+
+// Readiness reports the FailoverMailer as ready as long as at least one
+// provider's circuit isn't open, detailing the state of each provider.
+func (f FailoverMailer) Readiness() (bool, any) {
+	states := f.ProviderStates()
+	for _, s := range states {
+		if s.State != CircuitOpen.String() {
+			return true, states
+		}
+	}
+	return false, states
+}
+
+// candidates returns the providers in the order they must be tried: by priority and,
+// within the same priority, in a random order weighted by the provider weights.
+func (f FailoverMailer) candidates() []*failoverProvider {
+	ordered := make([]*failoverProvider, 0, len(f.providers))
+	for start := 0; start < len(f.providers); {
+		end := start
+		for end < len(f.providers) && f.providers[end].Priority == f.providers[start].Priority {
+			end++
+		}
+		ordered = append(ordered, f.weightedShuffle(f.providers[start:end])...)
+		start = end
+	}
+	return ordered
+}
+
+func (f FailoverMailer) weightedShuffle(tier []*failoverProvider) []*failoverProvider {
+	remaining := append([]*failoverProvider(nil), tier...)
+	shuffled := make([]*failoverProvider, 0, len(tier))
+	for len(remaining) > 0 {
+		total := 0
+		for _, p := range remaining {
+			total += p.Weight
+		}
+		pick := f.randIntN(total)
+		for i, p := range remaining {
+			if pick < p.Weight {
+				shuffled = append(shuffled, p)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+			pick -= p.Weight
+		}
+	}
+	return shuffled
+}
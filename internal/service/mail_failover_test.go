@@ -0,0 +1,165 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"notification/internal/service"
+	"notification/mocks"
+	"testing"
+	"time"
+)
+
+func TestFailoverMailer_SendEmail(t *testing.T) {
+	breakerConfig := service.CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Hour,
+	}
+
+	t.Run("primary provider is used", func(t *testing.T) {
+		primary := mocks.NewMailer(t)
+		primary.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		secondary := mocks.NewMailer(t)
+
+		mailer := service.NewFailoverMailer([]service.MailProvider{
+			{Name: "secondary", Mailer: secondary, Priority: 2},
+			{Name: "primary", Mailer: primary, Priority: 1},
+		}, breakerConfig)
+
+		require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+		secondary.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("fails over to the next provider", func(t *testing.T) {
+		primary := mocks.NewMailer(t)
+		primary.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("oops: %w", service.ErrMailRetryable))
+		secondary := mocks.NewMessageIDMailer(t)
+		secondary.On("SendEmailWithID", mock.Anything, mock.Anything, mock.Anything).
+			Return("secondary-id", nil)
+
+		mailer := service.NewFailoverMailer([]service.MailProvider{
+			{Name: "primary", Mailer: primary, Priority: 1},
+			{Name: "secondary", Mailer: secondary, Priority: 2},
+		}, breakerConfig)
+
+		messageID, err := mailer.SendEmailWithID("john@example.com", "Hi", "Hey there!")
+		require.NoError(t, err)
+		assert.Equal(t, "secondary-id", messageID)
+	})
+
+	t.Run("context is passed to the provider", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+		primary := mocks.NewContextMailer(t)
+		primary.On("SendEmailContext", ctx, "john@example.com", "Hi", "Hey there!").
+			Return("primary-id", nil)
+
+		mailer := service.NewFailoverMailer([]service.MailProvider{
+			{Name: "primary", Mailer: primary, Priority: 1},
+		}, breakerConfig)
+
+		messageID, err := mailer.SendEmailContext(ctx, "john@example.com", "Hi", "Hey there!")
+		require.NoError(t, err)
+		assert.Equal(t, "primary-id", messageID)
+	})
+
+	t.Run("permanent failures aren't failed over", func(t *testing.T) {
+		primary := mocks.NewMailer(t)
+		primary.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("oops: %w", service.ErrMailPermanent))
+		secondary := mocks.NewMailer(t)
+
+		mailer := service.NewFailoverMailer([]service.MailProvider{
+			{Name: "primary", Mailer: primary, Priority: 1},
+			{Name: "secondary", Mailer: secondary, Priority: 2},
+		}, breakerConfig)
+
+		err := mailer.SendEmail("john@example.com", "Hi", "Hey there!")
+		assert.ErrorIs(t, err, service.ErrMailPermanent)
+		secondary.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything, mock.Anything)
+		assert.Equal(t, "closed", mailer.ProviderStates()[0].State)
+	})
+
+	t.Run("every provider fails", func(t *testing.T) {
+		primary := mocks.NewMailer(t)
+		primary.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(assert.AnError)
+
+		mailer := service.NewFailoverMailer([]service.MailProvider{
+			{Name: "primary", Mailer: primary, Priority: 1},
+		}, breakerConfig)
+
+		err := mailer.SendEmail("john@example.com", "Hi", "Hey there!")
+		assert.ErrorIs(t, err, service.ErrNoMailProviderAvailable)
+		assert.ErrorIs(t, err, service.ErrMailRetryable)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("provider with open circuit is skipped", func(t *testing.T) {
+		primary := mocks.NewMailer(t)
+		primary.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).
+			Return(assert.AnError).
+			Times(2)
+		secondary := mocks.NewMailer(t)
+		secondary.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		mailer := service.NewFailoverMailer([]service.MailProvider{
+			{Name: "primary", Mailer: primary, Priority: 1},
+			{Name: "secondary", Mailer: secondary, Priority: 2},
+		}, breakerConfig)
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+		}
+
+		primary.AssertNumberOfCalls(t, "SendEmail", 2)
+		secondary.AssertNumberOfCalls(t, "SendEmail", 5)
+
+		t.Run("state is reported", func(t *testing.T) {
+			assert.Equal(t, []service.ProviderState{
+				{Name: "primary", Priority: 1, State: "open", ConsecutiveFailures: 2},
+				{Name: "secondary", Priority: 2, State: "closed", ConsecutiveFailures: 0},
+			}, mailer.ProviderStates())
+
+			ready, _ := mailer.Readiness()
+			assert.True(t, ready)
+		})
+	})
+
+	t.Run("not ready when every circuit is open", func(t *testing.T) {
+		primary := mocks.NewMailer(t)
+		primary.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(assert.AnError)
+
+		mailer := service.NewFailoverMailer([]service.MailProvider{
+			{Name: "primary", Mailer: primary, Priority: 1},
+		}, breakerConfig)
+		_ = mailer.SendEmail("john@example.com", "Hi", "Hey there!")
+		_ = mailer.SendEmail("john@example.com", "Hi", "Hey there!")
+
+		ready, _ := mailer.Readiness()
+		assert.False(t, ready)
+	})
+
+	t.Run("traffic is spread by weight", func(t *testing.T) {
+		heavy := mocks.NewMailer(t)
+		heavy.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		light := mocks.NewMailer(t)
+		light.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		mailer := service.NewFailoverMailer([]service.MailProvider{
+			{Name: "heavy", Mailer: heavy, Priority: 1, Weight: 3},
+			{Name: "light", Mailer: light, Priority: 1, Weight: 1},
+		}, breakerConfig)
+
+		const total = 2000
+		for i := 0; i < total; i++ {
+			require.NoError(t, mailer.SendEmail("john@example.com", "Hi", "Hey there!"))
+		}
+
+		heavyCalls := len(heavy.Calls)
+		assert.Equal(t, total, heavyCalls+len(light.Calls))
+		// expected share is 75%, allowing some randomness.
+		assert.InDelta(t, 0.75, float64(heavyCalls)/total, 0.05)
+	})
+}
@@ -0,0 +1,49 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"notification/internal/service"
+	"notification/mocks"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestSendWithMessageID(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	t.Run("context mailer", func(t *testing.T) {
+		mailer := mocks.NewContextMailer(t)
+		mailer.On("SendEmailContext", ctx, "john@example.com", "Hi", "Hey there!").Return("id-1", nil)
+
+		messageID, err := service.SendWithMessageID(ctx, mailer, "john@example.com", "Hi", "Hey there!")
+		assert.NoError(t, err)
+		assert.Equal(t, "id-1", messageID)
+	})
+
+	t.Run("message ID mailer", func(t *testing.T) {
+		mailer := mocks.NewMessageIDMailer(t)
+		mailer.On("SendEmailWithID", "john@example.com", "Hi", "Hey there!").Return("id-1", nil)
+
+		messageID, err := service.SendWithMessageID(ctx, mailer, "john@example.com", "Hi", "Hey there!")
+		assert.NoError(t, err)
+		assert.Equal(t, "id-1", messageID)
+	})
+
+	t.Run("plain mailer", func(t *testing.T) {
+		mailer := mocks.NewMailer(t)
+		mailer.On("SendEmail", "john@example.com", "Hi", "Hey there!").Return(assert.AnError)
+
+		messageID, err := service.SendWithMessageID(ctx, mailer, "john@example.com", "Hi", "Hey there!")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Empty(t, messageID)
+	})
+}
+
+func TestSenderFromContext(t *testing.T) {
+	assert.Equal(t, "noreply@example.com", service.SenderFromContext(context.Background(), "noreply@example.com"))
+
+	ctx := service.WithSender(context.Background(), "billing@example.com")
+	assert.Equal(t, "billing@example.com", service.SenderFromContext(ctx, "noreply@example.com"))
+}
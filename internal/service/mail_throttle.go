@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"notification/internal/logging"
+	"notification/internal/repository"
+	"strings"
+)
+
+// The scopes of the throughput limits.
+const (
+	// ThrottleGlobal is the scope of the limit on every email sent.
+	ThrottleGlobal = "global"
+	// ThrottleTenant is the scope of the limit on the emails of each tenant.
+	ThrottleTenant = "tenant"
+	// ThrottleDomain is the scope of the limit on the emails of each sender domain.
+	ThrottleDomain = "domain"
+)
+
+var (
+	// ErrMailThrottled is the error when the email can't be sent within the throughput limits
+	// before the max wait. It's always joined with ErrMailRetryable.
+	ErrMailThrottled = errors.New("mail throughput limit exceeded")
+)
+
+// ThroughputLimit caps the rate at which the emails of some traffic are handed to the mail provider.
+type ThroughputLimit struct {
+	// Scope is the kind of traffic limited: ThrottleGlobal, ThrottleTenant or ThrottleDomain.
+	Scope string
+	// Key identifies the traffic limited within its scope, e.g. the tenant ID. It's empty for the
+	// global scope.
+	Key string
+	// Rate is the max number of emails per second.
+	Rate int
+}
+
+// Throttle is the abstract representation of the throughput limiter shared by the replicas.
+type Throttle interface {
+	// Wait blocks until an email can be sent within the limit, reserving its slot. The emails
+	// are spread evenly over each second, so they're never sent in bursts.
+	//
+	// It returns ErrMailThrottled if the slot is further away than the max wait of the Throttle,
+	// or the error of ctx if it's done in the meantime. It never holds a slot when it fails.
+	Wait(ctx context.Context, limit ThroughputLimit) error
+	// Release gives back the slot reserved by Wait for an email that isn't sent after all, e.g.
+	// because another limit rejected it, so that it doesn't consume the capacity of the limit.
+	Release(ctx context.Context, limit ThroughputLimit) error
+}
+
+// ThroughputLimits defines the throughput limits of a ThrottledMailer. Zero rates mean no limit.
+type ThroughputLimits struct {
+	// Rate is the max number of emails sent per second, e.g. the cap of the mail provider.
+	Rate int
+	// TenantRate is the max number of emails sent per second for each tenant, so that the
+	// blasts of a tenant don't delay the emails of the others as much.
+	TenantRate int
+	// DomainRates are the max numbers of emails sent per second from each sender domain,
+	// e.g. to warm up a new domain.
+	DomainRates map[string]int
+}
+
+// NewThrottledMailer creates a new ThrottledMailer sending through mailer, whose sender address
+// is from unless the context carries another one, see WithSender.
+func NewThrottledMailer(mailer Mailer, throttle Throttle, from string, limits ThroughputLimits) *ThrottledMailer {
+	domainRates := make(map[string]int, len(limits.DomainRates))
+	for domain, rate := range limits.DomainRates {
+		domainRates[strings.ToLower(domain)] = rate
+	}
+	limits.DomainRates = domainRates
+	return &ThrottledMailer{
+		mailer:   mailer,
+		throttle: throttle,
+		from:     from,
+		limits:   limits,
+	}
+}
+
+// ThrottledMailer is a Mailer decorator keeping the email throughput within the limits of the
+// mail provider: the emails exceeding them are delayed, rather than rejected, until the max wait
+// of the Throttle.
+//
+// The tenant and sender domain limits are waited for before the global one, so that the emails
+// held back by their own tenant or domain don't hold a global slot meanwhile. The slots reserved
+// for an email rejected by a later limit are released, so that the rejected emails don't consume
+// the capacity of their tenant or domain.
+type ThrottledMailer struct {
+	mailer   Mailer
+	throttle Throttle
+	from     string
+	limits   ThroughputLimits
+}
+
+// SendEmail sends the email message through the decorated mailer, once the limits allow it.
+func (m ThrottledMailer) SendEmail(to string, subject string, msg string) error {
+	_, err := m.SendEmailWithID(to, subject, msg)
+	return err
+}
+
+// SendEmailWithID sends the email message through the decorated mailer, once the limits allow it,
+// returning the provider message ID when the decorated mailer is able to report it.
+func (m ThrottledMailer) SendEmailWithID(to string, subject string, msg string) (string, error) {
+	return m.SendEmailContext(context.Background(), to, subject, msg)
+}
+
+// SendEmailContext is SendEmailWithID applying the limits of the tenant and sender of ctx,
+// see repository.WithTenant and WithSender, and passing ctx along to the decorated mailer.
+func (m ThrottledMailer) SendEmailContext(ctx context.Context, to string, subject string, msg string) (string, error) {
+	limits := m.applicableLimits(ctx)
+	for i, limit := range limits {
+		if err := m.throttle.Wait(ctx, limit); err != nil {
+			m.release(ctx, limits[:i])
+			err = fmt.Errorf("%s throughput limit: %w", limit.Scope, err)
+			if errors.Is(err, ErrMailThrottled) {
+				return "", errors.Join(ErrMailRetryable, err)
+			}
+			return "", err
+		}
+	}
+	return SendWithMessageID(ctx, m.mailer, to, subject, msg)
+}
+
+// release gives back the slots reserved for an email that isn't sent. The slots are released even
+// if ctx is done, since the email may have been rejected because of it.
+func (m ThrottledMailer) release(ctx context.Context, limits []ThroughputLimit) {
+	ctx = context.WithoutCancel(ctx)
+	for _, limit := range limits {
+		if err := m.throttle.Release(ctx, limit); err != nil {
+			logging.FromContext(ctx).Warn().Err(err).Str("scope", limit.Scope).
+				Msg("failed to release throughput limit slot")
+		}
+	}
+}
+
+// applicableLimits returns the limits applying to the email of ctx, in the order they're waited for.
+func (m ThrottledMailer) applicableLimits(ctx context.Context) []ThroughputLimit {
+	var limits []ThroughputLimit
+	if m.limits.TenantRate > 0 {
+		limits = append(limits, ThroughputLimit{
+			Scope: ThrottleTenant,
+			Key:   repository.TenantFromContext(ctx),
+			Rate:  m.limits.TenantRate,
+		})
+	}
+	domain := senderDomain(SenderFromContext(ctx, m.from))
+	if rate := m.limits.DomainRates[domain]; rate > 0 {
+		limits = append(limits, ThroughputLimit{
+			Scope: ThrottleDomain,
+			Key:   domain,
+			Rate:  rate,
+		})
+	}
+	if m.limits.Rate > 0 {
+		limits = append(limits, ThroughputLimit{
+			Scope: ThrottleGlobal,
+			Rate:  m.limits.Rate,
+		})
+	}
+	return limits
+}
+
+// senderDomain returns the lowercase domain of the sender address, which may have a display name.
+func senderDomain(from string) string {
+	if address, err := mail.ParseAddress(from); err == nil {
+		from = address.Address
+	}
+	_, domain, _ := strings.Cut(from, "@")
+	return strings.ToLower(domain)
+}
@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"notification/internal/repository"
+	"notification/internal/service"
+	"notification/mocks"
+	"testing"
+)
+
+func TestThrottledMailer_SendEmailContext(t *testing.T) {
+	limits := service.ThroughputLimits{
+		Rate:        50,
+		TenantRate:  10,
+		DomainRates: map[string]int{"Billing.example.com": 5},
+	}
+
+	t.Run("limits are waited for before sending", func(t *testing.T) {
+		var waited []service.ThroughputLimit
+		throttle := mocks.NewThrottle(t)
+		throttle.
+			On("Wait", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				waited = append(waited, args.Get(1).(service.ThroughputLimit))
+			}).
+			Return(nil)
+		mailer := mocks.NewContextMailer(t)
+		mailer.
+			On("SendEmailContext", mock.Anything, "john@example.com", "Hi", "Hey there!").
+			Return("id-1", nil)
+
+		ctx := service.WithSender(repository.WithTenant(context.Background(), "billing"),
+			"Billing <billing@billing.example.com>")
+		throttled := service.NewThrottledMailer(mailer, throttle, "no-reply@example.com", limits)
+		messageID, err := throttled.SendEmailContext(ctx, "john@example.com", "Hi", "Hey there!")
+		require.NoError(t, err)
+
+		assert.Equal(t, "id-1", messageID)
+		assert.Equal(t, []service.ThroughputLimit{
+			{Scope: service.ThrottleTenant, Key: "billing", Rate: 10},
+			{Scope: service.ThrottleDomain, Key: "billing.example.com", Rate: 5},
+			{Scope: service.ThrottleGlobal, Rate: 50},
+		}, waited)
+	})
+
+	t.Run("sender domain without a limit", func(t *testing.T) {
+		throttle := mocks.NewThrottle(t)
+		throttle.
+			On("Wait", mock.Anything, service.ThroughputLimit{Scope: service.ThrottleGlobal, Rate: 50}).
+			Return(nil).Once()
+		mailer := mocks.NewMailer(t)
+		mailer.
+			On("SendEmail", "john@example.com", "Hi", "Hey there!").
+			Return(nil)
+
+		throttled := service.NewThrottledMailer(mailer, throttle, "no-reply@example.com",
+			service.ThroughputLimits{Rate: 50, DomainRates: limits.DomainRates})
+		assert.NoError(t, throttled.SendEmail("john@example.com", "Hi", "Hey there!"))
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		mailer := mocks.NewMailer(t)
+		mailer.
+			On("SendEmail", "john@example.com", "Hi", "Hey there!").
+			Return(nil)
+
+		throttled := service.NewThrottledMailer(mailer, mocks.NewThrottle(t), "no-reply@example.com",
+			service.ThroughputLimits{})
+		assert.NoError(t, throttled.SendEmail("john@example.com", "Hi", "Hey there!"))
+	})
+
+	t.Run("max wait exceeded is retryable", func(t *testing.T) {
+		throttle := mocks.NewThrottle(t)
+		throttle.
+			On("Wait", mock.Anything, mock.Anything).
+			Return(service.ErrMailThrottled)
+
+		throttled := service.NewThrottledMailer(mocks.NewMailer(t), throttle, "no-reply@example.com", limits)
+		_, err := throttled.SendEmailContext(context.Background(), "john@example.com", "Hi", "Hey there!")
+		assert.ErrorIs(t, err, service.ErrMailThrottled)
+		assert.ErrorIs(t, err, service.ErrMailRetryable)
+	})
+
+	t.Run("rejected email releases the slots of the earlier limits", func(t *testing.T) {
+		tenant := service.ThroughputLimit{Scope: service.ThrottleTenant, Key: "billing", Rate: 10}
+		domain := service.ThroughputLimit{Scope: service.ThrottleDomain, Key: "billing.example.com", Rate: 5}
+		global := service.ThroughputLimit{Scope: service.ThrottleGlobal, Rate: 50}
+		throttle := mocks.NewThrottle(t)
+		throttle.On("Wait", mock.Anything, tenant).Return(nil).Once()
+		throttle.On("Wait", mock.Anything, domain).Return(nil).Once()
+		throttle.On("Wait", mock.Anything, global).Return(service.ErrMailThrottled).Once()
+		throttle.On("Release", mock.Anything, tenant).Return(nil).Once()
+		throttle.On("Release", mock.Anything, domain).Return(errors.New("connection refused")).Once()
+
+		ctx := service.WithSender(repository.WithTenant(context.Background(), "billing"),
+			"billing@billing.example.com")
+		throttled := service.NewThrottledMailer(mocks.NewMailer(t), throttle, "no-reply@example.com", limits)
+		_, err := throttled.SendEmailContext(ctx, "john@example.com", "Hi", "Hey there!")
+		assert.ErrorIs(t, err, service.ErrMailThrottled)
+		throttle.AssertNotCalled(t, "Release", mock.Anything, global)
+	})
+
+	t.Run("throttle failure", func(t *testing.T) {
+		throttle := mocks.NewThrottle(t)
+		throttle.
+			On("Wait", mock.Anything, mock.Anything).
+			Return(context.Canceled)
+
+		throttled := service.NewThrottledMailer(mocks.NewMailer(t), throttle, "no-reply@example.com", limits)
+		_, err := throttled.SendEmailContext(context.Background(), "john@example.com", "Hi", "Hey there!")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NotErrorIs(t, err, service.ErrMailRetryable)
+	})
+}
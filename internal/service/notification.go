@@ -4,37 +4,105 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
-	"log"
 	"notification/internal/domain"
+	"notification/internal/logging"
 	"notification/internal/repository"
+	"notification/internal/tracing"
 	"time"
 )
 
 var (
 	// ErrIdempotencyViolation is the error when the same notification has already been processed before.
 	ErrIdempotencyViolation = errors.New("notification already processed")
+	// ErrRecipientSuppressed is the error when the user's email address is in the suppression list
+	// because of a previous hard bounce or spam complaint.
+	ErrRecipientSuppressed = errors.New("recipient is suppressed")
+	// ErrUnknownTenant is the error when the notification is sent on behalf of a tenant which hasn't
+	// been provisioned.
+	ErrUnknownTenant = errors.New("unknown tenant")
 )
 
 // NotificationSender is the abstract representation of the NotificationSender service layer.
 type NotificationSender interface {
 	// Send sends a message to the given user depending on the notification type.
-	Send(ctx context.Context,
-		userID string, notification domain.Notification) (retryAfter time.Duration, err error)
+	Send(ctx context.Context, userID string, notification domain.Notification) (SendResult, error)
+}
+
+// SendResult is the rate limit outcome of NotificationSender.Send.
+type SendResult struct {
+	// RetryAfter in case of ErrRateLimitExceeded informs how much time is left until the next token
+	// is available.
+	RetryAfter time.Duration
+	// Limits are the limits the notification is subject to, see LockResult.Limits. They're nil when
+	// the notification is rejected before the rate limits are checked.
+	Limits RateLimitLimits
+}
+
+// EmailNotificationSenderOption defines the optional params for EmailNotificationSender.
+type EmailNotificationSenderOption func(*EmailNotificationSender)
+
+// WithSuppressionList makes the EmailNotificationSender refuse to send notifications
+// to email addresses in the suppression list.
+func WithSuppressionList(repo repository.SuppressionRepository) EmailNotificationSenderOption {
+	return func(e *EmailNotificationSender) {
+		e.suppressions = repo
+	}
+}
+
+// WithDeliveryTracking records the delivery lifecycle of every notification in the given repository.
+func WithDeliveryTracking(repo repository.DeliveryRepository) EmailNotificationSenderOption {
+	return func(e *EmailNotificationSender) {
+		e.deliveries = repo
+	}
+}
+
+// WithDeliveryEvents posts the delivery events of the notifications having a callback URL
+// through the given publisher.
+func WithDeliveryEvents(publisher DeliveryEventPublisher) EmailNotificationSenderOption {
+	return func(e *EmailNotificationSender) {
+		e.events = publisher
+	}
+}
+
+// WithSubjects makes the EmailNotificationSender use the subjects of the catalog,
+// falling back to the built-in subject for notification types missing from it.
+func WithSubjects(subjects *Subjects) EmailNotificationSenderOption {
+	return func(e *EmailNotificationSender) {
+		e.subjects = subjects
+	}
+}
+
+// WithTenants makes the EmailNotificationSender send the notifications with the settings of their tenant,
+// see repository.WithTenant: its sender address, and its subjects which take precedence over the catalog.
+// The notifications of the tenants which aren't in the repository are rejected with ErrUnknownTenant,
+// except the default tenant's which are sent with the default settings.
+func WithTenants(repo repository.TenantRepository) EmailNotificationSenderOption {
+	return func(e *EmailNotificationSender) {
+		e.tenants = repo
+	}
 }
 
 // NewEmailNotificationSender creates a new EmailNotificationSender instance.
 func NewEmailNotificationSender(rateLimitHandler RateLimitHandler,
 	mailClient Mailer,
 	userRepo repository.UserRepository,
-	cacheService Cache) *EmailNotificationSender {
-	return &EmailNotificationSender{
+	cacheService Cache,
+	opts ...EmailNotificationSenderOption) *EmailNotificationSender {
+	sender := &EmailNotificationSender{
 		rateLimitHandler: rateLimitHandler,
 		client:           mailClient,
 		userRepo:         userRepo,
 		cache:            cacheService,
+		now:              time.Now,
 	}
+	for _, opt := range opts {
+		opt(sender)
+	}
+	return sender
 }
 
 // EmailNotificationSender is the concrete email notification sender.
@@ -43,51 +111,159 @@ type EmailNotificationSender struct {
 	client           Mailer
 	userRepo         repository.UserRepository
 	cache            Cache
+	suppressions     repository.SuppressionRepository
+	deliveries       repository.DeliveryRepository
+	events           DeliveryEventPublisher
+	subjects         *Subjects
+	tenants          repository.TenantRepository
+	now              func() time.Time
 }
 
 // Send sends an email notification message to the given user depending on the notification type.
-// It returns ErrRateLimitExceeded if the notification being sent exceeds the pre-defined rate-limiting rules.
+// It returns ErrRateLimitExceeded if the notification being sent exceeds the pre-defined rate-limiting rules,
+// or ErrNoRateLimitRule if the notification type has no rule to enforce.
+// It returns ErrUnknownTenant if the tenant of ctx hasn't been provisioned.
 func (e EmailNotificationSender) Send(ctx context.Context,
-	userID string, notification domain.Notification) (retryAfter time.Duration, err error) {
-	log.Printf("processing notification sending for correlation ID %s", notification.CorrelationID)
-	defer log.Printf("processing complete")
+	userID string, notification domain.Notification) (result SendResult, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "NotificationSender.Send", trace.WithAttributes(
+		tracing.CorrelationIDKey.String(notification.CorrelationID),
+		tracing.NotificationTypeKey.String(notification.Type.String()),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	logger := logging.FromContext(ctx)
+	logger.Debug().Msg("processing notification")
+
+	tenant, err := e.resolveTenant(ctx)
+	if err != nil {
+		return SendResult{}, err
+	}
 
 	// idempotency check: ensures that the notification hasn't already been processed.
 	if e.isAlreadyProcessed(ctx, notification.CorrelationID) {
-		log.Printf("notification already processed, skipping sending")
-		return 0, newIdempotencyError(notification.CorrelationID)
+		logger.Info().Msg("notification already processed, skipping sending")
+		return SendResult{}, newIdempotencyError(notification.CorrelationID)
 	}
 
-	user, err := e.userRepo.Get(userID)
+	user, err := e.userRepo.Get(ctx, userID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get user: %w", err)
+		return SendResult{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	record := domain.DeliveryRecord{
+		CorrelationID: notification.CorrelationID,
+		UserID:        userID,
+		Type:          notification.Type,
+		CallbackURL:   notification.CallbackURL,
+		Caller:        notification.Caller,
+	}
+	e.track(ctx, record, domain.DeliveryAccepted, "")
+
+	if err = e.checkSuppression(ctx, user.Email); err != nil {
+		e.track(ctx, record, domain.DeliverySuppressed, err.Error())
+		e.emit(ctx, record, domain.EventFailed, err.Error())
+		return SendResult{}, err
 	}
 
 	lockResult, err := e.acquireRateLimitLock(ctx, userID, notification.Type)
 	if err != nil {
 		if lockResult != nil {
-			retryAfter = lockResult.RetryAfter
+			result = SendResult{RetryAfter: lockResult.RetryAfter, Limits: lockResult.Limits}
 		}
-		return retryAfter, err
+		switch {
+		case errors.Is(err, ErrRateLimitExceeded):
+			e.track(ctx, record, domain.DeliveryRateLimited, err.Error())
+			e.emit(ctx, record, domain.EventRateLimited, err.Error())
+		case errors.Is(err, ErrNoRateLimitRule):
+			// retrying won't help until a rule is defined for the notification type.
+			e.track(ctx, record, domain.DeliveryFailed, err.Error())
+			e.emit(ctx, record, domain.EventFailed, err.Error())
+		default:
+			e.track(ctx, record, domain.DeliveryDeferred, err.Error())
+		}
+		return result, err
 	}
+	result.Limits = lockResult.Limits
 
-	subject := e.defineSubject(notification.Type)
-	if err := e.client.SendEmail(user.Email, subject, notification.Message); err != nil {
+	e.track(ctx, record, domain.DeliverySending, "")
+	subject := e.defineSubject(tenant, notification.Type)
+	if tenant.MailFrom != "" {
+		ctx = WithSender(ctx, tenant.MailFrom)
+	}
+	messageID, err := e.sendEmail(ctx, user.Email, subject, notification.Message)
+	if err != nil {
 		// if the email could not be sent for any reason, release the rate-limit lock.
-		e.safeRollback(lockResult)
-		return 0, fmt.Errorf("failed to send email: %w", err)
+		e.safeRollback(ctx, lockResult)
+		result.Limits = result.Limits.released()
+		if errors.Is(err, ErrMailPermanent) {
+			e.track(ctx, record, domain.DeliveryFailed, err.Error())
+			e.emit(ctx, record, domain.EventFailed, err.Error())
+		} else {
+			e.track(ctx, record, domain.DeliveryDeferred, err.Error())
+		}
+		return result, fmt.Errorf("failed to send email: %w", err)
 	}
+	record.ProviderMessageID = messageID
+	e.track(ctx, record, domain.DeliverySent, "")
+	e.emit(ctx, record, domain.EventSent, "")
 
 	// If everything went fine, mark the current notification as processed
 	// for the idempotency check.
 	if err = e.markAsProcessed(ctx, notification.CorrelationID, time.Hour*24); err != nil {
-		return 0, fmt.Errorf("failed to mark notification as processed: %w", err)
+		return result, fmt.Errorf("failed to mark notification as processed: %w", err)
 	}
 
-	return 0, nil
+	return result, nil
 }
 
-func (e EmailNotificationSender) defineSubject(notificationType domain.NotificationType) string {
+// sendEmail sends the email through the Mailer, returning the provider
+// message ID whenever the Mailer is able to report it.
+func (e EmailNotificationSender) sendEmail(ctx context.Context, to, subject, msg string) (messageID string, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Mailer.SendEmail")
+	defer func() { tracing.End(span, err) }()
+
+	logger := logging.FromContext(ctx)
+	logger.Debug().Str("recipient", logging.RedactEmail(to)).Msg("sending email")
+
+	messageID, err = SendWithMessageID(ctx, e.client, to, subject, msg)
+	if err != nil {
+		logger.Warn().Err(err).Msg("email sending failed")
+		return "", err
+	}
+	logger.Info().Str("providerMessageId", messageID).Msg("email accepted by the provider")
+	return messageID, nil
+}
+
+// resolveTenant retrieves the tenant of ctx. The default tenant has the default settings
+// unless it's been provisioned.
+func (e EmailNotificationSender) resolveTenant(ctx context.Context) (domain.Tenant, error) {
+	tenantID := repository.TenantFromContext(ctx)
+	if e.tenants == nil {
+		return domain.Tenant{ID: tenantID}, nil
+	}
+	tenant, err := e.tenants.Get(ctx, tenantID)
+	if errors.Is(err, repository.ErrTenantNotFound) {
+		if tenantID == domain.DefaultTenantID {
+			return domain.Tenant{ID: tenantID}, nil
+		}
+		return domain.Tenant{}, fmt.Errorf("%w: %s", ErrUnknownTenant, tenantID)
+	}
+	if err != nil {
+		return domain.Tenant{}, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	return tenant, nil
+}
+
+func (e EmailNotificationSender) defineSubject(tenant domain.Tenant, notificationType domain.NotificationType) string {
+	if subject := tenant.Subjects[notificationType]; subject != "" {
+		return subject
+	}
+	if e.subjects != nil {
+		if subject, ok := e.subjects.Get(notificationType); ok {
+			return subject
+		}
+	}
+
 	var subject string
 	switch notificationType {
 	case domain.Status:
@@ -107,16 +283,88 @@ func (e EmailNotificationSender) defineSubject(notificationType domain.Notificat
 	return fmt.Sprintf("%s: %s", prefix, subject)
 }
 
+// checkSuppression returns ErrRecipientSuppressed if the email address is in the suppression list.
+func (e EmailNotificationSender) checkSuppression(ctx context.Context, email string) error {
+	if e.suppressions == nil {
+		return nil
+	}
+	suppression, err := e.suppressions.Get(ctx, email)
+	if errors.Is(err, repository.ErrSuppressionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check suppression list: %w", err)
+	}
+	logging.FromContext(ctx).Info().
+		Str("reason", suppression.Reason.String()).
+		Msg("recipient is suppressed, skipping sending")
+	return fmt.Errorf("%w: %s", ErrRecipientSuppressed, suppression.Reason)
+}
+
+// track records a transition of the notification delivery lifecycle. Tracking is best-effort:
+// failures are logged without interrupting the sending.
+func (e EmailNotificationSender) track(ctx context.Context, record domain.DeliveryRecord,
+	status domain.DeliveryStatus, detail string) {
+	if e.deliveries == nil {
+		return
+	}
+	transition := domain.StatusTransition{
+		Status: status,
+		Detail: detail,
+		At:     e.now(),
+	}
+	if err := e.deliveries.AddTransition(ctx, record, transition); err != nil {
+		logging.FromContext(ctx).Error().Err(err).Stringer("status", status).Msg("failed to track delivery status")
+	}
+}
+
+// emit publishes a delivery event if the notification has a callback URL. Like tracking,
+// publishing is best-effort: failures are logged without interrupting the sending.
+func (e EmailNotificationSender) emit(ctx context.Context, record domain.DeliveryRecord,
+	eventType domain.DeliveryEventType, detail string) {
+	if e.events == nil || record.CallbackURL == "" {
+		return
+	}
+	err := e.events.Publish(ctx, domain.DeliveryEvent{
+		ID:            newEventID(),
+		Type:          eventType,
+		CorrelationID: record.CorrelationID,
+		UserID:        record.UserID,
+		CallbackURL:   record.CallbackURL,
+		Detail:        detail,
+		OccurredAt:    e.now(),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error().Err(err).Stringer("event", eventType).Msg("failed to publish delivery event")
+	}
+}
+
 func (e EmailNotificationSender) isAlreadyProcessed(ctx context.Context, correlationID string) bool {
-	return e.cache.Get(ctx, correlationID) != ""
+	ctx, span := tracing.Tracer().Start(ctx, "NotificationSender.isAlreadyProcessed")
+	defer span.End()
+
+	processed := e.cache.Get(ctx, tenantCacheKey(ctx, idempotencyCacheKind, correlationID)) != ""
+	if !processed {
+		// the notifications processed before the idempotency keys had their own namespace are
+		// marked under their legacy key until it expires.
+		if key := legacyCacheKey(ctx, correlationID); key != "" {
+			processed = e.cache.Get(ctx, key) == processedMark
+		}
+	}
+	span.SetAttributes(attribute.Bool("notification.duplicate", processed))
+	return processed
 }
 
 func (e EmailNotificationSender) markAsProcessed(ctx context.Context,
 	correlationID string, expiration time.Duration) error {
-	log.Print("marking notification as processed")
-	return e.cache.Set(ctx, correlationID, "processed", expiration)
+	logging.FromContext(ctx).Debug().Msg("marking notification as processed")
+	return e.cache.Set(ctx, tenantCacheKey(ctx, idempotencyCacheKind, correlationID), processedMark, expiration)
 }
 
+// processedMark is the value of the idempotency keys, which tells them apart from the other keys
+// sharing their legacy namespace, such as the rate limit counters.
+const processedMark = "processed"
+
 func newIdempotencyError(correlationID string) error {
 	return errors.Join(ErrIdempotencyViolation,
 		fmt.Errorf("the notification of correlation ID %s has already been processed", correlationID))
@@ -124,23 +372,24 @@ func newIdempotencyError(correlationID string) error {
 
 func (e EmailNotificationSender) acquireRateLimitLock(ctx context.Context,
 	userID string, notificationType domain.NotificationType) (*LockResult, error) {
-
-	log.Print("acquiring rate limit lock for notification")
+	logger := logging.FromContext(ctx)
+	logger.Debug().Msg("acquiring rate limit lock for notification")
 
 	lockResult, err := e.rateLimitHandler.LockIfAvailable(ctx, userID, notificationType)
 	if err != nil {
 		if errors.Is(err, ErrRateLimitExceeded) {
-			log.Print("notification exceeds the rate limit")
+			logger.Info().Msg("notification exceeds the rate limit")
 			return lockResult, fmt.Errorf("notification type %s exceeds the rate limit: %w", notificationType, err)
 		}
-		log.Print("failed to acquire rate limit lock for notification")
+		logger.Error().Err(err).Msg("failed to acquire rate limit lock for notification")
 		return nil, fmt.Errorf("rate limit check fail: %w", err)
 	}
 	return lockResult, nil
 }
 
-func (e EmailNotificationSender) safeRollback(lockResult *LockResult) {
-	log.Print("rolling back rate-limit lock...")
+func (e EmailNotificationSender) safeRollback(ctx context.Context, lockResult *LockResult) {
+	logger := logging.FromContext(ctx)
+	logger.Debug().Msg("rolling back rate-limit lock")
 
 	if lockResult == nil {
 		// no need to roll back because the lock hasn't been acquired.
@@ -148,6 +397,6 @@ func (e EmailNotificationSender) safeRollback(lockResult *LockResult) {
 	}
 
 	if err := lockResult.Rollback(); err != nil {
-		log.Printf("rollback of rate-limit counter failed: %v", err)
+		logger.Error().Err(err).Msg("rollback of rate-limit counter failed")
 	}
 }
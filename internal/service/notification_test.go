@@ -3,11 +3,17 @@ package service_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"notification/internal/domain"
 	"notification/internal/repository"
 	"notification/internal/service"
+	"notification/internal/tracing"
+	"notification/internal/tracing/tracingtest"
 	"notification/mocks"
 	"testing"
 	"time"
@@ -15,11 +21,13 @@ import (
 
 func TestEmailNotification_Send(t *testing.T) {
 	t.Run("notification is sent", func(t *testing.T) {
+		limits := service.RateLimitLimits{{Limit: 3, Remaining: 2, Window: time.Hour, Reset: time.Hour}}
 		rateLimitHandler := mocks.NewRateLimitHandler(t)
 		rateLimitHandler.
 			On("LockIfAvailable", mock.Anything, mock.Anything, mock.Anything).
 			Return(&service.LockResult{
 				RetryAfter: time.Duration(0),
+				Limits:     limits,
 			}, nil)
 
 		mailer := mocks.NewMailSender(t)
@@ -29,7 +37,7 @@ func TestEmailNotification_Send(t *testing.T) {
 
 		userRepo := mocks.NewUserRepository(t)
 		userRepo.
-			On("Get", mock.Anything).
+			On("Get", mock.Anything, mock.Anything).
 			Return(domain.User{}, nil)
 
 		cacheSvc := mocks.NewCache(t)
@@ -46,9 +54,89 @@ func TestEmailNotification_Send(t *testing.T) {
 			Message:       "Hey there!",
 		}
 
+		svc := service.NewEmailNotificationSender(rateLimitHandler, mailer, userRepo, cacheSvc)
+		result, err := svc.Send(context.Background(), "user1", notification)
+		assert.NoError(t, err)
+		assert.Equal(t, limits, result.Limits)
+	})
+
+	t.Run("notification is sent with the subject of the catalog", func(t *testing.T) {
+		rateLimitHandler := mocks.NewRateLimitHandler(t)
+		rateLimitHandler.
+			On("LockIfAvailable", mock.Anything, mock.Anything, mock.Anything).
+			Return(&service.LockResult{
+				RetryAfter: time.Duration(0),
+			}, nil)
+
+		mailer := mocks.NewMessageIDMailer(t)
+		mailer.
+			On("SendEmailWithID", "john@example.com", "Weekly offers", "Hey there!").
+			Return("provider-message-id", nil)
+
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.
+			On("Get", mock.Anything, mock.Anything).
+			Return(domain.User{Email: "john@example.com"}, nil)
+
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, mock.Anything).
+			Return("")
+		cacheSvc.
+			On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		notification := domain.Notification{
+			CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+			Type:          domain.Marketing,
+			Message:       "Hey there!",
+		}
+
+		subjects := service.NewSubjects(map[domain.NotificationType]string{
+			domain.Marketing: "Weekly offers",
+		})
+		svc := service.NewEmailNotificationSender(rateLimitHandler, mailer, userRepo, cacheSvc,
+			service.WithSubjects(subjects))
+		_, err := svc.Send(context.Background(), "user1", notification)
+		assert.NoError(t, err)
+	})
+
+	t.Run("notification is sent through a provider reporting message IDs", func(t *testing.T) {
+		rateLimitHandler := mocks.NewRateLimitHandler(t)
+		rateLimitHandler.
+			On("LockIfAvailable", mock.Anything, mock.Anything, mock.Anything).
+			Return(&service.LockResult{
+				RetryAfter: time.Duration(0),
+			}, nil)
+
+		mailer := mocks.NewMessageIDMailer(t)
+		mailer.
+			On("SendEmailWithID", "john@example.com", "Marketing: we've got a new offer for you!", "Hey there!").
+			Return("provider-message-id", nil)
+
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.
+			On("Get", mock.Anything, mock.Anything).
+			Return(domain.User{Email: "john@example.com"}, nil)
+
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, mock.Anything).
+			Return("")
+		cacheSvc.
+			On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		notification := domain.Notification{
+			CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+			Type:          domain.Marketing,
+			Message:       "Hey there!",
+		}
+
 		svc := service.NewEmailNotificationSender(rateLimitHandler, mailer, userRepo, cacheSvc)
 		_, err := svc.Send(context.Background(), "user1", notification)
 		assert.NoError(t, err)
+		mailer.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything, mock.Anything)
 	})
 
 	t.Run("rate limit exceeded", func(t *testing.T) {
@@ -70,7 +158,7 @@ func TestEmailNotification_Send(t *testing.T) {
 
 		userRepo := mocks.NewUserRepository(t)
 		userRepo.
-			On("Get", mock.Anything).
+			On("Get", mock.Anything, mock.Anything).
 			Return(domain.User{}, nil)
 
 		cacheSvc := mocks.NewCache(t)
@@ -85,9 +173,9 @@ func TestEmailNotification_Send(t *testing.T) {
 		}
 
 		svc := service.NewEmailNotificationSender(rateLimitHandler, mailer, userRepo, cacheSvc)
-		gotRetryAfter, err := svc.Send(context.Background(), "user1", notification)
+		result, err := svc.Send(context.Background(), "user1", notification)
 		assert.ErrorIs(t, err, service.ErrRateLimitExceeded)
-		assert.Equal(t, retryAfter, gotRetryAfter)
+		assert.Equal(t, retryAfter, result.RetryAfter)
 
 		mailer.AssertNotCalled(t, "SendEmail")
 		cacheSvc.AssertNotCalled(t, "Set")
@@ -110,7 +198,7 @@ func TestEmailNotification_Send(t *testing.T) {
 
 		userRepo := mocks.NewUserRepository(t)
 		userRepo.
-			On("Get", mock.Anything).
+			On("Get", mock.Anything, mock.Anything).
 			Return(domain.User{}, repository.ErrInvalidUserID)
 
 		cacheSvc := mocks.NewCache(t)
@@ -133,6 +221,44 @@ func TestEmailNotification_Send(t *testing.T) {
 		cacheSvc.AssertNotCalled(t, "Set")
 	})
 
+	t.Run("recipient is suppressed", func(t *testing.T) {
+		rateLimitHandler := mocks.NewRateLimitHandler(t)
+		mailer := mocks.NewMailSender(t)
+
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.
+			On("Get", mock.Anything, mock.Anything).
+			Return(domain.User{Email: "john@example.com"}, nil)
+
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, mock.Anything).
+			Return("")
+
+		suppressions := mocks.NewSuppressionRepository(t)
+		suppressions.
+			On("Get", mock.Anything, "john@example.com").
+			Return(domain.Suppression{
+				Email:  "john@example.com",
+				Reason: domain.SuppressedByBounce,
+			}, nil)
+
+		notification := domain.Notification{
+			CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+			Type:          domain.Marketing,
+			Message:       "Hey there!",
+		}
+
+		svc := service.NewEmailNotificationSender(rateLimitHandler, mailer, userRepo, cacheSvc,
+			service.WithSuppressionList(suppressions))
+		_, err := svc.Send(context.Background(), "user1", notification)
+		assert.ErrorIs(t, err, service.ErrRecipientSuppressed)
+
+		rateLimitHandler.AssertNotCalled(t, "LockIfAvailable")
+		mailer.AssertNotCalled(t, "SendEmail")
+		cacheSvc.AssertNotCalled(t, "Set")
+	})
+
 	t.Run("notification has already been processed", func(t *testing.T) {
 		rateLimitHandler := mocks.NewRateLimitHandler(t)
 		rateLimitHandler.
@@ -150,7 +276,7 @@ func TestEmailNotification_Send(t *testing.T) {
 
 		userRepo := mocks.NewUserRepository(t)
 		userRepo.
-			On("Get", mock.Anything).
+			On("Get", mock.Anything, mock.Anything).
 			Return(domain.User{}, nil).
 			Maybe()
 
@@ -190,6 +316,7 @@ func TestEmailNotification_Send(t *testing.T) {
 			Return(&service.LockResult{
 				RetryAfter: time.Duration(0),
 				Rollback:   spyRollback,
+				Limits:     service.RateLimitLimits{{Limit: 3, Remaining: 0, Window: time.Hour, Reset: time.Hour}},
 			}, nil)
 
 		mailer := mocks.NewMailSender(t)
@@ -199,7 +326,7 @@ func TestEmailNotification_Send(t *testing.T) {
 
 		userRepo := mocks.NewUserRepository(t)
 		userRepo.
-			On("Get", mock.Anything).
+			On("Get", mock.Anything, mock.Anything).
 			Return(domain.User{}, nil)
 
 		cacheSvc := mocks.NewCache(t)
@@ -218,15 +345,488 @@ func TestEmailNotification_Send(t *testing.T) {
 		}
 
 		svc := service.NewEmailNotificationSender(rateLimitHandler, mailer, userRepo, cacheSvc)
-		_, err := svc.Send(context.Background(), "user1", notification)
+		result, err := svc.Send(context.Background(), "user1", notification)
 		assert.Error(t, err)
 
 		t.Run("lock is rolled back", func(t *testing.T) {
 			assert.True(t, rolledBack)
 		})
+		t.Run("released token is remaining", func(t *testing.T) {
+			assert.Equal(t, service.RateLimitLimits{{Limit: 3, Remaining: 1, Window: time.Hour, Reset: time.Hour}},
+				result.Limits)
+		})
 
 		t.Run("notification is not marked as processed", func(t *testing.T) {
 			cacheSvc.AssertNotCalled(t, "Set")
 		})
 	})
 }
+
+func TestEmailNotification_Send_Tracing(t *testing.T) {
+	exporter := tracingtest.Setup(t)
+
+	notification := domain.Notification{
+		CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+		Type:          domain.Marketing,
+		Message:       "Hey there!",
+	}
+
+	cacheSvc := mocks.NewCache(t)
+	cacheSvc.
+		On("Get", mock.Anything, mock.Anything).
+		Return("")
+	cacheSvc.
+		On("Incr", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	cacheSvc.
+		On("TTL", mock.Anything, mock.Anything).
+		Return(time.Minute, nil)
+	cacheSvc.
+		On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	rateLimitHandler := service.NewCacheRateLimitHandler(cacheSvc, repository.NewInMemoryRateLimitRuleRepository(),
+		service.WithDefaultRule(domain.RateLimitRule{MaxCount: 5, Expiration: time.Hour}))
+
+	userRepo := mocks.NewUserRepository(t)
+	userRepo.
+		On("Get", mock.Anything, mock.Anything).
+		Return(domain.User{Email: "john@example.com"}, nil)
+
+	var mailerSpan trace.SpanContext
+	mailer := mocks.NewContextMailer(t)
+	mailer.
+		On("SendEmailContext", mock.Anything, "john@example.com", mock.Anything, "Hey there!").
+		Run(func(args mock.Arguments) {
+			mailerSpan = trace.SpanContextFromContext(args.Get(0).(context.Context))
+		}).
+		Return("provider-message-id", nil)
+
+	svc := service.NewEmailNotificationSender(rateLimitHandler, mailer, userRepo, cacheSvc)
+	_, err := svc.Send(context.Background(), "user1", notification)
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Equal(t, []string{
+		"NotificationSender.isAlreadyProcessed",
+		"RateLimitHandler.LockIfAvailable",
+		"Mailer.SendEmail",
+		"NotificationSender.Send",
+	}, tracingtest.SpanNames(spans))
+
+	root := spans[3]
+	assert.False(t, root.Parent.IsValid())
+	assert.Contains(t, root.Attributes, tracing.CorrelationIDKey.String(notification.CorrelationID))
+	assert.Contains(t, root.Attributes, tracing.NotificationTypeKey.String("marketing"))
+	for _, span := range spans[:3] {
+		assert.Equal(t, root.SpanContext.SpanID(), span.Parent.SpanID(), span.Name)
+	}
+	assert.Contains(t, spans[0].Attributes, attribute.Bool("notification.duplicate", false))
+	// the mailer continues the trace.
+	assert.Equal(t, spans[2].SpanContext, mailerSpan)
+}
+
+func TestEmailNotification_Send_DeliveryTracking(t *testing.T) {
+	notification := domain.Notification{
+		CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+		Type:          domain.Marketing,
+		Message:       "Hey there!",
+		Caller:        "billing-service",
+	}
+
+	statuses := func(record domain.DeliveryRecord) []domain.DeliveryStatus {
+		var got []domain.DeliveryStatus
+		for _, transition := range record.Transitions {
+			got = append(got, transition.Status)
+		}
+		return got
+	}
+
+	newCache := func(t *testing.T) *mocks.Cache {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, mock.Anything).
+			Return("")
+		cacheSvc.
+			On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil).
+			Maybe()
+		return cacheSvc
+	}
+
+	newUserRepo := func(t *testing.T) *mocks.UserRepository {
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.
+			On("Get", mock.Anything, mock.Anything).
+			Return(domain.User{Email: "john@example.com"}, nil)
+		return userRepo
+	}
+
+	t.Run("sent notification", func(t *testing.T) {
+		rateLimitHandler := mocks.NewRateLimitHandler(t)
+		rateLimitHandler.
+			On("LockIfAvailable", mock.Anything, mock.Anything, mock.Anything).
+			Return(&service.LockResult{}, nil)
+
+		mailer := mocks.NewMessageIDMailer(t)
+		mailer.
+			On("SendEmailWithID", mock.Anything, mock.Anything, mock.Anything).
+			Return("provider-message-id", nil)
+
+		deliveries := repository.NewInMemoryDeliveryRepository()
+		svc := service.NewEmailNotificationSender(rateLimitHandler, mailer, newUserRepo(t), newCache(t),
+			service.WithDeliveryTracking(deliveries))
+		_, err := svc.Send(context.Background(), "user1", notification)
+		require.NoError(t, err)
+
+		record, err := deliveries.Get(context.Background(), notification.CorrelationID)
+		require.NoError(t, err)
+		assert.Equal(t, "user1", record.UserID)
+		assert.Equal(t, domain.DeliverySent, record.Status)
+		assert.Equal(t, "provider-message-id", record.ProviderMessageID)
+		assert.Equal(t, "billing-service", record.Caller)
+		assert.Equal(t, []domain.DeliveryStatus{
+			domain.DeliveryAccepted,
+			domain.DeliverySending,
+			domain.DeliverySent,
+		}, statuses(record))
+	})
+
+	t.Run("rate-limited notification", func(t *testing.T) {
+		rateLimitHandler := mocks.NewRateLimitHandler(t)
+		rateLimitHandler.
+			On("LockIfAvailable", mock.Anything, mock.Anything, mock.Anything).
+			Return(&service.LockResult{RetryAfter: time.Minute}, service.ErrRateLimitExceeded)
+
+		deliveries := repository.NewInMemoryDeliveryRepository()
+		svc := service.NewEmailNotificationSender(rateLimitHandler, mocks.NewMailer(t), newUserRepo(t), newCache(t),
+			service.WithDeliveryTracking(deliveries))
+		_, err := svc.Send(context.Background(), "user1", notification)
+		require.ErrorIs(t, err, service.ErrRateLimitExceeded)
+
+		record, err := deliveries.Get(context.Background(), notification.CorrelationID)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.DeliveryStatus{
+			domain.DeliveryAccepted,
+			domain.DeliveryRateLimited,
+		}, statuses(record))
+	})
+
+	t.Run("notification type without rate limit rule", func(t *testing.T) {
+		rateLimitHandler := mocks.NewRateLimitHandler(t)
+		rateLimitHandler.
+			On("LockIfAvailable", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, service.ErrNoRateLimitRule)
+
+		deliveries := repository.NewInMemoryDeliveryRepository()
+		svc := service.NewEmailNotificationSender(rateLimitHandler, mocks.NewMailer(t), newUserRepo(t), newCache(t),
+			service.WithDeliveryTracking(deliveries))
+		retryAfter, err := svc.Send(context.Background(), "user1", notification)
+		require.ErrorIs(t, err, service.ErrNoRateLimitRule)
+		assert.Zero(t, retryAfter)
+
+		record, err := deliveries.Get(context.Background(), notification.CorrelationID)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.DeliveryStatus{
+			domain.DeliveryAccepted,
+			domain.DeliveryFailed,
+		}, statuses(record))
+	})
+
+	t.Run("mail failures", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			mailErr    error
+			wantStatus domain.DeliveryStatus
+		}{
+			{
+				name:       "retryable failure is deferred",
+				mailErr:    fmt.Errorf("oops: %w", service.ErrMailRetryable),
+				wantStatus: domain.DeliveryDeferred,
+			},
+			{
+				name:       "permanent failure is failed",
+				mailErr:    fmt.Errorf("oops: %w", service.ErrMailPermanent),
+				wantStatus: domain.DeliveryFailed,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rateLimitHandler := mocks.NewRateLimitHandler(t)
+				rateLimitHandler.
+					On("LockIfAvailable", mock.Anything, mock.Anything, mock.Anything).
+					Return(&service.LockResult{Rollback: func() error { return nil }}, nil)
+
+				mailer := mocks.NewMailer(t)
+				mailer.
+					On("SendEmail", mock.Anything, mock.Anything, mock.Anything).
+					Return(tt.mailErr)
+
+				deliveries := repository.NewInMemoryDeliveryRepository()
+				svc := service.NewEmailNotificationSender(rateLimitHandler, mailer, newUserRepo(t), newCache(t),
+					service.WithDeliveryTracking(deliveries))
+				_, err := svc.Send(context.Background(), "user1", notification)
+				require.Error(t, err)
+
+				record, err := deliveries.Get(context.Background(), notification.CorrelationID)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantStatus, record.Status)
+				assert.Equal(t, tt.mailErr.Error(), record.Transitions[len(record.Transitions)-1].Detail)
+			})
+		}
+	})
+}
+
+func TestEmailNotification_Send_DeliveryEvents(t *testing.T) {
+	newSender := func(t *testing.T, lockErr error, publisher service.DeliveryEventPublisher) *service.EmailNotificationSender {
+		rateLimitHandler := mocks.NewRateLimitHandler(t)
+		rateLimitHandler.
+			On("LockIfAvailable", mock.Anything, mock.Anything, mock.Anything).
+			Return(&service.LockResult{}, lockErr)
+
+		mailer := mocks.NewMessageIDMailer(t)
+		mailer.
+			On("SendEmailWithID", mock.Anything, mock.Anything, mock.Anything).
+			Return("provider-message-id", nil).
+			Maybe()
+
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.
+			On("Get", mock.Anything, mock.Anything).
+			Return(domain.User{Email: "john@example.com"}, nil)
+
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, mock.Anything).
+			Return("")
+		cacheSvc.
+			On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil).
+			Maybe()
+
+		return service.NewEmailNotificationSender(rateLimitHandler, mailer, userRepo, cacheSvc,
+			service.WithDeliveryEvents(publisher))
+	}
+
+	t.Run("sent event is published", func(t *testing.T) {
+		publisher := mocks.NewDeliveryEventPublisher(t)
+		publisher.
+			On("Publish", mock.Anything, mock.MatchedBy(func(event domain.DeliveryEvent) bool {
+				return event.Type == domain.EventSent &&
+					event.CorrelationID == "0990cc56-f1b7-4f69-bc60-08fac22d41bd" &&
+					event.UserID == "user1" &&
+					event.CallbackURL == "https://example.com/callback" &&
+					event.ID != ""
+			})).
+			Return(nil)
+
+		svc := newSender(t, nil, publisher)
+		_, err := svc.Send(context.Background(), "user1", domain.Notification{
+			CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+			Type:          domain.Marketing,
+			Message:       "Hey there!",
+			CallbackURL:   "https://example.com/callback",
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("rate-limited event is published", func(t *testing.T) {
+		publisher := mocks.NewDeliveryEventPublisher(t)
+		publisher.
+			On("Publish", mock.Anything, mock.MatchedBy(func(event domain.DeliveryEvent) bool {
+				return event.Type == domain.EventRateLimited
+			})).
+			Return(nil)
+
+		svc := newSender(t, service.ErrRateLimitExceeded, publisher)
+		_, err := svc.Send(context.Background(), "user1", domain.Notification{
+			CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+			Type:          domain.Marketing,
+			Message:       "Hey there!",
+			CallbackURL:   "https://example.com/callback",
+		})
+		require.ErrorIs(t, err, service.ErrRateLimitExceeded)
+	})
+
+	t.Run("no event without callback URL", func(t *testing.T) {
+		publisher := mocks.NewDeliveryEventPublisher(t)
+
+		svc := newSender(t, nil, publisher)
+		_, err := svc.Send(context.Background(), "user1", domain.Notification{
+			CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+			Type:          domain.Marketing,
+			Message:       "Hey there!",
+		})
+		require.NoError(t, err)
+		publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEmailNotification_Send_IdempotencyKeys(t *testing.T) {
+	notification := domain.Notification{
+		CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+		Type:          domain.Marketing,
+		Message:       "Hey there!",
+	}
+	// the unknown user stops the notifications passing the idempotency check.
+	newUserRepo := func(t *testing.T) repository.UserRepository {
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.
+			On("Get", mock.Anything, "user1").
+			Return(domain.User{}, repository.ErrInvalidUserID).Maybe()
+		return userRepo
+	}
+
+	t.Run("notification marked under its legacy key is a duplicate", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:default:idempotency:"+notification.CorrelationID).
+			Return("")
+		cacheSvc.
+			On("Get", mock.Anything, notification.CorrelationID).
+			Return("processed")
+
+		svc := service.NewEmailNotificationSender(mocks.NewRateLimitHandler(t), mocks.NewMailer(t),
+			newUserRepo(t), cacheSvc)
+		_, err := svc.Send(context.Background(), "user1", notification)
+		assert.ErrorIs(t, err, service.ErrIdempotencyViolation)
+	})
+
+	t.Run("legacy key of another kind isn't a mark", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:default:idempotency:user1:marketing").
+			Return("")
+		cacheSvc.
+			On("Get", mock.Anything, "user1:marketing").
+			Return("3")
+
+		svc := service.NewEmailNotificationSender(mocks.NewRateLimitHandler(t), mocks.NewMailer(t),
+			newUserRepo(t), cacheSvc)
+		_, err := svc.Send(context.Background(), "user1",
+			domain.Notification{CorrelationID: "user1:marketing", Type: domain.Marketing, Message: "Hey there!"})
+		assert.NotErrorIs(t, err, service.ErrIdempotencyViolation)
+	})
+
+	t.Run("keys of another tenant aren't read", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:default:idempotency:tenant:billing:"+notification.CorrelationID).
+			Return("").Once()
+
+		svc := service.NewEmailNotificationSender(mocks.NewRateLimitHandler(t), mocks.NewMailer(t),
+			newUserRepo(t), cacheSvc)
+		_, err := svc.Send(context.Background(), "user1", domain.Notification{
+			CorrelationID: "tenant:billing:" + notification.CorrelationID,
+			Type:          domain.Marketing,
+			Message:       "Hey there!",
+		})
+		assert.NotErrorIs(t, err, service.ErrIdempotencyViolation)
+	})
+}
+
+func TestEmailNotification_Send_Tenants(t *testing.T) {
+	billingCtx := repository.WithTenant(context.Background(), "billing")
+	notification := domain.Notification{
+		CorrelationID: "0990cc56-f1b7-4f69-bc60-08fac22d41bd",
+		Type:          domain.Marketing,
+		Message:       "Hey there!",
+	}
+
+	userRepo := repository.NewInMemoryUserRepository()
+	require.NoError(t, userRepo.Save(billingCtx, domain.User{ID: "user1", Email: "john@example.com"}))
+
+	tenantsRepo := repository.NewInMemoryTenantRepository()
+	require.NoError(t, tenantsRepo.Save(context.Background(), domain.Tenant{
+		ID:       "billing",
+		MailFrom: "Billing <billing@example.com>",
+		Subjects: map[domain.NotificationType]string{domain.Marketing: "Billing offers"},
+	}))
+
+	newRateLimitHandler := func(t *testing.T) service.RateLimitHandler {
+		rateLimitHandler := mocks.NewRateLimitHandler(t)
+		rateLimitHandler.
+			On("LockIfAvailable", mock.Anything, mock.Anything, mock.Anything).
+			Return(&service.LockResult{Rollback: func() error { return nil }}, nil).Maybe()
+		return rateLimitHandler
+	}
+
+	t.Run("notification is sent with the tenant settings", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:idempotency:"+notification.CorrelationID).
+			Return("")
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:"+notification.CorrelationID).
+			Return("")
+		cacheSvc.
+			On("Set", mock.Anything, "tenant:billing:idempotency:"+notification.CorrelationID,
+				mock.Anything, mock.Anything).
+			Return(nil)
+
+		mailer := mocks.NewContextMailer(t)
+		mailer.
+			On("SendEmailContext", mock.MatchedBy(func(ctx context.Context) bool {
+				return service.SenderFromContext(ctx, "") == "Billing <billing@example.com>"
+			}), "john@example.com", "Billing offers", "Hey there!").
+			Return("provider-message-id", nil)
+
+		svc := service.NewEmailNotificationSender(newRateLimitHandler(t), mailer, userRepo, cacheSvc,
+			service.WithTenants(tenantsRepo),
+			service.WithSubjects(service.NewSubjects(map[domain.NotificationType]string{
+				domain.Marketing: "Weekly offers",
+			})))
+		_, err := svc.Send(billingCtx, "user1", notification)
+		assert.NoError(t, err)
+	})
+
+	t.Run("default tenant isn't required to be provisioned", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:default:idempotency:"+notification.CorrelationID).
+			Return("")
+		cacheSvc.
+			On("Get", mock.Anything, notification.CorrelationID).
+			Return("")
+		cacheSvc.
+			On("Set", mock.Anything, "tenant:default:idempotency:"+notification.CorrelationID,
+				mock.Anything, mock.Anything).
+			Return(nil)
+
+		mailer := mocks.NewContextMailer(t)
+		mailer.
+			On("SendEmailContext", mock.MatchedBy(func(ctx context.Context) bool {
+				return service.SenderFromContext(ctx, "") == ""
+			}), "jane@example.com", "Marketing: we've got a new offer for you!", "Hey there!").
+			Return("provider-message-id", nil)
+
+		defaultUsers := mocks.NewUserRepository(t)
+		defaultUsers.
+			On("Get", mock.Anything, "user2").
+			Return(domain.User{ID: "user2", Email: "jane@example.com"}, nil)
+
+		svc := service.NewEmailNotificationSender(newRateLimitHandler(t), mailer, defaultUsers, cacheSvc,
+			service.WithTenants(tenantsRepo))
+		_, err := svc.Send(context.Background(), "user2", notification)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		svc := service.NewEmailNotificationSender(newRateLimitHandler(t), mocks.NewMailer(t), userRepo,
+			mocks.NewCache(t), service.WithTenants(tenantsRepo))
+		_, err := svc.Send(repository.WithTenant(context.Background(), "unknown"), "user1", notification)
+		assert.ErrorIs(t, err, service.ErrUnknownTenant)
+	})
+
+	t.Run("user of another tenant", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, mock.Anything).
+			Return("")
+
+		svc := service.NewEmailNotificationSender(newRateLimitHandler(t), mocks.NewMailer(t), userRepo, cacheSvc,
+			service.WithTenants(tenantsRepo))
+		_, err := svc.Send(context.Background(), "user1", notification)
+		assert.ErrorIs(t, err, repository.ErrInvalidUserID)
+	})
+}
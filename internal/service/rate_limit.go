@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"go.opentelemetry.io/otel/trace"
 	"notification/internal/domain"
+	"notification/internal/logging"
 	"notification/internal/repository"
+	"notification/internal/tracing"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,6 +18,12 @@ var (
 	// ErrRateLimitExceeded is the error when the notification cannot be sent because
 	// it exceeds the rate limiting rules defined.
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+	// ErrNoRateLimitRule is the error when the notification type has no rate limit rule
+	// and there's no default rule to fall back to.
+	ErrNoRateLimitRule = errors.New("no rate limit rule")
+	// ErrTenantQuotaExceeded is the error when the notification cannot be sent because it exceeds
+	// the quota of its tenant. It's always joined with ErrRateLimitExceeded.
+	ErrTenantQuotaExceeded = errors.New("tenant quota exceeded")
 )
 
 // RollbackFunc is the function to roll back the rate limit lock operation.
@@ -26,6 +36,52 @@ type LockResult struct {
 	RetryAfter time.Duration
 	// Rollback releases the token allocated by RateLimitHandler.LockIfAvailable.
 	Rollback RollbackFunc
+	// Limits are the limits the notification is subject to once the lock is acquired, or as they
+	// stand when it isn't. See RateLimitHandler.Quota.
+	Limits RateLimitLimits
+}
+
+// RateLimitStatus is the status of a limit a notification is subject to: either the rate limit rule
+// of its type, or the quota of its tenant.
+type RateLimitStatus struct {
+	// Limit is the max notification count allowed for the time span.
+	Limit int
+	// Remaining is the notification count left in the time span.
+	Remaining int
+	// Window is the time span of the limit.
+	Window time.Duration
+	// Reset is how long until the count is reset, at most: the time left of the time span, which
+	// each notification restarts, as soon as anything is counted, and zero otherwise.
+	Reset time.Duration
+}
+
+// RateLimitLimits are the limits a notification is subject to: the rate limit rule of its type, unless
+// it's unlimited, then the quota of its tenant, if any. No limits means the notification is unlimited.
+type RateLimitLimits []RateLimitStatus
+
+// Closest returns the limit closest to exhaustion, the first one in case of a tie,
+// and whether there's any limit.
+func (l RateLimitLimits) Closest() (RateLimitStatus, bool) {
+	if len(l) == 0 {
+		return RateLimitStatus{}, false
+	}
+	closest := l[0]
+	for _, status := range l[1:] {
+		if status.Remaining < closest.Remaining {
+			closest = status
+		}
+	}
+	return closest, true
+}
+
+// released returns the limits as they stand once the token locked for the notification is released.
+func (l RateLimitLimits) released() RateLimitLimits {
+	limits := make(RateLimitLimits, 0, len(l))
+	for _, status := range l {
+		status.Remaining = min(status.Remaining+1, status.Limit)
+		limits = append(limits, status)
+	}
+	return limits
 }
 
 // RateLimitHandler is the abstract representation of the rate limit checker,
@@ -42,14 +98,61 @@ type RateLimitHandler interface {
 	// It's the caller's responsibility to release the lock using the LockResult.Rollback function
 	// when handling failure scenarios.
 	LockIfAvailable(ctx context.Context, userID string, notificationType domain.NotificationType) (*LockResult, error)
+	// Quota returns the limits the notifications of the given type sent to the given user are subject to,
+	// as LockIfAvailable would, without locking any token.
+	//
+	// It returns ErrNoRateLimitRule if the notification type has no rule to enforce.
+	Quota(ctx context.Context, userID string, notificationType domain.NotificationType) (RateLimitLimits, error)
+}
+
+// CacheRateLimitHandlerOption defines the optional params for CacheRateLimitHandler.
+type CacheRateLimitHandlerOption func(*CacheRateLimitHandler)
+
+// WithDefaultRule sets the rule applied to the notification types without a rate limit rule,
+// which are rejected with ErrNoRateLimitRule otherwise.
+func WithDefaultRule(rule domain.RateLimitRule) CacheRateLimitHandlerOption {
+	return func(h *CacheRateLimitHandler) {
+		h.defaultRule = &rule
+	}
+}
+
+// WithTenantQuotas enforces the quotas of the tenants of the given repository, on top of the rate limit
+// rules. The tenants which aren't in the repository have no quota.
+func WithTenantQuotas(repo repository.TenantRepository) CacheRateLimitHandlerOption {
+	return func(h *CacheRateLimitHandler) {
+		h.tenants = repo
+	}
+}
+
+// WithOverrides applies the rate limit overrides of the given repository to the users they're set for,
+// and to the users of the segments they're set for, whose segments are read from users.
+func WithOverrides(overrides repository.RateLimitOverrideRepository,
+	users repository.UserRepository) CacheRateLimitHandlerOption {
+	return func(h *CacheRateLimitHandler) {
+		h.overrides = overrides
+		h.users = users
+	}
+}
+
+// WithExemptions lifts the rate limits of the notification types having an active exemption in the
+// given repository.
+func WithExemptions(repo repository.RateLimitExemptionRepository) CacheRateLimitHandlerOption {
+	return func(h *CacheRateLimitHandler) {
+		h.exemptions = repo
+	}
 }
 
 // NewCacheRateLimitHandler creates a new CacheRateLimitHandler instance.
-func NewCacheRateLimitHandler(cacheService Cache, rulesRepo repository.RateLimitRuleRepository) *CacheRateLimitHandler {
-	return &CacheRateLimitHandler{
+func NewCacheRateLimitHandler(cacheService Cache, rulesRepo repository.RateLimitRuleRepository,
+	opts ...CacheRateLimitHandlerOption) *CacheRateLimitHandler {
+	h := &CacheRateLimitHandler{
 		cacheService: cacheService,
 		repo:         rulesRepo,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // CacheRateLimitHandler handles the rate limiting checks and state
@@ -57,6 +160,11 @@ func NewCacheRateLimitHandler(cacheService Cache, rulesRepo repository.RateLimit
 type CacheRateLimitHandler struct {
 	cacheService Cache
 	repo         repository.RateLimitRuleRepository
+	defaultRule  *domain.RateLimitRule
+	tenants      repository.TenantRepository
+	overrides    repository.RateLimitOverrideRepository
+	users        repository.UserRepository
+	exemptions   repository.RateLimitExemptionRepository
 }
 
 // LockIfAvailable locks a token in the rate-limit filter, ensuring the resource is
@@ -68,64 +176,328 @@ type CacheRateLimitHandler struct {
 //
 // It's the caller's responsibility to release the lock using the LockResult.Rollback function
 // when handling failure scenarios.
+//
+// The rules and counters are the ones of the tenant carried by ctx, see repository.WithTenant.
+// The rule applied is, in order of precedence:
+//  1. unlimited, if the notification type has an active exemption, see WithExemptions;
+//  2. the rule of the user override for the notification type, then its default rule;
+//  3. the rule of the override of each segment of the user, in the order of the segments;
+//  4. the rule of the notification type, then the one of the default tenant;
+//  5. the default rule, if any, or else the notification is rejected with ErrNoRateLimitRule.
+//
+// Unlimited rules don't lock anything.
+//
+// The notifications of the tenants having a quota lock a token of the tenant quota as well,
+// unlimited rules included. The lock isn't possible if either the rule or the quota has no
+// capacity available, in which case ErrTenantQuotaExceeded is joined to ErrRateLimitExceeded
+// when it's the quota.
 func (h CacheRateLimitHandler) LockIfAvailable(ctx context.Context,
-	userID string, notificationType domain.NotificationType) (*LockResult, error) {
-	key := fmt.Sprintf("%s:%s", userID, notificationType)
-	rule, err := h.repo.GetByNotificationType(notificationType)
-	if err != nil {
-		return nil, fmt.Errorf("get rate limit rule by notification type fail: %w", err)
-	}
+	userID string, notificationType domain.NotificationType) (lockResult *LockResult, err error) {
+	// the rollback isn't part of the span, so it keeps ctx.
+	spanCtx, span := tracing.Tracer().Start(ctx, "RateLimitHandler.LockIfAvailable",
+		trace.WithAttributes(tracing.NotificationTypeKey.String(notificationType.String())))
+	defer func() { tracing.End(span, err) }()
 
-	// check if the lock can be acquired
-	ok, err := h.checkAvailability(ctx, key, rule.MaxCount)
+	counters, err := h.resolveCounters(spanCtx, userID, notificationType)
 	if err != nil {
-		return nil, fmt.Errorf("check availability fail: %w", err)
+		return nil, err
 	}
 
-	if !ok {
-		return &LockResult{
-			RetryAfter: rule.Expiration,
-		}, ErrRateLimitExceeded
+	// check if the lock can be acquired
+	counts := make([]int, len(counters))
+	for i, counter := range counters {
+		if counts[i], err = h.count(spanCtx, counter.key); err != nil {
+			return nil, fmt.Errorf("check availability fail: %w", err)
+		}
 	}
-
-	// allocate a token by incrementing the counter
-	if err = h.incrementCount(ctx, key, rule.Expiration); err != nil {
-		return nil, fmt.Errorf("increment count fail: %w", err)
+	for i, counter := range counters {
+		if counts[i] >= counter.rule.MaxCount {
+			limits := h.limitStatuses(spanCtx, counters, counts)
+			// the next token is available once the count is reset, and nothing is counted when
+			// the rule allows none.
+			retryAfter := limits[i].Reset
+			if retryAfter == 0 {
+				retryAfter = counter.rule.Expiration
+			}
+			return &LockResult{RetryAfter: retryAfter, Limits: limits}, counter.exceeded
+		}
 	}
 
 	// give the ability to roll back the operation to the caller.
+	var locked []string
 	rollback := func() error {
-		return h.cacheService.Decr(ctx, key)
+		var errs []error
+		for _, key := range locked {
+			errs = append(errs, h.cacheService.Decr(ctx, key))
+		}
+		return errors.Join(errs...)
+	}
+
+	// allocate a token by incrementing the counters
+	for i, counter := range counters {
+		if err = h.incrementCount(spanCtx, counter.key, counter.rule.Expiration); err != nil {
+			_ = rollback()
+			return nil, fmt.Errorf("increment count fail: %w", err)
+		}
+		locked = append(locked, counter.key)
+		counts[i]++
 	}
 
 	return &LockResult{
 		Rollback: rollback,
+		Limits:   h.limitStatuses(spanCtx, counters, counts),
 	}, nil
 }
 
-// check returns True if there's capacity available for the notification
-// to be sent based on the maximum allowed count for the given key.
-func (h CacheRateLimitHandler) checkAvailability(ctx context.Context,
-	key string, maxCount int) (bool, error) {
+// Quota returns the limits the notifications of the given type sent to the given user are subject to,
+// as LockIfAvailable would, without locking any token.
+//
+// It returns ErrNoRateLimitRule if the notification type has no rule to enforce.
+func (h CacheRateLimitHandler) Quota(ctx context.Context,
+	userID string, notificationType domain.NotificationType) (limits RateLimitLimits, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "RateLimitHandler.Quota",
+		trace.WithAttributes(tracing.NotificationTypeKey.String(notificationType.String())))
+	defer func() { tracing.End(span, err) }()
+
+	counters, err := h.resolveCounters(ctx, userID, notificationType)
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int, len(counters))
+	for i, counter := range counters {
+		if counts[i], err = h.count(ctx, counter.key); err != nil {
+			return nil, fmt.Errorf("get count fail: %w", err)
+		}
+	}
+	return h.limitStatuses(ctx, counters, counts), nil
+}
+
+// resolveCounters returns the counters the notifications of the type sent to the user lock a token of:
+// the one of the rule, unless it's unlimited, then the one of the tenant quota, if any.
+func (h CacheRateLimitHandler) resolveCounters(ctx context.Context,
+	userID string, notificationType domain.NotificationType) ([]rateLimitCounter, error) {
+	rule, err := h.resolveRule(ctx, userID, notificationType)
+	if err != nil {
+		return nil, err
+	}
+	quota, err := h.resolveQuota(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var counters []rateLimitCounter
+	if !rule.Unlimited {
+		counters = append(counters, rateLimitCounter{
+			key:      tenantCacheKey(ctx, rateLimitCacheKind, fmt.Sprintf("%s:%s", userID, notificationType)),
+			rule:     rule,
+			exceeded: ErrRateLimitExceeded,
+		})
+	}
+	if quota != nil {
+		counters = append(counters, rateLimitCounter{
+			key:      tenantQuotaKey(ctx),
+			rule:     *quota,
+			exceeded: errors.Join(ErrRateLimitExceeded, ErrTenantQuotaExceeded),
+		})
+	}
+	return counters, nil
+}
+
+// limitStatuses returns the status of the limits of the counters given their counts.
+func (h CacheRateLimitHandler) limitStatuses(ctx context.Context, counters []rateLimitCounter, counts []int) RateLimitLimits {
+	limits := make(RateLimitLimits, 0, len(counters))
+	for i, counter := range counters {
+		status := RateLimitStatus{
+			Limit:     counter.rule.MaxCount,
+			Remaining: max(counter.rule.MaxCount-counts[i], 0),
+			Window:    counter.rule.Expiration,
+		}
+		if counts[i] > 0 {
+			status.Reset = h.resetAfter(ctx, counter)
+		}
+		limits = append(limits, status)
+	}
+	return limits
+}
+
+// resetAfter returns how long until the count of the counter is reset: the time to live of the counter,
+// or the whole time span of its rule if it can't be retrieved.
+func (h CacheRateLimitHandler) resetAfter(ctx context.Context, counter rateLimitCounter) time.Duration {
+	ttl, err := h.cacheService.TTL(ctx, counter.key)
+	if err != nil {
+		logging.FromContext(ctx).Warn().Err(err).Msg("failed to get the time to live of the rate limit counter")
+		return counter.rule.Expiration
+	}
+	if ttl <= 0 {
+		return counter.rule.Expiration
+	}
+	return ttl
+}
+
+// rateLimitCounter is a counter locked by LockIfAvailable.
+type rateLimitCounter struct {
+	key      string
+	rule     domain.RateLimitRule
+	exceeded error
+}
+
+// tenantQuotaKey returns the cache key of the quota counter of the tenant of ctx.
+func tenantQuotaKey(ctx context.Context) string {
+	return "tenant:" + repository.TenantFromContext(ctx) + ":quota"
+}
+
+// CheckRules returns an error ErrNoRateLimitRule listing the notification types without a rate limit rule,
+// unless there's a default rule to fall back to.
+func (h CacheRateLimitHandler) CheckRules(ctx context.Context) error {
+	if h.defaultRule != nil {
+		return nil
+	}
+
+	rules, err := h.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list rate limit rules fail: %w", err)
+	}
+
+	var missing []string
+	for _, notificationType := range domain.NotificationTypes() {
+		if _, ok := rules[notificationType]; !ok {
+			missing = append(missing, notificationType.String())
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w for notification types %s", ErrNoRateLimitRule, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// resolveRule retrieves the rate limit rule of the notifications of the type sent to the user,
+// see LockIfAvailable for the precedence of the rules.
+func (h CacheRateLimitHandler) resolveRule(ctx context.Context,
+	userID string, notificationType domain.NotificationType) (domain.RateLimitRule, error) {
+	exempted, err := h.exempted(ctx, notificationType)
+	if err != nil {
+		return domain.RateLimitRule{}, err
+	}
+	if exempted {
+		return domain.RateLimitRule{Unlimited: true}, nil
+	}
+
+	rule, overridden, err := h.resolveOverride(ctx, userID, notificationType)
+	if err != nil || overridden {
+		return rule, err
+	}
+	return h.resolveTypeRule(ctx, notificationType)
+}
+
+// exempted reports whether the notification type has an active exemption.
+func (h CacheRateLimitHandler) exempted(ctx context.Context, notificationType domain.NotificationType) (bool, error) {
+	if h.exemptions == nil {
+		return false, nil
+	}
+	_, err := h.exemptions.Get(ctx, notificationType)
+	if errors.Is(err, repository.ErrExemptionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get rate limit exemption fail: %w", err)
+	}
+	return true, nil
+}
+
+// resolveOverride retrieves the rule of the override of the user, or else of the first segment of
+// the user having an override for the notification type, and whether there's one.
+func (h CacheRateLimitHandler) resolveOverride(ctx context.Context,
+	userID string, notificationType domain.NotificationType) (domain.RateLimitRule, bool, error) {
+	if h.overrides == nil {
+		return domain.RateLimitRule{}, false, nil
+	}
 
+	rule, ok, err := h.overrideRule(ctx, domain.OverrideUser, userID, notificationType)
+	if err != nil || ok {
+		return rule, ok, err
+	}
+
+	user, err := h.users.Get(ctx, userID)
+	if errors.Is(err, repository.ErrInvalidUserID) {
+		return domain.RateLimitRule{}, false, nil
+	}
+	if err != nil {
+		return domain.RateLimitRule{}, false, fmt.Errorf("get user segments fail: %w", err)
+	}
+	for _, segment := range user.Segments {
+		rule, ok, err = h.overrideRule(ctx, domain.OverrideSegment, segment, notificationType)
+		if err != nil || ok {
+			return rule, ok, err
+		}
+	}
+	return domain.RateLimitRule{}, false, nil
+}
+
+// overrideRule retrieves the rule of the override of the subject for the notification type, and whether there's one.
+func (h CacheRateLimitHandler) overrideRule(ctx context.Context, scope domain.OverrideScope, subject string,
+	notificationType domain.NotificationType) (domain.RateLimitRule, bool, error) {
+	override, err := h.overrides.Get(ctx, scope, subject)
+	if errors.Is(err, repository.ErrOverrideNotFound) {
+		return domain.RateLimitRule{}, false, nil
+	}
+	if err != nil {
+		return domain.RateLimitRule{}, false, fmt.Errorf("get %s rate limit override fail: %w", scope, err)
+	}
+	rule, ok := override.Rule(notificationType)
+	return rule, ok, nil
+}
+
+// resolveTypeRule retrieves the rate limit rule of the notification type, falling back to the rule of the
+// default tenant, then to the default rule.
+func (h CacheRateLimitHandler) resolveTypeRule(ctx context.Context,
+	notificationType domain.NotificationType) (domain.RateLimitRule, error) {
+	rule, err := h.repo.GetByNotificationType(ctx, notificationType)
+	if errors.Is(err, repository.ErrRuleNotFound) && repository.TenantFromContext(ctx) != domain.DefaultTenantID {
+		rule, err = h.repo.GetByNotificationType(repository.WithTenant(ctx, domain.DefaultTenantID), notificationType)
+	}
+	if errors.Is(err, repository.ErrRuleNotFound) {
+		if h.defaultRule != nil {
+			return *h.defaultRule, nil
+		}
+		return rule, fmt.Errorf("%w for notification type %s", ErrNoRateLimitRule, notificationType)
+	}
+	if err != nil {
+		return rule, fmt.Errorf("get rate limit rule by notification type fail: %w", err)
+	}
+	return rule, nil
+}
+
+// resolveQuota retrieves the quota of the tenant of ctx, which is nil if it has none.
+func (h CacheRateLimitHandler) resolveQuota(ctx context.Context) (*domain.RateLimitRule, error) {
+	if h.tenants == nil {
+		return nil, nil
+	}
+	tenant, err := h.tenants.Get(ctx, repository.TenantFromContext(ctx))
+	if errors.Is(err, repository.ErrTenantNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get tenant quota fail: %w", err)
+	}
+	return tenant.Quota, nil
+}
+
+// count returns the notification count of the rate limit counter of the given key,
+// which is 0 if the key doesn't exist.
+func (h CacheRateLimitHandler) count(ctx context.Context, key string) (int, error) {
 	stringCounts := h.cacheService.Get(ctx, key)
 	counts, err := strconv.Atoi(stringCounts)
 	if err != nil {
 		// if the int conversion fails and stringCounts is populated with anything but an empty string
 		// at this point it's not safe to assume its correct int counterpart.
 		if stringCounts != "" {
-			return false, fmt.Errorf("failed converting notification counts from cache: %w", err)
+			return 0, fmt.Errorf("failed converting notification counts from cache: %w", err)
 		}
 		// if it's an empty string, it's probably because the key doesn't currently exist in the cache yet
 		// and therefore, we can assume a 0 count.
 		counts = 0
 	}
-
-	if counts >= maxCount {
-		return false, nil
-	}
-
-	return true, nil
+	return counts, nil
 }
 
 // incrementCount adds to the rate limit counter based the key, applying the specified TTL.
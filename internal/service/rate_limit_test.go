@@ -2,10 +2,12 @@ package service_test
 
 import (
 	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"notification/internal/domain"
+	"notification/internal/repository"
 	"notification/internal/service"
 	"notification/mocks"
 	"testing"
@@ -30,13 +32,16 @@ func TestCacheRateLimitHandler_Check(t *testing.T) {
 
 	rateLimitRulesRepo := mocks.NewRateLimitRuleRepository(t)
 	rateLimitRulesRepo.
-		On("GetByNotificationType", mock.Anything).
-		Return(func(notificationType domain.NotificationType) (domain.RateLimitRule, error) {
+		On("GetByNotificationType", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, notificationType domain.NotificationType) (domain.RateLimitRule, error) {
 			return rules[notificationType], nil
 		})
 
 	t.Run("is not rate limited", func(t *testing.T) {
 		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("TTL", mock.Anything, mock.Anything).
+			Return(time.Minute, nil).Maybe()
 		cacheSvc.
 			On("Get", mock.Anything, mock.Anything).
 			Return("1")
@@ -51,6 +56,9 @@ func TestCacheRateLimitHandler_Check(t *testing.T) {
 
 	t.Run("is rate limited", func(t *testing.T) {
 		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("TTL", mock.Anything, mock.Anything).
+			Return(30*time.Second, nil).Maybe()
 		cacheSvc.
 			On("Get", mock.Anything, mock.Anything).
 			Return("100")
@@ -61,12 +69,17 @@ func TestCacheRateLimitHandler_Check(t *testing.T) {
 		checker := service.NewCacheRateLimitHandler(cacheSvc, rateLimitRulesRepo)
 		lockResult, err := checker.LockIfAvailable(context.Background(), "123", domain.Status)
 		require.ErrorIs(t, err, service.ErrRateLimitExceeded)
-		assert.Equal(t, rules[domain.Status].Expiration, lockResult.RetryAfter)
+		assert.Equal(t, 30*time.Second, lockResult.RetryAfter, "the time to live of the counter")
+		require.Len(t, lockResult.Limits, 1)
+		assert.Equal(t, lockResult.Limits[0].Reset, lockResult.RetryAfter)
 		cacheSvc.AssertNotCalled(t, "Incr", mock.Anything, mock.Anything, mock.Anything)
 	})
 
 	t.Run("when key is not set should default count to 0", func(t *testing.T) {
 		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("TTL", mock.Anything, mock.Anything).
+			Return(time.Minute, nil).Maybe()
 		cacheSvc.
 			On("Get", mock.Anything, mock.Anything).
 			Return("")
@@ -81,6 +94,9 @@ func TestCacheRateLimitHandler_Check(t *testing.T) {
 
 	t.Run("when check fails it doesn't increment count", func(t *testing.T) {
 		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("TTL", mock.Anything, mock.Anything).
+			Return(time.Minute, nil).Maybe()
 		cacheSvc.
 			On("Get", mock.Anything, mock.Anything).
 			Return("abc") // the string "abc" causes the string to int conversion to fail.
@@ -94,3 +110,537 @@ func TestCacheRateLimitHandler_Check(t *testing.T) {
 		cacheSvc.AssertNotCalled(t, "Incr", mock.Anything, mock.Anything, mock.Anything)
 	})
 }
+
+func TestCacheRateLimitHandler_RuleResolution(t *testing.T) {
+	rulesRepo := repository.NewInMemoryRateLimitRuleRepository()
+	require.NoError(t, rulesRepo.Save(context.Background(), domain.Status, domain.RateLimitRule{Unlimited: true}))
+
+	t.Run("missing rule is rejected", func(t *testing.T) {
+		checker := service.NewCacheRateLimitHandler(mocks.NewCache(t), rulesRepo)
+		_, err := checker.LockIfAvailable(context.Background(), "123", domain.News)
+		assert.ErrorIs(t, err, service.ErrNoRateLimitRule)
+	})
+
+	t.Run("missing rule falls back to the default rule", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		stubCounterWithoutTTL(cacheSvc)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:default:ratelimit:123:news").
+			Return("1")
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo,
+			service.WithDefaultRule(domain.RateLimitRule{MaxCount: 1, Expiration: time.Hour}))
+		lockResult, err := checker.LockIfAvailable(context.Background(), "123", domain.News)
+		require.ErrorIs(t, err, service.ErrRateLimitExceeded)
+		assert.Equal(t, time.Hour, lockResult.RetryAfter)
+	})
+
+	t.Run("unlimited rule doesn't lock", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo)
+		lockResult, err := checker.LockIfAvailable(context.Background(), "123", domain.Status)
+		require.NoError(t, err)
+		assert.NoError(t, lockResult.Rollback())
+		cacheSvc.AssertNotCalled(t, "Incr", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCacheRateLimitHandler_CheckRules(t *testing.T) {
+	rulesRepo := repository.NewInMemoryRateLimitRuleRepository()
+	require.NoError(t, rulesRepo.Save(context.Background(), domain.Status, domain.RateLimitRule{Unlimited: true}))
+
+	t.Run("missing rules", func(t *testing.T) {
+		err := service.NewCacheRateLimitHandler(mocks.NewCache(t), rulesRepo).CheckRules(context.Background())
+		require.ErrorIs(t, err, service.ErrNoRateLimitRule)
+		assert.ErrorContains(t, err, "news, marketing")
+	})
+
+	t.Run("missing rules with a default rule", func(t *testing.T) {
+		checker := service.NewCacheRateLimitHandler(mocks.NewCache(t), rulesRepo,
+			service.WithDefaultRule(domain.RateLimitRule{Unlimited: true}))
+		assert.NoError(t, checker.CheckRules(context.Background()))
+	})
+
+	t.Run("every notification type has a rule", func(t *testing.T) {
+		for _, notificationType := range domain.NotificationTypes() {
+			require.NoError(t, rulesRepo.Update(context.Background(), notificationType,
+				domain.RateLimitRule{MaxCount: 1, Expiration: time.Hour}))
+		}
+		err := service.NewCacheRateLimitHandler(mocks.NewCache(t), rulesRepo).CheckRules(context.Background())
+		assert.NoError(t, err)
+	})
+}
+
+func TestCacheRateLimitHandler_Tenants(t *testing.T) {
+	billingCtx := repository.WithTenant(context.Background(), "billing")
+	rulesRepo := repository.NewInMemoryRateLimitRuleRepository()
+	require.NoError(t, rulesRepo.Save(context.Background(), domain.News,
+		domain.RateLimitRule{MaxCount: 1, Expiration: time.Hour}))
+	require.NoError(t, rulesRepo.Save(context.Background(), domain.Status, domain.RateLimitRule{Unlimited: true}))
+	require.NoError(t, rulesRepo.Save(billingCtx, domain.Marketing,
+		domain.RateLimitRule{MaxCount: 3, Expiration: time.Minute}))
+
+	tenantsRepo := repository.NewInMemoryTenantRepository()
+	require.NoError(t, tenantsRepo.Save(context.Background(), domain.Tenant{
+		ID:    "billing",
+		Quota: &domain.RateLimitRule{MaxCount: 10, Expiration: 24 * time.Hour},
+	}))
+
+	t.Run("tenant rule and counter", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("TTL", mock.Anything, mock.Anything).
+			Return(time.Minute, nil).Maybe()
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:ratelimit:123:marketing").
+			Return("3")
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo)
+		lockResult, err := checker.LockIfAvailable(billingCtx, "123", domain.Marketing)
+		require.ErrorIs(t, err, service.ErrRateLimitExceeded)
+		assert.NotErrorIs(t, err, service.ErrTenantQuotaExceeded)
+		assert.Equal(t, time.Minute, lockResult.RetryAfter)
+	})
+
+	t.Run("missing tenant rule falls back to the default tenant rule", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		stubCounterWithoutTTL(cacheSvc)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:ratelimit:123:news").
+			Return("1")
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo)
+		lockResult, err := checker.LockIfAvailable(billingCtx, "123", domain.News)
+		require.ErrorIs(t, err, service.ErrRateLimitExceeded)
+		assert.Equal(t, time.Hour, lockResult.RetryAfter)
+	})
+
+	t.Run("rule of another tenant isn't used", func(t *testing.T) {
+		checker := service.NewCacheRateLimitHandler(mocks.NewCache(t), rulesRepo)
+		_, err := checker.LockIfAvailable(context.Background(), "123", domain.Marketing)
+		assert.ErrorIs(t, err, service.ErrNoRateLimitRule)
+	})
+
+	t.Run("quota exceeded", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		stubCounterWithoutTTL(cacheSvc)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:ratelimit:123:marketing").
+			Return("0")
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:quota").
+			Return("10")
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo, service.WithTenantQuotas(tenantsRepo))
+		lockResult, err := checker.LockIfAvailable(billingCtx, "123", domain.Marketing)
+		require.ErrorIs(t, err, service.ErrRateLimitExceeded)
+		assert.ErrorIs(t, err, service.ErrTenantQuotaExceeded)
+		assert.Equal(t, 24*time.Hour, lockResult.RetryAfter)
+		cacheSvc.AssertNotCalled(t, "Incr", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("quota applies to unlimited rules", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("TTL", mock.Anything, mock.Anything).
+			Return(time.Minute, nil).Maybe()
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:quota").
+			Return("10")
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo, service.WithTenantQuotas(tenantsRepo))
+		_, err := checker.LockIfAvailable(billingCtx, "123", domain.Status)
+		assert.ErrorIs(t, err, service.ErrTenantQuotaExceeded)
+	})
+
+	t.Run("rollback releases the rule and quota tokens", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("TTL", mock.Anything, mock.Anything).
+			Return(time.Minute, nil).Maybe()
+		cacheSvc.
+			On("Get", mock.Anything, mock.Anything).
+			Return("1")
+		cacheSvc.
+			On("Incr", mock.Anything, "tenant:billing:ratelimit:123:marketing", time.Minute).
+			Return(nil).Once()
+		cacheSvc.
+			On("Incr", mock.Anything, "tenant:billing:quota", 24*time.Hour).
+			Return(nil).Once()
+		cacheSvc.
+			On("Decr", mock.Anything, "tenant:billing:ratelimit:123:marketing").
+			Return(nil).Once()
+		cacheSvc.
+			On("Decr", mock.Anything, "tenant:billing:quota").
+			Return(nil).Once()
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo, service.WithTenantQuotas(tenantsRepo))
+		lockResult, err := checker.LockIfAvailable(billingCtx, "123", domain.Marketing)
+		require.NoError(t, err)
+		assert.NoError(t, lockResult.Rollback())
+	})
+
+	t.Run("tenant without a quota", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo, service.WithTenantQuotas(tenantsRepo))
+		_, err := checker.LockIfAvailable(context.Background(), "123", domain.Status)
+		assert.NoError(t, err)
+	})
+}
+
+func TestCacheRateLimitHandler_Overrides(t *testing.T) {
+	rulesRepo := repository.NewInMemoryRateLimitRuleRepository()
+	require.NoError(t, rulesRepo.Save(context.Background(), domain.News,
+		domain.RateLimitRule{MaxCount: 1, Expiration: time.Hour}))
+	require.NoError(t, rulesRepo.Save(context.Background(), domain.Status,
+		domain.RateLimitRule{MaxCount: 1, Expiration: 10 * time.Minute}))
+	defaultRule := domain.RateLimitRule{MaxCount: 1, Expiration: 2 * time.Hour}
+
+	// every rule allows a single notification, so the rule applied is told by its expiration.
+	rule := func(expiration time.Duration) domain.RateLimitRule {
+		return domain.RateLimitRule{MaxCount: 1, Expiration: expiration}
+	}
+	userOverride := func(rules domain.RateLimitRules, fallback *domain.RateLimitRule) domain.RateLimitOverride {
+		return domain.RateLimitOverride{Scope: domain.OverrideUser, Subject: "123", Rules: rules, Default: fallback}
+	}
+	segmentOverride := func(segment string, rules domain.RateLimitRules,
+		fallback *domain.RateLimitRule) domain.RateLimitOverride {
+		return domain.RateLimitOverride{Scope: domain.OverrideSegment, Subject: segment, Rules: rules, Default: fallback}
+	}
+	userDefault := rule(2 * time.Minute)
+	vipDefault := rule(4 * time.Minute)
+
+	tests := []struct {
+		name             string
+		segments         []string
+		overrides        []domain.RateLimitOverride
+		exempted         bool
+		notificationType domain.NotificationType
+		// want is the expiration of the rule applied, zero if it's unlimited.
+		want time.Duration
+	}{
+		{
+			name:             "type rule without override",
+			notificationType: domain.News,
+			want:             time.Hour,
+		},
+		{
+			name:             "default rule without override",
+			notificationType: domain.Marketing,
+			want:             2 * time.Hour,
+		},
+		{
+			name:             "user override rule beats the type rule",
+			overrides:        []domain.RateLimitOverride{userOverride(domain.RateLimitRules{domain.News: rule(time.Minute)}, nil)},
+			notificationType: domain.News,
+			want:             time.Minute,
+		},
+		{
+			name: "user override default rule beats the type rule",
+			overrides: []domain.RateLimitOverride{
+				userOverride(domain.RateLimitRules{domain.Status: rule(time.Minute)}, &userDefault),
+			},
+			notificationType: domain.News,
+			want:             2 * time.Minute,
+		},
+		{
+			name:             "user override default rule beats the default rule",
+			overrides:        []domain.RateLimitOverride{userOverride(nil, &userDefault)},
+			notificationType: domain.Marketing,
+			want:             2 * time.Minute,
+		},
+		{
+			name: "user override without rule for the type falls through",
+			overrides: []domain.RateLimitOverride{
+				userOverride(domain.RateLimitRules{domain.Marketing: rule(time.Minute)}, nil),
+			},
+			notificationType: domain.News,
+			want:             time.Hour,
+		},
+		{
+			name:             "segment override rule beats the type rule",
+			segments:         []string{"vip"},
+			overrides:        []domain.RateLimitOverride{segmentOverride("vip", domain.RateLimitRules{domain.News: rule(3 * time.Minute)}, nil)},
+			notificationType: domain.News,
+			want:             3 * time.Minute,
+		},
+		{
+			name:             "segment override default rule beats the type rule",
+			segments:         []string{"vip"},
+			overrides:        []domain.RateLimitOverride{segmentOverride("vip", nil, &vipDefault)},
+			notificationType: domain.News,
+			want:             4 * time.Minute,
+		},
+		{
+			name:     "user override beats segment override",
+			segments: []string{"vip"},
+			overrides: []domain.RateLimitOverride{
+				userOverride(domain.RateLimitRules{domain.News: rule(time.Minute)}, nil),
+				segmentOverride("vip", domain.RateLimitRules{domain.News: rule(3 * time.Minute)}, nil),
+			},
+			notificationType: domain.News,
+			want:             time.Minute,
+		},
+		{
+			name:     "user override default rule beats segment override rule",
+			segments: []string{"vip"},
+			overrides: []domain.RateLimitOverride{
+				userOverride(nil, &userDefault),
+				segmentOverride("vip", domain.RateLimitRules{domain.News: rule(3 * time.Minute)}, nil),
+			},
+			notificationType: domain.News,
+			want:             2 * time.Minute,
+		},
+		{
+			name:     "first segment of the user wins",
+			segments: []string{"beta", "vip"},
+			overrides: []domain.RateLimitOverride{
+				segmentOverride("vip", domain.RateLimitRules{domain.News: rule(3 * time.Minute)}, nil),
+				segmentOverride("beta", domain.RateLimitRules{domain.News: rule(5 * time.Minute)}, nil),
+			},
+			notificationType: domain.News,
+			want:             5 * time.Minute,
+		},
+		{
+			name:     "segment without rule for the type is skipped",
+			segments: []string{"vip", "beta"},
+			overrides: []domain.RateLimitOverride{
+				segmentOverride("vip", domain.RateLimitRules{domain.Marketing: rule(3 * time.Minute)}, nil),
+				segmentOverride("beta", domain.RateLimitRules{domain.News: rule(5 * time.Minute)}, nil),
+			},
+			notificationType: domain.News,
+			want:             5 * time.Minute,
+		},
+		{
+			name:             "override of a segment the user isn't in doesn't apply",
+			segments:         []string{"beta"},
+			overrides:        []domain.RateLimitOverride{segmentOverride("vip", nil, &vipDefault)},
+			notificationType: domain.News,
+			want:             time.Hour,
+		},
+		{
+			name:             "unlimited user override",
+			overrides:        []domain.RateLimitOverride{userOverride(domain.RateLimitRules{domain.News: {Unlimited: true}}, nil)},
+			notificationType: domain.News,
+			want:             0,
+		},
+		{
+			name:             "exemption beats the type rule",
+			exempted:         true,
+			notificationType: domain.Status,
+			want:             0,
+		},
+		{
+			name:             "exemption beats the overrides",
+			segments:         []string{"vip"},
+			overrides:        []domain.RateLimitOverride{userOverride(nil, &userDefault), segmentOverride("vip", nil, &vipDefault)},
+			exempted:         true,
+			notificationType: domain.Status,
+			want:             0,
+		},
+		{
+			name:             "exemption of another type doesn't apply",
+			overrides:        []domain.RateLimitOverride{userOverride(nil, &userDefault)},
+			exempted:         true,
+			notificationType: domain.News,
+			want:             2 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			usersRepo := repository.NewInMemoryUserRepository()
+			require.NoError(t, usersRepo.Save(ctx, domain.User{ID: "123", Email: "john@example.com", Segments: tt.segments}))
+			overridesRepo := repository.NewInMemoryRateLimitOverrideRepository()
+			for _, override := range tt.overrides {
+				require.NoError(t, overridesRepo.Save(ctx, override))
+			}
+			exemptionsRepo := repository.NewInMemoryRateLimitExemptionRepository()
+			if tt.exempted {
+				require.NoError(t, exemptionsRepo.Save(ctx, domain.RateLimitExemption{
+					Type: domain.Status, Reason: "INC-42", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}))
+			}
+
+			cacheSvc := mocks.NewCache(t)
+			stubCounterWithoutTTL(cacheSvc)
+			if tt.want != 0 {
+				cacheSvc.
+					On("Get", mock.Anything, "tenant:default:ratelimit:123:"+tt.notificationType.String()).
+					Return("1")
+			}
+
+			checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo,
+				service.WithDefaultRule(defaultRule),
+				service.WithOverrides(overridesRepo, usersRepo),
+				service.WithExemptions(exemptionsRepo))
+			lockResult, err := checker.LockIfAvailable(ctx, "123", tt.notificationType)
+			if tt.want == 0 {
+				require.NoError(t, err)
+				cacheSvc.AssertNotCalled(t, "Incr", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.ErrorIs(t, err, service.ErrRateLimitExceeded)
+			assert.Equal(t, tt.want, lockResult.RetryAfter)
+		})
+	}
+
+	t.Run("overrides of another tenant don't apply", func(t *testing.T) {
+		ctx := context.Background()
+		usersRepo := repository.NewInMemoryUserRepository()
+		require.NoError(t, usersRepo.Save(ctx, domain.User{ID: "123", Email: "john@example.com"}))
+		overridesRepo := repository.NewInMemoryRateLimitOverrideRepository()
+		require.NoError(t, overridesRepo.Save(repository.WithTenant(ctx, "billing"), userOverride(nil, &userDefault)))
+
+		cacheSvc := mocks.NewCache(t)
+		stubCounterWithoutTTL(cacheSvc)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:default:ratelimit:123:news").
+			Return("1")
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo, service.WithOverrides(overridesRepo, usersRepo))
+		lockResult, err := checker.LockIfAvailable(ctx, "123", domain.News)
+		require.ErrorIs(t, err, service.ErrRateLimitExceeded)
+		assert.Equal(t, time.Hour, lockResult.RetryAfter)
+	})
+
+	t.Run("unknown user has no segments", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		stubCounterWithoutTTL(cacheSvc)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:default:ratelimit:456:news").
+			Return("1")
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo, service.WithOverrides(
+			repository.NewInMemoryRateLimitOverrideRepository(), repository.NewInMemoryUserRepository()))
+		lockResult, err := checker.LockIfAvailable(context.Background(), "456", domain.News)
+		require.ErrorIs(t, err, service.ErrRateLimitExceeded)
+		assert.Equal(t, time.Hour, lockResult.RetryAfter)
+	})
+}
+
+func TestCacheRateLimitHandler_Limits(t *testing.T) {
+	billingCtx := repository.WithTenant(context.Background(), "billing")
+	rulesRepo := repository.NewInMemoryRateLimitRuleRepository()
+	require.NoError(t, rulesRepo.Save(context.Background(), domain.Status, domain.RateLimitRule{Unlimited: true}))
+	require.NoError(t, rulesRepo.Save(context.Background(), domain.Marketing,
+		domain.RateLimitRule{MaxCount: 3, Expiration: time.Minute}))
+	tenantsRepo := repository.NewInMemoryTenantRepository()
+	require.NoError(t, tenantsRepo.Save(context.Background(), domain.Tenant{
+		ID:    "billing",
+		Quota: &domain.RateLimitRule{MaxCount: 10, Expiration: 24 * time.Hour},
+	}))
+
+	t.Run("limits once locked", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:ratelimit:123:marketing").
+			Return("1")
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:quota").
+			Return("9")
+		cacheSvc.
+			On("Incr", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+		cacheSvc.
+			On("TTL", mock.Anything, "tenant:billing:ratelimit:123:marketing").
+			Return(time.Minute, nil)
+		cacheSvc.
+			On("TTL", mock.Anything, "tenant:billing:quota").
+			Return(24*time.Hour, nil)
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo, service.WithTenantQuotas(tenantsRepo))
+		lockResult, err := checker.LockIfAvailable(billingCtx, "123", domain.Marketing)
+		require.NoError(t, err)
+		assert.Equal(t, service.RateLimitLimits{
+			{Limit: 3, Remaining: 1, Window: time.Minute, Reset: time.Minute},
+			{Limit: 10, Remaining: 0, Window: 24 * time.Hour, Reset: 24 * time.Hour},
+		}, lockResult.Limits)
+
+		closest, ok := lockResult.Limits.Closest()
+		assert.True(t, ok)
+		assert.Equal(t, 10, closest.Limit)
+	})
+
+	t.Run("limits when exceeded", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:ratelimit:123:marketing").
+			Return("3")
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:billing:quota").
+			Return("")
+		cacheSvc.
+			On("TTL", mock.Anything, "tenant:billing:ratelimit:123:marketing").
+			Return(40*time.Second, nil)
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo, service.WithTenantQuotas(tenantsRepo))
+		lockResult, err := checker.LockIfAvailable(billingCtx, "123", domain.Marketing)
+		require.ErrorIs(t, err, service.ErrRateLimitExceeded)
+		assert.Equal(t, service.RateLimitLimits{
+			{Limit: 3, Remaining: 0, Window: time.Minute, Reset: 40 * time.Second},
+			{Limit: 10, Remaining: 10, Window: 24 * time.Hour},
+		}, lockResult.Limits)
+	})
+
+	t.Run("quota doesn't lock", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:default:ratelimit:123:marketing").
+			Return("2")
+		cacheSvc.
+			On("TTL", mock.Anything, "tenant:default:ratelimit:123:marketing").
+			Return(25*time.Second, nil)
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo, service.WithTenantQuotas(tenantsRepo))
+		limits, err := checker.Quota(context.Background(), "123", domain.Marketing)
+		require.NoError(t, err)
+		assert.Equal(t, service.RateLimitLimits{
+			{Limit: 3, Remaining: 1, Window: time.Minute, Reset: 25 * time.Second},
+		}, limits)
+		cacheSvc.AssertNotCalled(t, "Incr", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("reset falls back to the window when the ttl is unknown", func(t *testing.T) {
+		cacheSvc := mocks.NewCache(t)
+		cacheSvc.
+			On("Get", mock.Anything, "tenant:default:ratelimit:123:marketing").
+			Return("2")
+		cacheSvc.
+			On("TTL", mock.Anything, "tenant:default:ratelimit:123:marketing").
+			Return(time.Duration(0), errors.New("connection refused"))
+
+		checker := service.NewCacheRateLimitHandler(cacheSvc, rulesRepo)
+		limits, err := checker.Quota(context.Background(), "123", domain.Marketing)
+		require.NoError(t, err)
+		assert.Equal(t, service.RateLimitLimits{
+			{Limit: 3, Remaining: 1, Window: time.Minute, Reset: time.Minute},
+		}, limits)
+	})
+
+	t.Run("unlimited notifications have no limits", func(t *testing.T) {
+		checker := service.NewCacheRateLimitHandler(mocks.NewCache(t), rulesRepo, service.WithTenantQuotas(tenantsRepo))
+		limits, err := checker.Quota(context.Background(), "123", domain.Status)
+		require.NoError(t, err)
+		assert.Empty(t, limits)
+
+		_, ok := limits.Closest()
+		assert.False(t, ok)
+	})
+
+	t.Run("quota of a type without rule", func(t *testing.T) {
+		checker := service.NewCacheRateLimitHandler(mocks.NewCache(t), rulesRepo)
+		_, err := checker.Quota(context.Background(), "123", domain.News)
+		assert.ErrorIs(t, err, service.ErrNoRateLimitRule)
+	})
+}
+
+// stubCounterWithoutTTL makes the counters report no time to live, so that the rate limited notifications
+// are retried after the whole time span of their rule, which tells the rule applied.
+func stubCounterWithoutTTL(cacheSvc *mocks.Cache) {
+	cacheSvc.
+		On("TTL", mock.Anything, mock.Anything).
+		Return(time.Duration(-1), nil).Maybe()
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"notification/internal/domain"
+	"sync"
+)
+
+// NewSubjects creates a new Subjects instance with the given subjects per notification type.
+func NewSubjects(subjects map[domain.NotificationType]string) *Subjects {
+	s := &Subjects{subjects: make(map[domain.NotificationType]string, len(subjects))}
+	s.Set(subjects)
+	return s
+}
+
+// Subjects is the catalog of email subjects per notification type.
+// It's safe for concurrent use, so it can be replaced while notifications are being sent.
+type Subjects struct {
+	mu       sync.RWMutex
+	subjects map[domain.NotificationType]string
+}
+
+// Set replaces the subjects of the catalog. Empty subjects are ignored.
+func (s *Subjects) Set(subjects map[domain.NotificationType]string) {
+	replacement := make(map[domain.NotificationType]string, len(subjects))
+	for notificationType, subject := range subjects {
+		if subject != "" {
+			replacement[notificationType] = subject
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subjects = replacement
+}
+
+// Get returns the subject of the notification type, and whether it's defined in the catalog.
+func (s *Subjects) Get(notificationType domain.NotificationType) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	subject, ok := s.subjects[notificationType]
+	return subject, ok
+}
@@ -0,0 +1,30 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/domain"
+	"notification/internal/service"
+	"testing"
+)
+
+func TestSubjects(t *testing.T) {
+	subjects := service.NewSubjects(map[domain.NotificationType]string{
+		domain.Status: "Status update",
+		domain.News:   "",
+	})
+
+	subject, ok := subjects.Get(domain.Status)
+	assert.True(t, ok)
+	assert.Equal(t, "Status update", subject)
+
+	_, ok = subjects.Get(domain.News)
+	assert.False(t, ok, "empty subjects are ignored")
+
+	subjects.Set(map[domain.NotificationType]string{domain.News: "Fresh news"})
+
+	_, ok = subjects.Get(domain.Status)
+	assert.False(t, ok, "subjects are replaced")
+	subject, ok = subjects.Get(domain.News)
+	assert.True(t, ok)
+	assert.Equal(t, "Fresh news", subject)
+}
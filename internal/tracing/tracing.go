@@ -0,0 +1,96 @@
+// Package tracing sets up the OpenTelemetry tracing of the application. The components
+// create their spans through Tracer, which relies on the global tracer provider, so that
+// tracing is a no-op until SetGlobal installs one.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer of the application.
+const instrumentationName = "notification"
+
+// The supported span exporters.
+const (
+	// ExporterNone disables the span exporting.
+	ExporterNone = "none"
+	// ExporterOTLP exports the spans through OTLP over HTTP.
+	ExporterOTLP = "otlp"
+)
+
+// The attributes of the notification spans.
+const (
+	CorrelationIDKey    = attribute.Key("notification.correlation_id")
+	NotificationTypeKey = attribute.Key("notification.type")
+)
+
+// Config represents the tracing settings.
+type Config struct {
+	// Exporter is the span exporter: ExporterNone or ExporterOTLP.
+	Exporter string
+	// Endpoint is the URL of the OTLP collector, e.g. "http://collector:4318". When empty,
+	// the OTEL_EXPORTER_OTLP_* environment variables and the exporter defaults apply.
+	Endpoint string
+	// ServiceName is the service name the spans are reported with.
+	ServiceName string
+}
+
+// NewTracerProvider creates the tracer provider exporting the spans as configured. The caller
+// is responsible for shutting it down, which flushes the pending spans.
+func NewTracerProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+	switch cfg.Exporter {
+	case ExporterNone:
+	case ExporterOTLP:
+		var exporterOpts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("unsupported span exporter %q", cfg.Exporter)
+	}
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+// SetGlobal installs provider as the global tracer provider, along with the W3C trace
+// context and baggage propagators.
+func SetGlobal(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Tracer returns the tracer of the application.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// End records err, if any, in span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,56 @@
+package tracing_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"notification/internal/tracing"
+	"notification/internal/tracing/tracingtest"
+	"testing"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	t.Run("without exporter", func(t *testing.T) {
+		provider, err := tracing.NewTracerProvider(context.Background(),
+			tracing.Config{Exporter: tracing.ExporterNone, ServiceName: "notification"})
+		require.NoError(t, err)
+
+		_, span := provider.Tracer("test").Start(context.Background(), "span")
+		assert.True(t, span.SpanContext().IsValid())
+		span.End()
+		assert.NoError(t, provider.Shutdown(context.Background()))
+	})
+
+	t.Run("OTLP exporter", func(t *testing.T) {
+		provider, err := tracing.NewTracerProvider(context.Background(), tracing.Config{
+			Exporter:    tracing.ExporterOTLP,
+			Endpoint:    "http://127.0.0.1:4318",
+			ServiceName: "notification",
+		})
+		require.NoError(t, err)
+		assert.NoError(t, provider.Shutdown(context.Background()))
+	})
+
+	t.Run("unsupported exporter", func(t *testing.T) {
+		_, err := tracing.NewTracerProvider(context.Background(), tracing.Config{Exporter: "zipkin"})
+		assert.ErrorContains(t, err, "zipkin")
+	})
+}
+
+func TestEnd(t *testing.T) {
+	exporter := tracingtest.Setup(t)
+
+	_, span := tracing.Tracer().Start(context.Background(), "succeeded")
+	tracing.End(span, nil)
+	_, span = tracing.Tracer().Start(context.Background(), "failed")
+	tracing.End(span, assert.AnError)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, assert.AnError.Error(), spans[1].Status.Description)
+	require.Len(t, spans[1].Events, 1)
+	assert.Equal(t, "exception", spans[1].Events[0].Name)
+}
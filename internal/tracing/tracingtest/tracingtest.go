@@ -0,0 +1,37 @@
+// Package tracingtest provides the utilities to test the tracing of the application.
+package tracingtest
+
+import (
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"notification/internal/tracing"
+	"testing"
+)
+
+// Setup installs a global tracer provider recording the spans in memory for the duration
+// of the test, and returns the exporter holding the ended spans.
+//
+// The tracer provider is global, so the tests using it must not run in parallel.
+func Setup(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracing.SetGlobal(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	return exporter
+}
+
+// SpanNames returns the names of spans.
+func SpanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	return names
+}
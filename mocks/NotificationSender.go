@@ -8,7 +8,7 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
-	time "time"
+	service "notification/internal/service"
 )
 
 // NotificationSender is an autogenerated mock type for the NotificationSender type
@@ -17,22 +17,22 @@ type NotificationSender struct {
 }
 
 // Send provides a mock function with given fields: ctx, userID, notification
-func (_m *NotificationSender) Send(ctx context.Context, userID string, notification domain.Notification) (time.Duration, error) {
+func (_m *NotificationSender) Send(ctx context.Context, userID string, notification domain.Notification) (service.SendResult, error) {
 	ret := _m.Called(ctx, userID, notification)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Send")
 	}
 
-	var r0 time.Duration
+	var r0 service.SendResult
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Notification) (time.Duration, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Notification) (service.SendResult, error)); ok {
 		return rf(ctx, userID, notification)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Notification) time.Duration); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Notification) service.SendResult); ok {
 		r0 = rf(ctx, userID, notification)
 	} else {
-		r0 = ret.Get(0).(time.Duration)
+		r0 = ret.Get(0).(service.SendResult)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string, domain.Notification) error); ok {
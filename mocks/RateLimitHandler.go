@@ -46,6 +46,36 @@ func (_m *RateLimitHandler) LockIfAvailable(ctx context.Context, userID string,
 	return r0, r1
 }
 
+// Quota provides a mock function with given fields: ctx, userID, notificationType
+func (_m *RateLimitHandler) Quota(ctx context.Context, userID string, notificationType domain.NotificationType) (service.RateLimitLimits, error) {
+	ret := _m.Called(ctx, userID, notificationType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Quota")
+	}
+
+	var r0 service.RateLimitLimits
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.NotificationType) (service.RateLimitLimits, error)); ok {
+		return rf(ctx, userID, notificationType)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.NotificationType) service.RateLimitLimits); ok {
+		r0 = rf(ctx, userID, notificationType)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(service.RateLimitLimits)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.NotificationType) error); ok {
+		r1 = rf(ctx, userID, notificationType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewRateLimitHandler creates a new instance of RateLimitHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRateLimitHandler(t interface {
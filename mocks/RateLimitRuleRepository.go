@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "notification/internal/domain"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,9 +15,27 @@ type RateLimitRuleRepository struct {
 	mock.Mock
 }
 
-// GetByNotificationType provides a mock function with given fields: notificationType
-func (_m *RateLimitRuleRepository) GetByNotificationType(notificationType domain.NotificationType) (domain.RateLimitRule, error) {
-	ret := _m.Called(notificationType)
+// Delete provides a mock function with given fields: ctx, notificationType
+func (_m *RateLimitRuleRepository) Delete(ctx context.Context, notificationType domain.NotificationType) error {
+	ret := _m.Called(ctx, notificationType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NotificationType) error); ok {
+		r0 = rf(ctx, notificationType)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByNotificationType provides a mock function with given fields: ctx, notificationType
+func (_m *RateLimitRuleRepository) GetByNotificationType(ctx context.Context, notificationType domain.NotificationType) (domain.RateLimitRule, error) {
+	ret := _m.Called(ctx, notificationType)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByNotificationType")
@@ -23,17 +43,17 @@ func (_m *RateLimitRuleRepository) GetByNotificationType(notificationType domain
 
 	var r0 domain.RateLimitRule
 	var r1 error
-	if rf, ok := ret.Get(0).(func(domain.NotificationType) (domain.RateLimitRule, error)); ok {
-		return rf(notificationType)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NotificationType) (domain.RateLimitRule, error)); ok {
+		return rf(ctx, notificationType)
 	}
-	if rf, ok := ret.Get(0).(func(domain.NotificationType) domain.RateLimitRule); ok {
-		r0 = rf(notificationType)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NotificationType) domain.RateLimitRule); ok {
+		r0 = rf(ctx, notificationType)
 	} else {
 		r0 = ret.Get(0).(domain.RateLimitRule)
 	}
 
-	if rf, ok := ret.Get(1).(func(domain.NotificationType) error); ok {
-		r1 = rf(notificationType)
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NotificationType) error); ok {
+		r1 = rf(ctx, notificationType)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -41,17 +61,65 @@ func (_m *RateLimitRuleRepository) GetByNotificationType(notificationType domain
 	return r0, r1
 }
 
-// Save provides a mock function with given fields: notificationType, rule
-func (_m *RateLimitRuleRepository) Save(notificationType domain.NotificationType, rule domain.RateLimitRule) error {
-	ret := _m.Called(notificationType, rule)
+// List provides a mock function with given fields: ctx
+func (_m *RateLimitRuleRepository) List(ctx context.Context) (domain.RateLimitRules, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 domain.RateLimitRules
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (domain.RateLimitRules, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) domain.RateLimitRules); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.RateLimitRules)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, notificationType, rule
+func (_m *RateLimitRuleRepository) Save(ctx context.Context, notificationType domain.NotificationType, rule domain.RateLimitRule) error {
+	ret := _m.Called(ctx, notificationType, rule)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Save")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(domain.NotificationType, domain.RateLimitRule) error); ok {
-		r0 = rf(notificationType, rule)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NotificationType, domain.RateLimitRule) error); ok {
+		r0 = rf(ctx, notificationType, rule)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, notificationType, rule
+func (_m *RateLimitRuleRepository) Update(ctx context.Context, notificationType domain.NotificationType, rule domain.RateLimitRule) error {
+	ret := _m.Called(ctx, notificationType, rule)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NotificationType, domain.RateLimitRule) error); ok {
+		r0 = rf(ctx, notificationType, rule)
 	} else {
 		r0 = ret.Error(0)
 	}
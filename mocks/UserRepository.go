@@ -3,9 +3,13 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "notification/internal/domain"
 
 	mock "github.com/stretchr/testify/mock"
+
+	repository "notification/internal/repository"
 )
 
 // UserRepository is an autogenerated mock type for the UserRepository type
@@ -13,9 +17,27 @@ type UserRepository struct {
 	mock.Mock
 }
 
-// Get provides a mock function with given fields: id
-func (_m *UserRepository) Get(id string) (domain.User, error) {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: ctx, id
+func (_m *UserRepository) Delete(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Get provides a mock function with given fields: ctx, id
+func (_m *UserRepository) Get(ctx context.Context, id string) (domain.User, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Get")
@@ -23,17 +45,17 @@ func (_m *UserRepository) Get(id string) (domain.User, error) {
 
 	var r0 domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (domain.User, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.User, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(string) domain.User); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.User); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Get(0).(domain.User)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -41,17 +63,72 @@ func (_m *UserRepository) Get(id string) (domain.User, error) {
 	return r0, r1
 }
 
-// Save provides a mock function with given fields: user
-func (_m *UserRepository) Save(user domain.User) error {
-	ret := _m.Called(user)
+// List provides a mock function with given fields: ctx, query
+func (_m *UserRepository) List(ctx context.Context, query repository.UserQuery) ([]domain.User, int, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []domain.User
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, repository.UserQuery) ([]domain.User, int, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, repository.UserQuery) []domain.User); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, repository.UserQuery) int); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, repository.UserQuery) error); ok {
+		r2 = rf(ctx, query)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Save provides a mock function with given fields: ctx, user
+func (_m *UserRepository) Save(ctx context.Context, user domain.User) error {
+	ret := _m.Called(ctx, user)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Save")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(domain.User) error); ok {
-		r0 = rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.User) error); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, user
+func (_m *UserRepository) Update(ctx context.Context, user domain.User) error {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.User) error); ok {
+		r0 = rf(ctx, user)
 	} else {
 		r0 = ret.Error(0)
 	}